@@ -0,0 +1,53 @@
+// Command adminrole grants or withdraws the super_admin role. Super admins
+// manage flag rules, screening terms, report categories and purges; the
+// first one has to be promoted here. It reads the database settings the
+// same way as the server.
+//
+//	go run ./cmd/adminrole [flags] promote <username>   make the admin a super admin
+//	go run ./cmd/adminrole [flags] demote <username>    make a super admin a plain admin again;
+//	                                                    the last super admin cannot be demoted
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"pethubadmin/config"
+	"pethubadmin/middleware"
+	"pethubadmin/services"
+)
+
+func main() {
+	fs := flag.NewFlagSet("adminrole", flag.ExitOnError)
+	cfg, args, err := config.Read(fs, os.Args[1:])
+	if err == nil {
+		err = cfg.DB.Validate()
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if len(args) != 2 {
+		log.Fatal("usage: adminrole [flags] promote <username> | demote <username>")
+	}
+
+	role := ""
+	switch args[0] {
+	case "promote":
+		role = services.RoleSuperAdmin
+	case "demote":
+		role = services.RoleAdmin
+	default:
+		log.Fatalf("unknown command %q", args[0])
+	}
+
+	if middleware.ConnectDB(cfg.DB) {
+		os.Exit(1)
+	}
+	admin, err := services.SetAdminRole(middleware.DBConn, args[1], role)
+	if err != nil {
+		log.Fatalf("%s %s: %v", args[0], args[1], err)
+	}
+	fmt.Printf("%s is now %s\n", admin.Username, role)
+}
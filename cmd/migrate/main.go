@@ -0,0 +1,101 @@
+// Command migrate applies, rolls back and lists the schema migrations. It
+// reads the database settings the same way as the server.
+//
+//	go run ./cmd/migrate [flags] up [version]   apply pending migrations, up to version when given
+//	go run ./cmd/migrate [flags] down [steps]   roll back the last steps migrations (default 1);
+//	                                            reaching the baseline drops the shared tables and needs -yes
+//	go run ./cmd/migrate [flags] status         list migrations and when they were applied
+//	go run ./cmd/migrate -yes [flags] fresh     drop everything and migrate from scratch
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"pethubadmin/config"
+	"pethubadmin/middleware"
+	"pethubadmin/migrations"
+)
+
+func main() {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "confirm fresh, or a down that reaches the baseline; both drop the shared tables")
+	cfg, args, err := config.Read(fs, os.Args[1:])
+	if err == nil {
+		err = cfg.DB.Validate()
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if len(args) == 0 {
+		log.Fatal("usage: migrate [flags] up [version] | down [steps] | status | fresh")
+	}
+
+	command, n := args[0], 0
+	if len(args) > 1 {
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 0 {
+			log.Fatalf("%s: %q is not a valid number", command, args[1])
+		}
+	}
+	if command == "fresh" && !*yes {
+		log.Fatal("fresh drops every table; pass -yes to confirm")
+	}
+
+	if middleware.ConnectDB(cfg.DB) {
+		os.Exit(1)
+	}
+	db := middleware.DBConn
+
+	switch command {
+	case "up":
+		applied, err := migrations.Up(db, n)
+		report("Applied", applied)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		rolledBack, err := migrations.Down(db, n, *yes)
+		if errors.Is(err, migrations.ErrDestructive) {
+			log.Fatalf("%v; pass -yes to confirm", err)
+		}
+		report("Rolled back", rolledBack)
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		states, err := migrations.Status(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range states {
+			applied := "pending"
+			if s.Applied() {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+	case "fresh":
+		if err := migrations.Fresh(db); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Schema recreated from scratch")
+	default:
+		log.Fatalf("unknown command %q", command)
+	}
+}
+
+func report(verb string, done []migrations.Migration) {
+	if len(done) == 0 {
+		fmt.Println("Nothing to do")
+	}
+	for _, m := range done {
+		fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+	}
+}
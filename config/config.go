@@ -0,0 +1,363 @@
+// Package config loads the admin API settings once at startup. Values come
+// from the defaults, then an optional dotenv file, then the environment,
+// then command line flags, each overriding the one before.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"pethubadmin/services"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultFile is the dotenv file read when -config is not given
+const DefaultFile = ".env"
+
+// Placeholder is the value .env.example gives each secret. Validate refuses
+// it so a copied example cannot go live.
+const Placeholder = "change-me"
+
+// Config is every setting the admin API reads
+type Config struct {
+	Server ServerConfig
+	DB     DBConfig
+	JWT    JWTConfig
+	CORS   CORSConfig
+	Mail   MailConfig
+
+	Review              services.ReviewQueueSettings
+	FlagEvalInterval    time.Duration
+	ClassifyInterval    time.Duration
+	ScreenInterval      time.Duration
+	ShelterBlock        services.ShelterBlockPolicy
+	AdopterDeactivation services.AdopterDeactivationPolicy
+	AccountDeletion     services.AccountDeletionSettings
+}
+
+// ServerConfig names the app and the port it listens on
+type ServerConfig struct {
+	Name string
+	Port string
+}
+
+// DBConfig is the PostgreSQL connection
+type DBConfig struct {
+	Host     string
+	Port     string
+	Name     string
+	User     string
+	Password string
+	SSLMode  string
+	TimeZone string
+
+	// MigrateOnStart applies pending schema migrations when the server starts
+	MigrateOnStart bool
+}
+
+// DSN formats the connection string for the postgres driver
+func (c DBConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=%s TimeZone=%s",
+		c.Host, c.Port, c.Name, c.User, c.Password, c.SSLMode, c.TimeZone)
+}
+
+// Validate refuses connection settings that cannot work
+func (c DBConfig) Validate() error {
+	var errs []error
+	if c.Host == "" || c.Name == "" || c.User == "" {
+		errs = append(errs, errors.New("DB_HOST, DB_NAME and DB_USER are required"))
+	}
+	if err := validPort(c.Port); err != nil {
+		errs = append(errs, fmt.Errorf("DB_PORT: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// JWTConfig signs admin tokens
+type JWTConfig struct {
+	SecretKey string
+	TokenTTL  time.Duration
+
+	// AcceptKindless lets tokens without a "kind" claim through the shelter
+	// and adopter routes when their ID names an account of that kind only;
+	// see middleware.JWTAuth. Off by default. Admin routes refuse such
+	// tokens either way.
+	AcceptKindless bool
+}
+
+// CORSConfig is passed to the CORS middleware
+type CORSConfig struct {
+	AllowOrigins string
+	AllowMethods string
+	AllowHeaders string
+}
+
+// MailConfig picks the outcome notice channel and the SMTP account used by
+// the email channel
+type MailConfig struct {
+	Channel  string
+	SMTPHost string
+	SMTPPort string
+	Address  string
+	Password string
+}
+
+// SMTPNotifier returns the email channel for these settings
+func (c MailConfig) SMTPNotifier() services.SMTPNotifier {
+	return services.SMTPNotifier{Host: c.SMTPHost, Port: c.SMTPPort, From: c.Address, Password: c.Password}
+}
+
+// Default returns the settings used for anything not configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{Name: "PETHUB API", Port: "5566"},
+		DB:     DBConfig{Port: "5432", SSLMode: "disable", TimeZone: "Asia/Manila", MigrateOnStart: true},
+		JWT:    JWTConfig{TokenTTL: 72 * time.Hour},
+		CORS: CORSConfig{
+			AllowOrigins: "*",
+			AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
+			AllowHeaders: "Origin, Content-Type, Accept, Authorization, If-Match",
+		},
+		Mail:                MailConfig{Channel: services.ChannelInApp, SMTPHost: "smtp.gmail.com", SMTPPort: "587"},
+		Review:              services.DefaultReviewQueueSettings,
+		FlagEvalInterval:    15 * time.Minute,
+		ClassifyInterval:    5 * time.Minute,
+		ScreenInterval:      5 * time.Minute,
+		ShelterBlock:        services.DefaultShelterBlockPolicy,
+		AdopterDeactivation: services.DefaultAdopterDeactivationPolicy,
+		AccountDeletion:     services.DefaultAccountDeletionSettings,
+	}
+}
+
+// flagKeys maps each command line flag to the setting it overrides
+var flagKeys = []struct{ name, key, usage string }{
+	{"port", "PROJ_PORT", "port to listen on"},
+	{"db-host", "DB_HOST", "database host"},
+	{"db-port", "DB_PORT", "database port"},
+	{"db-name", "DB_NAME", "database name"},
+	{"db-user", "DB_USER", "database user"},
+	{"notify-channel", "NOTIFY_CHANNEL", "outcome notice channel (inapp, email or log)"},
+}
+
+// Load reads the settings from the file named by -config (.env when it
+// exists), the environment and the flags in args, and validates them
+func Load(args []string) (Config, error) {
+	values, _, err := read(flag.NewFlagSet("pethubadmin", flag.ContinueOnError), args)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := parse(values)
+	return cfg, errors.Join(err, cfg.Validate())
+}
+
+// Read parses the settings like Load without validating them, so commands
+// that need only part of the configuration can check just that part. The
+// settings flags are added to fs, which may carry the command's own flags;
+// the arguments left after the flags are returned.
+func Read(fs *flag.FlagSet, args []string) (Config, []string, error) {
+	values, rest, err := read(fs, args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	cfg, err := parse(values)
+	return cfg, rest, err
+}
+
+// read collects the raw values from the dotenv file, the environment and
+// the flags, later sources overriding earlier ones
+func read(fs *flag.FlagSet, args []string) (map[string]string, []string, error) {
+	file := fs.String("config", "", "dotenv file to read settings from (default .env when present)")
+	overrides := make(map[string]*string, len(flagKeys))
+	for _, f := range flagKeys {
+		overrides[f.name] = fs.String(f.name, "", f.usage+" ("+f.key+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	values := map[string]string{}
+	path := *file
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			path = DefaultFile
+		}
+	}
+	if path != "" {
+		fileValues, err := godotenv.Read(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		for key, value := range fileValues {
+			values[key] = value
+		}
+	}
+	// A blank variable counts as unset, as it does in the file
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok && strings.TrimSpace(value) != "" {
+			values[key] = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, known := range flagKeys {
+			if known.name == f.Name {
+				values[known.key] = *overrides[f.Name]
+			}
+		}
+	})
+
+	return values, fs.Args(), nil
+}
+
+// parse applies the values over the defaults. Values that do not parse are
+// reported instead of silently falling back.
+func parse(values map[string]string) (Config, error) {
+	cfg := Default()
+	p := parser{values: values}
+
+	p.string("PROJ_NAME", &cfg.Server.Name)
+	p.string("PROJ_PORT", &cfg.Server.Port)
+
+	p.string("DB_HOST", &cfg.DB.Host)
+	p.string("DB_PORT", &cfg.DB.Port)
+	p.string("DB_NAME", &cfg.DB.Name)
+	p.string("DB_USER", &cfg.DB.User)
+	p.string("DB_PASSWORD", &cfg.DB.Password)
+	p.string("DB_SSLM", &cfg.DB.SSLMode)
+	p.string("DB_TMEZ", &cfg.DB.TimeZone)
+	p.bool("MIGRATE_ON_START", &cfg.DB.MigrateOnStart)
+
+	p.string("SECRET_KEY", &cfg.JWT.SecretKey)
+	p.duration("JWT_TTL_HOURS", time.Hour, &cfg.JWT.TokenTTL)
+	p.bool("JWT_ACCEPT_KINDLESS", &cfg.JWT.AcceptKindless)
+
+	p.string("CORS_ALLOW_ORIGINS", &cfg.CORS.AllowOrigins)
+	p.string("CORS_ALLOW_METHODS", &cfg.CORS.AllowMethods)
+	p.string("CORS_ALLOW_HEADERS", &cfg.CORS.AllowHeaders)
+
+	p.status("NOTIFY_CHANNEL", &cfg.Mail.Channel)
+	p.string("SMTP_HOST", &cfg.Mail.SMTPHost)
+	p.string("SMTP_PORT", &cfg.Mail.SMTPPort)
+	p.string("EMAIL_ADDRESS", &cfg.Mail.Address)
+	p.string("EMAIL_PASSWORD", &cfg.Mail.Password)
+
+	p.duration("REVIEW_CLAIM_TTL_MINUTES", time.Minute, &cfg.Review.ClaimTTL)
+	p.duration("REVIEW_SLA_HOURS", time.Hour, &cfg.Review.SLA)
+	p.duration("REVIEW_ESCALATION_INTERVAL_MINUTES", time.Minute, &cfg.Review.EscalationInterval)
+	p.duration("FLAG_EVAL_INTERVAL_MINUTES", time.Minute, &cfg.FlagEvalInterval)
+	p.duration("REPORT_CLASSIFY_INTERVAL_MINUTES", time.Minute, &cfg.ClassifyInterval)
+	p.duration("CONTENT_SCREEN_INTERVAL_MINUTES", time.Minute, &cfg.ScreenInterval)
+
+	p.bool("SHELTER_BLOCK_HIDE_PETS", &cfg.ShelterBlock.HidePets)
+	p.status("SHELTER_BLOCK_APPLICATIONS", &cfg.ShelterBlock.Applications)
+	p.bool("SHELTER_BLOCK_CANCEL_INTERVIEWS", &cfg.ShelterBlock.CancelInterviews)
+	p.bool("SHELTER_BLOCK_NOTIFY_ADOPTERS", &cfg.ShelterBlock.NotifyAdopters)
+
+	p.status("ADOPTER_DEACTIVATE_APPLICATIONS", &cfg.AdopterDeactivation.Applications)
+	p.bool("ADOPTER_DEACTIVATE_CANCEL_INTERVIEWS", &cfg.AdopterDeactivation.CancelInterviews)
+	p.bool("ADOPTER_DEACTIVATE_NOTIFY_SHELTERS", &cfg.AdopterDeactivation.NotifyShelters)
+
+	p.duration("ACCOUNT_RESTORE_WINDOW_DAYS", 24*time.Hour, &cfg.AccountDeletion.RestoreWindow)
+	p.duration("ACCOUNT_PURGE_INTERVAL_MINUTES", time.Minute, &cfg.AccountDeletion.PurgeInterval)
+
+	return cfg, errors.Join(p.errs...)
+}
+
+// Validate refuses settings the API cannot run with
+func (c Config) Validate() error {
+	var errs []error
+	if secret := strings.TrimSpace(c.JWT.SecretKey); secret == "" || secret == Placeholder {
+		errs = append(errs, errors.New("SECRET_KEY is required"))
+	}
+	if err := c.DB.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validPort(c.Server.Port); err != nil {
+		errs = append(errs, fmt.Errorf("PROJ_PORT: %w", err))
+	}
+	if c.JWT.TokenTTL <= 0 || c.Review.ClaimTTL <= 0 || c.Review.SLA <= 0 || c.Review.EscalationInterval <= 0 ||
+		c.FlagEvalInterval <= 0 || c.ClassifyInterval <= 0 || c.ScreenInterval <= 0 {
+		errs = append(errs, errors.New("JWT_TTL_HOURS, REVIEW_CLAIM_TTL_MINUTES, REVIEW_SLA_HOURS, REVIEW_ESCALATION_INTERVAL_MINUTES, FLAG_EVAL_INTERVAL_MINUTES, REPORT_CLASSIFY_INTERVAL_MINUTES and CONTENT_SCREEN_INTERVAL_MINUTES must be positive"))
+	}
+	if c.AccountDeletion.RestoreWindow < 0 || c.AccountDeletion.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("ACCOUNT_RESTORE_WINDOW_DAYS must not be negative and ACCOUNT_PURGE_INTERVAL_MINUTES must be positive"))
+	}
+	if err := c.ShelterBlock.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("SHELTER_BLOCK_APPLICATIONS: %w", err))
+	}
+	if err := c.AdopterDeactivation.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("ADOPTER_DEACTIVATE_APPLICATIONS: %w", err))
+	}
+	switch c.Mail.Channel {
+	case services.ChannelInApp, services.ChannelLog:
+	case services.ChannelEmail:
+		if c.Mail.SMTPHost == "" || c.Mail.SMTPPort == "" || c.Mail.Address == "" || c.Mail.Password == "" || c.Mail.Password == Placeholder {
+			errs = append(errs, errors.New("SMTP_HOST, SMTP_PORT, EMAIL_ADDRESS and EMAIL_PASSWORD are required for the email channel"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("NOTIFY_CHANNEL: %w: %q", services.ErrUnknownChannel, c.Mail.Channel))
+	}
+	return errors.Join(errs...)
+}
+
+func validPort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// parser reads typed values, skipping keys that are unset or blank
+type parser struct {
+	values map[string]string
+	errs   []error
+}
+
+func (p *parser) lookup(key string) (string, bool) {
+	value := strings.TrimSpace(p.values[key])
+	return value, value != ""
+}
+
+func (p *parser) string(key string, dst *string) {
+	if value, ok := p.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (p *parser) status(key string, dst *string) {
+	if value, ok := p.lookup(key); ok {
+		*dst = services.NormalizeStatus(value)
+	}
+}
+
+func (p *parser) bool(key string, dst *bool) {
+	value, ok := p.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: %q is not a boolean", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (p *parser) duration(key string, unit time.Duration, dst *time.Duration) {
+	value, ok := p.lookup(key)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: %q is not a whole number", key, value))
+		return
+	}
+	*dst = time.Duration(n) * unit
+}
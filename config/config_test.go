@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pethubadmin/services"
+)
+
+// valid is a complete set of settings that passes Validate
+func valid() map[string]string {
+	return map[string]string{
+		"DB_HOST":    "127.0.0.1",
+		"DB_NAME":    "pethub",
+		"DB_USER":    "postgres",
+		"SECRET_KEY": "0123456789abcdef",
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name   string
+		values map[string]string
+		check  func(Config) bool
+		errKey string
+	}{
+		{"defaults", map[string]string{}, func(c Config) bool {
+			return c.Server.Port == "5566" && c.JWT.TokenTTL == 72*time.Hour && !c.JWT.AcceptKindless && c.Mail.Channel == services.ChannelInApp
+		}, ""},
+		{"values override the defaults", map[string]string{"PROJ_PORT": "4000", "JWT_TTL_HOURS": "2", "JWT_ACCEPT_KINDLESS": "true", "NOTIFY_CHANNEL": " Email "},
+			func(c Config) bool {
+				return c.Server.Port == "4000" && c.JWT.TokenTTL == 2*time.Hour && c.JWT.AcceptKindless && c.Mail.Channel == services.ChannelEmail
+			}, ""},
+		{"blank values keep the defaults", map[string]string{"PROJ_PORT": "  ", "REVIEW_SLA_HOURS": ""}, func(c Config) bool {
+			return c.Server.Port == "5566" && c.Review.SLA == services.DefaultReviewQueueSettings.SLA
+		}, ""},
+		{"malformed boolean", map[string]string{"MIGRATE_ON_START": "sometimes"}, nil, "MIGRATE_ON_START"},
+		{"malformed number", map[string]string{"REVIEW_SLA_HOURS": "2d"}, nil, "REVIEW_SLA_HOURS"},
+		{"fractional number", map[string]string{"ACCOUNT_RESTORE_WINDOW_DAYS": "1.5"}, nil, "ACCOUNT_RESTORE_WINDOW_DAYS"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parse(tc.values)
+			if tc.errKey != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errKey) {
+					t.Fatalf("err = %v, want one naming %s", err, tc.errKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !tc.check(cfg) {
+				t.Fatalf("parsed %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(c *Config)
+		errKey string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"missing secret", func(c *Config) { c.JWT.SecretKey = "" }, "SECRET_KEY"},
+		{"blank secret", func(c *Config) { c.JWT.SecretKey = "   " }, "SECRET_KEY"},
+		{"example secret", func(c *Config) { c.JWT.SecretKey = Placeholder }, "SECRET_KEY"},
+		{"missing database", func(c *Config) { c.DB.Host = "" }, "DB_HOST"},
+		{"malformed database port", func(c *Config) { c.DB.Port = "postgres" }, "DB_PORT"},
+		{"port out of range", func(c *Config) { c.Server.Port = "70000" }, "PROJ_PORT"},
+		{"zero interval", func(c *Config) { c.FlagEvalInterval = 0 }, "FLAG_EVAL_INTERVAL_MINUTES"},
+		{"negative restore window", func(c *Config) { c.AccountDeletion.RestoreWindow = -time.Hour }, "ACCOUNT_RESTORE_WINDOW_DAYS"},
+		{"unknown cascade policy", func(c *Config) { c.ShelterBlock.Applications = "delete" }, "SHELTER_BLOCK_APPLICATIONS"},
+		{"unknown channel", func(c *Config) { c.Mail.Channel = "sms" }, "NOTIFY_CHANNEL"},
+		{"email without a password", func(c *Config) {
+			c.Mail.Channel, c.Mail.Address = services.ChannelEmail, "pethub@example.com"
+		}, "EMAIL_PASSWORD"},
+		{"email with the example password", func(c *Config) {
+			c.Mail.Channel, c.Mail.Address, c.Mail.Password = services.ChannelEmail, "pethub@example.com", Placeholder
+		}, "EMAIL_PASSWORD"},
+		{"email", func(c *Config) {
+			c.Mail.Channel, c.Mail.Address, c.Mail.Password = services.ChannelEmail, "pethub@example.com", "app-password"
+		}, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parse(valid())
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			tc.change(&cfg)
+
+			err = cfg.Validate()
+			if tc.errKey == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.errKey) {
+				t.Fatalf("err = %v, want one naming %s", err, tc.errKey)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	// Blank variables must not hide the file's values
+	for key := range valid() {
+		t.Setenv(key, "")
+	}
+	t.Setenv("PROJ_PORT", "")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	complete := write("complete.env", "DB_HOST = db\nDB_NAME = pethub\nDB_USER = postgres\nSECRET_KEY = s3cret\nPROJ_PORT = 4000\n")
+	example := write("example.env", "DB_HOST = db\nDB_NAME = pethub\nDB_USER = postgres\nSECRET_KEY = "+Placeholder+"\n")
+	blank := write("blank.env", "DB_HOST = db\nDB_NAME = pethub\nDB_USER = postgres\nSECRET_KEY = \n")
+	malformed := write("malformed.env", "DB_HOST = db\nDB_NAME = pethub\nDB_USER = postgres\nSECRET_KEY = s3cret\nJWT_TTL_HOURS = forever\n")
+
+	cases := []struct {
+		name   string
+		args   []string
+		port   string
+		errKey string
+	}{
+		{"file", []string{"-config", complete}, "4000", ""},
+		{"flag overrides the file", []string{"-config", complete, "-port", "4100"}, "4100", ""},
+		{"example secret", []string{"-config", example}, "", "SECRET_KEY"},
+		{"blank secret", []string{"-config", blank}, "", "SECRET_KEY"},
+		{"malformed value", []string{"-config", malformed}, "", "JWT_TTL_HOURS"},
+		{"missing file", []string{"-config", filepath.Join(dir, "none.env")}, "", "none.env"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Load(tc.args)
+			if tc.errKey != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errKey) {
+					t.Fatalf("err = %v, want one naming %s", err, tc.errKey)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.Server.Port != tc.port || cfg.JWT.SecretKey != "s3cret" {
+				t.Fatalf("port %q, secret %q", cfg.Server.Port, cfg.JWT.SecretKey)
+			}
+		})
+	}
+}
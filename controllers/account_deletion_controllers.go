@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DeleteShelter soft deletes a shelter; it can be restored until the
+// restore window (ACCOUNT_RESTORE_WINDOW_DAYS) closes
+func (h *AdminHandler) DeleteShelter(c *fiber.Ctx) error {
+	return h.softDeleteAccount(c, "shelter", shelterAccounts)
+}
+
+// DeleteAdopter soft deletes an adopter; it can be restored until the
+// restore window (ACCOUNT_RESTORE_WINDOW_DAYS) closes
+func (h *AdminHandler) DeleteAdopter(c *fiber.Ctx) error {
+	return h.softDeleteAccount(c, "adopter", adopterAccounts)
+}
+
+// RestoreShelter brings back a soft deleted shelter
+func (h *AdminHandler) RestoreShelter(c *fiber.Ctx) error {
+	return h.changeDeletedAccount(c, "shelter", "restored", shelterAccounts, deletableAccounts.Restore)
+}
+
+// RestoreAdopter brings back a soft deleted adopter
+func (h *AdminHandler) RestoreAdopter(c *fiber.Ctx) error {
+	return h.changeDeletedAccount(c, "adopter", "restored", adopterAccounts, deletableAccounts.Restore)
+}
+
+// PurgeShelter hard deletes a soft deleted shelter right away
+func (h *AdminHandler) PurgeShelter(c *fiber.Ctx) error {
+	return h.changeDeletedAccount(c, "shelter", "permanently deleted", shelterAccounts, deletableAccounts.Purge)
+}
+
+// PurgeAdopter hard deletes a soft deleted adopter right away
+func (h *AdminHandler) PurgeAdopter(c *fiber.Ctx) error {
+	return h.changeDeletedAccount(c, "adopter", "permanently deleted", adopterAccounts, deletableAccounts.Purge)
+}
+
+// GetAccountDeletions lists deleted accounts. ?entity_type= limits it to
+// shelter or adopter; ?open=true leaves out restored and purged accounts.
+func (h *AdminHandler) GetAccountDeletions(c *fiber.Ctx) error {
+	entityType := services.NormalizeStatus(c.Query("entity_type"))
+	switch entityType {
+	case "", services.EntityShelter, services.EntityAdopter:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "entity_type must be 'shelter' or 'adopter'",
+		})
+	}
+
+	deletions, err := h.repos.Audit.Deletions(entityType, c.QueryBool("open"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch account deletions",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account deletions retrieved successfully",
+		"data":    deletions,
+	})
+}
+
+// deletableAccounts is the part of the shelter and adopter repositories
+// that deletes, restores and purges accounts
+type deletableAccounts interface {
+	CheckVersion(id uint, versions []uint) error
+	SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error)
+	Restore(id, adminID uint) (models.AccountDeletion, error)
+	Purge(id, adminID uint) (models.AccountDeletion, error)
+}
+
+func shelterAccounts(tx repository.Repositories) deletableAccounts { return tx.Shelters }
+
+func adopterAccounts(tx repository.Repositories) deletableAccounts { return tx.Adopters }
+
+func (h *AdminHandler) softDeleteAccount(c *fiber.Ctx, entity string, accounts func(repository.Repositories) deletableAccounts) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid " + entity + " ID",
+		})
+	}
+
+	// Optional reason kept with the deletion record
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	var deletion models.AccountDeletion
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = accounts(tx).CheckVersion(uint(id), ifMatch(c)); err != nil {
+			return err
+		}
+		deletion, err = accounts(tx).SoftDelete(uint(id), currentAdminID(c), request.Reason, h.cfg.AccountDeletion)
+		return err
+	})
+	if err != nil {
+		return accountDeletionError(c, err, entity)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account deleted; it can be restored until " + deletion.PurgeAfter.Format("2006-01-02 15:04"),
+		"data":    deletion,
+	})
+}
+
+func (h *AdminHandler) changeDeletedAccount(c *fiber.Ctx, entity, done string, accounts func(repository.Repositories) deletableAccounts,
+	change func(accounts deletableAccounts, id, adminID uint) (models.AccountDeletion, error)) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid " + entity + " ID",
+		})
+	}
+
+	var deletion models.AccountDeletion
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = accounts(tx).CheckVersion(uint(id), ifMatch(c)); err != nil {
+			return err
+		}
+		deletion, err = change(accounts(tx), uint(id), currentAdminID(c))
+		return err
+	})
+	if err != nil {
+		return accountDeletionError(c, err, entity)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account " + done + " successfully",
+		"data":    deletion,
+	})
+}
+
+// accountDeletionError maps account deletion service errors to HTTP responses
+func accountDeletionError(c *fiber.Ctx, err error, entity string) error {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Account not found",
+		})
+	case errors.Is(err, services.ErrAccountNotDeleted):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrVersionMismatch):
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrAccountDeleted), errors.Is(err, services.ErrRestoreWindowClosed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": "Failed to update " + entity + " account",
+		"error":   err.Error(),
+	})
+}
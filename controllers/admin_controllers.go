@@ -4,20 +4,40 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"pethubadmin/config"
 	"pethubadmin/middleware"
 	"pethubadmin/models"
+	"pethubadmin/pagination"
+	"pethubadmin/repository"
+	"pethubadmin/services"
 	"time"
 
 	"pethubadmin/models/response"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
-func RegisterAdmin(c *fiber.Ctx) error {
+// AdminHandler serves the admin endpoints. Reads go through the
+// repositories; writes go through the repositories of a unit of work, so an
+// action that touches several tables commits or rolls back as a whole.
+type AdminHandler struct {
+	cfg   config.Config
+	repos repository.Repositories
+	uow   repository.UnitOfWork
+	auth  *middleware.JWTAuth
+}
+
+// NewAdminHandler returns a handler using the given settings, repositories,
+// unit of work and token signer
+func NewAdminHandler(cfg config.Config, repos repository.Repositories, uow repository.UnitOfWork, auth *middleware.JWTAuth) *AdminHandler {
+	return &AdminHandler{cfg: cfg, repos: repos, uow: uow, auth: auth}
+}
+
+func (h *AdminHandler) RegisterAdmin(c *fiber.Ctx) error {
 	// Parse request body
 	requestBody := struct {
 		Username string `json:"username"`
@@ -31,9 +51,7 @@ func RegisterAdmin(c *fiber.Ctx) error {
 	}
 
 	// Check if username exists
-	var existingAdmin models.AdminAccount
-	result := middleware.DBConn.Where("username = ?", requestBody.Username).First(&existingAdmin)
-	if result.Error == nil {
+	if _, err := h.repos.Admins.ByUsername(requestBody.Username); err == nil {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"message": "Username already exists",
 		})
@@ -54,7 +72,7 @@ func RegisterAdmin(c *fiber.Ctx) error {
 	}
 
 	// Save admin account to the database
-	if err := middleware.DBConn.Create(&adminAccount).Error; err != nil {
+	if err := h.repos.Admins.Create(&adminAccount); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to register admin",
 		})
@@ -70,7 +88,7 @@ func RegisterAdmin(c *fiber.Ctx) error {
 
 // UPDATE SHELTER STATUS
 // ==============================================================
-func UpdateShelterStatus(c *fiber.Ctx) error {
+func (h *AdminHandler) UpdateShelterStatus(c *fiber.Ctx) error {
 	// Parse request body
 	var requestBody struct {
 		ShelterID uint   `json:"shelter_id"` // Must be exported (capitalized)
@@ -84,58 +102,49 @@ func UpdateShelterStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate status (case-insensitive check)
-	status := strings.ToLower(requestBody.Status)
-	if status != "active" && status != "inactive" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Status must be either 'active' or 'inactive'",
-		})
-	}
-
-	// Update shelter status directly in database
-	result := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("shelter_id = ?", requestBody.ShelterID).
-		Update("status", status) // Use the lowercased version
-
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update shelter status",
-			"error":   result.Error.Error(),
-		})
-	}
-
-	if result.RowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter not found",
-		})
-	}
-
-	// Fetch updated shelter to return in response
-	var shelter models.ShelterAccount
-	if err := middleware.DBConn.First(&shelter, requestBody.ShelterID).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to fetch updated shelter",
-			"error":   err.Error(),
-		})
+	// Deactivating blocks the shelter and activating reinstates it, with
+	// the same cascade and notices as the moderation routes
+	var result services.ShelterModerationResult
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Shelters.CheckVersion(requestBody.ShelterID, ifMatch(c)); err != nil {
+			return err
+		}
+		if result, err = tx.Shelters.SetStatus(requestBody.ShelterID, currentAdminID(c), requestBody.Status, h.cfg.ShelterBlock); err != nil {
+			return err
+		}
+		tx.AfterCommit(h.dispatchOutcomeNotices)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrShelterInfoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Shelter info not found",
+			})
+		}
+		return statusTransitionError(c, err, "Shelter not found", "Failed to update shelter status")
 	}
 
+	setETag(c, result.Account.Version)
 	return c.JSON(fiber.Map{
 		"message": "Shelter status updated successfully",
 		"data": fiber.Map{
-			"shelter_id": shelter.ShelterID,
-			"username":   shelter.Username,
-			"status":     shelter.Status,
+			"shelter_id":      result.Account.ShelterID,
+			"username":        result.Account.Username,
+			"status":          result.Account.Status,
+			"reports_updated": result.ReportsUpdated,
+			"cascade":         result.Cascade,
 		},
 	})
 }
 
 // UPDATE ADOPTER STATUS
 // ==============================================================
-func UpdateAdopterStatus(c *fiber.Ctx) error {
+func (h *AdminHandler) UpdateAdopterStatus(c *fiber.Ctx) error {
 	// Parse request body
 	requestBody := struct {
 		AdopterID uint   `json:"adopter_id"`
 		Status    string `json:"status"`
+		Restore   bool   `json:"restore"` // when activating, restore what the deactivation froze or withdrew
 	}{}
 
 	if err := c.BodyParser(&requestBody); err != nil {
@@ -144,38 +153,28 @@ func UpdateAdopterStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate status
-	if requestBody.Status != "active" && requestBody.Status != "inactive" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Status must be either 'active' or 'inactive'",
-		})
-	}
-
-	// Find adopter
 	var adopter models.AdopterAccount
-	if err := middleware.DBConn.First(&adopter, requestBody.AdopterID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Adopter not found",
-		})
-	}
-
-	// Update status
-	adopter.Status = requestBody.Status
-
-	// Save changes
-	if err := middleware.DBConn.Save(&adopter).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update adopter status",
-			"error":   err.Error(),
-		})
+	var cascade services.CascadeResult
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Adopters.CheckVersion(requestBody.AdopterID, ifMatch(c)); err != nil {
+			return err
+		}
+		adopter, cascade, err = tx.Adopters.SetStatus(requestBody.AdopterID, currentAdminID(c), requestBody.Status,
+			services.AdopterStatusOptions{Policy: h.cfg.AdopterDeactivation, Restore: requestBody.Restore})
+		return err
+	})
+	if err != nil {
+		return statusTransitionError(c, err, "Adopter not found", "Failed to update adopter status")
 	}
 
+	setETag(c, adopter.Version)
 	return c.JSON(fiber.Map{
 		"message": "Adopter status updated successfully",
 		"data": fiber.Map{
 			"adopter_id": adopter.AdopterID,
 			"username":   adopter.Username,
 			"status":     adopter.Status,
+			"cascade":    cascade,
 		},
 	})
 }
@@ -184,31 +183,65 @@ func UpdateAdopterStatus(c *fiber.Ctx) error {
 // @Summary Get pending shelter requests
 // @Description Get list of all shelter accounts with pending registration
 
-func GetAllPendingRequests(c *fiber.Ctx) error {
-	var pendingShelters []models.ShelterAccount
-
+func (h *AdminHandler) GetAllPendingRequests(c *fiber.Ctx) error {
 	// Get shelters with pending registration
-	if err := middleware.DBConn.
-		Where("reg_status = ?", "pending").
-		Find(&pendingShelters).
-		Error; err != nil {
+	pendingShelters, err := h.repos.Shelters.Accounts(repository.ShelterFilter{RegStatus: "pending"})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch pending shelters",
 			"error":   err.Error(),
 		})
 	}
 
-	// Get additional info for each shelter
+	shelterIDs := getShelterIDs(pendingShelters)
+
+	// Get verification documents for all pending shelters
+	docs, err := h.repos.Shelters.Documents(shelterIDs...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch verification documents",
+			"error":   err.Error(),
+		})
+	}
+
+	docsByShelter := make(map[uint][]models.ShelterDocument)
+	for _, doc := range docs {
+		docsByShelter[doc.ShelterID] = append(docsByShelter[doc.ShelterID], doc)
+	}
+
+	// Get profiles and their media for all pending shelters in one batch
+	var infos []models.ShelterInfo
+	if len(shelterIDs) > 0 {
+		infos, err = h.repos.Shelters.Infos(shelterIDs...)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to fetch shelter info",
+				"error":   err.Error(),
+			})
+		}
+	}
+	media, err := h.repos.Shelters.Media(shelterIDs...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch shelter media",
+			"error":   err.Error(),
+		})
+	}
+	infoByShelter := make(map[uint]models.ShelterInfo, len(infos))
+	for _, info := range infos {
+		info.ShelterMedia = media[info.ShelterID]
+		infoByShelter[info.ShelterID] = info
+	}
+
 	var results []fiber.Map
 	for _, shelter := range pendingShelters {
-		var info models.ShelterInfo
-		if err := middleware.DBConn.
-			Where("shelter_id = ?", shelter.ShelterID).
-			First(&info).
-			Error; err == nil {
+		if info, ok := infoByShelter[shelter.ShelterID]; ok {
+			checklist := services.BuildChecklist(docsByShelter[shelter.ShelterID])
 			results = append(results, fiber.Map{
-				"account": shelter,
-				"info":    info,
+				"account":            shelter,
+				"info":               info,
+				"documents":          checklist,
+				"documents_complete": services.ChecklistComplete(checklist),
 			})
 		}
 	}
@@ -223,12 +256,13 @@ func GetAllPendingRequests(c *fiber.Ctx) error {
 //UPDATE REGISTRATION STATUS
 // ==============================================================
 
-func UpdateRegistrationStatus(c *fiber.Ctx) error {
+func (h *AdminHandler) UpdateRegistrationStatus(c *fiber.Ctx) error {
 	// Parse request
 	var request struct {
-		ShelterID uint   `json:"shelter_id"`
-		RegStatus string `json:"reg_status"` // "approved" or "rejected"
-
+		ShelterID  uint   `json:"shelter_id"`
+		RegStatus  string `json:"reg_status"`  // "approved" or "rejected"
+		ReasonCode string `json:"reason_code"` // required when rejected
+		Feedback   string `json:"feedback"`    // required when rejected
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -238,132 +272,76 @@ func UpdateRegistrationStatus(c *fiber.Ctx) error {
 	}
 
 	// Validate status
-	if request.RegStatus != "approved" && request.RegStatus != "rejected" {
+	regStatus := services.NormalizeStatus(request.RegStatus)
+	if regStatus != services.RegStatusApproved && regStatus != services.RegStatusRejected {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "Reg status must be 'approved' or 'rejected'",
 		})
 	}
 
-	// Find shelter
 	var shelter models.ShelterAccount
-	if err := middleware.DBConn.
-		Where("shelter_id = ?", request.ShelterID).
-		First(&shelter).
-		Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter not found",
-		})
-	}
-
-	// Prepare updates
-	updates := map[string]interface{}{
-		"reg_status": request.RegStatus,
-	}
-
-	// If approved, also activate the account
-	if request.RegStatus == "approved" {
-		updates["status"] = "active"
-	}
-
-	// Add admin notes if provided
-	//
-
-	// Save changes
-	if err := middleware.DBConn.
-		Model(&shelter).
-		Updates(updates).
-		Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update registration status",
-			"error":   err.Error(),
-		})
+	var round models.ShelterReviewRound
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Shelters.CheckVersion(request.ShelterID, ifMatch(c)); err != nil {
+			return err
+		}
+		shelter, round, err = tx.Shelters.DecideRegistration(request.ShelterID, currentAdminID(c),
+			regStatus, request.ReasonCode, request.Feedback, h.cfg.Review.ClaimTTL)
+		return err
+	})
+	if err != nil {
+		return statusTransitionError(c, err, "Shelter not found", "Failed to update registration status")
 	}
 
+	setETag(c, shelter.Version)
 	return c.JSON(fiber.Map{
 		"message": "Shelter registration status updated",
 		"data": fiber.Map{
 			"shelter_id": shelter.ShelterID,
 			"username":   shelter.Username,
-			"reg_status": request.RegStatus,
+			"reg_status": shelter.RegStatus,
+			"review":     round,
 		},
 	})
 }
 
 // GetAllReports retrieves all submitted reports with filtering options
 
-// UpdateReportStatus changes the status of a submitted report
-func UpdateReportStatus(c *fiber.Ctx) error {
-	reportID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+// GetAllAdopters lists adopters with a profile, a page at a time. Filters:
+// ?status=, ?created_from=, ?created_to=; see parsePage for paging and sort.
+func (h *AdminHandler) GetAllAdopters(c *fiber.Ctx) error {
+	page, err := parsePage(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid report ID",
-		})
-	}
-
-	var request struct {
-		Status    string `json:"status" validate:"required,oneof=pending reviewed resolved"`
-		AdminNote string `json:"admin_note,omitempty"`
-	}
-
-	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return listQueryError(c, err)
 	}
-
-	// Find the report
-	var report models.SubmittedReport
-	if err := middleware.DBConn.First(&report, reportID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Report not found",
-		})
-	}
-
-	// Update report status
-	updates := map[string]interface{}{
-		"status": request.Status,
-	}
-
-	if request.AdminNote != "" {
-		updates["admin_note"] = request.AdminNote
-	}
-
-	if err := middleware.DBConn.Model(&report).Updates(updates).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update report status",
-		})
+	created, err := parseCreatedRange(c)
+	if err != nil {
+		return listQueryError(c, err)
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Report status updated successfully",
-		"report": fiber.Map{
-			"id":     report.ID, // Changed from report.ReportID to report.ID
-			"status": report.Status,
-		},
-	})
-}
-func GetAllAdopters(c *fiber.Ctx) error {
-	var accounts []models.AdopterAccount
-	if err := middleware.DBConn.Find(&accounts).Error; err != nil {
+	accounts, err := h.repos.Adopters.AccountPage(repository.AdopterFilter{
+		Status:     statusQuery(c, "status"),
+		Created:    created,
+		HasProfile: true,
+	}, page)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch adopter accounts",
 		})
 	}
 
-	var infos []models.AdopterInfo
-	if err := middleware.DBConn.Find(&infos).Error; err != nil {
+	infoMap, err := h.adopterInfoMap(getAdopterIDs(accounts.Items))
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch adopter info",
 		})
 	}
 
-	infoMap := make(map[uint]models.AdopterInfo)
-	for _, info := range infos {
-		infoMap[info.AdopterID] = info
-	}
-
-	var combined []fiber.Map
-	for _, account := range accounts {
+	combined := make([]fiber.Map, 0, len(accounts.Items))
+	for _, account := range accounts.Items {
 		if info, ok := infoMap[account.AdopterID]; ok {
 			combined = append(combined, fiber.Map{
 				"adopter": account,
@@ -373,38 +351,31 @@ func GetAllAdopters(c *fiber.Ctx) error {
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Adopters retrieved successfully",
-		"data":    combined,
+		"message":    "Adopters retrieved successfully",
+		"data":       combined,
+		"pagination": pageInfo(accounts),
 	})
 }
-func GetAllShelters(c *fiber.Ctx) error {
-	var accounts []models.ShelterAccount
-	if err := middleware.DBConn.Find(&accounts).Error; err != nil {
-		return c.JSON(response.ShelterResponseModel{
-			RetCode: "400",
-			Message: "Failed to fetch shelter accounts",
-			Data:    nil,
-		})
-	}
 
-	var infos []models.ShelterInfo
-	if err := middleware.DBConn.Find(&infos).Error; err != nil {
+// GetAllShelters lists shelters with a profile, a page at a time. Filters:
+// ?status=, ?reg_status=, ?created_from=, ?created_to=; see parsePage for
+// paging and sort.
+func (h *AdminHandler) GetAllShelters(c *fiber.Ctx) error {
+	accounts, infoMap, err := h.shelterPage(c)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
+		}
 		return c.JSON(response.ShelterResponseModel{
 			RetCode: "400",
-			Message: "Failed to fetch shelter info",
+			Message: "Failed to fetch shelters",
 			Data:    nil,
 		})
 	}
 
-	// Create a map of ShelterID to ShelterInfo for faster lookup
-	infoMap := make(map[uint]models.ShelterInfo)
-	for _, info := range infos {
-		infoMap[info.ShelterID] = info
-	}
-
 	// Combine data
 	shelters := []fiber.Map{}
-	for _, account := range accounts {
+	for _, account := range accounts.Items {
 		if info, ok := infoMap[account.ShelterID]; ok {
 			shelters = append(shelters, fiber.Map{
 				"shelter": account,
@@ -414,42 +385,31 @@ func GetAllShelters(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(response.ShelterResponseModel{
-		RetCode: "200",
-		Message: "All shelters retrieved successfully",
-		Data:    shelters,
+		RetCode:    "200",
+		Message:    "All shelters retrieved successfully",
+		Data:       shelters,
+		Pagination: pageInfo(accounts),
 	})
 }
 
 //try
 
-func GetAllSheltersAdmintry(c *fiber.Ctx) error {
-	var accounts []models.ShelterAccount
-	if err := middleware.DBConn.Find(&accounts).Error; err != nil {
-		return c.JSON(response.ShelterResponseModel{
-			RetCode: "400",
-			Message: "Failed to fetch shelter accounts",
-			Data:    nil,
-		})
-	}
-
-	var infos []models.ShelterInfo
-	if err := middleware.DBConn.Find(&infos).Error; err != nil {
+func (h *AdminHandler) GetAllSheltersAdmintry(c *fiber.Ctx) error {
+	accounts, infoMap, err := h.shelterPage(c)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
+		}
 		return c.JSON(response.ShelterResponseModel{
 			RetCode: "400",
-			Message: "Failed to fetch shelter info",
+			Message: "Failed to fetch shelters",
 			Data:    nil,
 		})
 	}
 
-	// Create a map of ShelterID to ShelterInfo for faster lookup
-	infoMap := make(map[uint]models.ShelterInfo)
-	for _, info := range infos {
-		infoMap[info.ShelterID] = info
-	}
-
 	// Combine data
-	var combined []fiber.Map
-	for _, account := range accounts {
+	combined := []fiber.Map{}
+	for _, account := range accounts.Items {
 		if info, ok := infoMap[account.ShelterID]; ok {
 			combined = append(combined, fiber.Map{
 				"shelter":    account,
@@ -460,15 +420,55 @@ func GetAllSheltersAdmintry(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(response.ShelterResponseModel{
-		RetCode: "200",
-		Message: "All shelters retrieved successfully",
-		Data:    combined,
+		RetCode:    "200",
+		Message:    "All shelters retrieved successfully",
+		Data:       combined,
+		Pagination: pageInfo(accounts),
 	})
 }
 
-func GetAllAdoptersAdmintry(c *fiber.Ctx) error {
-	var accounts []models.AdopterAccount
-	if err := middleware.DBConn.Find(&accounts).Error; err != nil {
+// shelterPage reads the shelter list parameters and returns the requested
+// page of accounts with their profiles
+func (h *AdminHandler) shelterPage(c *fiber.Ctx) (pagination.Result[models.ShelterAccount], map[uint]models.ShelterInfo, error) {
+	var accounts pagination.Result[models.ShelterAccount]
+	page, err := parsePage(c)
+	if err != nil {
+		return accounts, nil, err
+	}
+	created, err := parseCreatedRange(c)
+	if err != nil {
+		return accounts, nil, err
+	}
+
+	accounts, err = h.repos.Shelters.AccountPage(repository.ShelterFilter{
+		Status:     statusQuery(c, "status"),
+		RegStatus:  statusQuery(c, "reg_status"),
+		Created:    created,
+		HasProfile: true,
+	}, page)
+	if err != nil {
+		if isBadListQuery(err) {
+			return accounts, nil, err
+		}
+		return accounts, nil, fmt.Errorf("fetching shelter accounts: %w", err)
+	}
+
+	infoMap := make(map[uint]models.ShelterInfo, len(accounts.Items))
+	if len(accounts.Items) > 0 {
+		infos, err := h.repos.Shelters.Infos(getShelterIDs(accounts.Items)...)
+		if err != nil {
+			return accounts, nil, fmt.Errorf("fetching shelter info: %w", err)
+		}
+		for _, info := range infos {
+			infoMap[info.ShelterID] = info
+		}
+	}
+	return accounts, infoMap, nil
+}
+
+func (h *AdminHandler) GetAllAdoptersAdmintry(c *fiber.Ctx) error {
+	accounts, err := h.repos.Adopters.Accounts(repository.AdopterFilter{})
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "400",
 			Message: "Failed to fetch adopter accounts",
@@ -476,8 +476,8 @@ func GetAllAdoptersAdmintry(c *fiber.Ctx) error {
 		})
 	}
 
-	var infos []models.AdopterInfo
-	if err := middleware.DBConn.Find(&infos).Error; err != nil {
+	infos, err := h.repos.Adopters.Infos()
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "400",
 			Message: "Failed to fetch adopter info",
@@ -509,62 +509,78 @@ func GetAllAdoptersAdmintry(c *fiber.Ctx) error {
 	})
 }
 
-func ApproveShelterRegStatus(c *fiber.Ctx) error {
+func (h *AdminHandler) ApproveShelterRegStatus(c *fiber.Ctx) error {
 	// Get shelter_id from the URL
-	shelterID := c.Params("id")
+	shelterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
 
-	// Fetch the shelter to check its current status
 	var shelter models.ShelterAccount
-	if err := middleware.DBConn.Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"message": "Shelter not found",
-			})
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Shelters.CheckVersion(uint(shelterID), ifMatch(c)); err != nil {
+			return err
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Database error",
-			"error":   err.Error(),
-		})
+		shelter, _, err = tx.Shelters.DecideRegistration(uint(shelterID), currentAdminID(c),
+			services.RegStatusApproved, "", "", h.cfg.Review.ClaimTTL)
+		return err
+	})
+	if err != nil {
+		return statusTransitionError(c, err, "Shelter not found", "Failed to approve shelter registration")
 	}
 
-	// Check if the shelter is already approved
-	if shelter.RegStatus == "approved" {
+	setETag(c, shelter.Version)
+	return c.JSON(fiber.Map{
+		"message": "Shelter registration approved successfully",
+		"data": fiber.Map{
+			"shelter_id": shelter.ShelterID,
+			"username":   shelter.Username,
+			"reg_status": shelter.RegStatus,
+			"status":     shelter.Status,
+		},
+	})
+}
+
+// statusTransitionError maps account status service errors to HTTP responses
+func statusTransitionError(c *fiber.Ctx, err error, notFoundMessage, failedMessage string) error {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": notFoundMessage,
+		})
+	case errors.Is(err, services.ErrAdminIDRequired):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrVersionMismatch):
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrAlreadyInStatus), errors.Is(err, services.ErrRequiredDocsNotAccepted),
+		errors.Is(err, services.ErrClaimedByOther), errors.Is(err, services.ErrNotPending),
+		errors.Is(err, services.ErrShelterOnHold):
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"message": "Shelter is already approved",
+			"message": err.Error(),
 		})
-	}
-
-	// Check if the shelter is pending
-	if shelter.RegStatus != "pending" {
+	case errors.Is(err, services.ErrInvalidStatus), errors.Is(err, services.ErrInvalidTransition),
+		errors.Is(err, services.ErrRejectionReasonRequired), errors.Is(err, services.ErrUnknownRejectionReason),
+		errors.Is(err, services.ErrNotRejected):
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Shelter registration is not pending",
+			"message": err.Error(),
 		})
 	}
-
-	// Update the shelter's reg_status to "approved"
-	result := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("shelter_id = ?", shelterID).
-		Update("reg_status", "approved")
-
-	// Check for errors during the update
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to approve shelter registration",
-			"error":   result.Error.Error(),
-		})
-	}
-
-	return c.JSON(fiber.Map{
-		"message": "Shelter registration approved successfully",
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": failedMessage,
+		"error":   err.Error(),
 	})
 }
 
-func GetShelter(c *fiber.Ctx) error {
+func (h *AdminHandler) GetShelter(c *fiber.Ctx) error {
 	// Fetch all shelter info
-	var shelters []models.ShelterInfo
-	result := middleware.DBConn.Find(&shelters)
-
-	if result.Error != nil {
+	shelters, err := h.repos.Shelters.Infos()
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Error retrieving shelters",
 		})
@@ -573,31 +589,29 @@ func GetShelter(c *fiber.Ctx) error {
 	return c.JSON(shelters)
 }
 
-func GetAllSheltersByID(c *fiber.Ctx) error {
-	ShelterID := c.Params("id")
+func (h *AdminHandler) GetAllSheltersByID(c *fiber.Ctx) error {
+	ShelterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "shelter info not found",
+		})
+	}
 
 	// Fetch shelter info by ID
-	var ShelterInfo models.ShelterInfo
-	infoResult := middleware.DBConn.Table("shelterinfo").Where("shelter_id = ?", ShelterID).First(&ShelterInfo)
-
-	if errors.Is(infoResult.Error, gorm.ErrRecordNotFound) {
+	ShelterInfo, err := h.repos.Shelters.Info(uint(ShelterID))
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"message": "shelter info not found",
 		})
-	} else if infoResult.Error != nil {
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Database error",
 		})
 	}
 
-	// Fetch shelter account associated with the shelter info
-	var shelterData models.ShelterInfo
-	accountResult := middleware.DBConn.Where("shelter_id = ?", ShelterID).First(&shelterData)
-
-	if accountResult.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to fetch shelter info",
-		})
+	// The account version is the ETag for status changes on this shelter
+	if account, err := h.repos.Shelters.Account(uint(ShelterID)); err == nil {
+		setETag(c, account.Version)
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -608,13 +622,10 @@ func GetAllSheltersByID(c *fiber.Ctx) error {
 	})
 }
 
-func CountActiveShelters(c *fiber.Ctx) error {
-	var count int64
-
+func (h *AdminHandler) CountActiveShelters(c *fiber.Ctx) error {
 	// Count shelters with status = "active"
-	if err := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("status = ?", "active").
-		Count(&count).Error; err != nil {
+	count, err := h.repos.Shelters.CountAccounts(repository.ShelterFilter{Status: "active"})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count active shelters",
 			"error":   err.Error(),
@@ -627,11 +638,10 @@ func CountActiveShelters(c *fiber.Ctx) error {
 	})
 }
 
-func CountAdopters(c *fiber.Ctx) error {
-	var count int64
-
+func (h *AdminHandler) CountAdopters(c *fiber.Ctx) error {
 	// Count all adopters
-	if err := middleware.DBConn.Model(&models.AdopterAccount{}).Count(&count).Error; err != nil {
+	count, err := h.repos.Adopters.CountAccounts(repository.AdopterFilter{})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count adopters",
 			"error":   err.Error(),
@@ -644,13 +654,10 @@ func CountAdopters(c *fiber.Ctx) error {
 	})
 }
 
-func CountPets(c *fiber.Ctx) error {
-	var count int64
-
-	// Count all pets except those with status "unavailable"
-	if err := middleware.DBConn.Model(&models.PetInfo{}).
-		Where("status != ?", "unavailable").
-		Count(&count).Error; err != nil {
+func (h *AdminHandler) CountPets(c *fiber.Ctx) error {
+	// Count all listed pets except those with status "unavailable"
+	count, err := h.repos.Pets.Count(repository.PetFilter{ListedOnly: true, ExcludeStatuses: []string{"unavailable"}})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count pets",
 			"error":   err.Error(),
@@ -663,12 +670,9 @@ func CountPets(c *fiber.Ctx) error {
 	})
 }
 
-func CountPendingShelters(c *fiber.Ctx) error {
-	var count int64
-
-	if err := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("reg_status = ?", "pending").
-		Count(&count).Error; err != nil {
+func (h *AdminHandler) CountPendingShelters(c *fiber.Ctx) error {
+	count, err := h.repos.Shelters.CountAccounts(repository.ShelterFilter{RegStatus: "pending"})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count pending shelters",
 			"error":   err.Error(),
@@ -681,12 +685,9 @@ func CountPendingShelters(c *fiber.Ctx) error {
 	})
 }
 
-func CountApprovedShelters(c *fiber.Ctx) error {
-	var count int64
-
-	if err := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("reg_status = ?", "approved").
-		Count(&count).Error; err != nil {
+func (h *AdminHandler) CountApprovedShelters(c *fiber.Ctx) error {
+	count, err := h.repos.Shelters.CountAccounts(repository.ShelterFilter{RegStatus: "approved"})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count approve shelters",
 			"error":   err.Error(),
@@ -699,13 +700,11 @@ func CountApprovedShelters(c *fiber.Ctx) error {
 	})
 }
 
-func CountAdoptedPets(c *fiber.Ctx) error {
-	var count int64
-
-	// Count pets where adoption_status is "adopted"
-	if err := middleware.DBConn.Model(&models.PetInfo{}).
-		Where("status = ?", "adopted").
-		Count(&count).Error; err != nil {
+func (h *AdminHandler) CountAdoptedPets(c *fiber.Ctx) error {
+	// Count pets where adoption_status is "adopted", leaving out pets hidden
+	// with a blocked or deleted shelter
+	count, err := h.repos.Pets.Count(repository.PetFilter{Status: "adopted", ListedOnly: true})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to count adopted pets",
 			"error":   err.Error(),
@@ -718,14 +717,28 @@ func CountAdoptedPets(c *fiber.Ctx) error {
 	})
 }
 
-func GetInactiveAdopters(c *fiber.Ctx) error {
-	var inactiveAdopters []models.AdopterAccount
+// GetInactiveAdopters lists deactivated adopters a page at a time. Filters:
+// ?created_from=, ?created_to=; see parsePage for paging and sort.
+func (h *AdminHandler) GetInactiveAdopters(c *fiber.Ctx) error {
+	page, err := parsePage(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
+	created, err := parseCreatedRange(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
 
 	// Fetch adopter accounts with status = "inactive"
-	if err := middleware.DBConn.
-		Where("status = ?", "inactive").
-		Find(&inactiveAdopters).
-		Error; err != nil {
+	inactiveAdopters, err := h.repos.Adopters.AccountPage(repository.AdopterFilter{
+		Status:     "inactive",
+		Created:    created,
+		HasProfile: true,
+	}, page)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch inactive adopter accounts",
 			"error":   err.Error(),
@@ -733,26 +746,17 @@ func GetInactiveAdopters(c *fiber.Ctx) error {
 	}
 
 	// Fetch corresponding adopter info
-	var adopterInfos []models.AdopterInfo
-	if err := middleware.DBConn.
-		Where("adopter_id IN ?", getAdopterIDs(inactiveAdopters)).
-		Find(&adopterInfos).
-		Error; err != nil {
+	infoMap, err := h.adopterInfoMap(getAdopterIDs(inactiveAdopters.Items))
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch adopter info",
 			"error":   err.Error(),
 		})
 	}
 
-	// Map adopter info by AdopterID for easy lookup
-	infoMap := make(map[uint]models.AdopterInfo)
-	for _, info := range adopterInfos {
-		infoMap[info.AdopterID] = info
-	}
-
 	// Combine adopter accounts with their info
-	var combined []fiber.Map
-	for _, adopter := range inactiveAdopters {
+	combined := make([]fiber.Map, 0, len(inactiveAdopters.Items))
+	for _, adopter := range inactiveAdopters.Items {
 		if info, ok := infoMap[adopter.AdopterID]; ok {
 			combined = append(combined, fiber.Map{
 				"adopter": adopter,
@@ -762,11 +766,28 @@ func GetInactiveAdopters(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Inactive adopters retrieved successfully",
-		"data":    combined,
+		"message":    "Inactive adopters retrieved successfully",
+		"data":       combined,
+		"pagination": pageInfo(inactiveAdopters),
 	})
 }
 
+// adopterInfoMap maps the profiles of the given adopters by ID
+func (h *AdminHandler) adopterInfoMap(ids []uint) (map[uint]models.AdopterInfo, error) {
+	infoMap := make(map[uint]models.AdopterInfo, len(ids))
+	if len(ids) == 0 {
+		return infoMap, nil
+	}
+	infos, err := h.repos.Adopters.Infos(ids...)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		infoMap[info.AdopterID] = info
+	}
+	return infoMap, nil
+}
+
 // Helper function to extract AdopterIDs from AdopterAccount slice
 func getAdopterIDs(adopters []models.AdopterAccount) []uint {
 	var ids []uint
@@ -775,183 +796,243 @@ func getAdopterIDs(adopters []models.AdopterAccount) []uint {
 	}
 	return ids
 }
-func ActivateAdopter(c *fiber.Ctx) error {
+func (h *AdminHandler) ActivateAdopter(c *fiber.Ctx) error {
 	// Get adopter_id from the URL
-	adopterID := c.Params("id")
-
-	// Fetch the adopter to check its current status
-	var adopter models.AdopterAccount
-	if err := middleware.DBConn.Where("adopter_id = ?", adopterID).First(&adopter).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"message": "Adopter not found",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Database error",
-			"error":   err.Error(),
-		})
-	}
-
-	// Check if the adopter is already active
-	if adopter.Status == "active" {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"message": "Adopter is already active",
-		})
-	}
-
-	// Check if the adopter is inactive
-	if adopter.Status != "inactive" {
+	adopterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Adopter status is not inactive",
+			"message": "Invalid adopter ID",
 		})
 	}
 
-	// Update the adopter's status to "active"
-	result := middleware.DBConn.Model(&models.AdopterAccount{}).
-		Where("adopter_id = ?", adopterID).
-		Update("status", "active")
-
-	// Check for errors during the update
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to activate adopter",
-			"error":   result.Error.Error(),
-		})
+	// ?restore=true releases frozen applications and reopens withdrawn ones
+	var adopter models.AdopterAccount
+	var cascade services.CascadeResult
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Adopters.CheckVersion(uint(adopterID), ifMatch(c)); err != nil {
+			return err
+		}
+		adopter, cascade, err = tx.Adopters.SetStatus(uint(adopterID), currentAdminID(c), services.StatusActive,
+			services.AdopterStatusOptions{Restore: c.QueryBool("restore", false)})
+		return err
+	})
+	if err != nil {
+		return statusTransitionError(c, err, "Adopter not found", "Failed to activate adopter")
 	}
 
+	setETag(c, adopter.Version)
+
 	// Return success response
 	return c.JSON(fiber.Map{
 		"message": "Adopter activated successfully",
 		"data": fiber.Map{
 			"adopter_id": adopter.AdopterID,
 			"username":   adopter.Username,
-			"status":     "active",
+			"status":     adopter.Status,
+			"cascade":    cascade,
 		},
 	})
 }
 
-func GetSubmittedReports(c *fiber.Ctx) error {
+func (h *AdminHandler) GetSubmittedReports(c *fiber.Ctx) error {
 	// Response structures
 	type ReportedBy struct {
-		AdopterID    uint   `json:"adopter_id"`
-		AdopterName  string `json:"adopter_name"`
-		AdopterEmail string `json:"adopter_email"`
+		AdopterID    uint                 `json:"adopter_id"`
+		AdopterName  string               `json:"adopter_name"`
+		AdopterEmail string               `json:"adopter_email"`
+		Credibility  services.Credibility `json:"credibility"`
 	}
 
 	type ReportDetail struct {
-		ID          uint       `json:"id"`
-		Reason      string     `json:"reason"`
-		Description string     `json:"description"`
-		Status      string     `json:"status"`
-		CreatedAt   string     `json:"created_at"` // Changed from time.Time to string
-		ReportedBy  ReportedBy `json:"reported_by"`
+		ID          uint                        `json:"id"`
+		Reason      string                      `json:"reason"`
+		Description string                      `json:"description"`
+		Category    services.ReportCategoryView `json:"category"`
+		Status      string                      `json:"status"`
+		Version     uint                        `json:"version"`    // send back in If-Match; lists have no ETag
+		CreatedAt   string                      `json:"created_at"` // Changed from time.Time to string
+		ReportedBy  ReportedBy                  `json:"reported_by"`
+		Attachments []models.ReportAttachment   `json:"attachments"`
+
+		createdAt time.Time
+	}
+
+	type ShelterReportResponse struct {
+		ShelterID      uint                        `json:"shelter_id"`
+		ShelterName    string                      `json:"shelter_name"`
+		ShelterEmail   string                      `json:"shelter_email"`
+		ShelterStatus  string                      `json:"shelter_status"`
+		ShelterProfile string                      `json:"shelter_profile"`
+		TotalReports   int                         `json:"total_reports"`
+		MaxSeverity    int                         `json:"max_severity"`
+		Flags          services.ShelterFlagSummary `json:"flags"`
+		Reports        []ReportDetail              `json:"reports"`
+	}
+
+	// Helper function to format time
+	formatTime := func(t time.Time) string {
+		return t.Format("01-02-2006 03:04 PM") // MM-DD-YYYY hh:mm AM/PM format
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
+	created, err := parseCreatedRange(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
+
+	// Page the reported shelters in SQL: escalated shelters first, then by
+	// worst report severity and flag priority unless ?sort= asks otherwise
+	filter := repository.ReportFilter{
+		Status:        "reported",
+		ShelterStatus: "active",
+		Created:       created,
+	}
+	groups, err := h.repos.Reports.ReportedShelters(filter, page)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch reports",
+			"error":   err.Error(),
+		})
 	}
 
-	type ShelterReportResponse struct {
-		ShelterID      uint           `json:"shelter_id"`
-		ShelterName    string         `json:"shelter_name"`
-		ShelterEmail   string         `json:"shelter_email"`
-		ShelterStatus  string         `json:"shelter_status"`
-		ShelterProfile string         `json:"shelter_profile"`
-		TotalReports   int            `json:"total_reports"`
-		Reports        []ReportDetail `json:"reports"`
+	// Reports, with preloaded relationships, only for the shelters on this page
+	pageShelterIDs := make([]uint, len(groups.Items))
+	for i, group := range groups.Items {
+		pageShelterIDs[i] = group.ShelterID
+	}
+	var submittedReports []models.SubmittedReport
+	if len(pageShelterIDs) > 0 {
+		filter.ShelterIDs = pageShelterIDs
+		if submittedReports, err = h.repos.Reports.Reports(filter); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to fetch reports",
+				"error":   err.Error(),
+			})
+		}
 	}
 
-	// Helper function to clean strings
-	cleanString := func(s string) string {
-		s = strings.ReplaceAll(s, "{", "")
-		s = strings.ReplaceAll(s, "}", "")
-		s = strings.ReplaceAll(s, "\"", "")
-		return strings.TrimSpace(s)
+	// Load the report taxonomy for severity
+	categories, err := h.repos.Reports.Categories()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report categories",
+			"error":   err.Error(),
+		})
 	}
 
-	// Helper function to format time
-	formatTime := func(t time.Time) string {
-		return t.Format("01-02-2006 03:04 PM") // MM-DD-YYYY hh:mm AM/PM format
+	// Credibility of everyone who filed one of these reports
+	reporterIDs := make([]uint, 0, len(submittedReports))
+	for _, report := range submittedReports {
+		reporterIDs = append(reporterIDs, report.AdopterID)
+	}
+	credibility, err := h.repos.Reports.Credibility(reporterIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch reporter credibility",
+			"error":   err.Error(),
+		})
 	}
 
-	// Fetch reports with preloaded relationships
-	var submittedReports []models.SubmittedReport
-	if err := middleware.DBConn.
-		Where("status = ?", "reported").
-		Preload("Shelter").
-		Preload("Adopter").
-		Find(&submittedReports).Error; err != nil {
+	// Attachment lists for every report, kept whatever the report status
+	reportIDs := make([]uint, 0, len(submittedReports))
+	for _, report := range submittedReports {
+		reportIDs = append(reportIDs, report.ID)
+	}
+	attachments, err := h.repos.Reports.Attachments(reportIDs...)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to fetch reports",
+			"message": "Failed to fetch report attachments",
 			"error":   err.Error(),
 		})
 	}
 
-	// Create a map to store shelter information
-	shelterInfoMap := make(map[uint]models.ShelterInfo)
 	shelterReportsMap := make(map[uint][]ReportDetail)
-
 	for _, report := range submittedReports {
 		// Skip if essential relationships are missing
 		if report.Shelter.ShelterID == 0 || report.Adopter.AdopterID == 0 {
 			continue
 		}
 
-		// Store shelter info if not already stored
-		if _, exists := shelterInfoMap[report.ShelterID]; !exists {
-			shelterInfoMap[report.ShelterID] = report.Shelter
-		}
-
-		// Verify shelter is active
-		var shelterAccount models.ShelterAccount
-		if err := middleware.DBConn.
-			Where("shelter_id = ? AND status = ?", report.ShelterID, "active").
-			First(&shelterAccount).Error; err != nil {
-			continue
-		}
-
 		// Create report detail with cleaned strings and formatted time
 		detail := ReportDetail{
 			ID:          report.ID,
-			Reason:      cleanString(report.Reason),
-			Description: cleanString(report.Description),
+			Reason:      services.NormalizeText(report.Reason),
+			Description: services.NormalizeText(report.Description),
+			Attachments: nonNilAttachments(attachments[report.ID]),
+			Category:    services.CategoryView(categories, report.CategoryID),
 			Status:      report.Status,
+			Version:     report.Version,
 			CreatedAt:   formatTime(report.CreatedAt), // Format the time here
+			createdAt:   report.CreatedAt,
 			ReportedBy: ReportedBy{
 				AdopterID:    report.AdopterID,
 				AdopterName:  fmt.Sprintf("%s %s", report.Adopter.FirstName, report.Adopter.LastName),
 				AdopterEmail: report.Adopter.Email,
+				Credibility:  credibility[report.AdopterID],
 			},
 		}
 
 		shelterReportsMap[report.ShelterID] = append(shelterReportsMap[report.ShelterID], detail)
 	}
 
-	// Prepare final response
-	var response []ShelterReportResponse
-	for shelterID, reports := range shelterReportsMap {
-		shelterInfo := shelterInfoMap[shelterID]
+	// Open rule flags and profile images for the shelters on this page
+	flagSummaries, err := h.repos.Shelters.FlagSummaries(pageShelterIDs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch shelter flags",
+			"error":   err.Error(),
+		})
+	}
+	media, err := h.repos.Shelters.Media(pageShelterIDs...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch shelter media",
+			"error":   err.Error(),
+		})
+	}
+
+	// Prepare final response in page order
+	response := make([]ShelterReportResponse, 0, len(groups.Items))
+	for _, group := range groups.Items {
+		reports := shelterReportsMap[group.ShelterID]
+		if reports == nil {
+			reports = []ReportDetail{}
+		}
 
-		// Get shelter profile image
-		var shelterMedia models.ShelterMedia
-		middleware.DBConn.
-			Where("shelter_id = ?", shelterID).
-			First(&shelterMedia)
+		// Most severe, then newest reports first
+		sort.SliceStable(reports, func(i, j int) bool {
+			if reports[i].Category.Severity != reports[j].Category.Severity {
+				return reports[i].Category.Severity > reports[j].Category.Severity
+			}
+			return reports[i].createdAt.After(reports[j].createdAt)
+		})
 
 		response = append(response, ShelterReportResponse{
-			ShelterID:      shelterID,
-			ShelterName:    shelterInfo.ShelterName,
-			ShelterEmail:   shelterInfo.ShelterEmail,
+			ShelterID:      group.ShelterID,
+			ShelterName:    group.ShelterName,
+			ShelterEmail:   group.ShelterEmail,
 			ShelterStatus:  "active",
-			ShelterProfile: shelterMedia.ShelterProfile,
-			TotalReports:   len(reports),
+			ShelterProfile: media[group.ShelterID].ShelterProfile,
+			TotalReports:   group.TotalReports,
+			MaxSeverity:    group.MaxSeverity,
+			Flags:          flagSummaries[group.ShelterID],
 			Reports:        reports,
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"data": response,
+		"data":       response,
+		"pagination": pageInfo(groups),
 	})
 }
 
-func GetShelterPetCounts(c *fiber.Ctx) error {
+func (h *AdminHandler) GetShelterPetCounts(c *fiber.Ctx) error {
 	type ShelterPetCount struct {
 		ShelterID       uint    `json:"shelter_id"`
 		ShelterName     string  `json:"shelter_name"`
@@ -965,80 +1046,29 @@ func GetShelterPetCounts(c *fiber.Ctx) error {
 
 	result := make([]ShelterPetCount, 0)
 
-	// 1. Get active and approved shelters
-	var shelters []models.ShelterAccount
-	if err := middleware.DBConn.
-		Where("status = ? AND reg_status = ?", "active", "approved").
-		Find(&shelters).
-		Error; err != nil {
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Shelter pet counts retrieved successfully",
-			"data":    result,
+	// Count listed pets, excluding "unavailable" ones, of active and approved
+	// shelters in one grouped query
+	stats, err := h.repos.Pets.ShelterStats(
+		repository.ShelterFilter{Status: "active", RegStatus: "approved"},
+		repository.PetFilter{ExcludeStatuses: []string{"unavailable"}, ListedOnly: true},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to count shelter pets",
+			"error":   err.Error(),
 		})
 	}
 
-	for _, shelter := range shelters {
-		// 2. Get shelter info
-		var shelterInfo models.ShelterInfo
-		if err := middleware.DBConn.
-			Where("shelter_id = ?", shelter.ShelterID).
-			First(&shelterInfo).
-			Error; err != nil {
-			continue
-		}
-
-		// 3. Get pets excluding "unavailable" status
-		var pets []models.PetInfo
-		if err := middleware.DBConn.
-			Table("petinfo"). // Explicit table name
-			Preload("PetMedia").
-			Where("shelter_id = ? AND status NOT IN ?", shelter.ShelterID, []string{"unavailable"}).
-			Find(&pets).
-			Error; err != nil {
-			continue
-		}
-
-		// Count pets by type and vaccination status
-		counts := struct {
-			Total        int
-			Cats         int
-			Dogs         int
-			Vaccinated   int
-			Unvaccinated int
-		}{Total: len(pets)}
-
-		for _, pet := range pets {
-			// Count by pet type
-			petType := strings.ToLower(pet.PetType)
-			if petType == "cat" {
-				counts.Cats++
-			} else if petType == "dog" {
-				counts.Dogs++
-			}
-
-			// Count vaccination status
-			if pet.PetMedia.PetVaccine != "" && strings.TrimSpace(pet.PetMedia.PetVaccine) != "" {
-				counts.Vaccinated++
-			} else {
-				counts.Unvaccinated++
-			}
-		}
-
-		// Calculate vaccination rate
-		var rate float64
-		if counts.Total > 0 {
-			rate = float64(counts.Vaccinated) / float64(counts.Total) * 100
-		}
-
+	for _, shelter := range stats {
 		result = append(result, ShelterPetCount{
 			ShelterID:       shelter.ShelterID,
-			ShelterName:     shelterInfo.ShelterName,
-			TotalPets:       counts.Total,
-			Cats:            counts.Cats,
-			Dogs:            counts.Dogs,
-			Vaccinated:      counts.Vaccinated,
-			Unvaccinated:    counts.Unvaccinated,
-			VaccinationRate: math.Round(rate*100) / 100, // Round to 2 decimal places
+			ShelterName:     shelter.ShelterName,
+			TotalPets:       shelter.Total,
+			Cats:            shelter.Cats,
+			Dogs:            shelter.Dogs,
+			Vaccinated:      shelter.Vaccinated,
+			Unvaccinated:    shelter.Total - shelter.Vaccinated,
+			VaccinationRate: vaccinationRate(shelter.Vaccinated, shelter.Total),
 		})
 	}
 
@@ -1048,7 +1078,7 @@ func GetShelterPetCounts(c *fiber.Ctx) error {
 	})
 }
 
-func GetShelterVaccinationCounts(c *fiber.Ctx) error {
+func (h *AdminHandler) GetShelterVaccinationCounts(c *fiber.Ctx) error {
 	type ShelterVaccinationCount struct {
 		ShelterID       uint    `json:"shelter_id"`
 		ShelterName     string  `json:"shelter_name"`
@@ -1059,67 +1089,26 @@ func GetShelterVaccinationCounts(c *fiber.Ctx) error {
 
 	result := make([]ShelterVaccinationCount, 0)
 
-	// Get active and approved shelters
-	var shelters []models.ShelterAccount
-	if err := middleware.DBConn.
-		Where("status = ? AND reg_status = ?", "active", "approved").
-		Find(&shelters).
-		Error; err != nil {
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Shelter vaccination counts retrieved successfully",
-			"data":    result,
+	// Count listed, non-archived pets of active and approved shelters in one
+	// grouped query
+	stats, err := h.repos.Pets.ShelterStats(
+		repository.ShelterFilter{Status: "active", RegStatus: "approved"},
+		repository.PetFilter{ExcludeStatuses: []string{"archived"}, ListedOnly: true},
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to count shelter vaccinations",
+			"error":   err.Error(),
 		})
 	}
 
-	for _, shelter := range shelters {
-		// Get shelter info
-		var shelterInfo models.ShelterInfo
-		if err := middleware.DBConn.
-			Where("shelter_id = ?", shelter.ShelterID).
-			First(&shelterInfo).
-			Error; err != nil {
-			continue
-		}
-
-		// Get non-archived pets with media - using explicit table name
-		var pets []models.PetInfo
-		if err := middleware.DBConn.
-			Table("petinfo"). // Explicit table name
-			Preload("PetMedia").
-			Where("shelter_id = ? AND status != ?", shelter.ShelterID, "archived").
-			Find(&pets).
-			Error; err != nil {
-			continue
-		}
-
-		// Count vaccination status
-		counts := struct {
-			Vaccinated   int
-			Unvaccinated int
-		}{}
-
-		for _, pet := range pets {
-			// Safely check PetMedia (in case Preload failed)
-			if pet.PetMedia.PetVaccine != "" && strings.TrimSpace(pet.PetMedia.PetVaccine) != "" {
-				counts.Vaccinated++
-			} else {
-				counts.Unvaccinated++
-			}
-		}
-
-		// Calculate vaccination rate
-		total := counts.Vaccinated + counts.Unvaccinated
-		var rate float64
-		if total > 0 {
-			rate = float64(counts.Vaccinated) / float64(total) * 100
-		}
-
+	for _, shelter := range stats {
 		result = append(result, ShelterVaccinationCount{
 			ShelterID:       shelter.ShelterID,
-			ShelterName:     shelterInfo.ShelterName,
-			Vaccinated:      counts.Vaccinated,
-			Unvaccinated:    counts.Unvaccinated,
-			VaccinationRate: math.Round(rate*100) / 100, // Round to 2 decimal places
+			ShelterName:     shelter.ShelterName,
+			Vaccinated:      shelter.Vaccinated,
+			Unvaccinated:    shelter.Total - shelter.Vaccinated,
+			VaccinationRate: vaccinationRate(shelter.Vaccinated, shelter.Total),
 		})
 	}
 
@@ -1129,7 +1118,17 @@ func GetShelterVaccinationCounts(c *fiber.Ctx) error {
 	})
 }
 
-func UpdateShelterStatusByID(c *fiber.Ctx) error {
+// vaccinationRate is the vaccinated share of total as a percentage rounded
+// to 2 decimal places
+func vaccinationRate(vaccinated, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	rate := float64(vaccinated) / float64(total) * 100
+	return math.Round(rate*100) / 100
+}
+
+func (h *AdminHandler) UpdateShelterStatusByID(c *fiber.Ctx) error {
 	shelterID := c.Params("id")
 
 	id, err := strconv.ParseUint(shelterID, 10, 32)
@@ -1139,59 +1138,53 @@ func UpdateShelterStatusByID(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if shelter account exists
-	var shelterAccount models.ShelterAccount
-	if err := middleware.DBConn.Where("shelter_id = ?", uint(id)).First(&shelterAccount).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter account not found",
-			"error":   err.Error(),
-		})
-	}
-
-	// Check if shelter info exists
-	var shelterInfo models.ShelterInfo
-	if err := middleware.DBConn.First(&shelterInfo, uint(id)).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter info not found",
-			"error":   err.Error(),
-		})
+	// Optional suspension reason sent to the shelter
+	var request struct {
+		Reason string `json:"reason"`
 	}
-
-	// Update status in ShelterAccount
-	result := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("shelter_id = ?", uint(id)).
-		Update("status", "inactive")
-
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update shelter status",
-			"error":   result.Error.Error(),
-		})
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid request body",
+			})
+		}
 	}
 
-	// Ensure ALL reports with shelter_id and status 'reported' are updated to 'blocked'
-	reportUpdate := middleware.DBConn.Model(&models.SubmittedReport{}).
-		Where("shelter_id = ? AND status = ?", uint(id), "reported").
-		Updates(map[string]interface{}{"status": "blocked"})
-
-	if reportUpdate.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update submitted reports status",
-			"error":   reportUpdate.Error.Error(),
-		})
+	// The account, its reports, pets and applications change together;
+	// notices go out only once all of it has committed
+	var result services.ShelterModerationResult
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Shelters.CheckVersion(uint(id), ifMatch(c)); err != nil {
+			return err
+		}
+		if result, err = tx.Shelters.Block(uint(id), currentAdminID(c), h.cfg.ShelterBlock, request.Reason); err != nil {
+			return err
+		}
+		tx.AfterCommit(h.dispatchOutcomeNotices)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrShelterInfoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Shelter info not found",
+			})
+		}
+		return statusTransitionError(c, err, "Shelter account not found", "Failed to update shelter status")
 	}
 
+	setETag(c, result.Account.Version)
 	return c.JSON(fiber.Map{
 		"message":         "Shelter blocked successfully",
-		"shelter_id":      shelterAccount.ShelterID,
-		"shelter_name":    shelterInfo.ShelterName,
-		"shelter_email":   shelterInfo.ShelterEmail,
-		"shelter_status":  "inactive",
-		"reports_updated": reportUpdate.RowsAffected,
+		"shelter_id":      result.Account.ShelterID,
+		"shelter_name":    result.Info.ShelterName,
+		"shelter_email":   result.Info.ShelterEmail,
+		"shelter_status":  result.Account.Status,
+		"reports_updated": result.ReportsUpdated,
+		"cascade":         result.Cascade,
 	})
 }
 
-func GetBlockedShelters(c *fiber.Ctx) error {
+func (h *AdminHandler) GetBlockedShelters(c *fiber.Ctx) error {
 	// Response structures
 	type ReportedBy struct {
 		AdopterID    uint   `json:"adopter_id"`
@@ -1200,12 +1193,14 @@ func GetBlockedShelters(c *fiber.Ctx) error {
 	}
 
 	type ReportDetail struct {
-		ID          uint       `json:"id"`
-		Reason      string     `json:"reason"`
-		Description string     `json:"description"`
-		Status      string     `json:"status"`
-		CreatedAt   string     `json:"created_at"` // Changed from time.Time to string
-		ReportedBy  ReportedBy `json:"reported_by"`
+		ID          uint                      `json:"id"`
+		Reason      string                    `json:"reason"`
+		Description string                    `json:"description"`
+		Status      string                    `json:"status"`
+		Version     uint                      `json:"version"`    // send back in If-Match; lists have no ETag
+		CreatedAt   string                    `json:"created_at"` // Changed from time.Time to string
+		ReportedBy  ReportedBy                `json:"reported_by"`
+		Attachments []models.ReportAttachment `json:"attachments"`
 	}
 
 	type ShelterReportResponse struct {
@@ -1218,32 +1213,33 @@ func GetBlockedShelters(c *fiber.Ctx) error {
 		Reports        []ReportDetail `json:"reports"`
 	}
 
-	// Helper function to clean strings
-	cleanString := func(s string) string {
-		s = strings.ReplaceAll(s, "{", "")
-		s = strings.ReplaceAll(s, "}", "")
-		s = strings.ReplaceAll(s, "\"", "")
-		return strings.TrimSpace(s)
-	}
-
 	// Helper function to format time
 	formatTime := func(t time.Time) string {
 		return t.Format("01-02-2006 03:04 PM") // MM-DD-YYYY hh:mm AM/PM format
 	}
 
-	// Fetch reports with preloaded relationships
-	var submittedReports []models.SubmittedReport
-	if err := middleware.DBConn.
-		Where("status = ?", "blocked").
-		Preload("Shelter").
-		Preload("Adopter").
-		Find(&submittedReports).Error; err != nil {
+	// Fetch reports against blocked shelters with preloaded relationships
+	submittedReports, err := h.repos.Reports.Reports(repository.ReportFilter{Status: "blocked", ShelterStatus: "inactive"})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch reports",
 			"error":   err.Error(),
 		})
 	}
 
+	// Attachment lists for every report, kept whatever the report status
+	reportIDs := make([]uint, 0, len(submittedReports))
+	for _, report := range submittedReports {
+		reportIDs = append(reportIDs, report.ID)
+	}
+	attachments, err := h.repos.Reports.Attachments(reportIDs...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report attachments",
+			"error":   err.Error(),
+		})
+	}
+
 	// Create a map to store shelter information
 	shelterInfoMap := make(map[uint]models.ShelterInfo)
 	shelterReportsMap := make(map[uint][]ReportDetail)
@@ -1259,20 +1255,14 @@ func GetBlockedShelters(c *fiber.Ctx) error {
 			shelterInfoMap[report.ShelterID] = report.Shelter
 		}
 
-		// Verify shelter is active
-		var shelterAccount models.ShelterAccount
-		if err := middleware.DBConn.
-			Where("shelter_id = ? AND status = ?", report.ShelterID, "inactive").
-			First(&shelterAccount).Error; err != nil {
-			continue
-		}
-
 		// Create report detail with cleaned strings and formatted time
 		detail := ReportDetail{
 			ID:          report.ID,
-			Reason:      cleanString(report.Reason),
-			Description: cleanString(report.Description),
+			Reason:      services.NormalizeText(report.Reason),
+			Description: services.NormalizeText(report.Description),
+			Attachments: nonNilAttachments(attachments[report.ID]),
 			Status:      report.Status,
+			Version:     report.Version,
 			CreatedAt:   formatTime(report.CreatedAt), // Format the time here
 			ReportedBy: ReportedBy{
 				AdopterID:    report.AdopterID,
@@ -1284,23 +1274,30 @@ func GetBlockedShelters(c *fiber.Ctx) error {
 		shelterReportsMap[report.ShelterID] = append(shelterReportsMap[report.ShelterID], detail)
 	}
 
+	// Get profile images for the blocked shelters
+	blockedShelterIDs := make([]uint, 0, len(shelterReportsMap))
+	for shelterID := range shelterReportsMap {
+		blockedShelterIDs = append(blockedShelterIDs, shelterID)
+	}
+	media, err := h.repos.Shelters.Media(blockedShelterIDs...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch shelter media",
+			"error":   err.Error(),
+		})
+	}
+
 	// Prepare final response
 	var response []ShelterReportResponse
 	for shelterID, reports := range shelterReportsMap {
 		shelterInfo := shelterInfoMap[shelterID]
 
-		// Get shelter profile image
-		var shelterMedia models.ShelterMedia
-		middleware.DBConn.
-			Where("shelter_id = ?", shelterID).
-			First(&shelterMedia)
-
 		response = append(response, ShelterReportResponse{
 			ShelterID:      shelterID,
 			ShelterName:    shelterInfo.ShelterName,
 			ShelterEmail:   shelterInfo.ShelterEmail,
 			ShelterStatus:  "inactive",
-			ShelterProfile: shelterMedia.ShelterProfile,
+			ShelterProfile: media[shelterID].ShelterProfile,
 			TotalReports:   len(reports),
 			Reports:        reports,
 		})
@@ -1311,7 +1308,7 @@ func GetBlockedShelters(c *fiber.Ctx) error {
 	})
 }
 
-func UpdateShelterStatusByIDtoactive(c *fiber.Ctx) error {
+func (h *AdminHandler) UpdateShelterStatusByIDtoactive(c *fiber.Ctx) error {
 	shelterID := c.Params("id")
 
 	id, err := strconv.ParseUint(shelterID, 10, 32)
@@ -1321,59 +1318,39 @@ func UpdateShelterStatusByIDtoactive(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if shelter account exists
-	var shelterAccount models.ShelterAccount
-	if err := middleware.DBConn.Where("shelter_id = ?", uint(id)).First(&shelterAccount).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter account not found",
-			"error":   err.Error(),
-		})
-	}
-
-	// Check if shelter info exists
-	var shelterInfo models.ShelterInfo
-	if err := middleware.DBConn.First(&shelterInfo, uint(id)).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Shelter info not found",
-			"error":   err.Error(),
-		})
-	}
-
-	// Update status in ShelterAccount
-	result := middleware.DBConn.Model(&models.ShelterAccount{}).
-		Where("shelter_id = ?", uint(id)).
-		Update("status", "active")
-
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update shelter status",
-			"error":   result.Error.Error(),
-		})
-	}
-
-	// Ensure ALL reports with shelter_id and status 'reported' are updated to 'blocked'
-	reportUpdate := middleware.DBConn.Model(&models.SubmittedReport{}).
-		Where("shelter_id = ? AND status = ?", uint(id), "blocked").
-		Updates(map[string]interface{}{"status": "resolved"})
-
-	if reportUpdate.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Failed to update submitted reports status",
-			"error":   reportUpdate.Error.Error(),
-		})
+	var result services.ShelterModerationResult
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Shelters.CheckVersion(uint(id), ifMatch(c)); err != nil {
+			return err
+		}
+		if result, err = tx.Shelters.Reinstate(uint(id), currentAdminID(c)); err != nil {
+			return err
+		}
+		tx.AfterCommit(h.dispatchOutcomeNotices)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrShelterInfoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Shelter info not found",
+			})
+		}
+		return statusTransitionError(c, err, "Shelter account not found", "Failed to update shelter status")
 	}
 
+	setETag(c, result.Account.Version)
 	return c.JSON(fiber.Map{
 		"message":         "Shelter blocked successfully",
-		"shelter_id":      shelterAccount.ShelterID,
-		"shelter_name":    shelterInfo.ShelterName,
-		"shelter_email":   shelterInfo.ShelterEmail,
-		"shelter_status":  "active",
-		"reports_updated": reportUpdate.RowsAffected,
+		"shelter_id":      result.Account.ShelterID,
+		"shelter_name":    result.Info.ShelterName,
+		"shelter_email":   result.Info.ShelterEmail,
+		"shelter_status":  result.Account.Status,
+		"reports_updated": result.ReportsUpdated,
+		"cascade":         result.Cascade,
 	})
 }
 
-func GetAdopterInfoById(c *fiber.Ctx) error {
+func (h *AdminHandler) GetAdopterInfoById(c *fiber.Ctx) error {
 	adopterID := c.Params("adopter_id")
 
 	// Validate adopter_id
@@ -1385,15 +1362,18 @@ func GetAdopterInfoById(c *fiber.Ctx) error {
 		})
 	}
 
-	var adopterInfo models.AdopterInfo
-	infoResult := middleware.DBConn.Debug().
-		Where("adopter_id = ?", adopterID).
-		Preload("Adopter").
-		Preload("Adopter.AdopterMedia").
-		First(&adopterInfo)
+	id, err := strconv.ParseUint(adopterID, 10, 32)
+	if err != nil {
+		return c.JSON(response.AdopterResponseModel{
+			RetCode: "400",
+			Message: "Invalid adopter ID",
+			Data:    nil,
+		})
+	}
 
-	if infoResult.Error != nil {
-		if errors.Is(infoResult.Error, gorm.ErrRecordNotFound) {
+	adopterInfo, err := h.repos.Adopters.Info(uint(id))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
 			return c.JSON(response.AdopterResponseModel{
 				RetCode: "404",
 				Message: "No applications found for this adopter",
@@ -1407,32 +1387,41 @@ func GetAdopterInfoById(c *fiber.Ctx) error {
 		})
 	}
 
+	// The account version is the ETag for status changes on this adopter
+	if account, err := h.repos.Adopters.Account(uint(id)); err == nil {
+		setETag(c, account.Version)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Adoption details retrieved successfully",
 		"data":    adopterInfo,
 	})
 }
 
-func GetAllNotifications(c *fiber.Ctx) error {
+func (h *AdminHandler) GetAllNotifications(c *fiber.Ctx) error {
 	// Helper function to format time
 	formatTime := func(t time.Time) string {
 		return t.Format("01-02-2006 03:04 PM")
 	}
 
 	// Fetch all submitted reports with status "reported"
-	var submittedReports []models.SubmittedReport
-	if err := middleware.DBConn.
-		Where("status = ?", "reported").
-		Order("created_at DESC").
-		Preload("Shelter").
-		Preload("Adopter").
-		Find(&submittedReports).Error; err != nil {
+	submittedReports, err := h.repos.Reports.Reports(repository.ReportFilter{Status: "reported", NewestFirst: true})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch reports",
 			"error":   err.Error(),
 		})
 	}
 
+	// Report severity sets the notification urgency
+	categories, err := h.repos.Reports.Categories()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report categories",
+			"error":   err.Error(),
+		})
+	}
+
 	// Extract each report as individual item
 	var reportNotifications []fiber.Map
 	for _, report := range submittedReports {
@@ -1440,9 +1429,13 @@ func GetAllNotifications(c *fiber.Ctx) error {
 			continue
 		}
 
+		category := services.CategoryView(categories, report.CategoryID)
 		reportNotifications = append(reportNotifications, fiber.Map{
 			"report_id":    report.ID,
 			"reason":       report.Reason,
+			"category":     category.Name,
+			"severity":     category.Severity,
+			"urgency":      category.Urgency,
 			"description":  report.Description,
 			"status":       report.Status,
 			"created_at":   formatTime(report.CreatedAt),
@@ -1454,12 +1447,14 @@ func GetAllNotifications(c *fiber.Ctx) error {
 		})
 	}
 
+	// Most urgent reports first; the query already ordered them newest first
+	sort.SliceStable(reportNotifications, func(i, j int) bool {
+		return reportNotifications[i]["severity"].(int) > reportNotifications[j]["severity"].(int)
+	})
+
 	// Fetch shelters with reg_status = "pending"
-	var pendingShelters []models.ShelterAccount
-	if err := middleware.DBConn.
-		Where("reg_status = ?", "pending").
-		Order("created_at DESC").
-		Find(&pendingShelters).Error; err != nil {
+	pendingShelters, err := h.repos.Shelters.Accounts(repository.ShelterFilter{RegStatus: "pending", NewestFirst: true})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch shelter signup notifications",
 			"error":   err.Error(),
@@ -1467,10 +1462,8 @@ func GetAllNotifications(c *fiber.Ctx) error {
 	}
 
 	// Fetch corresponding shelter info
-	var shelterInfos []models.ShelterInfo
-	if err := middleware.DBConn.
-		Where("shelter_id IN ?", getShelterIDs(pendingShelters)).
-		Find(&shelterInfos).Error; err != nil {
+	shelterInfos, err := h.repos.Shelters.Infos(getShelterIDs(pendingShelters)...)
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Failed to fetch shelter info",
 			"error":   err.Error(),
@@ -1515,10 +1508,9 @@ func getShelterIDs(shelters []models.ShelterAccount) []uint {
 }
 
 // adoption history for shelter
-func GetApplicationsByShelterID(c *fiber.Ctx) error {
-	shelterID := c.Params("shelter_id")
-
-	if shelterID == "" {
+func (h *AdminHandler) GetApplicationsByShelterID(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("shelter_id"), 10, 32)
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "400",
 			Message: "Shelter ID is required",
@@ -1526,17 +1518,9 @@ func GetApplicationsByShelterID(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get shelter info and preload shelter media
-	var shelter models.ShelterInfo
-	shelterResult := middleware.DBConn.
-		Select("shelter_id", "shelter_name", "shelter_email", "shelter_contact", "shelter_address").
-		Preload("ShelterMedia", func(db *gorm.DB) *gorm.DB {
-			return db.Select("shelter_id", "shelter_profile")
-		}).
-		Where("shelter_id = ?", shelterID).
-		First(&shelter)
-
-	if shelterResult.Error != nil {
+	// Get shelter info with shelter media
+	shelter, err := h.repos.Shelters.Info(uint(shelterID))
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "404",
 			Message: "Shelter not found",
@@ -1545,19 +1529,22 @@ func GetApplicationsByShelterID(c *fiber.Ctx) error {
 	}
 
 	// Get adoption submissions with only the needed related fields
-	var submissions []models.AdoptionSubmission
-	queryResult := middleware.DBConn.
-		Preload("Adopter", func(db *gorm.DB) *gorm.DB {
-			return db.Select("adopter_id", "first_name", "last_name")
-		}).
-		Preload("Pet", func(db *gorm.DB) *gorm.DB {
-			return db.Select("pet_id", "pet_name")
-		}).
-		Where("shelter_id = ?", shelterID).
-		Select("application_id", "adopter_id", "pet_id", "status", "created_at").
-		Find(&submissions)
-
-	if queryResult.Error != nil {
+	submissions, err := h.repos.Applications.ByShelter(uint(shelterID))
+	if err != nil {
+		return c.JSON(response.AdopterResponseModel{
+			RetCode: "500",
+			Message: "Something went wrong retrieving adoption history",
+			Data:    nil,
+		})
+	}
+
+	// Applications on unlisted pets are frozen
+	applicationIDs := make([]uint, 0, len(submissions))
+	for _, submission := range submissions {
+		applicationIDs = append(applicationIDs, submission.ApplicationID)
+	}
+	frozen, err := h.repos.Applications.Frozen(applicationIDs)
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "500",
 			Message: "Something went wrong retrieving adoption history",
@@ -1572,6 +1559,7 @@ func GetApplicationsByShelterID(c *fiber.Ctx) error {
 			"adopter_name": submission.Adopter.FirstName + " " + submission.Adopter.LastName,
 			"pet_name":     submission.Pet.PetName,
 			"status":       submission.Status,
+			"frozen":       frozen[submission.ApplicationID],
 			"date":         submission.CreatedAt.Format("2006-01-02"),
 		})
 	}
@@ -1591,11 +1579,13 @@ func GetApplicationsByShelterID(c *fiber.Ctx) error {
 	})
 }
 
-func GetPetsByShelterID(c *fiber.Ctx) error {
-	shelterID := c.Params("shelter_id")
-
+// GetPetsByShelterID lists a shelter's pets a page at a time. Filters:
+// ?status=, ?pet_type=, ?created_from=, ?created_to=; see parsePage for
+// paging and sort.
+func (h *AdminHandler) GetPetsByShelterID(c *fiber.Ctx) error {
 	// Validate shelter_id
-	if shelterID == "" {
+	shelterID, err := strconv.ParseUint(c.Params("shelter_id"), 10, 32)
+	if err != nil {
 		return c.JSON(fiber.Map{
 			"retCode": "400",
 			"message": "Shelter ID is required",
@@ -1603,19 +1593,24 @@ func GetPetsByShelterID(c *fiber.Ctx) error {
 		})
 	}
 
-	var pets []models.PetInfo
-	infoResult := middleware.DBConn.Debug().
-		Where("shelter_id = ?", shelterID).
-		Preload("PetMedia").
-		Find(&pets)
+	page, err := parsePage(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
+	created, err := parseCreatedRange(c)
+	if err != nil {
+		return listQueryError(c, err)
+	}
 
-	if infoResult.Error != nil {
-		if errors.Is(infoResult.Error, gorm.ErrRecordNotFound) {
-			return c.JSON(fiber.Map{
-				"retCode": "404",
-				"message": "No pets found for this shelter",
-				"data":    nil,
-			})
+	pets, err := h.repos.Pets.Page(repository.PetFilter{
+		ShelterID: uint(shelterID),
+		Status:    statusQuery(c, "status"),
+		PetType:   strings.TrimSpace(c.Query("pet_type")),
+		Created:   created,
+	}, page)
+	if err != nil {
+		if isBadListQuery(err) {
+			return listQueryError(c, err)
 		}
 		return c.JSON(fiber.Map{
 			"retCode": "500",
@@ -1625,16 +1620,43 @@ func GetPetsByShelterID(c *fiber.Ctx) error {
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Pets retrieved successfully",
-		"data":    pets,
+		"message":    "Pets retrieved successfully",
+		"data":       pets.Items,
+		"pagination": pageInfo(pets),
 	})
 }
 
-// adoption history
-func GetApplicationsByAdopterID(c *fiber.Ctx) error {
-	adopterID := c.Params("adopter_id")
+// GetPetByID returns one pet with its media
+func (h *AdminHandler) GetPetByID(c *fiber.Ctx) error {
+	petID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid pet ID",
+		})
+	}
 
-	if adopterID == "" {
+	pet, err := h.repos.Pets.Pet(uint(petID))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Pet not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch pet",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Pet retrieved successfully",
+		"data":    pet,
+	})
+}
+
+// adoption history
+func (h *AdminHandler) GetApplicationsByAdopterID(c *fiber.Ctx) error {
+	adopterID, err := strconv.ParseUint(c.Params("adopter_id"), 10, 32)
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "400",
 			Message: "Adopter ID is required",
@@ -1642,17 +1664,9 @@ func GetApplicationsByAdopterID(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get adopter info and preload adopter media
-	var adopter models.AdopterInfo
-	adopterResult := middleware.DBConn.
-		Select("adopter_id", "first_name", "last_name", "email", "contact_number", "address").
-		Preload("AdopterMedia", func(db *gorm.DB) *gorm.DB {
-			return db.Select("adopter_id", "adopter_profile")
-		}).
-		Where("adopter_id = ?", adopterID).
-		First(&adopter)
-
-	if adopterResult.Error != nil {
+	// Get adopter info with adopter media
+	adopter, err := h.repos.Adopters.Info(uint(adopterID))
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "404",
 			Message: "Adopter not found",
@@ -1661,19 +1675,22 @@ func GetApplicationsByAdopterID(c *fiber.Ctx) error {
 	}
 
 	// Get adoption submissions with only the needed related fields
-	var submissions []models.AdoptionSubmission
-	queryResult := middleware.DBConn.
-		Preload("Shelter", func(db *gorm.DB) *gorm.DB {
-			return db.Select("shelter_id", "shelter_name")
-		}).
-		Preload("Pet", func(db *gorm.DB) *gorm.DB {
-			return db.Select("pet_id", "pet_name")
-		}).
-		Where("adopter_id = ?", adopterID).
-		Select("application_id", "shelter_id", "pet_id", "status", "created_at").
-		Find(&submissions)
-
-	if queryResult.Error != nil {
+	submissions, err := h.repos.Applications.ByAdopter(uint(adopterID))
+	if err != nil {
+		return c.JSON(response.AdopterResponseModel{
+			RetCode: "500",
+			Message: "Something went wrong retrieving adoption history",
+			Data:    nil,
+		})
+	}
+
+	// Applications on unlisted pets are frozen
+	applicationIDs := make([]uint, 0, len(submissions))
+	for _, submission := range submissions {
+		applicationIDs = append(applicationIDs, submission.ApplicationID)
+	}
+	frozen, err := h.repos.Applications.Frozen(applicationIDs)
+	if err != nil {
 		return c.JSON(response.AdopterResponseModel{
 			RetCode: "500",
 			Message: "Something went wrong retrieving adoption history",
@@ -1688,6 +1705,7 @@ func GetApplicationsByAdopterID(c *fiber.Ctx) error {
 			"shelter_name": submission.Shelter.ShelterName,
 			"pet_name":     submission.Pet.PetName,
 			"status":       submission.Status,
+			"frozen":       frozen[submission.ApplicationID],
 			"date":         submission.CreatedAt.Format("2006-01-02"),
 		})
 	}
@@ -1706,3 +1724,24 @@ func GetApplicationsByAdopterID(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// Helper function to read the admin ID stored by JWTMiddleware (0 when the
+// route is not behind the middleware)
+func currentAdminID(c *fiber.Ctx) uint {
+	return tokenID(c)
+}
+
+// Helper function to read the adopter ID stored by RequireAdopter
+func currentAdopterID(c *fiber.Ctx) uint {
+	return tokenID(c)
+}
+
+func tokenID(c *fiber.Ctx) uint {
+	switch id := c.Locals("id").(type) {
+	case float64:
+		return uint(id)
+	case uint:
+		return id
+	}
+	return 0
+}
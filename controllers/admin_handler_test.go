@@ -0,0 +1,786 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pethubadmin/config"
+	"pethubadmin/middleware"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// failingUnit is a unit of work that never reaches a store; every write
+// handed to it fails with err
+type failingUnit struct{ err error }
+
+func (u failingUnit) Do(func(tx repository.Repositories) error) error { return u.err }
+
+// errNoDatabase is what failingUnit returns when a test does not expect a write
+var errNoDatabase = errors.New("no database in handler tests")
+
+// newTestApp serves h as admin 7, the way the JWT middleware would
+func newTestApp(h *AdminHandler, register func(app fiber.Router)) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("id", float64(7))
+		return c.Next()
+	})
+	register(app)
+	return app
+}
+
+// newTestHandler reads through the in-memory repositories. Writes go to
+// uow, which fails every write when nil; pass mem to write to the same store.
+func newTestHandler(mem *repository.Memory, uow repository.UnitOfWork) *AdminHandler {
+	if uow == nil {
+		uow = failingUnit{errNoDatabase}
+	}
+	return NewAdminHandler(config.Config{}, mem.Repositories(), uow, middleware.NewJWTAuth(config.JWTConfig{}, nil))
+}
+
+// seed adds rows to the store in order
+func seed(t *testing.T, mem *repository.Memory, rows ...interface{}) {
+	t.Helper()
+	if err := mem.Add(rows...); err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+}
+
+// auditRows returns the number of audit entries in the store
+func auditRows(t *testing.T, mem *repository.Memory) int {
+	t.Helper()
+	logs, err := mem.Repositories().Audit.Trail("", 0, 0)
+	if err != nil {
+		t.Fatalf("reading the audit trail: %v", err)
+	}
+	return len(logs)
+}
+
+type testResponse struct {
+	status int
+	header http.Header
+	body   map[string]interface{}
+}
+
+func call(t *testing.T, app *fiber.App, method, target, body string) testResponse {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	out := testResponse{status: resp.StatusCode, header: resp.Header}
+	raw, _ := io.ReadAll(resp.Body)
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &out.body); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, target, raw, err)
+		}
+	}
+	return out
+}
+
+func dataList(t *testing.T, r testResponse) []interface{} {
+	t.Helper()
+	list, ok := r.body["data"].([]interface{})
+	if !ok {
+		t.Fatalf("data is %T, want a list: %v", r.body["data"], r.body)
+	}
+	return list
+}
+
+func TestFlagRuleCRUD(t *testing.T) {
+	mem := repository.NewMemory()
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Get("/flagrules", h.GetFlagRules)
+		app.Post("/flagrules", h.CreateFlagRule)
+		app.Put("/flagrules/:id", h.UpdateFlagRule)
+		app.Delete("/flagrules/:id", h.DeleteFlagRule)
+	})
+	rules := mem.Repositories().Flags
+	rule := func() models.FlagRule {
+		rule, _ := rules.Rule(1)
+		return rule
+	}
+
+	if r := call(t, app, "POST", "/flagrules", `{"name":"x","kind":"nope"}`); r.status != fiber.StatusBadRequest {
+		t.Fatalf("invalid rule: status %d, want 400", r.status)
+	}
+
+	r := call(t, app, "POST", "/flagrules", `{"name":"Volume","kind":"report_volume","min_reports":3,"window_days":7,"action":"escalate"}`)
+	if r.status != fiber.StatusCreated {
+		t.Fatalf("create: status %d: %v", r.status, r.body)
+	}
+	if all, _ := rules.Rules(); len(all) != 1 || all[0].CreatedBy != 7 || !all[0].Enabled {
+		t.Fatalf("stored rules = %+v", all)
+	}
+
+	r = call(t, app, "PUT", "/flagrules/1", `{"name":"Volume","kind":"report_volume","min_reports":5,"window_days":7,"action":"escalate","enabled":false}`)
+	if r.status != fiber.StatusOK {
+		t.Fatalf("update: status %d: %v", r.status, r.body)
+	}
+	if got := rule(); got.MinReports != 5 || got.Enabled {
+		t.Fatalf("updated rule = %+v", got)
+	}
+	if r := call(t, app, "PUT", "/flagrules/9", `{"name":"x"}`); r.status != fiber.StatusNotFound {
+		t.Fatalf("update missing: status %d, want 404", r.status)
+	}
+
+	if got := dataList(t, call(t, app, "GET", "/flagrules", "")); len(got) != 1 {
+		t.Fatalf("listed %d rules, want 1", len(got))
+	}
+
+	if r := call(t, app, "DELETE", "/flagrules/1", ""); r.status != fiber.StatusOK {
+		t.Fatalf("delete: status %d", r.status)
+	}
+	if r := call(t, app, "DELETE", "/flagrules/1", ""); r.status != fiber.StatusNotFound {
+		t.Fatalf("delete again: status %d, want 404", r.status)
+	}
+}
+
+func TestGetShelterFlags(t *testing.T) {
+	mem := repository.NewMemory()
+	now := time.Now()
+	seed(t, mem,
+		&models.FlagRule{RuleID: 1, Name: "Volume", Kind: services.RuleReportVolume, Action: services.FlagEscalate},
+		&models.ShelterFlag{FlagID: 1, ShelterID: 10, RuleID: 1, CreatedAt: now.Add(-2 * time.Hour)},
+		&models.ShelterFlag{FlagID: 2, ShelterID: 10, RuleID: 1, CreatedAt: now.Add(-time.Hour), ResolvedAt: &now},
+		&models.ShelterFlag{FlagID: 3, ShelterID: 11, RuleID: 1, CreatedAt: now},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/flags", h.GetShelterFlags) })
+
+	open := dataList(t, call(t, app, "GET", "/flags", ""))
+	if len(open) != 2 {
+		t.Fatalf("open flags = %d, want 2", len(open))
+	}
+	first := open[0].(map[string]interface{})
+	if first["flag_id"].(float64) != 3 || first["rule"].(map[string]interface{})["name"] != "Volume" {
+		t.Fatalf("first flag = %v, want flag 3 with its rule", first)
+	}
+
+	if got := dataList(t, call(t, app, "GET", "/flags?shelter_id=10&include_resolved=true", "")); len(got) != 2 {
+		t.Fatalf("shelter 10 flags = %d, want 2", len(got))
+	}
+}
+
+func TestGetReportDetail(t *testing.T) {
+	mem := repository.NewMemory()
+	categoryID := uint(2)
+	seed(t, mem,
+		&models.ShelterInfo{ShelterID: 10, ShelterName: "Paws"},
+		&models.AdopterInfo{AdopterID: 20, FirstName: "Ana", LastName: "Cruz", Email: "ana@example.com"},
+		&models.ReportCategory{CategoryID: 2, Code: "neglect", Name: "Neglect", Severity: 3},
+		&models.SubmittedReport{ID: 5, ShelterID: 10, AdopterID: 20, Reason: "neglect", Status: "reported", CategoryID: &categoryID, Version: 4},
+		&models.ReportAttachment{AttachmentID: 1, ReportID: 5, FileName: "a.png", FileData: "secret"},
+		&models.OutcomeNotice{NoticeID: 8, Template: "report_resolved", RecipientType: services.RecipientAdopter, RecipientID: 20, Subject: "Update"},
+		&models.OutcomeNoticeReport{NoticeID: 8, ReportID: 5},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/reports/:id", h.GetReportDetail) })
+
+	r := call(t, app, "GET", "/reports/5", "")
+	if r.status != fiber.StatusOK {
+		t.Fatalf("status %d: %v", r.status, r.body)
+	}
+	if etag := r.header.Get(fiber.HeaderETag); etag != `"4"` {
+		t.Fatalf("ETag = %q, want \"4\"", etag)
+	}
+	data := r.body["data"].(map[string]interface{})
+	if data["shelter_name"] != "Paws" || data["category"].(map[string]interface{})["code"] != "neglect" {
+		t.Fatalf("detail = %v", data)
+	}
+	attachments := data["attachments"].([]interface{})
+	if _, hasData := attachments[0].(map[string]interface{})["file_data"]; len(attachments) != 1 || hasData {
+		t.Fatalf("attachments = %v, want one without file data", attachments)
+	}
+	if notices := data["notices"].([]interface{}); len(notices) != 1 {
+		t.Fatalf("notices = %v, want 1", notices)
+	}
+
+	if r := call(t, app, "GET", "/reports/6", ""); r.status != fiber.StatusNotFound {
+		t.Fatalf("missing report: status %d, want 404", r.status)
+	}
+}
+
+func TestGetShelterDocument(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem, &models.ShelterDocument{DocumentID: 3, ShelterID: 10, DocType: "owner_id", FileData: "data"})
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Get("/documents/:id", h.GetShelterDocument)
+		app.Get("/shelters/:id/documents", h.GetShelterDocumentChecklist)
+	})
+
+	r := call(t, app, "GET", "/documents/3", "")
+	if r.status != fiber.StatusOK || r.body["data"].(map[string]interface{})["file_data"] != "data" {
+		t.Fatalf("document: status %d: %v", r.status, r.body)
+	}
+	if r := call(t, app, "GET", "/documents/4", ""); r.status != fiber.StatusNotFound {
+		t.Fatalf("missing document: status %d, want 404", r.status)
+	}
+
+	r = call(t, app, "GET", "/shelters/10/documents", "")
+	data := r.body["data"].(map[string]interface{})
+	if data["complete"] != false || len(data["documents"].([]interface{})) != 1 {
+		t.Fatalf("checklist = %v", data)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem,
+		&models.Notification{NotificationID: 1, RecipientType: services.RecipientAdopter, RecipientID: 20, Title: "one"},
+		&models.Notification{NotificationID: 2, RecipientType: services.RecipientShelter, RecipientID: 20, Title: "other recipient"},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Get("/adopter/:adopter_id/notifications", h.GetAdopterNotifications)
+		app.Put("/adopter/:adopter_id/notifications/:id/read", h.MarkAdopterNotificationRead)
+	})
+
+	if got := dataList(t, call(t, app, "GET", "/adopter/20/notifications?unread=true", "")); len(got) != 1 {
+		t.Fatalf("unread = %d, want 1", len(got))
+	}
+	if r := call(t, app, "PUT", "/adopter/20/notifications/2/read", ""); r.status != fiber.StatusNotFound {
+		t.Fatalf("other recipient's notification: status %d, want 404", r.status)
+	}
+	if r := call(t, app, "PUT", "/adopter/20/notifications/1/read", ""); r.status != fiber.StatusOK {
+		t.Fatalf("mark read: status %d", r.status)
+	}
+	if got := dataList(t, call(t, app, "GET", "/adopter/20/notifications?unread=true", "")); len(got) != 0 {
+		t.Fatalf("unread after marking = %d, want 0", len(got))
+	}
+
+	// Admins read their own, by the token's admin ID
+	seed(t, mem,
+		&models.Notification{NotificationID: 3, RecipientType: services.RecipientAdmin, RecipientID: 7, Kind: services.KindReviewEscalated, Title: "overdue"},
+		&models.Notification{NotificationID: 4, RecipientType: services.RecipientAdmin, RecipientID: 8, Kind: services.KindReviewEscalated, Title: "overdue"},
+	)
+	admin := newTestApp(h, func(app fiber.Router) {
+		app.Get("/admin/notifications", h.GetAdminNotifications)
+		app.Put("/admin/notifications/:id/read", h.MarkAdminNotificationRead)
+	})
+	if got := dataList(t, call(t, admin, "GET", "/admin/notifications", "")); len(got) != 1 || got[0].(map[string]interface{})["notification_id"] != float64(3) {
+		t.Fatalf("admin 7 notifications = %v, want notification 3 only", got)
+	}
+	if r := call(t, admin, "PUT", "/admin/notifications/4/read", ""); r.status != fiber.StatusNotFound {
+		t.Fatalf("another admin's notification: status %d, want 404", r.status)
+	}
+	if r := call(t, admin, "PUT", "/admin/notifications/3/read", ""); r.status != fiber.StatusOK {
+		t.Fatalf("mark admin notification read: status %d", r.status)
+	}
+}
+
+func TestGetDuplicateCandidates(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem,
+		&models.AdopterAccount{AdopterID: 1, Username: "ana", Status: services.StatusActive, Version: 1},
+		&models.AdopterAccount{AdopterID: 2, Username: "anacruz", Status: services.StatusActive, Version: 1},
+		&models.AdopterAccount{AdopterID: 3, Username: "ben", Status: services.StatusActive, Version: 1},
+		&models.AdopterInfo{AdopterID: 1, FirstName: "Ana", LastName: "Cruz", Email: "ana.cruz@gmail.com"},
+		&models.AdopterInfo{AdopterID: 2, FirstName: "Ana", LastName: "Cruz", Email: "anacruz+pets@gmail.com"},
+		&models.AdopterInfo{AdopterID: 3, FirstName: "Ben", LastName: "Reyes", Email: "ben@example.com"},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/duplicates/:entity", h.GetDuplicateCandidates) })
+
+	got := dataList(t, call(t, app, "GET", "/duplicates/adopters", ""))
+	if len(got) != 1 {
+		t.Fatalf("candidates = %v, want the Ana Cruz pair", got)
+	}
+
+	seed(t, mem, &models.DuplicateDismissal{EntityType: "adopters", IDA: 1, IDB: 2})
+	if got := dataList(t, call(t, app, "GET", "/duplicates/adopters", "")); len(got) != 0 {
+		t.Fatalf("candidates after dismissal = %v, want none", got)
+	}
+	if r := call(t, app, "GET", "/duplicates/pets", ""); r.status != fiber.StatusBadRequest {
+		t.Fatalf("unknown entity: status %d, want 400", r.status)
+	}
+}
+
+func TestGetReviewQueue(t *testing.T) {
+	mem := repository.NewMemory()
+	now := time.Now()
+	seed(t, mem,
+		&models.ShelterAccount{ShelterID: 1, Username: "new", RegStatus: services.RegStatusPending, Version: 1, CreatedAt: now.Add(-time.Hour)},
+		&models.ShelterAccount{ShelterID: 2, Username: "old", RegStatus: services.RegStatusPending, Version: 1, CreatedAt: now.Add(-100 * time.Hour)},
+		&models.ShelterAccount{ShelterID: 3, Username: "done", RegStatus: services.RegStatusApproved, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "New"},
+		&models.ShelterInfo{ShelterID: 2, ShelterName: "Old"},
+		&models.ShelterReviewRound{RoundID: 4, ShelterID: 2, Round: 2, Decision: services.RegStatusPending, SubmittedAt: now.Add(-50 * time.Hour)},
+		&models.ShelterReviewClaim{ShelterID: 1, AdminID: 7, ExpiresAt: now.Add(time.Minute)},
+	)
+	h := newTestHandler(mem, nil)
+	h.cfg.Review = services.DefaultReviewQueueSettings
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/reviewqueue", h.GetReviewQueue) })
+
+	r := call(t, app, "GET", "/reviewqueue", "")
+	queue := dataList(t, r)
+	if len(queue) != 2 || r.body["overdue"].(float64) != 1 {
+		t.Fatalf("queue = %v", r.body)
+	}
+	oldest := queue[0].(map[string]interface{})
+	if oldest["shelter_name"] != "Old" || oldest["round"].(float64) != 2 || oldest["overdue"] != true {
+		t.Fatalf("first item = %v, want the overdue round 2", oldest)
+	}
+	newest := queue[1].(map[string]interface{})
+	if newest["round"].(float64) != 1 || newest["claimed_by"].(float64) != 7 {
+		t.Fatalf("second item = %v, want round 1 claimed by admin 7", newest)
+	}
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	mem := repository.NewMemory()
+	now := time.Now()
+	seed(t, mem,
+		&models.AdminAuditLog{AuditID: 1, Action: "block", EntityType: "shelter", EntityID: 1, CreatedAt: now.Add(-time.Minute)},
+		&models.AdminAuditLog{AuditID: 2, Action: "block", EntityType: "shelter", EntityID: 2, CreatedAt: now},
+		&models.AdminAuditLog{AuditID: 3, Action: "block", EntityType: "adopter", EntityID: 1, CreatedAt: now},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/auditlogs", h.GetAuditLogs) })
+
+	if got := dataList(t, call(t, app, "GET", "/auditlogs?entity_type=shelter", "")); len(got) != 2 {
+		t.Fatalf("shelter entries = %d, want 2", len(got))
+	}
+	if got := dataList(t, call(t, app, "GET", "/auditlogs?entity_type=shelter&entity_id=1", "")); len(got) != 1 {
+		t.Fatalf("shelter 1 entries = %d, want 1", len(got))
+	}
+	if r := call(t, app, "GET", "/auditlogs?entity_id=-1", ""); r.status != fiber.StatusBadRequest {
+		t.Fatalf("negative id: status %d, want 400", r.status)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	mem := repository.NewMemory()
+	for id := uint(1); id <= 3; id++ {
+		seed(t, mem,
+			&models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", id), Status: services.StatusActive},
+			&models.ShelterInfo{ShelterID: id, ShelterName: "Shelter"},
+		)
+	}
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/shelters", h.GetAllShelters) })
+	page := func(target string) map[string]interface{} {
+		r := call(t, app, "GET", target, "")
+		info, ok := r.body["pagination"].(map[string]interface{})
+		if r.status != fiber.StatusOK || !ok {
+			t.Fatalf("%s: status %d, body %v", target, r.status, r.body)
+		}
+		if _, sent := info["next_cursor"]; !sent {
+			t.Fatalf("%s: next_cursor missing from %v", target, info)
+		}
+		return info
+	}
+
+	if info := page("/shelters"); info["limit"] != float64(50) || info["total"] != float64(3) || info["has_more"] != false || info["next_cursor"] != "" {
+		t.Fatalf("default page = %v, want limit 50, total 3 and no next page", info)
+	}
+	first := page("/shelters?limit=2")
+	cursor, _ := first["next_cursor"].(string)
+	if cursor == "" || first["has_more"] != true {
+		t.Fatalf("first of two pages = %v, want a next cursor", first)
+	}
+	if last := page("/shelters?limit=2&cursor=" + cursor); last["has_more"] != false || last["next_cursor"] != "" {
+		t.Fatalf("last page = %v, want no next page", last)
+	}
+}
+
+func TestGetReportAnalytics(t *testing.T) {
+	mem := repository.NewMemory()
+	cat := uint(1)
+	seed(t, mem,
+		&models.ReportCategory{CategoryID: 1, Code: "neglect", Name: "Neglect", Severity: 3},
+		&models.SubmittedReport{ID: 1, ShelterID: 10, CategoryID: &cat, Status: services.ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 11, CategoryID: &cat, Status: services.ReportStatusBlocked, Version: 1},
+		&models.SubmittedReport{ID: 3, ShelterID: 10, Status: services.ReportStatusReported, Version: 1},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/analytics", h.GetReportAnalytics) })
+
+	r := call(t, app, "GET", "/analytics", "")
+	rows := dataList(t, r)
+	row := rows[0].(map[string]interface{})
+	if row["total"].(float64) != 2 || row["shelters"].(float64) != 2 || r.body["uncategorized"].(float64) != 1 {
+		t.Fatalf("analytics = %v", r.body)
+	}
+}
+
+func TestScreeningTermValidation(t *testing.T) {
+	h := newTestHandler(repository.NewMemory(), nil)
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Post("/screeningterms", h.CreateScreeningTerm)
+		app.Get("/screeningterms", h.GetScreeningTerms)
+	})
+
+	if r := call(t, app, "POST", "/screeningterms", `{"category":"payment","pattern":"([","is_regex":true}`); r.status != fiber.StatusBadRequest {
+		t.Fatalf("bad regex: status %d, want 400", r.status)
+	}
+	if r := call(t, app, "POST", "/screeningterms", `{"category":"payment","pattern":"gcash"}`); r.status != fiber.StatusCreated {
+		t.Fatalf("create: status %d: %v", r.status, r.body)
+	}
+	if got := dataList(t, call(t, app, "GET", "/screeningterms", "")); len(got) != 1 {
+		t.Fatalf("terms = %d, want 1", len(got))
+	}
+}
+
+func TestWriteErrorsFromUnitOfWork(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"version mismatch", services.ErrVersionMismatch, "PUT", "/reports/5/dismiss", `{}`, fiber.StatusPreconditionFailed},
+		{"report closed", services.ErrReportNotPending, "PUT", "/reports/5/dismiss", `{}`, fiber.StatusConflict},
+		{"pet missing", services.ErrPetNotFound, "PUT", "/pets/5/unlist", `{"reason":"x"}`, fiber.StatusNotFound},
+		{"merged already", services.ErrAlreadyMerged, "POST", "/duplicates/adopters/merge", `{"survivor_id":1,"duplicate_id":2}`, fiber.StatusConflict},
+		{"database down", errNoDatabase, "PUT", "/flags/1/resolve", ``, fiber.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(repository.NewMemory(), failingUnit{tc.err})
+			app := newTestApp(h, func(app fiber.Router) {
+				app.Put("/reports/:id/dismiss", h.DismissReport)
+				app.Put("/pets/:id/unlist", h.UnlistPet)
+				app.Post("/duplicates/:entity/merge", h.MergeDuplicateAccounts)
+				app.Put("/flags/:id/resolve", h.ResolveShelterFlag)
+			})
+			if r := call(t, app, tc.method, tc.target, tc.body); r.status != tc.want {
+				t.Fatalf("status %d, want %d: %v", r.status, tc.want, r.body)
+			}
+		})
+	}
+}
+
+func TestLargestDocumentFitsTheBodyLimit(t *testing.T) {
+	// A PNG of exactly MaxUploadBytes, sent as a data URI
+	file := make([]byte, services.MaxUploadBytes)
+	copy(file, "\x89PNG\r\n\x1a\n")
+	body, _ := json.Marshal(map[string]string{
+		"doc_type":  services.DocOwnerID,
+		"file_name": "id.png",
+		"file_data": "data:image/png;base64," + base64.StdEncoding.EncodeToString(file),
+	})
+
+	for _, tc := range []struct {
+		name    string
+		config  fiber.Config
+		arrives bool
+	}{
+		{"server body limit", fiber.Config{BodyLimit: services.UploadBodyLimit}, true},
+		{"fiber default", fiber.Config{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The failing unit of work answers 500 once the upload reaches the handler
+			h := newTestHandler(repository.NewMemory(), nil)
+			app := fiber.New(tc.config)
+			app.Post("/shelter/:shelter_id/documents", h.UploadShelterDocument)
+
+			req := httptest.NewRequest("POST", "/shelter/3/documents", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req, -1)
+			arrived := err == nil && resp.StatusCode == fiber.StatusInternalServerError
+			if err == nil {
+				resp.Body.Close()
+			}
+			if arrived != tc.arrives {
+				t.Fatalf("upload reached the handler: %v, want %v (err %v)", arrived, tc.arrives, err)
+			}
+		})
+	}
+}
+
+// Search runs on Postgres full text; services.TestSearchLeavesOutRowsOfDeletedOwners
+// covers its filter
+func TestDeletedOwnersAreLeftOut(t *testing.T) {
+	mem := repository.NewMemory()
+	deleted := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	seed(t, mem,
+		&models.ShelterAccount{ShelterID: 1, Username: "live", Status: services.StatusActive, Version: 1},
+		&models.ShelterAccount{ShelterID: 2, Username: "gone", Status: services.StatusActive, Version: 1, DeletedAt: deleted},
+		&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", Status: "adopted", ListingStatus: services.ListingListed},
+		&models.PetInfo{PetID: 2, ShelterID: 2, PetName: "Rex", Status: "adopted", ListingStatus: services.ListingListed, HiddenBy: services.HoldShelterDeleted},
+	)
+	h := newTestHandler(mem, nil)
+	app := newTestApp(h, func(app fiber.Router) { app.Get("/adoptedpets/count", h.CountAdoptedPets) })
+
+	if r := call(t, app, "GET", "/adoptedpets/count", ""); r.body["count"] != float64(1) {
+		t.Fatalf("adopted count = %v, want the live shelter's pet only", r.body["count"])
+	}
+}
+
+func TestBulkShelterVersions(t *testing.T) {
+	mem := repository.NewMemory()
+	for id := uint(1); id <= 2; id++ {
+		seed(t, mem,
+			&models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", id), Status: services.StatusActive, RegStatus: services.RegStatusApproved, Version: 3},
+			&models.ShelterInfo{ShelterID: id, ShelterName: "Shelter"},
+		)
+	}
+
+	h := newTestHandler(mem, mem)
+	h.cfg.ShelterBlock = services.DefaultShelterBlockPolicy
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Post("/bulk/shelters", h.BulkUpdateShelterStatus)
+	})
+	status := func(id uint) string {
+		shelter, _ := mem.Repositories().Shelters.Account(id)
+		return shelter.Status
+	}
+
+	// A stale version rolls back the whole atomic request
+	resp := call(t, app, "POST", "/bulk/shelters", `{"ids":[1,2],"action":"block","mode":"atomic","versions":{"1":3,"2":2}}`)
+	if resp.status != fiber.StatusConflict {
+		t.Fatalf("atomic with a stale version: status %d, want 409: %v", resp.status, resp.body)
+	}
+	if status(1) != services.StatusActive || status(2) != services.StatusActive {
+		t.Fatalf("shelters %s, %s after a rolled back request, want both active", status(1), status(2))
+	}
+	if n := auditRows(t, mem); n != 0 {
+		t.Fatalf("%d audit rows after a rolled back request, want none", n)
+	}
+
+	// Best effort writes the current one and reports the stale one
+	resp = call(t, app, "POST", "/bulk/shelters", `{"ids":[1,2],"action":"block","versions":{"1":3,"2":2}}`)
+	if resp.status != fiber.StatusMultiStatus {
+		t.Fatalf("best effort with a stale version: status %d, want 207: %v", resp.status, resp.body)
+	}
+	results := resp.body["data"].(map[string]interface{})["results"].([]interface{})
+	stale := results[1].(map[string]interface{})
+	if stale["success"] != false || !strings.Contains(stale["error"].(string), services.ErrVersionMismatch.Error()) {
+		t.Errorf("stale item = %v, want a version mismatch", stale)
+	}
+	if status(1) != services.StatusInactive || status(2) != services.StatusActive {
+		t.Errorf("shelters %s, %s, want only the first blocked", status(1), status(2))
+	}
+
+	// Ids without a version are written unconditionally
+	resp = call(t, app, "POST", "/bulk/shelters", `{"ids":[2],"action":"block"}`)
+	if resp.status != fiber.StatusOK || status(2) != services.StatusInactive {
+		t.Errorf("unconditional block: status %d, shelter %s", resp.status, status(2))
+	}
+}
+
+func TestReportAttachmentsOnlyFromTheReporter(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem,
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 5, Reason: "neglect", Status: services.ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 1, AdopterID: 7, Reason: "scam", Status: services.ReportStatusReported, Version: 1},
+	)
+
+	// The app serves adopter 7, as RequireAdopter would
+	h := newTestHandler(mem, mem)
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Post("/reports/:id/attachments", h.UploadReportAttachment)
+	})
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	body := `{"file_name":"proof.png","file_data":"data:image/png;base64,` + base64.StdEncoding.EncodeToString(png) + `"}`
+
+	if resp := call(t, app, "POST", "/reports/1/attachments", body); resp.status != fiber.StatusForbidden {
+		t.Fatalf("another adopter's report: status %d, want 403: %v", resp.status, resp.body)
+	}
+	if resp := call(t, app, "POST", "/reports/2/attachments", body); resp.status != fiber.StatusCreated {
+		t.Fatalf("own report: status %d, want 201: %v", resp.status, resp.body)
+	}
+	attachments, _ := mem.Repositories().Reports.Attachments(1, 2)
+	if len(attachments[1]) != 0 || len(attachments[2]) != 1 {
+		t.Fatalf("attachments = %v, want the reporter's only", attachments)
+	}
+}
+
+func TestShelterStatusWrites(t *testing.T) {
+	mem := repository.NewMemory()
+	for id := uint(1); id <= 2; id++ {
+		seed(t, mem,
+			&models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", id), Status: services.StatusActive, RegStatus: services.RegStatusApproved, Version: 3},
+			&models.ShelterInfo{ShelterID: id, ShelterName: "Shelter"},
+		)
+	}
+	seed(t, mem, &models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", ListingStatus: services.ListingListed})
+	h := newTestHandler(mem, mem)
+	h.cfg.ShelterBlock = services.DefaultShelterBlockPolicy
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Post("/updateshelterstatus", h.UpdateShelterStatus)
+		app.Post("/bulk/shelters", h.BulkUpdateShelterStatus)
+	})
+	updateStatus := func(version string) *http.Response {
+		req := httptest.NewRequest("POST", "/updateshelterstatus", strings.NewReader(`{"shelter_id":1,"status":"inactive"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(fiber.HeaderIfMatch, version)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	repos := mem.Repositories()
+	shelter := func() models.ShelterAccount {
+		shelter, _ := repos.Shelters.Account(1)
+		return shelter
+	}
+
+	if resp := updateStatus(`"2"`); resp.StatusCode != fiber.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status %d, want 412", resp.StatusCode)
+	}
+	if got := shelter(); got.Status != services.StatusActive || auditRows(t, mem) != 0 {
+		t.Fatalf("a refused write changed the shelter: %+v", got)
+	}
+
+	resp := updateStatus(`"3"`)
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"4"` {
+		t.Fatalf("current If-Match: status %d, ETag %s; want 200, \"4\"", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+	audits := auditRows(t, mem)
+	if got := shelter(); got.Status != services.StatusInactive || audits == 0 {
+		t.Fatalf("after the update: %+v, %d audit rows", got, audits)
+	}
+	if pet, _ := repos.Pets.Pet(1); pet.HiddenBy != services.HoldShelterBlocked {
+		t.Fatalf("pet hidden by %q after deactivating, want %q", pet.HiddenBy, services.HoldShelterBlocked)
+	}
+
+	// The first item reinstates shelter 1, then the stale second item rolls
+	// it back along with its audit row
+	r := call(t, app, "POST", "/bulk/shelters", `{"ids":[1,2],"action":"reinstate","mode":"atomic","versions":{"2":2}}`)
+	if r.status != fiber.StatusConflict {
+		t.Fatalf("atomic with a stale version: status %d, want 409: %v", r.status, r.body)
+	}
+	if got := shelter(); got.Status != services.StatusInactive || got.Version != 4 || auditRows(t, mem) != audits {
+		t.Fatalf("after the rolled back request: %+v", got)
+	}
+}
+
+func TestStaleIfMatchIsRefused(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem,
+		&models.ShelterAccount{ShelterID: 1, Username: "shelter1", Status: services.StatusActive, RegStatus: services.RegStatusPending, Version: 3},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Shelter"},
+		&models.AdopterAccount{AdopterID: 1, Username: "ana", Status: services.StatusInactive, Version: 3},
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 1, Reason: "neglect", Status: services.ReportStatusReported, Version: 3},
+	)
+	h := newTestHandler(mem, mem)
+	h.cfg.ShelterBlock = services.DefaultShelterBlockPolicy
+	app := newTestApp(h, func(app fiber.Router) {
+		app.Post("/updateadopterstatus", h.UpdateAdopterStatus)
+		app.Post("/updateregstatus", h.UpdateRegistrationStatus)
+		app.Put("/shelters/:id/approve", h.ApproveShelterRegStatus)
+		app.Put("/shelters/:id/status", h.UpdateShelterStatusByID)
+		app.Put("/shelters/:id/activate", h.UpdateShelterStatusByIDtoactive)
+		app.Put("/adopters/:id/activate", h.ActivateAdopter)
+		app.Delete("/shelters/:id", h.DeleteShelter)
+		app.Post("/shelters/:id/restore", h.RestoreShelter)
+		app.Delete("/shelters/:id/purge", h.PurgeShelter)
+		app.Delete("/adopters/:id", h.DeleteAdopter)
+		app.Post("/adopters/:id/restore", h.RestoreAdopter)
+		app.Delete("/adopters/:id/purge", h.PurgeAdopter)
+		app.Put("/reports/:id/dismiss", h.DismissReport)
+	})
+	send := func(method, target, body, version string) *http.Response {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, target, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set(fiber.HeaderIfMatch, version)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	repos := mem.Repositories()
+	versions := func() string {
+		shelter, _ := repos.Shelters.Account(1)
+		adopter, _ := repos.Adopters.Account(1)
+		report, _ := repos.Reports.Report(1)
+		return fmt.Sprint(shelter.Version, adopter.Version, report.Version)
+	}
+
+	cases := []struct {
+		method, target, body string
+	}{
+		{"POST", "/updateadopterstatus", `{"adopter_id":1,"status":"active"}`},
+		{"POST", "/updateregstatus", `{"shelter_id":1,"reg_status":"approved"}`},
+		{"PUT", "/shelters/1/approve", ""},
+		{"PUT", "/shelters/1/status", `{"reason":"spam"}`},
+		{"PUT", "/shelters/1/activate", ""},
+		{"PUT", "/adopters/1/activate", ""},
+		{"DELETE", "/shelters/1", ""},
+		{"POST", "/shelters/1/restore", ""},
+		{"DELETE", "/shelters/1/purge", ""},
+		{"DELETE", "/adopters/1", ""},
+		{"POST", "/adopters/1/restore", ""},
+		{"DELETE", "/adopters/1/purge", ""},
+		{"PUT", "/reports/1/dismiss", `{}`},
+	}
+	for _, tc := range cases {
+		if resp := send(tc.method, tc.target, tc.body, `"2"`); resp.StatusCode != fiber.StatusPreconditionFailed {
+			t.Errorf("%s %s with a stale If-Match: status %d, want 412", tc.method, tc.target, resp.StatusCode)
+		}
+	}
+	deletions, _ := repos.Audit.Deletions("", false)
+	if got := versions(); got != "3 3 3" || auditRows(t, mem) != 0 || len(deletions) != 0 {
+		t.Fatalf("refused writes changed the records: versions %s", got)
+	}
+
+	resp := send("PUT", "/reports/1/dismiss", `{}`, `"3"`)
+	if resp.StatusCode != fiber.StatusOK || resp.Header.Get(fiber.HeaderETag) != `"4"` {
+		t.Fatalf("current If-Match: status %d, ETag %s; want 200, \"4\"", resp.StatusCode, resp.Header.Get(fiber.HeaderETag))
+	}
+	if report, _ := repos.Reports.Report(1); report.Status != services.ReportStatusDismissed || auditRows(t, mem) != 1 {
+		t.Fatalf("after the dismissal: report %s, want dismissed and audited", report.Status)
+	}
+}
+
+func TestResubmitTakesOnlyTheCorrectedFields(t *testing.T) {
+	mem := repository.NewMemory()
+	seed(t, mem,
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: services.StatusInactive, RegStatus: services.RegStatusRejected, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws", ShelterEmail: "a@paws.ph", ShelterContact: "0917"},
+		&models.ShelterReviewRound{ShelterID: 1, Round: 1, Decision: services.RegStatusRejected},
+	)
+	h := newTestHandler(mem, mem)
+	app := newTestApp(h, func(app fiber.Router) { app.Put("/shelter/:shelter_id/resubmit", h.ResubmitShelterRegistration) })
+
+	if r := call(t, app, "PUT", "/shelter/1/resubmit", `{"shelter_contact":"0918"}`); r.status != fiber.StatusBadRequest {
+		t.Fatalf("resubmit leaving the address blank: status %d, want 400: %v", r.status, r.body)
+	}
+	if r := call(t, app, "PUT", "/shelter/1/resubmit", `{"shelter_address":"Cebu City"}`); r.status != fiber.StatusOK {
+		t.Fatalf("resubmit adding the address: status %d: %v", r.status, r.body)
+	}
+	if info, _ := mem.Repositories().Shelters.Info(1); info.ShelterAddress != "Cebu City" || info.ShelterContact != "0917" || info.ShelterName != "Paws" {
+		t.Fatalf("info after resubmit = %+v", info)
+	}
+}
@@ -2,19 +2,17 @@ package controllers
 
 import (
 	"errors"
-	"pethubadmin/middleware"
-	"pethubadmin/models"
+	"pethubadmin/repository"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/gorm"
 )
 
 // LoginAdopter authenticates an adopter and retrieves their info
 
 // ==============================================================
 
-func LoginAdmin(c *fiber.Ctx) error {
+func (h *AdminHandler) LoginAdmin(c *fiber.Ctx) error {
 	// Parse request body
 	requestBody := struct {
 		Username string `json:"username"`
@@ -28,14 +26,12 @@ func LoginAdmin(c *fiber.Ctx) error {
 	}
 
 	// Check if the admin exists
-	var adminAccount models.AdminAccount
-	result := middleware.DBConn.Where("username = ?", requestBody.Username).First(&adminAccount)
-
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	adminAccount, err := h.repos.Admins.ByUsername(requestBody.Username)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"message": "Invalid username or password",
 		})
-	} else if result.Error != nil {
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Database error",
 		})
@@ -49,7 +45,7 @@ func LoginAdmin(c *fiber.Ctx) error {
 	}
 
 	// Generate JWT token
-	token, err := middleware.GenerateJWT(adminAccount.AdminID)
+	token, err := h.auth.GenerateJWT(adminAccount.AdminID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Error generating token",
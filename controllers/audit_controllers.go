@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAuditLogs lists moderation audit entries, optionally filtered by
+// ?entity_type= and ?entity_id=
+func (h *AdminHandler) GetAuditLogs(c *fiber.Ctx) error {
+	entityType := c.Query("entity_type")
+	entityID := c.QueryInt("entity_id", 0)
+	if entityID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid entity ID",
+		})
+	}
+
+	logs, err := h.repos.Audit.Trail(entityType, uint(entityID), c.QueryInt("limit", 100))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch audit logs",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Audit logs retrieved successfully",
+		"data":    logs,
+	})
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bulkRequest is the shared body of every bulk moderation endpoint
+type bulkRequest struct {
+	IDs        []uint `json:"ids"`
+	Action     string `json:"action"`
+	Mode       string `json:"mode"`        // "atomic" or "best_effort" (default)
+	ReasonCode string `json:"reason_code"` // registrations only, required to reject
+	Feedback   string `json:"feedback"`    // required to reject registrations; suspension reason when blocking shelters
+	Restore    bool   `json:"restore"`     // adopters only, restore applications on activate
+	// Versions maps an id to the version the client last read, the bulk
+	// counterpart of If-Match. Ids left out are written unconditionally.
+	Versions map[uint]uint `json:"versions"`
+}
+
+// expectedVersions returns the If-Match versions for one item
+func (r bulkRequest) expectedVersions(id uint) []uint {
+	version, ok := r.Versions[id]
+	if !ok {
+		return nil
+	}
+	return []uint{version}
+}
+
+// BulkUpdateRegistrationStatus approves or rejects many pending shelter registrations
+func (h *AdminHandler) BulkUpdateRegistrationStatus(c *fiber.Ctx) error {
+	var request bulkRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var decision string
+	switch services.NormalizeStatus(request.Action) {
+	case "approve":
+		decision = services.RegStatusApproved
+	case "reject":
+		decision = services.RegStatusRejected
+		if err := services.ValidateRejection(request.ReasonCode, request.Feedback); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Action must be 'approve' or 'reject'",
+		})
+	}
+
+	adminID := currentAdminID(c)
+	return h.runBulk(c, request, func(tx repository.Repositories, id uint) (string, error) {
+		if err := tx.Shelters.CheckVersion(id, request.expectedVersions(id)); err != nil {
+			return "", err
+		}
+		shelter, _, err := tx.Shelters.DecideRegistration(id, adminID, decision, request.ReasonCode, request.Feedback, h.cfg.Review.ClaimTTL)
+		return shelter.RegStatus, err
+	})
+}
+
+// BulkUpdateAdopterStatus activates or deactivates many adopter accounts
+func (h *AdminHandler) BulkUpdateAdopterStatus(c *fiber.Ctx) error {
+	var request bulkRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var status string
+	switch services.NormalizeStatus(request.Action) {
+	case "activate":
+		status = services.StatusActive
+	case "deactivate":
+		status = services.StatusInactive
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Action must be 'activate' or 'deactivate'",
+		})
+	}
+
+	adminID := currentAdminID(c)
+	options := services.AdopterStatusOptions{Policy: h.cfg.AdopterDeactivation, Restore: request.Restore}
+	return h.runBulk(c, request, func(tx repository.Repositories, id uint) (string, error) {
+		if err := tx.Adopters.CheckVersion(id, request.expectedVersions(id)); err != nil {
+			return "", err
+		}
+		adopter, _, err := tx.Adopters.SetStatus(id, adminID, status, options)
+		return adopter.Status, err
+	})
+}
+
+// BulkUpdateShelterStatus blocks or reinstates many shelters
+func (h *AdminHandler) BulkUpdateShelterStatus(c *fiber.Ctx) error {
+	var request bulkRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var moderate func(repository.Repositories, uint, uint) (services.ShelterModerationResult, error)
+	switch services.NormalizeStatus(request.Action) {
+	case "block":
+		policy := h.cfg.ShelterBlock
+		moderate = func(tx repository.Repositories, id, adminID uint) (services.ShelterModerationResult, error) {
+			return tx.Shelters.Block(id, adminID, policy, request.Feedback)
+		}
+	case "reinstate":
+		moderate = func(tx repository.Repositories, id, adminID uint) (services.ShelterModerationResult, error) {
+			return tx.Shelters.Reinstate(id, adminID)
+		}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Action must be 'block' or 'reinstate'",
+		})
+	}
+
+	adminID := currentAdminID(c)
+	// Notices go out once, and only if some item committed
+	var committed bool
+	err := h.runBulk(c, request, func(tx repository.Repositories, id uint) (string, error) {
+		if err := tx.Shelters.CheckVersion(id, request.expectedVersions(id)); err != nil {
+			return "", err
+		}
+		result, err := moderate(tx, id, adminID)
+		if err == nil {
+			tx.AfterCommit(func() { committed = true })
+		}
+		return result.Account.Status, err
+	})
+	if committed {
+		h.dispatchOutcomeNotices()
+	}
+	return err
+}
+
+// Helper function to run a bulk action and write the per-item results
+func (h *AdminHandler) runBulk(c *fiber.Ctx, request bulkRequest, fn services.BulkItemFunc[repository.Repositories]) error {
+	summary, err := services.RunBulk(h.uow.Do, request.IDs, request.Mode, fn)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBulkMode) || errors.Is(err, services.ErrBulkEmpty) || errors.Is(err, services.ErrBulkTooLarge) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Bulk operation failed",
+			"error":   err.Error(),
+		})
+	}
+
+	status := fiber.StatusOK
+	message := "Bulk operation completed"
+	switch {
+	case !summary.Committed:
+		status = fiber.StatusConflict
+		message = "Bulk operation rolled back"
+	case summary.Failed > 0:
+		status = fiber.StatusMultiStatus
+		message = "Bulk operation completed with errors"
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message": message,
+		"data":    summary,
+	})
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDuplicateCandidates lists scored duplicate pairs for :entity (adopters or
+// shelters). ?min_score= defaults to 0.5.
+func (h *AdminHandler) GetDuplicateCandidates(c *fiber.Ctx) error {
+	minScore := 0.5
+	if raw := c.Query("min_score"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "min_score must be a number between 0 and 1",
+			})
+		}
+		minScore = parsed
+	}
+
+	var candidates []services.DuplicateCandidate
+	var err error
+	switch c.Params("entity") {
+	case "adopters":
+		candidates, err = h.repos.Adopters.Duplicates(minScore)
+	case "shelters":
+		candidates, err = h.repos.Shelters.Duplicates(minScore)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": services.ErrUnknownEntity.Error(),
+		})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to find duplicate accounts",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Duplicate candidates retrieved successfully",
+		"min_score": minScore,
+		"count":     len(candidates),
+		"data":      candidates,
+	})
+}
+
+// DismissDuplicateCandidate marks a pair as reviewed and not a duplicate
+func (h *AdminHandler) DismissDuplicateCandidate(c *fiber.Ctx) error {
+	entity := c.Params("entity")
+	if entity != "adopters" && entity != "shelters" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": services.ErrUnknownEntity.Error(),
+		})
+	}
+
+	var request struct {
+		IDA uint `json:"id_a"`
+		IDB uint `json:"id_b"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	err := h.uow.Do(func(tx repository.Repositories) error {
+		return duplicateAccounts(tx, entity).DismissDuplicate(request.IDA, request.IDB, currentAdminID(c))
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrSameAccount) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to dismiss duplicate candidate",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Duplicate candidate dismissed",
+	})
+}
+
+// MergeDuplicateAccounts folds the duplicate account into the survivor
+func (h *AdminHandler) MergeDuplicateAccounts(c *fiber.Ctx) error {
+	var request struct {
+		SurvivorID  uint `json:"survivor_id"`
+		DuplicateID uint `json:"duplicate_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	entity := c.Params("entity")
+	if entity != "adopters" && entity != "shelters" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": services.ErrUnknownEntity.Error(),
+		})
+	}
+
+	var result models.AccountMerge
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		result, err = duplicateAccounts(tx, entity).Merge(request.SurvivorID, request.DuplicateID, currentAdminID(c))
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSameAccount):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrAlreadyMerged):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return statusTransitionError(c, err, "Account not found", "Failed to merge accounts")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Accounts merged successfully",
+		"data":    result,
+	})
+}
+
+// mergeableAccounts is the part of the shelter and adopter repositories
+// that dismisses and merges duplicates
+type mergeableAccounts interface {
+	DismissDuplicate(idA, idB, adminID uint) error
+	Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error)
+}
+
+// duplicateAccounts picks the repository for "shelters" or "adopters"
+func duplicateAccounts(tx repository.Repositories, entity string) mergeableAccounts {
+	if entity == "shelters" {
+		return tx.Shelters
+	}
+	return tx.Adopters
+}
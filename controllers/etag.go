@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setETag sends a record's version as its ETag, for clients to echo back in
+// If-Match when they change it. Only single-record responses carry one: a
+// list has no version of its own to match against, so list endpoints send
+// no ETag and put each record's version in its "version" field instead.
+func setETag(c *fiber.Ctx, version uint) {
+	c.Set(fiber.HeaderETag, strconv.Quote(strconv.FormatUint(uint64(version), 10)))
+}
+
+// ifMatch reads the versions named by If-Match. It returns nil when the
+// header is missing or "*", which leaves the write unconditional. Weak and
+// malformed tags never match, so a header made only of those yields
+// version 0, which no record has.
+func ifMatch(c *fiber.Ctx) []uint {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []uint{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil || !strings.HasPrefix(tag, `"`) {
+			continue
+		}
+		version, err := strconv.ParseUint(unquoted, 10, 32)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, uint(version))
+	}
+	if len(versions) == 0 {
+		return []uint{0}
+	}
+	return versions
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// flagRuleRequest is the editable part of a flag rule
+type flagRuleRequest struct {
+	Name                string `json:"name"`
+	Kind                string `json:"kind"`
+	MinReports          int    `json:"min_reports"`
+	DistinctAdopters    bool   `json:"distinct_adopters"`
+	WindowDays          int    `json:"window_days"`
+	ReasonPattern       string `json:"reason_pattern"`
+	Action              string `json:"action"`
+	HoldHours           int    `json:"hold_hours"`
+	WeightByCredibility bool   `json:"weight_by_credibility"`
+	Enabled             *bool  `json:"enabled"`
+}
+
+func (r flagRuleRequest) apply(rule *models.FlagRule) {
+	rule.Name = r.Name
+	rule.Kind = r.Kind
+	rule.MinReports = r.MinReports
+	rule.DistinctAdopters = r.DistinctAdopters
+	rule.WindowDays = r.WindowDays
+	rule.ReasonPattern = r.ReasonPattern
+	rule.Action = r.Action
+	rule.HoldHours = r.HoldHours
+	rule.WeightByCredibility = r.WeightByCredibility
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+}
+
+// GetFlagRules lists every flag rule
+func (h *AdminHandler) GetFlagRules(c *fiber.Ctx) error {
+	rules, err := h.repos.Flags.Rules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch flag rules",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Flag rules retrieved successfully",
+		"data":    rules,
+	})
+}
+
+// CreateFlagRule adds a new flag rule (super admins only)
+func (h *AdminHandler) CreateFlagRule(c *fiber.Ctx) error {
+	var request flagRuleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	rule := models.FlagRule{Enabled: true, CreatedBy: currentAdminID(c)}
+	request.apply(&rule)
+	if err := services.ValidateFlagRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Flags.SaveRule(&rule); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to create flag rule",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Flag rule created successfully",
+		"data":    rule,
+	})
+}
+
+// UpdateFlagRule replaces the settings of an existing rule (super admins only)
+func (h *AdminHandler) UpdateFlagRule(c *fiber.Ctx) error {
+	ruleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid rule ID",
+		})
+	}
+
+	var request flagRuleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := h.repos.Flags.Rule(uint(ruleID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Flag rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Database error",
+			"error":   err.Error(),
+		})
+	}
+
+	request.apply(&rule)
+	if err := services.ValidateFlagRule(rule); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Flags.SaveRule(&rule); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to update flag rule",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Flag rule updated successfully",
+		"data":    rule,
+	})
+}
+
+// DeleteFlagRule removes a rule; flags it already raised are kept (super admins only)
+func (h *AdminHandler) DeleteFlagRule(c *fiber.Ctx) error {
+	ruleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid rule ID",
+		})
+	}
+
+	if err := h.repos.Flags.DeleteRule(uint(ruleID)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Flag rule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to delete flag rule",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Flag rule deleted successfully",
+	})
+}
+
+// EvaluateFlagRules runs every rule now, for one shelter (?shelter_id=) or all
+func (h *AdminHandler) EvaluateFlagRules(c *fiber.Ctx) error {
+	shelterID := c.QueryInt("shelter_id", 0)
+
+	if shelterID > 0 {
+		var raised []models.ShelterFlag
+		err := h.uow.Do(func(tx repository.Repositories) (err error) {
+			raised, err = tx.Flags.Evaluate(uint(shelterID))
+			return err
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to evaluate flag rules",
+				"error":   err.Error(),
+			})
+		}
+		return c.JSON(fiber.Map{
+			"message": "Flag rules evaluated successfully",
+			"raised":  len(raised),
+			"data":    raised,
+		})
+	}
+
+	var raised int
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		raised, err = tx.Flags.EvaluateAll()
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to evaluate flag rules",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Flag rules evaluated successfully",
+		"raised":  raised,
+	})
+}
+
+// GetShelterFlags lists open flags, or every flag with ?include_resolved=true
+func (h *AdminHandler) GetShelterFlags(c *fiber.Ctx) error {
+	shelterID := c.QueryInt("shelter_id", 0)
+	if shelterID < 0 {
+		shelterID = 0
+	}
+
+	flags, err := h.repos.Flags.Flags(uint(shelterID), c.QueryBool("include_resolved", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch shelter flags",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Shelter flags retrieved successfully",
+		"data":    flags,
+	})
+}
+
+// ResolveShelterFlag closes an open flag
+func (h *AdminHandler) ResolveShelterFlag(c *fiber.Ctx) error {
+	flagID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid flag ID",
+		})
+	}
+
+	err = h.uow.Do(func(tx repository.Repositories) error {
+		return tx.Flags.Resolve(uint(flagID), currentAdminID(c))
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrFlagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Open flag not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to resolve flag",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Flag resolved successfully",
+	})
+}
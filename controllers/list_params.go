@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pethubadmin/models/response"
+	"pethubadmin/pagination"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errBadListQuery marks list query parameters that do not parse
+var errBadListQuery = errors.New("invalid list query")
+
+// parsePage reads ?limit=, ?offset=, ?cursor= and ?sort=. limit defaults
+// to pagination.DefaultLimit (50); see response.Pagination. sort is a comma
+// separated list of fields, each prefixed with - for descending, e.g.
+// sort=-created_at,username.
+func parsePage(c *fiber.Ctx) (pagination.Page, error) {
+	var page pagination.Page
+	for _, param := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &page.Limit}, {"offset", &page.Offset}} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return page, fmt.Errorf("%w: %s must be a non-negative whole number", errBadListQuery, param.name)
+		}
+		*param.dst = n
+	}
+	if page.Limit > pagination.MaxLimit {
+		return page, fmt.Errorf("%w: limit must be at most %d", errBadListQuery, pagination.MaxLimit)
+	}
+
+	page.Cursor = c.Query("cursor")
+	if page.Cursor != "" && page.Offset > 0 {
+		return page, fmt.Errorf("%w: use either cursor or offset, not both", errBadListQuery)
+	}
+
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := strings.HasPrefix(field, "-")
+		page.Sort = append(page.Sort, pagination.SortField{Field: strings.TrimPrefix(field, "-"), Desc: desc})
+	}
+	return page, nil
+}
+
+// parseCreatedRange reads ?created_from= and ?created_to= as YYYY-MM-DD
+// dates or RFC 3339 times. A date-only created_to includes that whole day.
+func parseCreatedRange(c *fiber.Ctx) (repository.CreatedRange, error) {
+	var r repository.CreatedRange
+	parse := func(name string, endOfDay bool) (time.Time, error) {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			return time.Time{}, nil
+		}
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s must be a YYYY-MM-DD date or an RFC 3339 time", errBadListQuery, name)
+		}
+		if endOfDay {
+			day = day.AddDate(0, 0, 1)
+		}
+		return day, nil
+	}
+
+	var err error
+	if r.From, err = parse("created_from", false); err != nil {
+		return r, err
+	}
+	if r.To, err = parse("created_to", true); err != nil {
+		return r, err
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && !r.From.Before(r.To) {
+		return r, fmt.Errorf("%w: created_from must be before created_to", errBadListQuery)
+	}
+	return r, nil
+}
+
+// statusQuery reads a status style filter in its stored form
+func statusQuery(c *fiber.Ctx, name string) string {
+	return services.NormalizeStatus(c.Query(name))
+}
+
+// pageInfo is the pagination part of a list response
+func pageInfo[T any](result pagination.Result[T]) *response.Pagination {
+	return &response.Pagination{
+		Total:      result.Total,
+		Limit:      result.Limit,
+		Offset:     result.Offset,
+		NextCursor: result.NextCursor,
+		HasMore:    result.NextCursor != "",
+	}
+}
+
+// isBadListQuery reports whether err comes from the caller's list parameters
+func isBadListQuery(err error) bool {
+	return errors.Is(err, errBadListQuery) ||
+		errors.Is(err, pagination.ErrInvalidSort) ||
+		errors.Is(err, pagination.ErrInvalidCursor)
+}
+
+// listQueryError answers a bad list parameter with 400
+func listQueryError(c *fiber.Ctx, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
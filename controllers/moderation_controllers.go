@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ModerationItemResponse is a queued item with its decoded matches
+type ModerationItemResponse struct {
+	models.ModerationItem
+	Matches []services.ScreeningMatch `json:"matches"`
+}
+
+// screeningTermRequest is the editable part of a screening term
+type screeningTermRequest struct {
+	Category string `json:"category"`
+	Pattern  string `json:"pattern"`
+	IsRegex  bool   `json:"is_regex"`
+	Enabled  *bool  `json:"enabled"`
+}
+
+func (r screeningTermRequest) apply(term *models.ScreeningTerm) {
+	term.Category = r.Category
+	term.Pattern = r.Pattern
+	term.IsRegex = r.IsRegex
+	if r.Enabled != nil {
+		term.Enabled = *r.Enabled
+	}
+}
+
+// GetModerationQueue lists screened content, open items by default (?status=, ?source=)
+func (h *AdminHandler) GetModerationQueue(c *fiber.Ctx) error {
+	items, err := h.repos.Moderation.Queue(c.Query("status", services.ModerationOpen), c.Query("source"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch moderation queue",
+			"error":   err.Error(),
+		})
+	}
+
+	response := make([]ModerationItemResponse, 0, len(items))
+	for _, item := range items {
+		response = append(response, ModerationItemResponse{ModerationItem: item, Matches: services.DecodeMatches(item)})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Moderation queue retrieved successfully",
+		"data":    response,
+	})
+}
+
+// ReviewModerationItem approves a queued item or removes the matched text from its source
+func (h *AdminHandler) ReviewModerationItem(c *fiber.Ctx) error {
+	itemID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid item ID",
+		})
+	}
+
+	var request struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var item models.ModerationItem
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		item, err = tx.Moderation.Review(uint(itemID), currentAdminID(c), request.Action, request.Note)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidModeration):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrModerationItemNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Open moderation item not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to review moderation item",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Moderation item reviewed successfully",
+		"data":    ModerationItemResponse{ModerationItem: item, Matches: services.DecodeMatches(item)},
+	})
+}
+
+// ScanContent screens stored text for one source (?source=) or every source
+func (h *AdminHandler) ScanContent(c *fiber.Ctx) error {
+	sources := services.ContentSources
+	if name := c.Query("source"); name != "" {
+		source, err := services.FindContentSource(name)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		sources = []services.ContentSource{source}
+	}
+
+	queued := 0
+	for _, source := range sources {
+		var count int
+		err := h.uow.Do(func(tx repository.Repositories) (err error) {
+			count, err = tx.Moderation.Scan(source)
+			return err
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to scan " + source.Name,
+				"error":   err.Error(),
+				"queued":  queued,
+			})
+		}
+		queued += count
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Content scanned successfully",
+		"queued":  queued,
+	})
+}
+
+// GetScreeningTerms lists every screening term
+func (h *AdminHandler) GetScreeningTerms(c *fiber.Ctx) error {
+	terms, err := h.repos.Moderation.Terms()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch screening terms",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Screening terms retrieved successfully",
+		"data":    terms,
+	})
+}
+
+// CreateScreeningTerm adds a word or regex to the screening list (super admins only)
+func (h *AdminHandler) CreateScreeningTerm(c *fiber.Ctx) error {
+	var request screeningTermRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	term := models.ScreeningTerm{Enabled: true}
+	request.apply(&term)
+	if err := services.ValidateScreeningTerm(term); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Moderation.SaveTerm(&term); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to create screening term",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Screening term created successfully",
+		"data":    term,
+	})
+}
+
+// UpdateScreeningTerm replaces an existing term (super admins only)
+func (h *AdminHandler) UpdateScreeningTerm(c *fiber.Ctx) error {
+	termID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid term ID",
+		})
+	}
+
+	var request screeningTermRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	term, err := h.repos.Moderation.Term(uint(termID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Screening term not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Database error",
+			"error":   err.Error(),
+		})
+	}
+
+	request.apply(&term)
+	if err := services.ValidateScreeningTerm(term); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Moderation.SaveTerm(&term); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to update screening term",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Screening term updated successfully",
+		"data":    term,
+	})
+}
+
+// DeleteScreeningTerm removes a term; items it already queued are kept (super admins only)
+func (h *AdminHandler) DeleteScreeningTerm(c *fiber.Ctx) error {
+	termID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid term ID",
+		})
+	}
+
+	if err := h.repos.Moderation.DeleteTerm(uint(termID)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Screening term not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to delete screening term",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Screening term deleted successfully",
+	})
+}
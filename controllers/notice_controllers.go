@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper function to send queued outcome notices through the notifier
+// built from the mail settings. The channel is inapp (default), email or
+// log; email sends through the configured SMTP account. In-app messages and
+// the notice statuses commit together.
+func (h *AdminHandler) sendOutcomeNotices() (channel string, sent, failed int, err error) {
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		channel, sent, failed, err = tx.Reports.DispatchNotices(h.cfg.Mail.Channel, h.cfg.Mail.SMTPNotifier())
+		return err
+	})
+	return channel, sent, failed, err
+}
+
+// Helper function to send queued outcome notices in the background once the
+// moderation change has committed
+func (h *AdminHandler) dispatchOutcomeNotices() {
+	go func() {
+		if _, _, failed, err := h.sendOutcomeNotices(); err != nil {
+			log.Printf("Outcome notice dispatch error: %v\n", err)
+		} else if failed > 0 {
+			log.Printf("%d outcome notices failed to send\n", failed)
+		}
+	}()
+}
+
+// DispatchOutcomeNotices sends pending notices and retries failed ones now
+func (h *AdminHandler) DispatchOutcomeNotices(c *fiber.Ctx) error {
+	channel, sent, failed, err := h.sendOutcomeNotices()
+	if errors.Is(err, services.ErrUnknownChannel) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Outcome notifier is misconfigured",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to dispatch outcome notices",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Outcome notices dispatched",
+		"channel": channel,
+		"sent":    sent,
+		"failed":  failed,
+	})
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAdopterNotifications lists an adopter's notifications (?unread=true for unread only)
+func (h *AdminHandler) GetAdopterNotifications(c *fiber.Ctx) error {
+	return h.recipientNotifications(c, services.RecipientAdopter, "adopter_id")
+}
+
+// GetShelterNotifications lists a shelter's notifications (?unread=true for unread only)
+func (h *AdminHandler) GetShelterNotifications(c *fiber.Ctx) error {
+	return h.recipientNotifications(c, services.RecipientShelter, "shelter_id")
+}
+
+// MarkAdopterNotificationRead marks one of an adopter's notifications as read
+func (h *AdminHandler) MarkAdopterNotificationRead(c *fiber.Ctx) error {
+	return h.markNotificationRead(c, services.RecipientAdopter, "adopter_id")
+}
+
+// MarkShelterNotificationRead marks one of a shelter's notifications as read
+func (h *AdminHandler) MarkShelterNotificationRead(c *fiber.Ctx) error {
+	return h.markNotificationRead(c, services.RecipientShelter, "shelter_id")
+}
+
+// GetAdminNotifications lists the signed-in admin's notifications, such as
+// overdue registration escalations (?unread=true for unread only)
+func (h *AdminHandler) GetAdminNotifications(c *fiber.Ctx) error {
+	return h.listNotifications(c, services.RecipientAdmin, currentAdminID(c))
+}
+
+// MarkAdminNotificationRead marks one of the signed-in admin's
+// notifications as read
+func (h *AdminHandler) MarkAdminNotificationRead(c *fiber.Ctx) error {
+	return h.markRead(c, services.RecipientAdmin, currentAdminID(c))
+}
+
+func (h *AdminHandler) recipientNotifications(c *fiber.Ctx, recipientType, param string) error {
+	recipientID, err := strconv.ParseUint(c.Params(param), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid " + recipientType + " ID",
+		})
+	}
+	return h.listNotifications(c, recipientType, uint(recipientID))
+}
+
+func (h *AdminHandler) listNotifications(c *fiber.Ctx, recipientType string, recipientID uint) error {
+	notifications, err := h.repos.Notifications.ForRecipient(recipientType, recipientID, c.QueryBool("unread", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch notifications",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notifications retrieved successfully",
+		"data":    notifications,
+	})
+}
+
+func (h *AdminHandler) markNotificationRead(c *fiber.Ctx, recipientType, param string) error {
+	recipientID, err := strconv.ParseUint(c.Params(param), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid " + recipientType + " ID",
+		})
+	}
+	return h.markRead(c, recipientType, uint(recipientID))
+}
+
+func (h *AdminHandler) markRead(c *fiber.Ctx, recipientType string, recipientID uint) error {
+	notificationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid notification ID",
+		})
+	}
+
+	if err := h.repos.Notifications.MarkRead(recipientType, recipientID, uint(notificationID)); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Unread notification not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to update notification",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification marked as read",
+	})
+}
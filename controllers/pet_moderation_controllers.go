@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UnlistPet hides a pet listing and freezes its open applications
+func (h *AdminHandler) UnlistPet(c *fiber.Ctx) error {
+	return h.moderatePet(c, services.ListingUnlisted, "Pet unlisted successfully")
+}
+
+// RelistPet puts a pet listing back and releases its frozen applications
+func (h *AdminHandler) RelistPet(c *fiber.Ctx) error {
+	return h.moderatePet(c, services.ListingListed, "Pet relisted successfully")
+}
+
+// FlagPet marks a pet listing for follow-up without hiding it
+func (h *AdminHandler) FlagPet(c *fiber.Ctx) error {
+	return h.moderatePet(c, services.ListingFlagged, "Pet flagged successfully")
+}
+
+func (h *AdminHandler) moderatePet(c *fiber.Ctx, to, successMessage string) error {
+	petID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid pet ID",
+		})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var result services.PetModerationResult
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		result, err = tx.Pets.Moderate(uint(petID), currentAdminID(c), to, request.Reason)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPetNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Pet not found",
+			})
+		case errors.Is(err, services.ErrModerationReasonMissing):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return statusTransitionError(c, err, "Pet not found", "Failed to update pet listing")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": successMessage,
+		"data":    result,
+	})
+}
+
+// GetPetModerationHistory lists the moderation actions taken on a shelter's pets
+func (h *AdminHandler) GetPetModerationHistory(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("shelter_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	history, err := h.repos.Pets.ModerationHistory(uint(shelterID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch pet moderation history",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Pet moderation history retrieved successfully",
+		"data":    history,
+	})
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"pethubadmin/config"
+	"pethubadmin/middleware"
+	"pethubadmin/migrations"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openCountingDB returns an in-memory database with every admin table and
+// a counter that goes up once per statement sent to it
+func openCountingDB(t *testing.T) (*gorm.DB, *int64) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(migrations.Models...); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+
+	var queries int64
+	count := func(*gorm.DB) { atomic.AddInt64(&queries, 1) }
+	callbacks := db.Callback()
+	callbacks.Query().After("gorm:query").Register("test:count_queries", count)
+	callbacks.Row().After("gorm:row").Register("test:count_rows", count)
+	callbacks.Raw().After("gorm:raw").Register("test:count_raw", count)
+	return db, &queries
+}
+
+// newGormHandler reads through the GORM repositories over db and fails every write
+func newGormHandler(db *gorm.DB) *AdminHandler {
+	return NewAdminHandler(config.Config{}, repository.NewGorm(db), failingUnit{errNoDatabase}, middleware.NewJWTAuth(config.JWTConfig{}, nil))
+}
+
+// seedReportedShelters creates n shelters in the given status and registration
+// state, each reported twice by its own adopter
+func seedReportedShelters(t *testing.T, db *gorm.DB, n int, status, regStatus, reportStatus string) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		id := uint(i)
+		rows := []interface{}{
+			&models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", i), Status: status, RegStatus: regStatus, Version: 1},
+			&models.ShelterInfo{ShelterID: id, ShelterName: fmt.Sprintf("Shelter %d", i)},
+			&models.ShelterMedia{ShelterID: id, ShelterProfile: "profile"},
+			&models.ShelterDocument{ShelterID: id, DocType: services.DocOwnerID, Status: services.DocStatusPending},
+			&models.AdopterAccount{AdopterID: id, Username: fmt.Sprintf("adopter%d", i), Status: services.StatusActive, Version: 1},
+			&models.AdopterInfo{AdopterID: id, FirstName: "Ana", LastName: fmt.Sprint(i), Email: fmt.Sprintf("ana%d@example.com", i)},
+			&models.SubmittedReport{ShelterID: id, AdopterID: id, Reason: "neglect", Status: reportStatus, Version: 1},
+			&models.SubmittedReport{ShelterID: id, AdopterID: id, Reason: "scam", Status: reportStatus, Version: 1},
+		}
+		for _, row := range rows {
+			if err := db.Create(row).Error; err != nil {
+				t.Fatalf("seeding: %v", err)
+			}
+		}
+		if err := db.Create(&models.ReportAttachment{ReportID: uint(2*i - 1), FileName: "a.png"}).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+// TestListQueryCounts checks that the grouped list endpoints load their
+// related rows in batches: the number of statements must not grow with the
+// number of shelters on the page.
+func TestListQueryCounts(t *testing.T) {
+	cases := []struct {
+		name      string
+		target    string
+		status    string
+		regStatus string
+		reports   string
+		handler   func(h *AdminHandler) fiber.Handler
+	}{
+		{"submitted reports", "/", services.StatusActive, services.RegStatusApproved, services.ReportStatusReported,
+			func(h *AdminHandler) fiber.Handler { return h.GetSubmittedReports }},
+		{"blocked shelters", "/", services.StatusInactive, services.RegStatusApproved, services.ReportStatusBlocked,
+			func(h *AdminHandler) fiber.Handler { return h.GetBlockedShelters }},
+		{"pending requests", "/", services.StatusInactive, services.RegStatusPending, services.ReportStatusReported,
+			func(h *AdminHandler) fiber.Handler { return h.GetAllPendingRequests }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			counts := make(map[int]int64)
+			for _, shelters := range []int{2, 12} {
+				db, queries := openCountingDB(t)
+				seedReportedShelters(t, db, shelters, tc.status, tc.regStatus, tc.reports)
+
+				h := newGormHandler(db)
+				app := fiber.New()
+				app.Get("/", tc.handler(h))
+
+				atomic.StoreInt64(queries, 0)
+				r := call(t, app, "GET", tc.target, "")
+				counts[shelters] = atomic.LoadInt64(queries)
+				if r.status != fiber.StatusOK {
+					t.Fatalf("%d shelters: status %d: %v", shelters, r.status, r.body)
+				}
+				if listed := len(dataList(t, r)); listed != shelters {
+					t.Fatalf("listed %d shelters, want %d", listed, shelters)
+				}
+			}
+
+			if counts[2] != counts[12] {
+				t.Fatalf("queries grew with the shelters listed: %d for 2 shelters, %d for 12", counts[2], counts[12])
+			}
+			t.Logf("%d queries", counts[2])
+		})
+	}
+}
+
+func TestShelterStatsFailuresAreReported(t *testing.T) {
+	db, _ := openCountingDB(t)
+	// Without the petinfo table the grouped query fails
+	if err := db.Migrator().DropTable(&models.PetInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	h := newGormHandler(db)
+	app := fiber.New()
+	app.Get("/petcounts", h.GetShelterPetCounts)
+	app.Get("/vaccinecounts", h.GetShelterVaccinationCounts)
+
+	for _, target := range []string{"/petcounts", "/vaccinecounts"} {
+		if r := call(t, app, "GET", target, ""); r.status != fiber.StatusInternalServerError || r.body["error"] == nil {
+			t.Errorf("%s: status %d, want 500 with the error: %v", target, r.status, r.body)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// reportCategoryRequest is the editable part of a report category
+type reportCategoryRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Keywords    string `json:"keywords"`
+	Severity    int    `json:"severity"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+func (r reportCategoryRequest) apply(category *models.ReportCategory) {
+	category.Code = strings.ToLower(strings.TrimSpace(r.Code))
+	category.Name = strings.TrimSpace(r.Name)
+	category.Description = r.Description
+	category.Keywords = r.Keywords
+	category.Severity = r.Severity
+	if r.Enabled != nil {
+		category.Enabled = *r.Enabled
+	}
+}
+
+// GetReportCategories lists the report taxonomy, most severe first. Adopter
+// apps use it to let reporters pick a category.
+func (h *AdminHandler) GetReportCategories(c *fiber.Ctx) error {
+	categories, err := h.repos.Reports.CategoryList(c.QueryBool("include_disabled", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report categories",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Report categories retrieved successfully",
+		"data":    categories,
+	})
+}
+
+// CreateReportCategory adds a category to the taxonomy (super admins only)
+func (h *AdminHandler) CreateReportCategory(c *fiber.Ctx) error {
+	var request reportCategoryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	category := models.ReportCategory{Enabled: true}
+	request.apply(&category)
+	if err := services.ValidateReportCategory(category); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Reports.SaveCategory(&category); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to create report category",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Report category created successfully",
+		"data":    category,
+	})
+}
+
+// UpdateReportCategory replaces a category's settings; categories are
+// disabled rather than deleted so existing reports keep them (super admins only)
+func (h *AdminHandler) UpdateReportCategory(c *fiber.Ctx) error {
+	categoryID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid category ID",
+		})
+	}
+
+	var request reportCategoryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	category, err := h.repos.Reports.Category(uint(categoryID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Report category not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Database error",
+			"error":   err.Error(),
+		})
+	}
+
+	request.apply(&category)
+	if err := services.ValidateReportCategory(category); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	if err := h.repos.Reports.SaveCategory(&category); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to update report category",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Report category updated successfully",
+		"data":    category,
+	})
+}
+
+// BackfillReportCategories classifies reports submitted without a category
+func (h *AdminHandler) BackfillReportCategories(c *fiber.Ctx) error {
+	var updated int
+	err := h.uow.Do(func(tx repository.Repositories) (err error) {
+		updated, err = tx.Reports.BackfillCategories()
+		return err
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to classify reports",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Reports classified successfully",
+		"updated": updated,
+	})
+}
+
+// GetReportAnalytics counts reports per category and status (?days= limits the window)
+func (h *AdminHandler) GetReportAnalytics(c *fiber.Ctx) error {
+	var since time.Time
+	if days := c.QueryInt("days", 0); days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+
+	rows, uncategorized, err := h.repos.Reports.Analytics(since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to build report analytics",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Report analytics retrieved successfully",
+		"data":          rows,
+		"uncategorized": uncategorized,
+	})
+}
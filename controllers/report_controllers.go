@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DismissReport closes a report without acting on the shelter
+func (h *AdminHandler) DismissReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid report ID",
+		})
+	}
+
+	var request struct {
+		Note string `json:"note"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var report models.SubmittedReport
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		if err = tx.Reports.CheckVersion(uint(reportID), ifMatch(c)); err != nil {
+			return err
+		}
+		report, err = tx.Reports.Dismiss(uint(reportID), currentAdminID(c), request.Note)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReportNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Report not found",
+			})
+		case errors.Is(err, services.ErrReportNotPending):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		case errors.Is(err, services.ErrVersionMismatch):
+			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to dismiss report",
+			"error":   err.Error(),
+		})
+	}
+
+	setETag(c, report.Version)
+	return c.JSON(fiber.Map{
+		"message": "Report dismissed successfully",
+		"data":    report,
+	})
+}
+
+// GetReporterCredibility returns one adopter's report history and credibility score
+func (h *AdminHandler) GetReporterCredibility(c *fiber.Ctx) error {
+	adopterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid adopter ID",
+		})
+	}
+
+	credibility, err := h.repos.Reports.Credibility([]uint{uint(adopterID)})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch reporter credibility",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Reporter credibility retrieved successfully",
+		"data":    credibility[uint(adopterID)],
+	})
+}
+
+// GetReportDetail returns one report with its category, reporter credibility,
+// attachment list and the outcome notices sent about it
+func (h *AdminHandler) GetReportDetail(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid report ID",
+		})
+	}
+
+	report, err := h.repos.Reports.Report(uint(reportID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Report not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report",
+			"error":   err.Error(),
+		})
+	}
+
+	categories, err := h.repos.Reports.Categories()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report categories",
+			"error":   err.Error(),
+		})
+	}
+	credibility, err := h.repos.Reports.Credibility([]uint{report.AdopterID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch reporter credibility",
+			"error":   err.Error(),
+		})
+	}
+	attachments, err := h.repos.Reports.Attachments(report.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report attachments",
+			"error":   err.Error(),
+		})
+	}
+
+	notices, err := h.repos.Reports.Notices(report.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch report notices",
+			"error":   err.Error(),
+		})
+	}
+
+	setETag(c, report.Version)
+	return c.JSON(fiber.Map{
+		"message": "Report retrieved successfully",
+		"data": fiber.Map{
+			"id":           report.ID,
+			"version":      report.Version,
+			"reason":       services.NormalizeText(report.Reason),
+			"description":  services.NormalizeText(report.Description),
+			"status":       report.Status,
+			"category":     services.CategoryView(categories, report.CategoryID),
+			"created_at":   report.CreatedAt,
+			"shelter_id":   report.ShelterID,
+			"shelter_name": report.Shelter.ShelterName,
+			"reported_by": fiber.Map{
+				"adopter_id":    report.AdopterID,
+				"adopter_name":  report.Adopter.FirstName + " " + report.Adopter.LastName,
+				"adopter_email": report.Adopter.Email,
+				"credibility":   credibility[report.AdopterID],
+			},
+			"attachments": nonNilAttachments(attachments[report.ID]),
+			"notices":     notices,
+		},
+	})
+}
+
+// UploadReportAttachment adds an evidence file to an open report. It runs
+// behind the reporter's token and only accepts files for their own reports.
+func (h *AdminHandler) UploadReportAttachment(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid report ID",
+		})
+	}
+
+	var request struct {
+		FileName string `json:"file_name"`
+		FileData string `json:"file_data"` // Base64-encoded image or PDF
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var attachment models.ReportAttachment
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		attachment, err = tx.Reports.AddAttachment(uint(reportID), currentAdopterID(c), request.FileName, request.FileData)
+		return err
+	})
+	if err != nil {
+		return attachmentError(c, err, "Failed to upload attachment")
+	}
+
+	attachment.FileData = ""
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Attachment uploaded successfully",
+		"data":    attachment,
+	})
+}
+
+// GetReportAttachment returns one attachment including its file data
+func (h *AdminHandler) GetReportAttachment(c *fiber.Ctx) error {
+	attachmentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid attachment ID",
+		})
+	}
+
+	attachment, err := h.repos.Reports.Attachment(uint(attachmentID))
+	if err != nil {
+		return attachmentError(c, err, "Failed to fetch attachment")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Attachment retrieved successfully",
+		"data":    attachment,
+	})
+}
+
+// Helper function to map attachment errors to HTTP responses
+func attachmentError(c *fiber.Ctx, err error, failedMessage string) error {
+	switch {
+	case errors.Is(err, services.ErrReportNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Report not found",
+		})
+	case errors.Is(err, services.ErrNotReportOwner):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Attachment not found",
+		})
+	case errors.Is(err, services.ErrUploadTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrTooManyAttachments), errors.Is(err, services.ErrReportAlreadyClosed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrEmptyUpload), errors.Is(err, services.ErrInvalidEncoding),
+		errors.Is(err, services.ErrUnsupportedType):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": failedMessage,
+		"error":   err.Error(),
+	})
+}
+
+// Helper function so reports without attachments serialize as []
+func nonNilAttachments(attachments []models.ReportAttachment) []models.ReportAttachment {
+	if attachments == nil {
+		return []models.ReportAttachment{}
+	}
+	return attachments
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetReviewQueue lists pending shelter registrations ordered by urgency
+func (h *AdminHandler) GetReviewQueue(c *fiber.Ctx) error {
+	settings := h.cfg.Review
+
+	queue, err := h.repos.Shelters.ReviewQueue(settings)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch review queue",
+			"error":   err.Error(),
+		})
+	}
+
+	overdue := 0
+	for _, item := range queue {
+		if item.Overdue {
+			overdue++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Review queue retrieved successfully",
+		"count":     len(queue),
+		"overdue":   overdue,
+		"sla_hours": settings.SLA.Hours(),
+		"data":      queue,
+	})
+}
+
+// ClaimShelterReview locks a pending registration for the current admin
+func (h *AdminHandler) ClaimShelterReview(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	var claim models.ShelterReviewClaim
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		claim, err = tx.Shelters.ClaimReview(uint(shelterID), currentAdminID(c), h.cfg.Review.ClaimTTL)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrAdminIDRequired) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return statusTransitionError(c, err, "Shelter not found", "Failed to claim registration")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Registration claimed successfully",
+		"data":    claim,
+	})
+}
+
+// ReleaseShelterReview gives up the current admin's claim on a registration
+func (h *AdminHandler) ReleaseShelterReview(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	err = h.uow.Do(func(tx repository.Repositories) error {
+		return tx.Shelters.ReleaseReview(uint(shelterID), currentAdminID(c))
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrClaimNotHeld) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to release claim",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Claim released successfully",
+	})
+}
+
+// GetReviewerThroughput reports decisions per reviewer over the last ?days= (default 30)
+func (h *AdminHandler) GetReviewerThroughput(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "days must be a positive number",
+		})
+	}
+
+	stats, err := h.repos.Shelters.ReviewerThroughput(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch reviewer throughput",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Reviewer throughput retrieved successfully",
+		"days":    days,
+		"data":    stats,
+	})
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pethubadmin/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Search result limits per entity type
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// searchLinks is the detail endpoint of each searchable entity type
+var searchLinks = map[string]string{
+	services.SearchAdopters: "/api/admin/adopters/%d",
+	services.SearchShelters: "/api/users/shelters/%d",
+	services.SearchPets:     "/api/admin/pets/%d",
+	services.SearchReports:  "/api/admin/reports/%d",
+}
+
+// Search finds adopters, shelters, pets and reports matching ?q=. ?types=
+// narrows the entity types (comma separated) and ?limit= caps the hits per
+// type. Hits are ranked and grouped by type, best group first, each with a
+// link to its detail endpoint.
+func (h *AdminHandler) Search(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultSearchLimit)
+	if limit < 1 || limit > maxSearchLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit),
+		})
+	}
+
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	groups, err := h.repos.Search.Search(c.Query("q"), types, limit)
+	if errors.Is(err, services.ErrSearchQuery) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Search failed",
+			"error":   err.Error(),
+		})
+	}
+
+	total := 0
+	for _, group := range groups {
+		for i := range group.Hits {
+			group.Hits[i].Link = fmt.Sprintf(searchLinks[group.EntityType], group.Hits[i].ID)
+		}
+		total += len(group.Hits)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Search results retrieved successfully",
+		"total":   total,
+		"data":    groups,
+	})
+}
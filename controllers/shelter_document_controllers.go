@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadShelterDocument stores a verification document for a shelter's registration
+func (h *AdminHandler) UploadShelterDocument(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("shelter_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	var request struct {
+		DocType  string `json:"doc_type"` // business_permit, owner_id or facility_photo
+		FileName string `json:"file_name"`
+		FileData string `json:"file_data"` // Base64-encoded file
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var doc models.ShelterDocument
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		doc, err = tx.Shelters.UploadDocument(uint(shelterID), request.DocType, request.FileName, request.FileData)
+		return err
+	})
+	if err != nil {
+		return documentError(c, err, "Failed to upload document")
+	}
+
+	doc.FileData = ""
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Document uploaded successfully",
+		"data":    doc,
+	})
+}
+
+// GetShelterDocumentChecklist returns the required document checklist and all
+// uploads for a shelter. Used by both the admin review screen and the shelter.
+func (h *AdminHandler) GetShelterDocumentChecklist(c *fiber.Ctx) error {
+	param := c.Params("id")
+	if param == "" {
+		param = c.Params("shelter_id")
+	}
+	shelterID, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	docs, err := h.repos.Shelters.Documents(uint(shelterID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch documents",
+			"error":   err.Error(),
+		})
+	}
+
+	checklist := services.BuildChecklist(docs)
+	return c.JSON(fiber.Map{
+		"message": "Documents retrieved successfully",
+		"data": fiber.Map{
+			"checklist": checklist,
+			"complete":  services.ChecklistComplete(checklist),
+			"documents": docs,
+		},
+	})
+}
+
+// GetShelterDocument returns a single document including its file data
+func (h *AdminHandler) GetShelterDocument(c *fiber.Ctx) error {
+	documentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid document ID",
+		})
+	}
+
+	doc, err := h.repos.Shelters.Document(uint(documentID))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Document not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Database error",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Document retrieved successfully",
+		"data":    doc,
+	})
+}
+
+// ReviewShelterDocument accepts or rejects one verification document
+func (h *AdminHandler) ReviewShelterDocument(c *fiber.Ctx) error {
+	documentID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid document ID",
+		})
+	}
+
+	var request struct {
+		Status string `json:"status"` // "accepted" or "rejected"
+		Reason string `json:"reason"` // required when rejected
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var doc models.ShelterDocument
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		doc, err = tx.Shelters.ReviewDocument(uint(documentID), currentAdminID(c), request.Status, request.Reason)
+		return err
+	})
+	if err != nil {
+		return documentError(c, err, "Failed to review document")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Document reviewed successfully",
+		"data":    doc,
+	})
+}
+
+// Helper function to map document and upload errors to HTTP responses
+func documentError(c *fiber.Ctx, err error, failedMessage string) error {
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Shelter not found",
+		})
+	case errors.Is(err, services.ErrDocumentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Document not found",
+		})
+	case errors.Is(err, services.ErrUploadTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case errors.Is(err, services.ErrUnknownDocumentType), errors.Is(err, services.ErrInvalidDocumentStatus),
+		errors.Is(err, services.ErrDocumentRejectReason), errors.Is(err, services.ErrEmptyUpload),
+		errors.Is(err, services.ErrInvalidEncoding), errors.Is(err, services.ErrUnsupportedType):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": failedMessage,
+		"error":   err.Error(),
+	})
+}
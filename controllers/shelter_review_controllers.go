@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"errors"
+	"pethubadmin/models"
+	"pethubadmin/repository"
+	"pethubadmin/services"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetRejectionReasons lists the reason codes accepted when rejecting a shelter
+func (h *AdminHandler) GetRejectionReasons(c *fiber.Ctx) error {
+	reasons := []fiber.Map{}
+	for code, label := range services.RejectionReasons {
+		reasons = append(reasons, fiber.Map{
+			"code":  code,
+			"label": label,
+		})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		return reasons[i]["code"].(string) < reasons[j]["code"].(string)
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Rejection reasons retrieved successfully",
+		"data":    reasons,
+	})
+}
+
+// GetShelterReviewHistory returns every review round of a shelter registration
+// including the changes made between rejections
+func (h *AdminHandler) GetShelterReviewHistory(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	history, err := h.repos.Shelters.ReviewHistory(uint(shelterID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to fetch review history",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Review history retrieved successfully",
+		"count":   len(history),
+		"data":    history,
+	})
+}
+
+// ResubmitShelterRegistration lets a rejected shelter correct its info and
+// send the registration back for review. Only the shelter's own token gets
+// here; see middleware.RequireShelterOwner. Fields left out keep their
+// current value.
+func (h *AdminHandler) ResubmitShelterRegistration(c *fiber.Ctx) error {
+	shelterID, err := strconv.ParseUint(c.Params("shelter_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid shelter ID",
+		})
+	}
+
+	var request services.ShelterInfoSnapshot
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	var round models.ShelterReviewRound
+	err = h.uow.Do(func(tx repository.Repositories) (err error) {
+		round, err = tx.Shelters.Resubmit(uint(shelterID), request)
+		return err
+	})
+	if errors.Is(err, services.ErrIncompleteRegistration) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		return statusTransitionError(c, err, "Shelter not found", "Failed to resubmit registration")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Registration resubmitted for review",
+		"data": fiber.Map{
+			"shelter_id": round.ShelterID,
+			"round":      round.Round,
+			"reg_status": services.RegStatusPending,
+		},
+	})
+}
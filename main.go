@@ -2,37 +2,81 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
+
 	//"pethub_api/controllers"
+	"pethubadmin/config"
 	"pethubadmin/middleware"
+	"pethubadmin/migrations"
 	"pethubadmin/routes"
+	"pethubadmin/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
-func init() {
-	fmt.Println("STARTING SERVER...")
+func initDatabase(cfg config.Config) {
 	fmt.Println("INITIALIZE DB CONNECTION...")
-	if middleware.ConnectDB() {
+	if middleware.ConnectDB(cfg.DB) {
 		fmt.Println("DB CONNECTION FAILED!")
 	} else {
 		fmt.Println("DB CONNECTION SUCCESSFUL!")
 		// Assign the database connection to the controllers.DB variable
 		//controllers.DB = middleware.DBConn
+
+		// Bring the schema up to date (MIGRATE_ON_START, default true)
+		if cfg.DB.MigrateOnStart {
+			applied, err := migrations.Up(middleware.DBConn, 0)
+			for _, m := range applied {
+				log.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+			}
+			if err != nil {
+				log.Fatalf("Migration failed: %v\n", err)
+			}
+		}
+
+		// Seed the default screening wordlists
+		if err := services.SeedScreeningTerms(middleware.DBConn); err != nil {
+			log.Printf("Failed to seed screening terms: %v\n", err)
+		}
+
+		// Seed the report taxonomy and classify the reports filed while the
+		// admin was down
+		if err := services.SeedReportCategories(middleware.DBConn); err != nil {
+			log.Printf("Failed to seed report categories: %v\n", err)
+		}
+		if _, err := services.BackfillReportCategories(middleware.DBConn); err != nil {
+			log.Printf("Failed to classify reports: %v\n", err)
+		}
 	}
 }
 
 func main() {
+	fmt.Println("STARTING SERVER...")
+
+	// Settings are read and validated once; the server refuses to start
+	// with a bad configuration
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	initDatabase(cfg)
+
 	app := fiber.New(fiber.Config{
-		AppName: middleware.GetEnv("PROJ_NAME"),
+		AppName: cfg.Server.Name,
+		// Uploads arrive base64 encoded in JSON, well past Fiber's 4 MB default
+		BodyLimit: services.UploadBodyLimit,
 	})
 
 	// CORS CONFIG
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Allow all origins (update for production)
-		AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowOrigins: cfg.CORS.AllowOrigins,
+		AllowMethods: cfg.CORS.AllowMethods,
+		AllowHeaders: cfg.CORS.AllowHeaders,
+		// clients read the ETag to send it back in If-Match
+		ExposeHeaders: fiber.HeaderETag,
 	}))
 
 	// LOGGER
@@ -45,14 +89,36 @@ func main() {
 		return c.SendStatus(204) // No Content
 	})
 
-	routes.AppRoutes(app)
+	routes.AppRoutes(app, cfg, middleware.DBConn)
 
-	// Start Server
-	port := middleware.GetEnv("PROJ_PORT")
-	if port == "" {
-		port = "5566" // Default to port 5566 if not set
+	// Re-evaluate flag rules and release expired holds on a schedule
+	// (FLAG_EVAL_INTERVAL_MINUTES, default 15)
+	if middleware.DBConn != nil {
+		services.StartFlagScheduler(middleware.DBConn, cfg.FlagEvalInterval, make(chan struct{}))
+
+		// Classify new reports against the taxonomy
+		// (REPORT_CLASSIFY_INTERVAL_MINUTES, default 5)
+		services.StartClassificationScheduler(middleware.DBConn, cfg.ClassifyInterval, make(chan struct{}))
+
+		// Screen the user text the main app changed since the last pass
+		// (CONTENT_SCREEN_INTERVAL_MINUTES, default 5)
+		services.StartScreeningScheduler(middleware.DBConn, cfg.ScreenInterval, make(chan struct{}))
+
+		// Purge deleted accounts whose restore window has closed
+		// (ACCOUNT_PURGE_INTERVAL_MINUTES, default 60)
+		services.StartPurgeScheduler(middleware.DBConn, cfg.AccountDeletion.PurgeInterval, make(chan struct{}))
+
+		// Escalate registrations past the review SLA and tell the admins
+		// (REVIEW_ESCALATION_INTERVAL_MINUTES, default 15)
+		notifier, err := services.NewNotifier(cfg.Mail.Channel, middleware.DBConn, cfg.Mail.SMTPNotifier())
+		if err != nil {
+			log.Fatalf("Invalid notifier: %v\n", err)
+		}
+		services.StartEscalationScheduler(middleware.DBConn, cfg.Review, notifier, cfg.Mail.Address, make(chan struct{}))
 	}
-	app.Listen(fmt.Sprintf(":%s", port))
+
+	// Start Server
+	app.Listen(fmt.Sprintf(":%s", cfg.Server.Port))
 }
 
 //johnrev
@@ -2,36 +2,64 @@ package middleware
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"pethubadmin/config"
+	"pethubadmin/models"
 	"pethubadmin/models/response"
+	"pethubadmin/services"
+	"strconv"
 
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/joho/godotenv"
 )
 
-func init() {
-	err := godotenv.Load() // Load from .env file
-	if err != nil {
-		log.Println("Warning: No .env file found")
-	}
+// Token kinds, carried in the "kind" claim. Admin tokens are issued here;
+// shelter and adopter tokens come from the main PetHub app, signed with the
+// same key, and carry the account ID as "id". Admin routes refuse tokens
+// without the claim, so a main-app token can never pass as an admin one.
+//
+// Shelter and adopter IDs come from separate sequences, so a token without
+// the claim cannot say which account its "id" names. Such tokens are
+// refused unless JWT_ACCEPT_KINDLESS is on (it is off by default). Even
+// then one only passes when its ID is an account of the kind the route
+// expects and of no other kind, looked up with the AccountFinder.
+const (
+	KindAdmin   = "admin"
+	KindShelter = "shelter"
+	KindAdopter = "adopter"
+)
+
+// AccountFinder tells whether an account of a kind has an ID
+type AccountFinder interface {
+	HasAccount(kind string, id uint) (bool, error)
 }
 
-// Secret key for signing tokens (should be stored in env variables)
-var SecretKey = os.Getenv("SECRET_KEY")
+// JWTAuth issues and checks admin tokens signed with the configured key
+type JWTAuth struct {
+	secret         []byte
+	ttl            time.Duration
+	acceptKindless bool
+	accounts       AccountFinder
+}
+
+// NewJWTAuth returns the token signer for the JWT settings. accounts
+// resolves kindless tokens when the settings accept them; with nil
+// accounts they are always refused.
+func NewJWTAuth(cfg config.JWTConfig, accounts AccountFinder) *JWTAuth {
+	return &JWTAuth{secret: []byte(cfg.SecretKey), ttl: cfg.TokenTTL, acceptKindless: cfg.AcceptKindless, accounts: accounts}
+}
 
 // GenerateJWT generates a new JWT token
-func GenerateJWT(ID uint) (string, error) {
+func (a *JWTAuth) GenerateJWT(ID uint) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	claims := token.Claims.(jwt.MapClaims)
 	claims["id"] = ID
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix() // Expires in 72 hours
+	claims["kind"] = KindAdmin
+	claims["exp"] = time.Now().Add(a.ttl).Unix()
 
-	tokenString, err := token.SignedString([]byte(SecretKey))
+	tokenString, err := token.SignedString(a.secret)
 	if err != nil {
 		return "", err
 	}
@@ -39,59 +67,193 @@ func GenerateJWT(ID uint) (string, error) {
 	return tokenString, nil
 }
 
-func JWTMiddleware() fiber.Handler {
+func (a *JWTAuth) JWTMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		tokenString := c.Get("Authorization")
+		claims, message := a.claims(c)
+		if message == "" && tokenKind(claims) != KindAdmin {
+			message = "Unauthorized: Not an admin token"
+		}
+		if message != "" {
+			return c.JSON(response.ResponseModel{
+				RetCode: "401",
+				Message: message,
+				Data:    nil,
+			})
+		}
 
-		if tokenString == "" {
+		c.Locals("id", claims["id"])
+		return c.Next()
+	}
+}
+
+// RequireToken lets any token signed with the key through, whatever its
+// kind, as JWTMiddleware did before it checked kinds. It guards the shared
+// read-only routes used by both the admin panel and the main app.
+func (a *JWTAuth) RequireToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, message := a.claims(c)
+		if message != "" {
 			return c.JSON(response.ResponseModel{
 				RetCode: "401",
-				Message: "Unauthorized: No token provided",
+				Message: message,
 				Data:    nil,
 			})
 		}
 
-		// Remove "Bearer " prefix if present
-		if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
-			tokenString = tokenString[7:]
+		c.Locals("id", claims["id"])
+		c.Locals("kind", tokenKind(claims))
+		return c.Next()
+	}
+}
+
+// RequireShelterOwner only lets a shelter's own token through to routes
+// carrying its ID in the param route parameter. It takes the place of
+// JWTMiddleware on shelter-facing routes.
+func (a *JWTAuth) RequireShelterOwner(param string) fiber.Handler {
+	return a.requireOwner(KindShelter, param)
+}
+
+// RequireAdopterOwner is RequireShelterOwner for adopter-facing routes
+func (a *JWTAuth) RequireAdopterOwner(param string) fiber.Handler {
+	return a.requireOwner(KindAdopter, param)
+}
+
+// RequireAdopter lets any adopter token through, for routes whose record
+// does not carry the adopter ID in the path. The handler checks that the
+// record belongs to the adopter stored in "id".
+func (a *JWTAuth) RequireAdopter() fiber.Handler {
+	return a.requireOwner(KindAdopter, "")
+}
+
+// requireOwner checks for a token of kind and, when param is set, that it
+// belongs to the account named by that route parameter
+func (a *JWTAuth) requireOwner(kind, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, message := a.claims(c)
+		id, hasID := claims["id"].(float64)
+		if message == "" && tokenKind(claims) != kind && !(tokenKind(claims) == "" && hasID && a.kindlessIs(kind, uint(id))) {
+			message = "Unauthorized: Not " + article(kind) + " token"
+		}
+		if message != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(response.ResponseModel{
+				RetCode: "401",
+				Message: message,
+				Data:    nil,
+			})
 		}
 
-		// var count int64
-		// err := DBConn.Table("token_blacklists").Where("token = ?", tokenString).Count(&count).Error
-		// if err != nil {
-		// 	return c.JSON(response.ResponseModel{
-		// 		RetCode: "500",
-		// 		Message: "Error checking token blacklist",
-		// 		Data:    err,
-		// 	})
-		// }
-
-		// if count > 0 {
-		// 	return c.JSON(response.ResponseModel{
-		// 		RetCode: "401",
-		// 		Message: "Unauthorized: Token is blacklisted",
-		// 		Data:    nil,
-		// 	})
-		// }
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return []byte(SecretKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			return c.JSON(response.ResponseModel{
+		if !hasID || (param != "" && c.Params(param) != strconv.FormatUint(uint64(id), 10)) {
+			return c.Status(fiber.StatusForbidden).JSON(response.ResponseModel{
+				RetCode: "403",
+				Message: "Forbidden: Token does not belong to this " + kind,
+				Data:    nil,
+			})
+		}
+
+		c.Locals("id", claims["id"])
+		c.Locals("kind", kind)
+		return c.Next()
+	}
+}
+
+// kindlessIs reports whether a kindless token for id can only be for an
+// account of kind: the ID must be an account of that kind and of no other.
+// Lookup errors refuse the token.
+func (a *JWTAuth) kindlessIs(kind string, id uint) bool {
+	if !a.acceptKindless || a.accounts == nil {
+		return false
+	}
+	for _, k := range []string{KindAdmin, KindShelter, KindAdopter} {
+		found, err := a.accounts.HasAccount(k, id)
+		if err != nil || found != (k == kind) {
+			return false
+		}
+	}
+	return true
+}
+
+func article(kind string) string {
+	if kind == KindAdmin || kind == KindAdopter {
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// claims verifies the bearer token and returns its claims, or the reason
+// it was refused
+func (a *JWTAuth) claims(c *fiber.Ctx) (jwt.MapClaims, string) {
+	tokenString := c.Get("Authorization")
+	if tokenString == "" {
+		return nil, "Unauthorized: No token provided"
+	}
+
+	// Remove "Bearer " prefix if present
+	if len(tokenString) > 7 && tokenString[:7] == "Bearer " {
+		tokenString = tokenString[7:]
+	}
+
+	// var count int64
+	// err := DBConn.Table("token_blacklists").Where("token = ?", tokenString).Count(&count).Error
+	// if err != nil {
+	// 	return nil, "Error checking token blacklist"
+	// }
+	// if count > 0 {
+	// 	return nil, "Unauthorized: Token is blacklisted"
+	// }
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return a.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, "Unauthorized: Invalid token"
+	}
+	return token.Claims.(jwt.MapClaims), ""
+}
+
+func tokenKind(claims jwt.MapClaims) string {
+	kind, _ := claims["kind"].(string)
+	return kind
+}
+
+// AdminFinder looks up an admin account by its ID
+type AdminFinder interface {
+	ByID(id uint) (models.AdminAccount, error)
+}
+
+// RequireSuperAdmin only lets admins with the super_admin role through,
+// looking them up with admins. It must run after JWTMiddleware, which
+// stores the admin ID.
+func RequireSuperAdmin(admins AdminFinder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, ok := c.Locals("id").(float64)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(response.ResponseModel{
 				RetCode: "401",
-				Message: "Unauthorized: Invalid token",
+				Message: "Unauthorized: No admin in token",
 				Data:    nil,
 			})
 		}
 
-		fmt.Println("Token received:", tokenString)
+		admin, err := admins.ByID(uint(id))
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(response.ResponseModel{
+				RetCode: "401",
+				Message: "Unauthorized: Admin not found",
+				Data:    nil,
+			})
+		}
+
+		if admin.Role != services.RoleSuperAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(response.ResponseModel{
+				RetCode: "403",
+				Message: "Forbidden: Super admin access required",
+				Data:    nil,
+			})
+		}
 
-		c.Locals("id", token.Claims.(jwt.MapClaims)["id"])
 		return c.Next()
 	}
 }
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pethubadmin/config"
+	"pethubadmin/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+// shelterToken signs a token the way the main app does for a shelter
+func shelterToken(t *testing.T, shelterID uint, secret string) string {
+	t.Helper()
+	return signToken(t, jwt.MapClaims{"id": shelterID, "kind": KindShelter}, secret)
+}
+
+func signToken(t *testing.T, claims jwt.MapClaims, secret string) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestTokenKinds(t *testing.T) {
+	accounts := accountTable{KindAdmin: {7}, KindShelter: {3, 4, 6}, KindAdopter: {5, 6}}
+	auth := NewJWTAuth(config.JWTConfig{SecretKey: testSecret, TokenTTL: time.Hour}, accounts)
+	legacy := NewJWTAuth(config.JWTConfig{SecretKey: testSecret, TokenTTL: time.Hour, AcceptKindless: true}, accounts)
+	adminToken, err := auth.GenerateJWT(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+	app.Get("/api/admin", auth.JWTMiddleware(), ok)
+	app.Put("/shelter/:shelter_id/resubmit", auth.RequireShelterOwner("shelter_id"), ok)
+	app.Put("/reports/3/attachments", auth.RequireAdopter(), ok)
+	app.Put("/adopter/:adopter_id/notifications", auth.RequireAdopterOwner("adopter_id"), ok)
+	app.Get("/legacy/admin", legacy.JWTMiddleware(), ok)
+	app.Put("/legacy/shelter/:shelter_id", legacy.RequireShelterOwner("shelter_id"), ok)
+	app.Put("/legacy/adopter/:adopter_id", legacy.RequireAdopterOwner("adopter_id"), ok)
+
+	cases := []struct {
+		name   string
+		target string
+		token  string
+		want   int
+		passed bool
+	}{
+		{"admin token on admin route", "/api/admin", adminToken, fiber.StatusOK, true},
+		{"shelter token on admin route", "/api/admin", shelterToken(t, 3, testSecret), fiber.StatusOK, false},
+		{"kindless token on admin route", "/api/admin", signToken(t, jwt.MapClaims{"id": 7}, testSecret), fiber.StatusOK, false},
+		{"kindless token on shelter route", "/shelter/3/resubmit", signToken(t, jwt.MapClaims{"id": 3}, testSecret), fiber.StatusUnauthorized, false},
+		{"no token on shelter route", "/shelter/3/resubmit", "", fiber.StatusUnauthorized, false},
+		{"admin token on shelter route", "/shelter/3/resubmit", adminToken, fiber.StatusUnauthorized, false},
+		{"forged shelter token", "/shelter/3/resubmit", shelterToken(t, 3, "other-secret"), fiber.StatusUnauthorized, false},
+		{"another shelter's token", "/shelter/3/resubmit", shelterToken(t, 4, testSecret), fiber.StatusForbidden, false},
+		{"own shelter token", "/shelter/3/resubmit", shelterToken(t, 3, testSecret), fiber.StatusOK, true},
+		{"shelter token on adopter route", "/reports/3/attachments", shelterToken(t, 3, testSecret), fiber.StatusUnauthorized, false},
+		{"adopter token on adopter route", "/reports/3/attachments", signToken(t, jwt.MapClaims{"id": 5, "kind": KindAdopter}, testSecret), fiber.StatusOK, true},
+		{"another adopter's token", "/adopter/6/notifications", signToken(t, jwt.MapClaims{"id": 5, "kind": KindAdopter}, testSecret), fiber.StatusForbidden, false},
+		{"own adopter token", "/adopter/5/notifications", signToken(t, jwt.MapClaims{"id": 5, "kind": KindAdopter}, testSecret), fiber.StatusOK, true},
+		{"kindless token on legacy admin route", "/legacy/admin", signToken(t, jwt.MapClaims{"id": 7}, testSecret), fiber.StatusOK, false},
+		{"kindless token on legacy shelter route", "/legacy/shelter/3", signToken(t, jwt.MapClaims{"id": 3}, testSecret), fiber.StatusOK, true},
+		{"kindless token on legacy adopter route", "/legacy/adopter/5", signToken(t, jwt.MapClaims{"id": 5}, testSecret), fiber.StatusOK, true},
+		{"another account's kindless token", "/legacy/shelter/3", signToken(t, jwt.MapClaims{"id": 4}, testSecret), fiber.StatusForbidden, false},
+		{"adopter's kindless token on legacy shelter route", "/legacy/shelter/5", signToken(t, jwt.MapClaims{"id": 5}, testSecret), fiber.StatusUnauthorized, false},
+		{"admin's kindless token on legacy shelter route", "/legacy/shelter/7", signToken(t, jwt.MapClaims{"id": 7}, testSecret), fiber.StatusUnauthorized, false},
+		{"ambiguous kindless token on legacy shelter route", "/legacy/shelter/6", signToken(t, jwt.MapClaims{"id": 6}, testSecret), fiber.StatusUnauthorized, false},
+		{"ambiguous kindless token on legacy adopter route", "/legacy/adopter/6", signToken(t, jwt.MapClaims{"id": 6}, testSecret), fiber.StatusUnauthorized, false},
+		{"unknown account's kindless token", "/legacy/shelter/9", signToken(t, jwt.MapClaims{"id": 9}, testSecret), fiber.StatusUnauthorized, false},
+		{"admin token on legacy shelter route", "/legacy/shelter/7", adminToken, fiber.StatusUnauthorized, false},
+		{"adopter token on legacy shelter route", "/legacy/shelter/5", signToken(t, jwt.MapClaims{"id": 5, "kind": KindAdopter}, testSecret), fiber.StatusUnauthorized, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := "PUT"
+			if tc.target == "/api/admin" || tc.target == "/legacy/admin" {
+				method = "GET"
+			}
+			req := httptest.NewRequest(method, tc.target, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			body := make([]byte, 2)
+			n, _ := resp.Body.Read(body)
+			passed := string(body[:n]) == "ok"
+			if resp.StatusCode != tc.want || passed != tc.passed {
+				t.Fatalf("status %d, reached handler %v; want %d, %v", resp.StatusCode, passed, tc.want, tc.passed)
+			}
+		})
+	}
+}
+
+// accountTable is an AccountFinder over fixed IDs per kind
+type accountTable map[string][]uint
+
+func (t accountTable) HasAccount(kind string, id uint) (bool, error) {
+	for _, known := range t[kind] {
+		if known == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// adminTable is an AdminFinder over a fixed set of accounts
+type adminTable map[uint]models.AdminAccount
+
+func (t adminTable) ByID(id uint) (models.AdminAccount, error) {
+	admin, ok := t[id]
+	if !ok {
+		return admin, errAdminNotFound
+	}
+	return admin, nil
+}
+
+var errAdminNotFound = errors.New("admin not found")
+
+func TestRequireSuperAdmin(t *testing.T) {
+	admins := adminTable{
+		1: {AdminID: 1, Role: "super_admin"},
+		2: {AdminID: 2, Role: "admin"},
+	}
+
+	cases := []struct {
+		name string
+		id   interface{}
+		want int
+	}{
+		{"super admin", float64(1), fiber.StatusOK},
+		{"plain admin", float64(2), fiber.StatusForbidden},
+		{"unknown admin", float64(3), fiber.StatusUnauthorized},
+		{"no admin in token", nil, fiber.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if tc.id != nil {
+					c.Locals("id", tc.id)
+				}
+				return c.Next()
+			})
+			app.Get("/", RequireSuperAdmin(admins), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,8 @@
 package middleware
 
 import (
-	"fmt"
 	"log"
-	"pethubadmin/models"
+	"pethubadmin/config"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -15,17 +14,12 @@ var (
 )
 
 // ConnectDB initializes the connection to the PostgreSQL database using
-// environment variables for configuration and assigns the connection
-// to the global variable DBConn. It returns true if there was an error
-// establishing the connection, otherwise false.
-func ConnectDB() bool {
-	// Database Config
-	dns := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=%s TimeZone=%s",
-		GetEnv("DB_HOST"), GetEnv("DB_PORT"), GetEnv("DB_NAME"),
-		GetEnv("DB_USER"), GetEnv("DB_PASSWORD"), GetEnv("DB_SSLM"),
-		GetEnv("DB_TMEZ"))
-
-	DBConn, DBErr = gorm.Open(postgres.Open(dns), &gorm.Config{})
+// the database settings and assigns the connection to the global variable
+// DBConn. It returns true if there was an error establishing the
+// connection, otherwise false. The schema is managed by the migrations
+// package, not here.
+func ConnectDB(cfg config.DBConfig) bool {
+	DBConn, DBErr = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
 	if DBErr != nil {
 		log.Printf("Database connection error: %v\n", DBErr)
 		return true
@@ -33,18 +27,6 @@ func ConnectDB() bool {
 
 	log.Println("Database connection established successfully")
 
-	// Auto-migrate models
-	if err := DBConn.AutoMigrate(
-		&models.AdopterAccount{},
-		&models.AdopterInfo{},
-		&models.ShelterAccount{},
-		&models.ShelterInfo{},
-		&models.ShelterMedia{},
-	); err != nil {
-		log.Printf("AutoMigrate error: %v\n", err)
-		return true
-	}
-
 	return false
 }
 
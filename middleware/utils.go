@@ -1,20 +0,0 @@
-package middleware
-
-import (
-	"fmt"
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
-)
-
-func GetEnv(key string) string {
-	err := godotenv.Load(".env")
-
-	if err != nil {
-		fmt.Println("Error loading .env file")
-		log.Fatalf("Error loading .env file")
-		return err.Error()
-	}
-	return os.Getenv(key)
-}
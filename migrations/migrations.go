@@ -0,0 +1,312 @@
+// Package migrations versions the database schema. Each migration is a pair
+// of SQL scripts, sql/NNNN_name.up.sql and sql/NNNN_name.down.sql, embedded
+// in the binary and applied in version order. Applied versions are recorded
+// in schema_migrations.
+//
+// The scripts use IF NOT EXISTS so a database created earlier by the main
+// app or by AutoMigrate adopts the history without changes.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var scripts embed.FS
+
+// VersionTable records the applied migrations
+const VersionTable = "schema_migrations"
+
+// lockKey serialises migrators running against the same database
+const lockKey = 7_204_311
+
+// BaselineVersion is the migration that adopts the tables shared with the
+// main app. Rolling it back drops them.
+const BaselineVersion = 1
+
+var (
+	// ErrUnknownVersion is returned for a target version with no migration
+	ErrUnknownVersion = errors.New("unknown migration version")
+	// ErrDestructive is returned when a rollback would reach the baseline
+	// without being confirmed
+	ErrDestructive = errors.New("rolling back the baseline drops the shared main app tables")
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Destructive reports whether rolling the migration back drops tables that
+// the main app owns
+func (m Migration) Destructive() bool {
+	return m.Version == BaselineVersion
+}
+
+// State is a migration and when it was applied, if it was
+type State struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Applied reports whether the migration is in the version table
+func (s State) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// All returns every migration in version order. Each must have both an up
+// and a down script.
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(scripts, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(scripts, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	all := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		all = append(all, *m)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all, nil
+}
+
+// appliedVersion is a row of the version table
+type appliedVersion struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (appliedVersion) TableName() string {
+	return VersionTable
+}
+
+func ensureVersionTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS ` + VersionTable + ` (
+		version    bigint PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+func applied(db *gorm.DB) (map[int]time.Time, error) {
+	var rows []appliedVersion
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	versions := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
+
+// Status lists every migration with its applied time
+func Status(db *gorm.DB) ([]State, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureVersionTable(db); err != nil {
+		return nil, err
+	}
+	versions, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]State, len(all))
+	for i, m := range all {
+		states[i] = State{Migration: m}
+		if at, ok := versions[m.Version]; ok {
+			states[i].AppliedAt = &at
+		}
+	}
+	return states, nil
+}
+
+// Up applies pending migrations up to and including target, or all of them
+// when target is 0. It returns the migrations it applied.
+func Up(db *gorm.DB, target int) ([]Migration, error) {
+	states, err := Status(db)
+	if err != nil {
+		return nil, err
+	}
+	if target != 0 && !hasVersion(states, target) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	var done []Migration
+	for _, s := range states {
+		if target != 0 && s.Version > target {
+			break
+		}
+		if s.Applied() {
+			continue
+		}
+		ran, err := run(db, s.Migration, true)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, s.Migration)
+		}
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first. It
+// returns the migrations it rolled back. Unless destructive is set, a
+// rollback that would reach a destructive migration fails with
+// ErrDestructive before anything is rolled back.
+func Down(db *gorm.DB, steps int, destructive bool) ([]Migration, error) {
+	states, err := Status(db)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := downPlan(states, steps, destructive)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range plan {
+		ran, err := run(db, m, false)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// downPlan picks the last steps applied migrations, newest first
+func downPlan(states []State, steps int, destructive bool) ([]Migration, error) {
+	var plan []Migration
+	for i := len(states) - 1; i >= 0 && len(plan) < steps; i-- {
+		if !states[i].Applied() {
+			continue
+		}
+		m := states[i].Migration
+		if m.Destructive() && !destructive {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrDestructive, m.Version, m.Name)
+		}
+		plan = append(plan, m)
+	}
+	return plan, nil
+}
+
+// Fresh rolls back every applied migration and applies them all again,
+// leaving an empty schema. It is meant for test and development databases.
+func Fresh(db *gorm.DB) error {
+	states, err := Status(db)
+	if err != nil {
+		return err
+	}
+	if _, err := Down(db, len(states), true); err != nil {
+		return err
+	}
+	_, err = Up(db, 0)
+	return err
+}
+
+// run applies or rolls back one migration in its own transaction. The
+// advisory lock keeps two migrators from running the same script, and the
+// version is checked again under it so the loser skips instead of failing.
+func run(db *gorm.DB, m Migration, up bool) (bool, error) {
+	ran := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&appliedVersion{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		script := m.Down
+		if up {
+			script = m.Up
+		}
+		if err := tx.Exec(script).Error; err != nil {
+			return err
+		}
+
+		if up {
+			err := tx.Create(&appliedVersion{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+			if err != nil {
+				return err
+			}
+		} else if err := tx.Where("version = ?", m.Version).Delete(&appliedVersion{}).Error; err != nil {
+			return err
+		}
+		ran = true
+		return nil
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return false, fmt.Errorf("migration %04d_%s %s: %w", m.Version, m.Name, direction, err)
+	}
+	return ran, nil
+}
+
+func hasVersion(states []State, version int) bool {
+	for _, s := range states {
+		if s.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package migrations
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// testDSNVariable names a throwaway Postgres database for the tests that
+// build the schema. They drop every table in it.
+const testDSNVariable = "PETHUB_TEST_DSN"
+
+func TestAll(t *testing.T) {
+	all, err := All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	for i, m := range all {
+		if m.Version != i+1 {
+			t.Errorf("migration %d is %04d_%s; versions must run 1, 2, 3 without gaps", i, m.Version, m.Name)
+		}
+		if m.Destructive() != (m.Version == BaselineVersion) {
+			t.Errorf("%04d_%s: Destructive() = %v", m.Version, m.Name, m.Destructive())
+		}
+	}
+}
+
+func TestDownPlan(t *testing.T) {
+	at := time.Now()
+	states := []State{
+		{Migration: Migration{Version: 1, Name: "baseline"}, AppliedAt: &at},
+		{Migration: Migration{Version: 2, Name: "two"}, AppliedAt: &at},
+		{Migration: Migration{Version: 3, Name: "three"}, AppliedAt: &at},
+		{Migration: Migration{Version: 4, Name: "four"}},
+	}
+
+	cases := []struct {
+		name        string
+		steps       int
+		destructive bool
+		want        []int
+		wantErr     error
+	}{
+		{"one step skips pending", 1, false, []int{3}, nil},
+		{"down to the baseline", 2, false, []int{3, 2}, nil},
+		{"past the baseline unconfirmed", 3, false, nil, ErrDestructive},
+		{"more steps than applied unconfirmed", 10, false, nil, ErrDestructive},
+		{"past the baseline confirmed", 3, true, []int{3, 2, 1}, nil},
+		{"more steps than applied confirmed", 10, true, []int{3, 2, 1}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := downPlan(states, tc.steps, tc.destructive)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			var got []int
+			for _, m := range plan {
+				got = append(got, m.Version)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("plan = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScriptsCoverModels(t *testing.T) {
+	all, err := All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	columns := scriptColumns(all)
+
+	cache := &sync.Map{}
+	for _, model := range Models {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parsing %T: %v", model, err)
+		}
+		table, ok := columns[s.Table]
+		if !ok {
+			t.Errorf("%T: no migration creates table %s", model, s.Table)
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.DBName != "" && !table[field.DBName] {
+				t.Errorf("%T: no migration adds column %s.%s", model, s.Table, field.DBName)
+			}
+		}
+	}
+}
+
+var (
+	sqlComment  = regexp.MustCompile(`--[^\n]*`)
+	createTable = regexp.MustCompile(`(?is)^CREATE TABLE (?:IF NOT EXISTS )?(\w+)\s*\((.*)\)$`)
+	alterTable  = regexp.MustCompile(`(?is)^ALTER TABLE (?:IF EXISTS )?(?:ONLY )?(\w+)\s+(.*)$`)
+	dropTable   = regexp.MustCompile(`(?is)^DROP TABLE (?:IF EXISTS )?([\w, ]+?)(?: CASCADE)?$`)
+	addColumn   = regexp.MustCompile(`(?is)^ADD COLUMN (?:IF NOT EXISTS )?(\w+)`)
+	dropColumn  = regexp.MustCompile(`(?is)^DROP COLUMN (?:IF EXISTS )?(\w+)`)
+	notColumn   = regexp.MustCompile(`(?i)^(PRIMARY|UNIQUE|CONSTRAINT|FOREIGN|CHECK|EXCLUDE)\b`)
+)
+
+// scriptColumns replays the up scripts in order and returns the columns of
+// every table they leave behind
+func scriptColumns(all []Migration) map[string]map[string]bool {
+	tables := make(map[string]map[string]bool)
+	for _, m := range all {
+		for _, stmt := range strings.Split(sqlComment.ReplaceAllString(m.Up, ""), ";") {
+			stmt = strings.Join(strings.Fields(stmt), " ")
+			if match := createTable.FindStringSubmatch(stmt); match != nil {
+				name := strings.ToLower(match[1])
+				if tables[name] == nil {
+					tables[name] = make(map[string]bool)
+				}
+				for _, def := range splitTopLevel(match[2]) {
+					if def = strings.TrimSpace(def); def != "" && !notColumn.MatchString(def) {
+						tables[name][strings.ToLower(strings.Fields(def)[0])] = true
+					}
+				}
+			} else if match := alterTable.FindStringSubmatch(stmt); match != nil {
+				table := tables[strings.ToLower(match[1])]
+				for _, action := range splitTopLevel(match[2]) {
+					action = strings.TrimSpace(action)
+					if col := addColumn.FindStringSubmatch(action); col != nil && table != nil {
+						table[strings.ToLower(col[1])] = true
+					} else if col := dropColumn.FindStringSubmatch(action); col != nil && table != nil {
+						delete(table, strings.ToLower(col[1]))
+					}
+				}
+			} else if match := dropTable.FindStringSubmatch(stmt); match != nil {
+				for _, name := range strings.Split(match[1], ",") {
+					delete(tables, strings.ToLower(strings.TrimSpace(name)))
+				}
+			}
+		}
+	}
+	return tables
+}
+
+// splitTopLevel splits a definition list on the commas outside parentheses
+func splitTopLevel(list string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, list[start:])
+}
+
+// openTestDB connects to the database in PETHUB_TEST_DSN, skipping the test
+// when it is not set
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNVariable)
+	if dsn == "" {
+		t.Skipf("%s is not set; these tests need a throwaway Postgres database", testDSNVariable)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestFreshBuildsTheSchemaFromScratch(t *testing.T) {
+	db := openTestDB(t)
+
+	// Twice: the second run starts from a fully migrated database
+	for run := 1; run <= 2; run++ {
+		if err := Fresh(db); err != nil {
+			t.Fatalf("Fresh run %d: %v", run, err)
+		}
+	}
+
+	states, err := Status(db)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, s := range states {
+		if !s.Applied() {
+			t.Errorf("%04d_%s not applied after Fresh", s.Version, s.Name)
+		}
+	}
+
+	migrator := db.Migrator()
+	for _, model := range Models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parsing %T: %v", model, err)
+		}
+		table := stmt.Schema.Table
+		if !migrator.HasTable(table) {
+			t.Errorf("table %s missing", table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" && !migrator.HasColumn(model, field.DBName) {
+				t.Errorf("column %s.%s missing", table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestDownStopsAtTheBaseline(t *testing.T) {
+	db := openTestDB(t)
+	if err := Fresh(db); err != nil {
+		t.Fatalf("Fresh: %v", err)
+	}
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := Down(db, len(all), false)
+	if !errors.Is(err, ErrDestructive) || len(done) != 0 {
+		t.Fatalf("Down past the baseline rolled back %d, err %v; want nothing and ErrDestructive", len(done), err)
+	}
+
+	done, err = Down(db, len(all)-1, false)
+	if err != nil || len(done) != len(all)-1 {
+		t.Fatalf("Down to the baseline rolled back %d, err %v; want %d", len(done), err, len(all)-1)
+	}
+	if !db.Migrator().HasTable("shelteraccount") || db.Migrator().HasTable("shelter_flags") {
+		t.Fatal("rolling back to the baseline must keep the shared tables and drop the admin ones")
+	}
+
+	if _, err := Up(db, 0); err != nil {
+		t.Fatalf("Up after Down: %v", err)
+	}
+	if !db.Migrator().HasTable("shelter_flags") {
+		t.Fatal("Up did not recreate shelter_flags")
+	}
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "pethubadmin/models"
+
+// Models are every model the admin reads or writes; the schema the scripts
+// build has to hold all of them. Tests build their SQLite schemas from this
+// list, and TestScriptsCoverModels checks it against the scripts, so a model
+// field without a migration fails without a Postgres database.
+var Models = []interface{}{
+	&models.AdminAccount{}, &models.AdminAuditLog{}, &models.AccountDeletion{},
+	&models.AdopterAccount{}, &models.AdopterInfo{}, &models.AdopterMedia{}, &models.AdoptedPet{},
+	&models.ShelterAccount{}, &models.ShelterInfo{}, &models.ShelterMedia{}, &models.ShelterDonations{},
+	&models.ShelterDocument{}, &models.ShelterReviewRound{}, &models.ShelterReviewClaim{},
+	&models.PetInfo{}, &models.PetMedia{},
+	&models.AdoptionSubmission{}, &models.ApplicationPhotos{}, &models.ScheduleInterview{}, &models.ApplicationHold{},
+	&models.ApplicationWithdrawal{},
+	&models.SubmittedReport{}, &models.ReportAttachment{}, &models.ReportCategory{},
+	&models.OutcomeNotice{}, &models.OutcomeNoticeReport{}, &models.Notification{},
+	&models.FlagRule{}, &models.ShelterFlag{}, &models.ScreeningTerm{}, &models.ModerationItem{},
+	&models.ScreenedContent{},
+	&models.DuplicateDismissal{}, &models.AccountMerge{},
+}
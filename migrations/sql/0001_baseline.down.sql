@@ -0,0 +1,16 @@
+-- Drops the shared main app tables; only meant for throwaway databases.
+DROP TABLE IF EXISTS schedule_interview;
+DROP TABLE IF EXISTS application_photos;
+DROP TABLE IF EXISTS adoption_submissions;
+DROP TABLE IF EXISTS submittedreports;
+DROP TABLE IF EXISTS adopterpets;
+DROP TABLE IF EXISTS petmedia;
+DROP TABLE IF EXISTS petinfo;
+DROP TABLE IF EXISTS shelterdonations;
+DROP TABLE IF EXISTS sheltermedia;
+DROP TABLE IF EXISTS shelterinfo;
+DROP TABLE IF EXISTS shelteraccount;
+DROP TABLE IF EXISTS adopter_media;
+DROP TABLE IF EXISTS adopterinfo;
+DROP TABLE IF EXISTS adopteraccount;
+DROP TABLE IF EXISTS adminaccount;
@@ -0,0 +1,161 @@
+-- Tables shared with the PetHub main app. IF NOT EXISTS lets a database the
+-- main app already created adopt the migration history unchanged.
+
+CREATE TABLE IF NOT EXISTS adminaccount (
+    admin_id bigserial PRIMARY KEY,
+    username text,
+    password text
+);
+
+CREATE TABLE IF NOT EXISTS adopteraccount (
+    adopter_id bigserial PRIMARY KEY,
+    username   text NOT NULL UNIQUE,
+    password   text,
+    status     text DEFAULT 'active',
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS adopterinfo (
+    adopter_id     bigint PRIMARY KEY,
+    first_name     text,
+    last_name      text,
+    age            bigint,
+    sex            text,
+    address        text,
+    contact_number text,
+    email          text UNIQUE,
+    occupation     text,
+    civil_status   text,
+    social_media   text
+);
+
+CREATE TABLE IF NOT EXISTS adopter_media (
+    adopter_id      bigint PRIMARY KEY,
+    adopter_profile text
+);
+
+CREATE TABLE IF NOT EXISTS shelteraccount (
+    shelter_id bigserial PRIMARY KEY,
+    username   text NOT NULL UNIQUE,
+    password   text,
+    status     text DEFAULT 'active',
+    reg_status text,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS shelterinfo (
+    shelter_id          bigint PRIMARY KEY,
+    shelter_name        text,
+    shelter_address     text,
+    shelter_landmark    text,
+    shelter_contact     text,
+    shelter_email       text,
+    shelter_owner       text,
+    shelter_description text,
+    shelter_social      text
+);
+
+CREATE TABLE IF NOT EXISTS sheltermedia (
+    shelter_id      bigint PRIMARY KEY,
+    shelter_profile text,
+    shelter_cover   text
+);
+
+CREATE TABLE IF NOT EXISTS shelterdonations (
+    donation_id    bigserial PRIMARY KEY,
+    shelter_id     bigint,
+    account_number text,
+    account_name   text,
+    qr_image       text,
+    created_at     timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS petinfo (
+    pet_id           bigserial PRIMARY KEY,
+    shelter_id       bigint,
+    pet_type         text,
+    pet_name         text,
+    pet_age          bigint,
+    age_type         text,
+    pet_sex          text,
+    pet_descriptions text,
+    status           text DEFAULT 'available',
+    created_at       timestamptz,
+    pet_size         text,
+    priority_status  boolean
+);
+
+CREATE TABLE IF NOT EXISTS petmedia (
+    pet_id      bigint NOT NULL,
+    pet_image1  text,
+    pet_vaccine text
+);
+
+CREATE TABLE IF NOT EXISTS adopterpets (
+    adopted_id bigserial PRIMARY KEY,
+    adopter_id bigint,
+    pet_id     bigint
+);
+
+CREATE TABLE IF NOT EXISTS submittedreports (
+    id          bigserial PRIMARY KEY,
+    shelter_id  bigint,
+    adopter_id  bigint,
+    reason      text,
+    description text,
+    status      text DEFAULT 'pending',
+    created_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS adoption_submissions (
+    application_id        bigserial PRIMARY KEY,
+    shelter_id            bigint,
+    pet_id                bigint,
+    adopter_id            bigint,
+    alt_f_name            text NOT NULL,
+    alt_l_name            text NOT NULL,
+    relationship          text NOT NULL,
+    alt_contact_number    text NOT NULL,
+    alt_email             text NOT NULL,
+    reason_for_adoption   text NOT NULL,
+    ideal_pet_description text,
+    housing_situation     text NOT NULL,
+    pets_at_home          text,
+    allergies             text,
+    family_support        text,
+    past_pets             text,
+    interview_setting     text,
+    image_id              bigint,
+    status                varchar(20) DEFAULT 'pending',
+    reason_for_rejection  text,
+    created_at            timestamptz,
+    updated_at            timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS application_photos (
+    image_id         bigserial PRIMARY KEY,
+    adopter_id_type  text,
+    adopter_valid_id text,
+    alt_id_type      text,
+    alt_valid_id     text,
+    home_image1      text,
+    home_image2      text,
+    home_image3      text,
+    home_image4      text,
+    home_image5      text,
+    home_image6      text,
+    home_image7      text,
+    home_image8      text
+);
+
+CREATE TABLE IF NOT EXISTS schedule_interview (
+    interview_id     bigserial PRIMARY KEY,
+    application_id   bigint,
+    shelter_id       bigint,
+    adopter_id       bigint,
+    interview_date   timestamptz,
+    interview_time   text,
+    interview_notes  text,
+    interview_status varchar(20) DEFAULT 'scheduled',
+    created_at       timestamptz
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shelter_review_claims;
+DROP TABLE IF EXISTS shelter_documents;
+DROP TABLE IF EXISTS shelter_review_rounds;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS shelter_review_rounds (
+    round_id     bigserial PRIMARY KEY,
+    shelter_id   bigint NOT NULL,
+    round        bigint NOT NULL,
+    decision     varchar(20) DEFAULT 'pending',
+    reason_code  varchar(50),
+    feedback     text,
+    reviewed_by  bigint,
+    snapshot     text,
+    changes      text,
+    submitted_at timestamptz,
+    reviewed_at  timestamptz,
+    escalated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_shelter_review_rounds_shelter_id ON shelter_review_rounds (shelter_id);
+
+CREATE TABLE IF NOT EXISTS shelter_documents (
+    document_id   bigserial PRIMARY KEY,
+    shelter_id    bigint NOT NULL,
+    doc_type      varchar(50) NOT NULL,
+    file_name     text,
+    mime_type     varchar(100),
+    size_bytes    bigint,
+    file_data     text,
+    status        varchar(20) DEFAULT 'pending',
+    reject_reason text,
+    reviewed_by   bigint,
+    reviewed_at   timestamptz,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_shelter_documents_shelter_id ON shelter_documents (shelter_id);
+
+CREATE TABLE IF NOT EXISTS shelter_review_claims (
+    shelter_id bigint PRIMARY KEY,
+    admin_id   bigint NOT NULL,
+    claimed_at timestamptz,
+    expires_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_shelter_review_claims_expires_at ON shelter_review_claims (expires_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS admin_audit_logs;
+ALTER TABLE adminaccount DROP COLUMN IF EXISTS role;
@@ -0,0 +1,15 @@
+ALTER TABLE adminaccount ADD COLUMN IF NOT EXISTS role varchar(20) DEFAULT 'admin';
+
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    audit_id    bigserial PRIMARY KEY,
+    admin_id    bigint,
+    action      varchar(50) NOT NULL,
+    entity_type varchar(30) NOT NULL,
+    entity_id   bigint NOT NULL,
+    from_status varchar(20),
+    to_status   varchar(20),
+    details     text,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_admin_id ON admin_audit_logs (admin_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON admin_audit_logs (entity_type, entity_id);
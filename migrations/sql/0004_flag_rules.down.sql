@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shelter_flags;
+DROP TABLE IF EXISTS flag_rules;
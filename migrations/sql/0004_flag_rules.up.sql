@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS flag_rules (
+    rule_id               bigserial PRIMARY KEY,
+    name                  text NOT NULL,
+    kind                  varchar(30) NOT NULL,
+    min_reports           bigint,
+    distinct_adopters     boolean,
+    window_days           bigint,
+    reason_pattern        text,
+    action                varchar(30) NOT NULL,
+    hold_hours            bigint,
+    weight_by_credibility boolean,
+    enabled               boolean DEFAULT true,
+    created_by            bigint,
+    created_at            timestamptz,
+    updated_at            timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS shelter_flags (
+    flag_id     bigserial PRIMARY KEY,
+    shelter_id  bigint NOT NULL,
+    rule_id     bigint,
+    action      varchar(30),
+    details     text,
+    hold_until  timestamptz,
+    created_at  timestamptz,
+    resolved_at timestamptz,
+    resolved_by bigint
+);
+CREATE INDEX IF NOT EXISTS idx_shelter_flags_shelter_id ON shelter_flags (shelter_id);
+CREATE INDEX IF NOT EXISTS idx_shelter_flags_rule_id ON shelter_flags (rule_id);
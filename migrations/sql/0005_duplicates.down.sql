@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS account_merges;
+DROP TABLE IF EXISTS duplicate_dismissals;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS duplicate_dismissals (
+    dismissal_id bigserial PRIMARY KEY,
+    entity_type  varchar(20) NOT NULL,
+    id_a         bigint NOT NULL,
+    id_b         bigint NOT NULL,
+    dismissed_by bigint,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_duplicate_pair ON duplicate_dismissals (entity_type, id_a, id_b);
+
+CREATE TABLE IF NOT EXISTS account_merges (
+    merge_id    bigserial PRIMARY KEY,
+    entity_type varchar(20) NOT NULL,
+    survivor_id bigint NOT NULL,
+    merged_id   bigint NOT NULL,
+    moved_rows  text,
+    merged_by   bigint,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_account_merges_survivor_id ON account_merges (survivor_id);
+CREATE INDEX IF NOT EXISTS idx_account_merges_merged_id ON account_merges (merged_id);
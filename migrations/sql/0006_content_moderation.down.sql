@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS application_holds;
+ALTER TABLE petinfo DROP COLUMN IF EXISTS hidden_by;
+ALTER TABLE petinfo DROP COLUMN IF EXISTS moderated_at;
+ALTER TABLE petinfo DROP COLUMN IF EXISTS moderation_reason;
+ALTER TABLE petinfo DROP COLUMN IF EXISTS listing_status;
+DROP TABLE IF EXISTS moderation_queue;
+DROP TABLE IF EXISTS screening_terms;
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS screening_terms (
+    term_id    bigserial PRIMARY KEY,
+    category   varchar(30) NOT NULL,
+    pattern    text NOT NULL,
+    is_regex   boolean,
+    enabled    boolean DEFAULT true,
+    created_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS moderation_queue (
+    item_id     bigserial PRIMARY KEY,
+    source      varchar(40) NOT NULL,
+    entity_id   bigint NOT NULL,
+    content     text,
+    matches     text,
+    status      varchar(20) DEFAULT 'open',
+    review_note text,
+    reviewed_by bigint,
+    reviewed_at timestamptz,
+    created_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_moderation_source ON moderation_queue (source, entity_id);
+CREATE INDEX IF NOT EXISTS idx_moderation_queue_status ON moderation_queue (status);
+
+-- Pet listing moderation and cascade hiding
+ALTER TABLE petinfo ADD COLUMN IF NOT EXISTS listing_status varchar(20) DEFAULT 'listed';
+ALTER TABLE petinfo ADD COLUMN IF NOT EXISTS moderation_reason text;
+ALTER TABLE petinfo ADD COLUMN IF NOT EXISTS moderated_at timestamptz;
+ALTER TABLE petinfo ADD COLUMN IF NOT EXISTS hidden_by varchar(30) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS application_holds (
+    application_id bigint NOT NULL,
+    source         varchar(30) NOT NULL,
+    reason         text,
+    held_by        bigint,
+    created_at     timestamptz,
+    PRIMARY KEY (application_id, source)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id bigserial PRIMARY KEY,
+    recipient_type  varchar(20) NOT NULL,
+    recipient_id    bigint NOT NULL,
+    kind            varchar(40),
+    title           text,
+    message         text,
+    read_at         timestamptz,
+    created_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_notification_recipient ON notifications (recipient_type, recipient_id);
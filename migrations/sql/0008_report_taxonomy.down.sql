@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS report_attachments;
+DROP INDEX IF EXISTS idx_submittedreports_category_id;
+ALTER TABLE submittedreports DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS report_categories;
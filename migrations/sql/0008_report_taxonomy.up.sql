@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS report_categories (
+    category_id bigserial PRIMARY KEY,
+    code        varchar(40) NOT NULL,
+    name        text NOT NULL,
+    description text,
+    keywords    text,
+    severity    bigint NOT NULL DEFAULT 2,
+    enabled     boolean DEFAULT true,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_report_categories_code ON report_categories (code);
+
+ALTER TABLE submittedreports ADD COLUMN IF NOT EXISTS category_id bigint;
+CREATE INDEX IF NOT EXISTS idx_submittedreports_category_id ON submittedreports (category_id);
+
+CREATE TABLE IF NOT EXISTS report_attachments (
+    attachment_id bigserial PRIMARY KEY,
+    report_id     bigint NOT NULL,
+    file_name     text,
+    mime_type     varchar(100),
+    size_bytes    bigint,
+    file_data     text,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_report_attachments_report_id ON report_attachments (report_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outcome_notice_reports;
+DROP TABLE IF EXISTS outcome_notices;
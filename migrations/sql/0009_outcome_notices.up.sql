@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS outcome_notices (
+    notice_id      bigserial PRIMARY KEY,
+    template       varchar(40) NOT NULL,
+    recipient_type varchar(20) NOT NULL,
+    recipient_id   bigint NOT NULL,
+    address        text,
+    subject        text,
+    body           text,
+    channel        varchar(20),
+    status         varchar(20) DEFAULT 'pending',
+    attempts       bigint,
+    last_error     text,
+    created_at     timestamptz,
+    sent_at        timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outcome_notices_status ON outcome_notices (status);
+
+CREATE TABLE IF NOT EXISTS outcome_notice_reports (
+    notice_id bigint NOT NULL,
+    report_id bigint NOT NULL,
+    PRIMARY KEY (notice_id, report_id)
+);
+CREATE INDEX IF NOT EXISTS idx_outcome_notice_reports_report_id ON outcome_notice_reports (report_id);
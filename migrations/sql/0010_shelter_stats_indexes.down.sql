@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_petmedia_pet_id;
+DROP INDEX IF EXISTS idx_petinfo_shelter_id;
+DROP INDEX IF EXISTS idx_shelteraccount_status_reg_status;
@@ -0,0 +1,5 @@
+-- Support the grouped shelter statistics queries: shelters by status, pets
+-- by shelter and the vaccine lookup per pet
+CREATE INDEX IF NOT EXISTS idx_shelteraccount_status_reg_status ON shelteraccount (status, reg_status);
+CREATE INDEX IF NOT EXISTS idx_petinfo_shelter_id ON petinfo (shelter_id);
+CREATE INDEX IF NOT EXISTS idx_petmedia_pet_id ON petmedia (pet_id);
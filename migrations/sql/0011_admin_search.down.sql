@@ -0,0 +1,9 @@
+-- pg_trgm is left installed; other schemas on the server may use it
+DROP INDEX IF EXISTS idx_submittedreports_search_trgm;
+DROP INDEX IF EXISTS idx_submittedreports_search_fts;
+DROP INDEX IF EXISTS idx_petinfo_search_trgm;
+DROP INDEX IF EXISTS idx_petinfo_search_fts;
+DROP INDEX IF EXISTS idx_shelterinfo_search_trgm;
+DROP INDEX IF EXISTS idx_shelterinfo_search_fts;
+DROP INDEX IF EXISTS idx_adopterinfo_search_trgm;
+DROP INDEX IF EXISTS idx_adopterinfo_search_fts;
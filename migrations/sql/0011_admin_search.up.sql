@@ -0,0 +1,31 @@
+-- Full-text and trigram indexes for /admin/search. The indexed expressions
+-- must match the documents in services/search.go exactly.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_adopterinfo_search_fts ON adopterinfo USING gin (
+    to_tsvector('simple', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '') || ' ' || coalesce(contact_number, '') || ' ' || coalesce(address, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_adopterinfo_search_trgm ON adopterinfo USING gin (
+    (coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '') || ' ' || coalesce(contact_number, '') || ' ' || coalesce(address, '')) gin_trgm_ops
+);
+
+CREATE INDEX IF NOT EXISTS idx_shelterinfo_search_fts ON shelterinfo USING gin (
+    to_tsvector('simple', coalesce(shelter_name, '') || ' ' || coalesce(shelter_owner, '') || ' ' || coalesce(shelter_email, '') || ' ' || coalesce(shelter_contact, '') || ' ' || coalesce(shelter_address, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_shelterinfo_search_trgm ON shelterinfo USING gin (
+    (coalesce(shelter_name, '') || ' ' || coalesce(shelter_owner, '') || ' ' || coalesce(shelter_email, '') || ' ' || coalesce(shelter_contact, '') || ' ' || coalesce(shelter_address, '')) gin_trgm_ops
+);
+
+CREATE INDEX IF NOT EXISTS idx_petinfo_search_fts ON petinfo USING gin (
+    to_tsvector('simple', coalesce(pet_name, '') || ' ' || coalesce(pet_type, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_petinfo_search_trgm ON petinfo USING gin (
+    (coalesce(pet_name, '') || ' ' || coalesce(pet_type, '')) gin_trgm_ops
+);
+
+CREATE INDEX IF NOT EXISTS idx_submittedreports_search_fts ON submittedreports USING gin (
+    to_tsvector('simple', coalesce(reason, '') || ' ' || coalesce(description, ''))
+);
+CREATE INDEX IF NOT EXISTS idx_submittedreports_search_trgm ON submittedreports USING gin (
+    (coalesce(reason, '') || ' ' || coalesce(description, '')) gin_trgm_ops
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS account_deletions;
+ALTER TABLE sheltermedia DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE shelterinfo DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE shelteraccount DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE adopter_media DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE adopterinfo DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE adopteraccount DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,30 @@
+-- Soft delete for adopter and shelter accounts with their info and media
+ALTER TABLE adopteraccount ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE adopterinfo ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE adopter_media ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE shelteraccount ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE shelterinfo ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE sheltermedia ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_adopteraccount_deleted_at ON adopteraccount (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_adopterinfo_deleted_at ON adopterinfo (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_adopter_media_deleted_at ON adopter_media (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shelteraccount_deleted_at ON shelteraccount (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_shelterinfo_deleted_at ON shelterinfo (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_sheltermedia_deleted_at ON sheltermedia (deleted_at);
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    deletion_id  bigserial PRIMARY KEY,
+    entity_type  varchar(20) NOT NULL,
+    entity_id    bigint NOT NULL,
+    reason       text,
+    prior_status varchar(20),
+    deleted_by   bigint,
+    deleted_at   timestamptz,
+    purge_after  timestamptz,
+    restored_by  bigint,
+    restored_at  timestamptz,
+    purged_at    timestamptz,
+    purged_rows  text
+);
+CREATE INDEX IF NOT EXISTS idx_account_deletion_entity ON account_deletions (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_account_deletions_purge_after ON account_deletions (purge_after);
@@ -0,0 +1,3 @@
+ALTER TABLE submittedreports DROP COLUMN IF EXISTS version;
+ALTER TABLE adopteraccount DROP COLUMN IF EXISTS version;
+ALTER TABLE shelteraccount DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+-- Row versions for optimistic concurrency on admin edits (ETag / If-Match)
+ALTER TABLE shelteraccount ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE adopteraccount ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE submittedreports ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+-- The text each row held when it was last screened, so the scheduled scan
+-- can tell which rows the main app changed
+CREATE TABLE IF NOT EXISTS screened_contents (
+    source      varchar(40) NOT NULL,
+    entity_id   bigint NOT NULL,
+    checksum    varchar(64) NOT NULL,
+    screened_at timestamptz,
+    PRIMARY KEY (source, entity_id)
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_shelter_flags_open;
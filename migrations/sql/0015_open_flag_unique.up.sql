@@ -0,0 +1,10 @@
+-- At most one open flag per rule on a shelter, so two evaluations racing on
+-- the same shelter cannot both raise it. Duplicates raised before this are
+-- resolved, keeping the oldest.
+UPDATE shelter_flags f SET resolved_at = now()
+WHERE f.resolved_at IS NULL AND EXISTS (
+    SELECT 1 FROM shelter_flags o
+    WHERE o.shelter_id = f.shelter_id AND o.rule_id = f.rule_id
+      AND o.resolved_at IS NULL AND o.flag_id < f.flag_id
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shelter_flags_open ON shelter_flags (shelter_id, rule_id) WHERE resolved_at IS NULL;
@@ -0,0 +1,10 @@
+-- What a cascade withdrawal replaced, so a restore can put it back
+CREATE TABLE IF NOT EXISTS application_withdrawals (
+    application_id bigint NOT NULL,
+    source         varchar(30) NOT NULL,
+    prior_status   varchar(20) NOT NULL,
+    prior_reason   text NOT NULL DEFAULT '',
+    withdrawn_by   bigint,
+    created_at     timestamptz,
+    PRIMARY KEY (application_id, source)
+);
@@ -0,0 +1 @@
+ALTER TABLE outcome_notices DROP COLUMN IF EXISTS claimed_at;
@@ -0,0 +1,3 @@
+-- When a dispatcher claimed the notice, so a claim left behind by one that
+-- died mid-send can be retried
+ALTER TABLE outcome_notices ADD COLUMN IF NOT EXISTS claimed_at timestamptz;
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AccountDeletion records a soft deleted adopter or shelter account. The
+// account can be restored until PurgeAfter; after that, or when an admin
+// asks for it, the account and everything that belongs to it is removed.
+type AccountDeletion struct {
+	DeletionID  uint       `gorm:"primaryKey;autoIncrement" json:"deletion_id"`
+	EntityType  string     `gorm:"type:varchar(20);not null;index:idx_account_deletion_entity" json:"entity_type"` // adopter or shelter
+	EntityID    uint       `gorm:"not null;index:idx_account_deletion_entity" json:"entity_id"`
+	Reason      string     `gorm:"type:text" json:"reason"`
+	PriorStatus string     `gorm:"type:varchar(20)" json:"prior_status"`
+	DeletedBy   uint       `json:"deleted_by"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	PurgeAfter  time.Time  `gorm:"index" json:"purge_after"`
+	RestoredBy  uint       `json:"restored_by,omitempty"`
+	RestoredAt  *time.Time `json:"restored_at"`
+	PurgedAt    *time.Time `json:"purged_at"`
+	PurgedRows  string     `gorm:"type:text" json:"purged_rows,omitempty"` // JSON map of table name to rows removed
+}
+
+func (AccountDeletion) TableName() string {
+	return "account_deletions"
+}
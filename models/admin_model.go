@@ -4,6 +4,7 @@ type AdminAccount struct {
 	AdminID  uint   `json:"admin_id" gorm:"primaryKey"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Role     string `gorm:"type:varchar(20);default:'admin'" json:"role"` // admin or super_admin
 }
 
 // TableName overrides default table name
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // AdopterAccount model (linked to existing "adopteraccount" table)
@@ -9,8 +11,10 @@ type AdopterAccount struct {
 	AdopterID uint   `gorm:"primaryKey" json:"adopter_id"`
 	Username  string `gorm:"unique;not null" json:"username"`
 	Password  string `json:"password"`
-	Status    string `gorm:"default:'active'" json:"status"` // Add this line
+	Status    string `gorm:"default:'active'" json:"status"`    // Add this line
+	Version   uint   `gorm:"not null;default:1" json:"version"` // see services.CheckAdopterVersion
 	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // set while the account is soft deleted
 }
 
 // TableName overrides default table name
@@ -20,17 +24,18 @@ func (AdopterAccount) TableName() string {
 
 // AdopterInfo model (linked to existing "adopterinfo" table)
 type AdopterInfo struct {
-	AdopterID     uint   `gorm:"primaryKey;autoIncrement:false" json:"adopter_id"`
-	FirstName     string `json:"first_name"`
-	LastName      string `json:"last_name"`
-	Age           int    `json:"age"`
-	Sex           string `json:"sex"`
-	Address       string `json:"address"`
-	ContactNumber string `json:"contact_number"`
-	Email         string `gorm:"unique" json:"email"`
-	Occupation    string `json:"occupation"`
-	CivilStatus   string `json:"civil_status"`
-	SocialMedia   string `json:"social_media"`
+	AdopterID     uint           `gorm:"primaryKey;autoIncrement:false" json:"adopter_id"`
+	FirstName     string         `json:"first_name"`
+	LastName      string         `json:"last_name"`
+	Age           int            `json:"age"`
+	Sex           string         `json:"sex"`
+	Address       string         `json:"address"`
+	ContactNumber string         `json:"contact_number"`
+	Email         string         `gorm:"unique" json:"email"`
+	Occupation    string         `json:"occupation"`
+	CivilStatus   string         `json:"civil_status"`
+	SocialMedia   string         `json:"social_media"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 
 	AdopterMedia AdopterMedia `gorm:"foreignKey:AdopterID;references:AdopterID" json:"adoptermedia"`
 }
@@ -41,8 +46,9 @@ func (AdopterInfo) TableName() string {
 }
 
 type AdopterMedia struct {
-	AdopterID      uint   `gorm:"primaryKey;autoIncrement:false" json:"adopter_id"`
-	AdopterProfile string `json:"adopter_profile"`
+	AdopterID      uint           `gorm:"primaryKey;autoIncrement:false" json:"adopter_id"`
+	AdopterProfile string         `json:"adopter_profile"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (AdopterMedia) TableName() string {
@@ -77,3 +77,33 @@ type ScheduleInterview struct {
 func (ScheduleInterview) TableName() string {
 	return "schedule_interview"
 }
+
+// ApplicationHold freezes an adoption application while something it depends
+// on is under moderation. An application can be held for several reasons at
+// once and is frozen until every hold is released.
+type ApplicationHold struct {
+	ApplicationID uint      `gorm:"primaryKey;autoIncrement:false" json:"application_id"`
+	Source        string    `gorm:"primaryKey;type:varchar(30)" json:"source"` // e.g. pet_unlisted
+	Reason        string    `gorm:"type:text" json:"reason"`
+	HeldBy        uint      `json:"held_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (ApplicationHold) TableName() string {
+	return "application_holds"
+}
+
+// ApplicationWithdrawal records the status and reason an application had
+// before a cascade withdrew it, so restoring the account can put them back
+type ApplicationWithdrawal struct {
+	ApplicationID uint      `gorm:"primaryKey;autoIncrement:false" json:"application_id"`
+	Source        string    `gorm:"primaryKey;type:varchar(30)" json:"source"` // e.g. adopter_deactivated
+	PriorStatus   string    `gorm:"type:varchar(20);not null" json:"prior_status"`
+	PriorReason   string    `gorm:"type:text;not null;default:''" json:"prior_reason"`
+	WithdrawnBy   uint      `json:"withdrawn_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (ApplicationWithdrawal) TableName() string {
+	return "application_withdrawals"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// AdminAuditLog records every moderation action taken by an admin
+type AdminAuditLog struct {
+	AuditID    uint      `gorm:"primaryKey;autoIncrement" json:"audit_id"`
+	AdminID    uint      `gorm:"index" json:"admin_id"`
+	Action     string    `gorm:"type:varchar(50);not null" json:"action"`
+	EntityType string    `gorm:"type:varchar(30);not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20)" json:"to_status"`
+	Details    string    `gorm:"type:text" json:"details"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
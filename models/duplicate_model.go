@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DuplicateDismissal marks a candidate pair an admin reviewed and decided
+// is not a duplicate. IDA is always the smaller of the two IDs.
+type DuplicateDismissal struct {
+	DismissalID uint      `gorm:"primaryKey;autoIncrement" json:"dismissal_id"`
+	EntityType  string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_duplicate_pair" json:"entity_type"`
+	IDA         uint      `gorm:"column:id_a;not null;uniqueIndex:idx_duplicate_pair" json:"id_a"`
+	IDB         uint      `gorm:"column:id_b;not null;uniqueIndex:idx_duplicate_pair" json:"id_b"`
+	DismissedBy uint      `json:"dismissed_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (DuplicateDismissal) TableName() string {
+	return "duplicate_dismissals"
+}
+
+// AccountMerge records a duplicate account folded into a surviving account
+type AccountMerge struct {
+	MergeID    uint      `gorm:"primaryKey;autoIncrement" json:"merge_id"`
+	EntityType string    `gorm:"type:varchar(20);not null" json:"entity_type"`
+	SurvivorID uint      `gorm:"not null;index" json:"survivor_id"`
+	MergedID   uint      `gorm:"not null;index" json:"merged_id"`
+	MovedRows  string    `gorm:"type:text" json:"moved_rows"` // JSON map of table name to rows moved
+	MergedBy   uint      `json:"merged_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (AccountMerge) TableName() string {
+	return "account_merges"
+}
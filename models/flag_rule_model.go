@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// FlagRule is a super-admin managed rule that flags shelters based on the
+// reports they receive
+type FlagRule struct {
+	RuleID              uint      `gorm:"primaryKey;autoIncrement" json:"rule_id"`
+	Name                string    `gorm:"not null" json:"name"`
+	Kind                string    `gorm:"type:varchar(30);not null" json:"kind"` // report_volume or reason_match
+	MinReports          int       `json:"min_reports"`
+	DistinctAdopters    bool      `json:"distinct_adopters"`
+	WindowDays          int       `json:"window_days"`
+	ReasonPattern       string    `json:"reason_pattern"`
+	Action              string    `gorm:"type:varchar(30);not null" json:"action"` // raise_priority, escalate or temporary_hold
+	HoldHours           int       `json:"hold_hours"`
+	WeightByCredibility bool      `json:"weight_by_credibility"` // count each report by its reporter's credibility score
+	Enabled             bool      `gorm:"default:true" json:"enabled"`
+	CreatedBy           uint      `json:"created_by"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (FlagRule) TableName() string {
+	return "flag_rules"
+}
+
+// ShelterFlag is raised on a shelter when a FlagRule matches
+type ShelterFlag struct {
+	FlagID     uint       `gorm:"primaryKey;autoIncrement" json:"flag_id"`
+	ShelterID  uint       `gorm:"index;not null;uniqueIndex:idx_shelter_flags_open,where:resolved_at IS NULL" json:"shelter_id"`
+	RuleID     uint       `gorm:"index;uniqueIndex:idx_shelter_flags_open" json:"rule_id"` // one open flag per rule and shelter
+	Action     string     `gorm:"type:varchar(30)" json:"action"`
+	Details    string     `gorm:"type:text" json:"details"`
+	HoldUntil  *time.Time `json:"hold_until"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	ResolvedBy uint       `json:"resolved_by"`
+
+	Rule FlagRule `gorm:"foreignKey:RuleID;references:RuleID" json:"rule"`
+}
+
+func (ShelterFlag) TableName() string {
+	return "shelter_flags"
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ScreeningTerm is a configurable word or regex used to screen user text
+type ScreeningTerm struct {
+	TermID    uint      `gorm:"primaryKey;autoIncrement" json:"term_id"`
+	Category  string    `gorm:"type:varchar(30);not null" json:"category"` // profanity, contact or payment
+	Pattern   string    `gorm:"not null" json:"pattern"`
+	IsRegex   bool      `json:"is_regex"`
+	Enabled   bool      `gorm:"default:true" json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ScreeningTerm) TableName() string {
+	return "screening_terms"
+}
+
+// ModerationItem is a piece of user text that matched a screening term and
+// is waiting for an admin decision
+type ModerationItem struct {
+	ItemID     uint       `gorm:"primaryKey;autoIncrement" json:"item_id"`
+	Source     string     `gorm:"type:varchar(40);not null;index:idx_moderation_source" json:"source"`
+	EntityID   uint       `gorm:"not null;index:idx_moderation_source" json:"entity_id"`
+	Content    string     `gorm:"type:text" json:"content"`
+	Matches    string     `gorm:"type:text" json:"-"` // JSON list of ScreeningMatch
+	Status     string     `gorm:"type:varchar(20);default:'open';index" json:"status"`
+	ReviewNote string     `gorm:"type:text" json:"review_note"`
+	ReviewedBy uint       `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (ModerationItem) TableName() string {
+	return "moderation_queue"
+}
+
+// ScreenedContent records the text a row held when it was last screened, so
+// the scheduled scan only screens rows whose text has changed since
+type ScreenedContent struct {
+	Source     string    `gorm:"primaryKey;type:varchar(40)" json:"source"`
+	EntityID   uint      `gorm:"primaryKey" json:"entity_id"`
+	Checksum   string    `gorm:"type:varchar(64);not null" json:"checksum"` // sha256 of the normalized text
+	ScreenedAt time.Time `json:"screened_at"`
+}
+
+func (ScreenedContent) TableName() string {
+	return "screened_contents"
+}
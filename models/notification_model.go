@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Notification is an in-app message for an adopter or a shelter
+type Notification struct {
+	NotificationID uint       `gorm:"primaryKey;autoIncrement" json:"notification_id"`
+	RecipientType  string     `gorm:"type:varchar(20);not null;index:idx_notification_recipient" json:"recipient_type"` // adopter or shelter
+	RecipientID    uint       `gorm:"not null;index:idx_notification_recipient" json:"recipient_id"`
+	Kind           string     `gorm:"type:varchar(40)" json:"kind"`
+	Title          string     `json:"title"`
+	Message        string     `gorm:"type:text" json:"message"`
+	ReadAt         *time.Time `json:"read_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
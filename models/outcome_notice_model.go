@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// OutcomeNotice is a templated moderation outcome message waiting for or
+// already sent through the configured delivery channel
+type OutcomeNotice struct {
+	NoticeID      uint       `gorm:"primaryKey;autoIncrement" json:"notice_id"`
+	Template      string     `gorm:"type:varchar(40);not null" json:"template"`
+	RecipientType string     `gorm:"type:varchar(20);not null" json:"recipient_type"` // adopter or shelter
+	RecipientID   uint       `gorm:"not null" json:"recipient_id"`
+	Address       string     `json:"address"`
+	Subject       string     `json:"subject"`
+	Body          string     `gorm:"type:text" json:"body"`
+	Channel       string     `gorm:"type:varchar(20)" json:"channel"`
+	Status        string     `gorm:"type:varchar(20);default:'pending';index" json:"status"` // pending, sending, sent or failed
+	Attempts      int        `json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	ClaimedAt     *time.Time `json:"claimed_at,omitempty"` // when a dispatcher last took it for sending
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+func (OutcomeNotice) TableName() string {
+	return "outcome_notices"
+}
+
+// OutcomeNoticeReport links a notice to each report it was sent about
+type OutcomeNoticeReport struct {
+	NoticeID uint `gorm:"primaryKey;autoIncrement:false" json:"notice_id"`
+	ReportID uint `gorm:"primaryKey;autoIncrement:false;index" json:"report_id"`
+}
+
+func (OutcomeNoticeReport) TableName() string {
+	return "outcome_notice_reports"
+}
@@ -4,7 +4,7 @@ import "time"
 
 type PetInfo struct {
 	PetID           uint      `gorm:"primaryKey" json:"pet_id"`
-	ShelterID       uint      `gorm:"autoIncrement:false" json:"shelter_id"`
+	ShelterID       uint      `gorm:"autoIncrement:false;index:idx_petinfo_shelter_id" json:"shelter_id"`
 	PetType         string    `json:"pet_type"`
 	PetName         string    `json:"pet_name"`
 	PetAge          int       `json:"pet_age"`
@@ -15,7 +15,14 @@ type PetInfo struct {
 	CreatedAt       time.Time `json:"created_at"`
 	PetSize         string    `json:"pet_size"`
 	PriorityStatus  bool      `json:"priority_status"`
-	PetMedia        PetMedia  `gorm:"foreignKey:PetID;references:PetID" json:"petmedia"`
+
+	// Admin moderation: listed, unlisted or flagged
+	ListingStatus    string     `gorm:"type:varchar(20);default:'listed'" json:"listing_status"`
+	ModerationReason string     `gorm:"type:text" json:"moderation_reason"`
+	ModeratedAt      *time.Time `json:"moderated_at"`
+	HiddenBy         string     `gorm:"type:varchar(30);not null;default:''" json:"hidden_by"` // set while a cascade hides the pet, e.g. shelter_blocked
+
+	PetMedia PetMedia `gorm:"foreignKey:PetID;references:PetID" json:"petmedia"`
 }
 
 func (PetInfo) TableName() string {
@@ -23,7 +30,7 @@ func (PetInfo) TableName() string {
 }
 
 type PetMedia struct {
-	PetID      uint   `gorm:"not null" json:"pet_id"`
+	PetID      uint   `gorm:"not null;index:idx_petmedia_pet_id" json:"pet_id"`
 	PetImage1  string `json:"pet_image1"` // Base64-encoded image
 	PetVaccine string `json:"pet_vaccine"`
 }
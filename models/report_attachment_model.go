@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ReportAttachment is an evidence file (image or document) attached to a
+// submitted report. Attachments are never removed when the report changes status.
+type ReportAttachment struct {
+	AttachmentID uint      `gorm:"primaryKey;autoIncrement" json:"attachment_id"`
+	ReportID     uint      `gorm:"index;not null" json:"report_id"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `gorm:"type:varchar(100)" json:"mime_type"`
+	SizeBytes    int       `json:"size_bytes"`
+	FileData     string    `gorm:"type:text" json:"file_data,omitempty"` // Base64-encoded file
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (ReportAttachment) TableName() string {
+	return "report_attachments"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ReportCategory is one entry in the managed report reason taxonomy
+type ReportCategory struct {
+	CategoryID  uint      `gorm:"primaryKey;autoIncrement" json:"category_id"`
+	Code        string    `gorm:"type:varchar(40);uniqueIndex;not null" json:"code"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Keywords    string    `gorm:"type:text" json:"keywords"`          // comma separated, used to classify free-text reasons
+	Severity    int       `gorm:"not null;default:2" json:"severity"` // 1 low, 2 medium, 3 high, 4 critical
+	Enabled     bool      `gorm:"default:true" json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ReportCategory) TableName() string {
+	return "report_categories"
+}
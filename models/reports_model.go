@@ -9,6 +9,8 @@ type SubmittedReport struct {
 	Reason      string    `gorm:"type:text;column:reason" json:"reason"`
 	Description string    `gorm:"type:text;column:description" json:"description"`
 	Status      string    `gorm:"type:text;column:status;default:'pending'" json:"status"`
+	CategoryID  *uint     `gorm:"column:category_id;index" json:"category_id"`
+	Version     uint      `gorm:"column:version;not null;default:1" json:"version"` // optimistic lock for admin edits
 	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 
 	Shelter ShelterInfo `gorm:"foreignKey:ShelterID;references:ShelterID" json:"shelter"`
@@ -19,7 +19,24 @@ type AdopterResponseModel struct {
 }
 
 type ShelterResponseModel struct {
-	RetCode string      `json:"retCode"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	RetCode    string      `json:"retCode"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page returned by a list endpoint. Pass
+// next_cursor as ?cursor= to get the following page.
+//
+// Lists return 50 rows unless ?limit= asks for another size, up to 200.
+// They used to return every row, so a client that needs the whole list
+// must keep following next_cursor while has_more is true; total counts
+// every matching row. next_cursor is always sent and is empty on the last
+// page.
+type Pagination struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ShelterDocument is a verification document uploaded by a shelter during
+// registration (business permit, owner ID, facility photos)
+type ShelterDocument struct {
+	DocumentID   uint       `gorm:"primaryKey;autoIncrement" json:"document_id"`
+	ShelterID    uint       `gorm:"index;not null" json:"shelter_id"`
+	DocType      string     `gorm:"type:varchar(50);not null" json:"doc_type"`
+	FileName     string     `json:"file_name"`
+	MimeType     string     `gorm:"type:varchar(100)" json:"mime_type"`
+	SizeBytes    int        `json:"size_bytes"`
+	FileData     string     `gorm:"type:text" json:"file_data,omitempty"` // Base64-encoded file
+	Status       string     `gorm:"type:varchar(20);default:'pending'" json:"status"`
+	RejectReason string     `gorm:"type:text" json:"reject_reason"`
+	ReviewedBy   uint       `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (ShelterDocument) TableName() string {
+	return "shelter_documents"
+}
@@ -1,15 +1,21 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // ShelterAccount model (linked to existing "shelteraccount" table)
 type ShelterAccount struct {
 	ShelterID uint   `gorm:"primaryKey" json:"shelter_id"`
 	Username  string `gorm:"unique;not null" json:"username"`
 	Password  string `json:"password"`
-	Status    string `gorm:"default:'active'" json:"status"` // Add this line
-	RegStatus string `json:"reg_status"`
+	Status    string `gorm:"default:'active';index:idx_shelteraccount_status_reg_status" json:"status"` // Add this line
+	RegStatus string `gorm:"index:idx_shelteraccount_status_reg_status" json:"reg_status"`
+	Version   uint   `gorm:"not null;default:1" json:"version"` // bumped on every write; sent as the ETag
 	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"` // set while the account is soft deleted
 
 	ShelterInfo ShelterInfo `gorm:"foreignKey:ShelterID" json:"shelterinfo"`
 }
@@ -21,15 +27,16 @@ func (ShelterAccount) TableName() string {
 
 // ShelterInfo model (linked to existing "shelterinfo" table)
 type ShelterInfo struct {
-	ShelterID          uint   `gorm:"primaryKey;autoIncrement:false" json:"shelter_id"`
-	ShelterName        string `json:"shelter_name"`
-	ShelterAddress     string `json:"shelter_address"`
-	ShelterLandmark    string `json:"shelter_landmark"`
-	ShelterContact     string `json:"shelter_contact"`
-	ShelterEmail       string `json:"shelter_email"`
-	ShelterOwner       string `json:"shelter_owner"`
-	ShelterDescription string `json:"shelter_description"`
-	ShelterSocial      string `json:"shelter_social"`
+	ShelterID          uint           `gorm:"primaryKey;autoIncrement:false" json:"shelter_id"`
+	ShelterName        string         `json:"shelter_name"`
+	ShelterAddress     string         `json:"shelter_address"`
+	ShelterLandmark    string         `json:"shelter_landmark"`
+	ShelterContact     string         `json:"shelter_contact"`
+	ShelterEmail       string         `json:"shelter_email"`
+	ShelterOwner       string         `json:"shelter_owner"`
+	ShelterDescription string         `json:"shelter_description"`
+	ShelterSocial      string         `json:"shelter_social"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
 
 	ShelterMedia ShelterMedia `gorm:"foreignKey:ShelterID;references:ShelterID" json:"sheltermedia"`
 }
@@ -40,9 +47,10 @@ func (ShelterInfo) TableName() string {
 }
 
 type ShelterMedia struct {
-	ShelterID      uint   `gorm:"primaryKey;autoIncrement:false" json:"shelter_id"`
-	ShelterProfile string `json:"shelter_profile"`
-	ShelterCover   string `json:"shelter_cover"`
+	ShelterID      uint           `gorm:"primaryKey;autoIncrement:false" json:"shelter_id"`
+	ShelterProfile string         `json:"shelter_profile"`
+	ShelterCover   string         `json:"shelter_cover"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (ShelterMedia) TableName() string {
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// ShelterReviewRound records one submission of a shelter registration and
+// the admin decision taken on it. A new round is opened every time a
+// rejected shelter resubmits its info.
+type ShelterReviewRound struct {
+	RoundID     uint       `gorm:"primaryKey;autoIncrement" json:"round_id"`
+	ShelterID   uint       `gorm:"index;not null" json:"shelter_id"`
+	Round       int        `gorm:"not null" json:"round"`
+	Decision    string     `gorm:"type:varchar(20);default:'pending'" json:"decision"`
+	ReasonCode  string     `gorm:"type:varchar(50)" json:"reason_code"`
+	Feedback    string     `gorm:"type:text" json:"feedback"`
+	ReviewedBy  uint       `json:"reviewed_by"`
+	Snapshot    string     `gorm:"type:text" json:"-"` // JSON copy of the submitted ShelterInfo
+	Changes     string     `gorm:"type:text" json:"-"` // JSON diff against the last rejected round
+	SubmittedAt time.Time  `json:"submitted_at"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	EscalatedAt *time.Time `json:"escalated_at"` // set when the round passed the review SLA
+}
+
+func (ShelterReviewRound) TableName() string {
+	return "shelter_review_rounds"
+}
+
+// ShelterReviewClaim is an expiring lock an admin holds while reviewing a
+// pending shelter registration
+type ShelterReviewClaim struct {
+	ShelterID uint      `gorm:"primaryKey;autoIncrement:false" json:"shelter_id"`
+	AdminID   uint      `gorm:"not null" json:"admin_id"`
+	ClaimedAt time.Time `json:"claimed_at"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
+
+func (ShelterReviewClaim) TableName() string {
+	return "shelter_review_claims"
+}
@@ -0,0 +1,418 @@
+// Package pagination pages, sorts and counts list queries. A Spec names the
+// fields a list can be sorted by and pages a GORM query, or a slice held in
+// memory, by them. Strings sort by the database collation.
+//
+// Pages are chosen by offset or by cursor. A cursor holds the sort values of
+// the last row of the previous page, so the next page starts right after it
+// however many rows were inserted or removed before it (keyset pagination).
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Page size limits
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+var (
+	// ErrInvalidSort is returned for a sort field the list does not support
+	ErrInvalidSort = errors.New("invalid sort field")
+	// ErrInvalidCursor is returned for a cursor that is malformed or was
+	// issued for a different sort order
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortField orders a list by one field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+func (f SortField) String() string {
+	if f.Desc {
+		return "-" + f.Field
+	}
+	return f.Field
+}
+
+// Page selects one page of a list. With a Cursor the page starts after the
+// row the cursor was issued for and Offset is ignored. An empty Sort uses
+// the list's default order.
+type Page struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   []SortField
+}
+
+// limit clamps the page size to 1..MaxLimit, defaulting to DefaultLimit
+func (p Page) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultLimit
+	case p.Limit > MaxLimit:
+		return MaxLimit
+	}
+	return p.Limit
+}
+
+// Result is one page of a list
+type Result[T any] struct {
+	Items []T
+	// Total counts every row matching the filters, not just this page
+	Total  int64
+	Limit  int
+	Offset int
+	// NextCursor continues after the last item; empty on the last page
+	NextCursor string
+}
+
+// Key is one sortable field of T: the column it is sorted by in SQL and how
+// to read it from a scanned row for the next cursor.
+type Key[T any] struct {
+	field  string
+	column string
+	kind   kind
+	value  func(T) interface{}
+}
+
+type kind int
+
+const (
+	kindInt kind = iota
+	kindString
+	kindTime
+)
+
+// Int is a whole number field read from column
+func Int[T any](field, column string, value func(T) int64) Key[T] {
+	return Key[T]{field, column, kindInt, func(item T) interface{} { return value(item) }}
+}
+
+// String is a text field read from column
+func String[T any](field, column string, value func(T) string) Key[T] {
+	return Key[T]{field, column, kindString, func(item T) interface{} { return value(item) }}
+}
+
+// Time is a timestamp field read from column
+func Time[T any](field, column string, value func(T) time.Time) Key[T] {
+	return Key[T]{field, column, kindTime, func(item T) interface{} { return value(item).UTC() }}
+}
+
+// expr is the column as sorted and compared in SQL. NULLs read as the Go
+// zero value so they sort, and compare against cursors, like the scanned
+// rows do.
+func (k Key[T]) expr() string {
+	switch k.kind {
+	case kindInt:
+		return fmt.Sprintf("COALESCE(%s, 0)", k.column)
+	case kindTime:
+		return fmt.Sprintf("COALESCE(%s, '0001-01-01 00:00:00+00')", k.column)
+	}
+	return fmt.Sprintf("COALESCE(%s, '')", k.column)
+}
+
+// Spec is the sortable fields of a list. The unique field breaks ties so
+// every row has a distinct position.
+type Spec[T any] struct {
+	keys        map[string]Key[T]
+	unique      string
+	defaultSort []SortField
+}
+
+// NewSpec builds a spec from its keys. unique names the key that identifies
+// a row; defaultSort is used when a page asks for no order.
+func NewSpec[T any](unique string, defaultSort []SortField, keys ...Key[T]) Spec[T] {
+	spec := Spec[T]{keys: make(map[string]Key[T], len(keys)), unique: unique, defaultSort: defaultSort}
+	for _, key := range keys {
+		spec.keys[key.field] = key
+	}
+	if _, ok := spec.keys[unique]; !ok {
+		panic("pagination: unique field " + unique + " has no key")
+	}
+	return spec
+}
+
+// Fields lists the sortable field names
+func (s Spec[T]) Fields() []string {
+	fields := make([]string, 0, len(s.keys))
+	for field := range s.keys {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// order resolves the page's sort against the spec and appends the unique
+// field as the final tie-breaker
+func (s Spec[T]) order(page Page) ([]SortField, []Key[T], error) {
+	fields := page.Sort
+	if len(fields) == 0 {
+		fields = s.defaultSort
+	}
+
+	var order []SortField
+	var keys []Key[T]
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		key, ok := s.keys[f.Field]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w %q, use one of %s", ErrInvalidSort, f.Field, strings.Join(s.Fields(), ", "))
+		}
+		if seen[f.Field] {
+			continue
+		}
+		seen[f.Field] = true
+		order = append(order, f)
+		keys = append(keys, key)
+	}
+	if !seen[s.unique] {
+		order = append(order, SortField{Field: s.unique})
+		keys = append(keys, s.keys[s.unique])
+	}
+	return order, keys, nil
+}
+
+// cursor is the encoded form of a page position
+type cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func signature(order []SortField) string {
+	parts := make([]string, len(order))
+	for i, f := range order {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(order []SortField, values []interface{}) string {
+	encoded := make([]interface{}, len(values))
+	for i, v := range values {
+		if t, ok := v.(time.Time); ok {
+			encoded[i] = t.Format(time.RFC3339Nano)
+		} else {
+			encoded[i] = v
+		}
+	}
+	data, _ := json.Marshal(cursor{Sort: signature(order), Values: encoded})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the typed sort values held by a cursor issued for
+// the same order
+func decodeCursor[T any](raw string, order []SortField, keys []Key[T]) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var c cursor
+	if err := decoder.Decode(&c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != signature(order) || len(c.Values) != len(keys) {
+		return nil, fmt.Errorf("%w: it was issued for a different sort", ErrInvalidCursor)
+	}
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		switch key.kind {
+		case kindInt:
+			n, ok := c.Values[i].(json.Number)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			v, err := n.Int64()
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = v
+		case kindString:
+			v, ok := c.Values[i].(string)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = v
+		case kindTime:
+			s, ok := c.Values[i].(string)
+			if !ok {
+				return nil, ErrInvalidCursor
+			}
+			v, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			values[i] = v.UTC()
+		}
+	}
+	return values, nil
+}
+
+// nextCursor is the cursor after item
+func nextCursor[T any](item T, order []SortField, keys []Key[T]) string {
+	return encodeCursor(order, valuesOf(keys, item))
+}
+
+// start resolves the page's order and cursor into an empty result
+func (s Spec[T]) start(page Page) (Result[T], []SortField, []Key[T], []interface{}, error) {
+	result := Result[T]{Items: []T{}, Limit: page.limit()}
+	order, keys, err := s.order(page)
+	if err != nil {
+		return result, nil, nil, nil, err
+	}
+	var after []interface{}
+	if page.Cursor != "" {
+		if after, err = decodeCursor(page.Cursor, order, keys); err != nil {
+			return result, nil, nil, nil, err
+		}
+	} else if page.Offset > 0 {
+		result.Offset = page.Offset
+	}
+	return result, order, keys, after, nil
+}
+
+// Query counts the rows matched by query and returns the requested page of
+// them. query must select T's table with the filters already applied.
+func (s Spec[T]) Query(query *gorm.DB, page Page) (Result[T], error) {
+	result, order, keys, after, err := s.start(page)
+	if err != nil {
+		return result, err
+	}
+
+	query = query.Session(&gorm.Session{})
+	if err := query.Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	rows := query
+	if after != nil {
+		clause, args := afterClause(order, keys, after)
+		rows = rows.Where(clause, args...)
+	}
+	for i, f := range order {
+		direction := " ASC"
+		if f.Desc {
+			direction = " DESC"
+		}
+		rows = rows.Order(keys[i].expr() + direction)
+	}
+	// One extra row tells whether there is a next page
+	if err := rows.Offset(result.Offset).Limit(result.Limit + 1).Find(&result.Items).Error; err != nil {
+		return result, err
+	}
+
+	if len(result.Items) > result.Limit {
+		result.Items = result.Items[:result.Limit]
+		result.NextCursor = nextCursor(result.Items[len(result.Items)-1], order, keys)
+	}
+	return result, nil
+}
+
+// afterClause matches the rows after the cursor values in the sort order:
+// (a > x) OR (a = x AND b > y) OR ..., with < for descending fields
+func afterClause[T any](order []SortField, keys []Key[T], values []interface{}) (string, []interface{}) {
+	var terms []string
+	var args []interface{}
+	for i := range order {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].expr()+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if order[i].Desc {
+			op = " < ?"
+		}
+		parts = append(parts, keys[i].expr()+op)
+		args = append(args, values[i])
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(terms, " OR ") + ")", args
+}
+
+// Slice pages items held in memory the same way Query pages a table, so a
+// cursor from one works with the other. Strings compare byte by byte, as
+// under the C collation.
+func (s Spec[T]) Slice(items []T, page Page) (Result[T], error) {
+	result, order, keys, after, err := s.start(page)
+	if err != nil {
+		return result, err
+	}
+	result.Total = int64(len(items))
+
+	sorted := append([]T(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareRows(order, keys, sorted[i], valuesOf(keys, sorted[j])) < 0
+	})
+	if after != nil {
+		first := sort.Search(len(sorted), func(i int) bool {
+			return compareRows(order, keys, sorted[i], after) > 0
+		})
+		sorted = sorted[first:]
+	}
+	if result.Offset < len(sorted) {
+		sorted = sorted[result.Offset:]
+	} else {
+		sorted = nil
+	}
+
+	if len(sorted) > result.Limit {
+		sorted = sorted[:result.Limit]
+		result.NextCursor = nextCursor(sorted[len(sorted)-1], order, keys)
+	}
+	result.Items = append(result.Items, sorted...)
+	return result, nil
+}
+
+func valuesOf[T any](keys []Key[T], item T) []interface{} {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		values[i] = key.value(item)
+	}
+	return values
+}
+
+// compareRows compares item with a row's sort values in the sort order
+func compareRows[T any](order []SortField, keys []Key[T], item T, values []interface{}) int {
+	for i, key := range keys {
+		c := compareValues(key.value(item), values[i])
+		if order[i].Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return strings.Compare(a.(string), b.(string))
+}
@@ -0,0 +1,285 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type pet struct {
+	ID        int64
+	Name      string
+	Age       int64
+	CreatedAt time.Time
+}
+
+var petSort = NewSpec("id", []SortField{{Field: "id"}},
+	Int("id", "pets.id", func(p pet) int64 { return p.ID }),
+	String("name", "pets.name", func(p pet) string { return p.Name }),
+	Int("age", "pets.age", func(p pet) int64 { return p.Age }),
+	Time("created_at", "pets.created_at", func(p pet) time.Time { return p.CreatedAt }),
+)
+
+// seedPets creates n pets with few distinct names, ages and creation times,
+// so every sort has ties
+func seedPets(t *testing.T, n int) (*gorm.DB, []pet) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&pet{}); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{"Rex", "Mia", "Ace", "mia"}
+	pets := make([]pet, n)
+	for i := range pets {
+		pets[i] = pet{
+			ID:        int64(i + 1),
+			Name:      names[i%len(names)],
+			Age:       int64(i % 3),
+			CreatedAt: start.Add(time.Duration(i%5) * time.Hour),
+		}
+	}
+	if err := db.Create(&pets).Error; err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+	return db, pets
+}
+
+// expected sorts pets by the order in Go, with id as the final tie-breaker
+func expected(pets []pet, order []SortField) []int64 {
+	sorted := append([]pet(nil), pets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, f := range append(order, SortField{Field: "id"}) {
+			var c int
+			a, b := sorted[i], sorted[j]
+			switch f.Field {
+			case "id":
+				c = int(a.ID - b.ID)
+			case "name":
+				c = strings.Compare(a.Name, b.Name)
+			case "age":
+				c = int(a.Age - b.Age)
+			case "created_at":
+				c = a.CreatedAt.Compare(b.CreatedAt)
+			}
+			if f.Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	ids := make([]int64, len(sorted))
+	for i, p := range sorted {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// walk follows next cursors from the first page to the last
+func walk(t *testing.T, db *gorm.DB, page Page) []int64 {
+	t.Helper()
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatal("cursor never ran out")
+		}
+		result, err := petSort.Query(db.Model(&pet{}), page)
+		if err != nil {
+			t.Fatalf("page %d: %v", pages, err)
+		}
+		for _, p := range result.Items {
+			ids = append(ids, p.ID)
+		}
+		if result.NextCursor == "" {
+			return ids
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+func TestCursorPagesFollowTheSort(t *testing.T) {
+	db, pets := seedPets(t, 23)
+
+	cases := []struct {
+		name string
+		sort []SortField
+	}{
+		{"default", nil},
+		{"one field descending", []SortField{{Field: "age", Desc: true}}},
+		{"ties on a string", []SortField{{Field: "name"}}},
+		{"mixed directions", []SortField{{Field: "name", Desc: true}, {Field: "age"}}},
+		{"three fields", []SortField{{Field: "age"}, {Field: "created_at", Desc: true}, {Field: "name"}}},
+		{"unique field first", []SortField{{Field: "id", Desc: true}, {Field: "name"}}},
+		{"repeated field", []SortField{{Field: "age", Desc: true}, {Field: "age"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			order := tc.sort
+			if order == nil {
+				order = []SortField{{Field: "id"}}
+			} else if tc.name == "repeated field" {
+				order = order[:1]
+			}
+			want := expected(pets, order)
+			for _, limit := range []int{1, 2, 5, 23, 40} {
+				if got := walk(t, db, Page{Limit: limit, Sort: tc.sort}); !reflect.DeepEqual(got, want) {
+					t.Fatalf("limit %d: %v, want %v", limit, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPageSizes(t *testing.T) {
+	db, _ := seedPets(t, MaxLimit+10)
+
+	cases := []struct {
+		name  string
+		page  Page
+		items int
+		more  bool
+	}{
+		{"default limit", Page{}, DefaultLimit, true},
+		{"requested limit", Page{Limit: 7}, 7, true},
+		{"limit over the maximum", Page{Limit: MaxLimit + 50}, MaxLimit, true},
+		{"last page by offset", Page{Limit: 20, Offset: MaxLimit}, 10, false},
+		{"offset past the end", Page{Offset: MaxLimit + 20}, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := petSort.Query(db.Model(&pet{}), tc.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Items) != tc.items || (result.NextCursor != "") != tc.more || result.Total != MaxLimit+10 {
+				t.Fatalf("%d items, next cursor %q, total %d; want %d items, more %v",
+					len(result.Items), result.NextCursor, result.Total, tc.items, tc.more)
+			}
+		})
+	}
+}
+
+func TestBadCursors(t *testing.T) {
+	db, _ := seedPets(t, 10)
+	byName := []SortField{{Field: "name"}}
+	first, err := petSort.Query(db.Model(&pet{}), Page{Limit: 3, Sort: byName})
+	if err != nil || first.NextCursor == "" {
+		t.Fatalf("first page: %v", err)
+	}
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	cases := []struct {
+		name   string
+		cursor string
+		sort   []SortField
+	}{
+		{"not base64", "!!!", byName},
+		{"not JSON", encode("name,Rex"), byName},
+		{"truncated", first.NextCursor[:len(first.NextCursor)-4], byName},
+		{"value of the wrong type", encode(`{"s":"name,id","v":["Rex","3"]}`), byName},
+		{"missing value", encode(`{"s":"name,id","v":["Rex"]}`), byName},
+		{"extra value", encode(`{"s":"name,id","v":["Rex",3,4]}`), byName},
+		{"forged sort", encode(`{"s":"age,id","v":["Rex",3]}`), byName},
+		{"malformed time", encode(`{"s":"created_at,id","v":["yesterday",3]}`), []SortField{{Field: "created_at"}}},
+		{"reused with another direction", first.NextCursor, []SortField{{Field: "name", Desc: true}}},
+		{"reused with another field", first.NextCursor, []SortField{{Field: "age"}}},
+		{"reused with an added field", first.NextCursor, []SortField{{Field: "name"}, {Field: "age"}}},
+		{"reused with the default sort", first.NextCursor, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := petSort.Query(db.Model(&pet{}), Page{Limit: 3, Cursor: tc.cursor, Sort: tc.sort})
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	if _, err := petSort.Query(db.Model(&pet{}), Page{Sort: []SortField{{Field: "owner"}}}); !errors.Is(err, ErrInvalidSort) {
+		t.Fatalf("unknown sort field: err = %v, want ErrInvalidSort", err)
+	}
+}
+
+func TestCursorSurvivesInsertsBeforeIt(t *testing.T) {
+	db, _ := seedPets(t, 6)
+	first, err := petSort.Query(db.Model(&pet{}), Page{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A row sorting before the cursor must not shift the next page
+	if err := db.Create(&pet{ID: 0, Name: "Zed"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	next, err := petSort.Query(db.Model(&pet{}), Page{Limit: 3, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(ids(next.Items)); got != "[4 5 6]" {
+		t.Fatalf("second page = %s, want [4 5 6]", got)
+	}
+}
+
+func ids(pets []pet) []int64 {
+	out := make([]int64, len(pets))
+	for i, p := range pets {
+		out[i] = p.ID
+	}
+	return out
+}
+
+func TestSliceMatchesQuery(t *testing.T) {
+	db, pets := seedPets(t, 23)
+
+	for _, order := range [][]SortField{
+		nil,
+		{{Field: "name", Desc: true}, {Field: "age"}},
+		{{Field: "age"}, {Field: "created_at", Desc: true}},
+	} {
+		for _, page := range []Page{{Limit: 4, Sort: order}, {Limit: 5, Offset: 7, Sort: order}} {
+			sliced, err := petSort.Slice(pets, page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for {
+				queried, err := petSort.Query(db.Model(&pet{}), page)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(ids(sliced.Items), ids(queried.Items)) || sliced.NextCursor != queried.NextCursor || sliced.Total != queried.Total {
+					t.Fatalf("sort %v: sliced %v (next %q), queried %v (next %q)",
+						order, ids(sliced.Items), sliced.NextCursor, ids(queried.Items), queried.NextCursor)
+				}
+				if sliced.NextCursor == "" {
+					break
+				}
+				page.Cursor = sliced.NextCursor
+				if sliced, err = petSort.Slice(pets, page); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,582 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/pagination"
+	"pethubadmin/services"
+
+	"gorm.io/gorm"
+)
+
+// NewGorm returns repositories backed by the database
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Shelters:      gormShelters{db},
+		Adopters:      gormAdopters{db},
+		Pets:          gormPets{db},
+		Reports:       gormReports{db},
+		Applications:  gormApplications{db},
+		Admins:        gormAdmins{db},
+		Search:        gormSearch{db},
+		Flags:         gormFlags{db},
+		Moderation:    gormModeration{db},
+		Audit:         gormAudit{db},
+		Notifications: gormNotifications{db},
+	}
+}
+
+// created applies a created_at range to column
+func created(query *gorm.DB, column string, r CreatedRange) *gorm.DB {
+	if !r.From.IsZero() {
+		query = query.Where(column+" >= ?", r.From)
+	}
+	if !r.To.IsZero() {
+		query = query.Where(column+" < ?", r.To)
+	}
+	return query
+}
+
+// notFound maps GORM's missing record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormShelters struct{ db *gorm.DB }
+
+func (r gormShelters) filtered(filter ShelterFilter) *gorm.DB {
+	query := r.db.Model(&models.ShelterAccount{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.RegStatus != "" {
+		query = query.Where("reg_status = ?", filter.RegStatus)
+	}
+	if filter.HasProfile {
+		query = query.Where("EXISTS (SELECT 1 FROM shelterinfo WHERE shelterinfo.shelter_id = shelteraccount.shelter_id AND shelterinfo.deleted_at IS NULL)")
+	}
+	return created(query, "shelteraccount.created_at", filter.Created)
+}
+
+func (r gormShelters) Account(id uint) (models.ShelterAccount, error) {
+	var account models.ShelterAccount
+	err := r.db.Where("shelter_id = ?", id).First(&account).Error
+	return account, notFound(err)
+}
+
+func (r gormShelters) Accounts(filter ShelterFilter) ([]models.ShelterAccount, error) {
+	query := r.filtered(filter)
+	if filter.NewestFirst {
+		query = query.Order("created_at DESC")
+	}
+	var accounts []models.ShelterAccount
+	err := query.Find(&accounts).Error
+	return accounts, err
+}
+
+func (r gormShelters) CountAccounts(filter ShelterFilter) (int64, error) {
+	var count int64
+	err := r.filtered(filter).Count(&count).Error
+	return count, err
+}
+
+func (r gormShelters) AccountPage(filter ShelterFilter, page pagination.Page) (pagination.Result[models.ShelterAccount], error) {
+	return ShelterSort.Query(r.filtered(filter), page)
+}
+
+func (r gormShelters) Infos(ids ...uint) ([]models.ShelterInfo, error) {
+	var infos []models.ShelterInfo
+	query := r.db.Model(&models.ShelterInfo{})
+	if len(ids) > 0 {
+		query = query.Where("shelter_id IN ?", ids)
+	}
+	err := query.Find(&infos).Error
+	return infos, err
+}
+
+func (r gormShelters) Info(id uint) (models.ShelterInfo, error) {
+	var info models.ShelterInfo
+	err := r.db.Preload("ShelterMedia").Where("shelter_id = ?", id).First(&info).Error
+	return info, notFound(err)
+}
+
+func (r gormShelters) Media(ids ...uint) (map[uint]models.ShelterMedia, error) {
+	byShelter := make(map[uint]models.ShelterMedia)
+	if len(ids) == 0 {
+		return byShelter, nil
+	}
+	var media []models.ShelterMedia
+	if err := r.db.Where("shelter_id IN ?", ids).Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		byShelter[m.ShelterID] = m
+	}
+	return byShelter, nil
+}
+
+func (r gormShelters) Documents(ids ...uint) ([]models.ShelterDocument, error) {
+	return services.ShelterDocuments(r.db, ids...)
+}
+
+func (r gormShelters) Document(id uint) (models.ShelterDocument, error) {
+	var doc models.ShelterDocument
+	err := r.db.Where("document_id = ?", id).First(&doc).Error
+	return doc, notFound(err)
+}
+
+func (r gormShelters) FlagSummaries(ids []uint) (map[uint]services.ShelterFlagSummary, error) {
+	return services.ShelterFlagSummaries(r.db, ids)
+}
+
+func (r gormShelters) ReviewHistory(id uint) ([]services.ReviewRoundView, error) {
+	return services.ShelterReviewHistory(r.db, id)
+}
+
+func (r gormShelters) ReviewQueue(settings services.ReviewQueueSettings) ([]services.QueueItem, error) {
+	return services.ReviewQueue(r.db, settings)
+}
+
+func (r gormShelters) ReviewerThroughput(since time.Time) ([]services.ReviewerStats, error) {
+	return services.ReviewerThroughput(r.db, since)
+}
+
+func (r gormShelters) Duplicates(minScore float64) ([]services.DuplicateCandidate, error) {
+	return services.FindShelterDuplicates(r.db, minScore)
+}
+
+type gormAdopters struct{ db *gorm.DB }
+
+func (r gormAdopters) filtered(filter AdopterFilter) *gorm.DB {
+	query := r.db.Model(&models.AdopterAccount{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.HasProfile {
+		query = query.Where("EXISTS (SELECT 1 FROM adopterinfo WHERE adopterinfo.adopter_id = adopteraccount.adopter_id AND adopterinfo.deleted_at IS NULL)")
+	}
+	return created(query, "adopteraccount.created_at", filter.Created)
+}
+
+func (r gormAdopters) Account(id uint) (models.AdopterAccount, error) {
+	var account models.AdopterAccount
+	err := r.db.Where("adopter_id = ?", id).First(&account).Error
+	return account, notFound(err)
+}
+
+func (r gormAdopters) Accounts(filter AdopterFilter) ([]models.AdopterAccount, error) {
+	var accounts []models.AdopterAccount
+	err := r.filtered(filter).Find(&accounts).Error
+	return accounts, err
+}
+
+func (r gormAdopters) CountAccounts(filter AdopterFilter) (int64, error) {
+	var count int64
+	err := r.filtered(filter).Count(&count).Error
+	return count, err
+}
+
+func (r gormAdopters) AccountPage(filter AdopterFilter, page pagination.Page) (pagination.Result[models.AdopterAccount], error) {
+	return AdopterSort.Query(r.filtered(filter), page)
+}
+
+func (r gormAdopters) Infos(ids ...uint) ([]models.AdopterInfo, error) {
+	var infos []models.AdopterInfo
+	query := r.db.Model(&models.AdopterInfo{})
+	if len(ids) > 0 {
+		query = query.Where("adopter_id IN ?", ids)
+	}
+	err := query.Find(&infos).Error
+	return infos, err
+}
+
+func (r gormAdopters) Info(id uint) (models.AdopterInfo, error) {
+	var info models.AdopterInfo
+	err := r.db.Preload("AdopterMedia").Where("adopter_id = ?", id).First(&info).Error
+	return info, notFound(err)
+}
+
+func (r gormAdopters) Duplicates(minScore float64) ([]services.DuplicateCandidate, error) {
+	return services.FindAdopterDuplicates(r.db, minScore)
+}
+
+type gormPets struct{ db *gorm.DB }
+
+func (r gormPets) filtered(filter PetFilter) *gorm.DB {
+	query := r.db.Model(&models.PetInfo{})
+	if filter.ListedOnly {
+		query = query.Scopes(services.ListedPets)
+	}
+	if filter.ShelterID != 0 {
+		query = query.Where("shelter_id = ?", filter.ShelterID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if len(filter.ExcludeStatuses) > 0 {
+		query = query.Where("status NOT IN ?", filter.ExcludeStatuses)
+	}
+	if filter.PetType != "" {
+		query = query.Where("lower(pet_type) = lower(?)", filter.PetType)
+	}
+	return created(query, "petinfo.created_at", filter.Created)
+}
+
+func (r gormPets) Pet(id uint) (models.PetInfo, error) {
+	var pet models.PetInfo
+	err := r.db.Preload("PetMedia").Where("pet_id = ?", id).First(&pet).Error
+	return pet, notFound(err)
+}
+
+func (r gormPets) Pets(filter PetFilter) ([]models.PetInfo, error) {
+	var pets []models.PetInfo
+	err := r.filtered(filter).Preload("PetMedia").Find(&pets).Error
+	return pets, err
+}
+
+func (r gormPets) Page(filter PetFilter, page pagination.Page) (pagination.Result[models.PetInfo], error) {
+	return PetSort.Query(r.filtered(filter).Preload("PetMedia"), page)
+}
+
+func (r gormPets) Count(filter PetFilter) (int64, error) {
+	var count int64
+	err := r.filtered(filter).Count(&count).Error
+	return count, err
+}
+
+// vaccinatedPet is true when a pet has a non-blank vaccine record. A
+// record is blank when stripping leading whitespace leaves nothing; the
+// whitespace characters sit in a plain literal so the SQL stays portable.
+const vaccinatedPet = "EXISTS (SELECT 1 FROM petmedia m WHERE m.pet_id = p.pet_id AND ltrim(m.pet_vaccine, ' \t\r\n') <> '')"
+
+// ShelterStats groups in one query instead of loading every shelter's pets
+// and media. The pet filter goes in the join so shelters with no matching
+// pets still get a row.
+func (r gormPets) ShelterStats(shelters ShelterFilter, pets PetFilter) ([]ShelterPetStats, error) {
+	on := []string{"p.shelter_id = s.shelter_id"}
+	var args []interface{}
+	if pets.ListedOnly {
+		on = append(on, "p.listing_status <> ? AND p.hidden_by = ''")
+		args = append(args, services.ListingUnlisted)
+	}
+	if pets.ShelterID != 0 {
+		on = append(on, "p.shelter_id = ?")
+		args = append(args, pets.ShelterID)
+	}
+	if pets.Status != "" {
+		on = append(on, "p.status = ?")
+		args = append(args, pets.Status)
+	}
+	if len(pets.ExcludeStatuses) > 0 {
+		on = append(on, "p.status NOT IN ?")
+		args = append(args, pets.ExcludeStatuses)
+	}
+	if pets.PetType != "" {
+		on = append(on, "lower(p.pet_type) = lower(?)")
+		args = append(args, pets.PetType)
+	}
+	if !pets.Created.From.IsZero() {
+		on = append(on, "p.created_at >= ?")
+		args = append(args, pets.Created.From)
+	}
+	if !pets.Created.To.IsZero() {
+		on = append(on, "p.created_at < ?")
+		args = append(args, pets.Created.To)
+	}
+
+	query := r.db.Table("shelteraccount AS s").
+		Select(`s.shelter_id, i.shelter_name,
+			COUNT(p.pet_id) AS total,
+			COUNT(p.pet_id) FILTER (WHERE lower(p.pet_type) = 'cat') AS cats,
+			COUNT(p.pet_id) FILTER (WHERE lower(p.pet_type) = 'dog') AS dogs,
+			COUNT(p.pet_id) FILTER (WHERE `+vaccinatedPet+`) AS vaccinated`).
+		Joins("JOIN shelterinfo AS i ON i.shelter_id = s.shelter_id AND i.deleted_at IS NULL").
+		Joins("LEFT JOIN petinfo AS p ON "+strings.Join(on, " AND "), args...).
+		Where("s.deleted_at IS NULL")
+	if shelters.Status != "" {
+		query = query.Where("s.status = ?", shelters.Status)
+	}
+	if shelters.RegStatus != "" {
+		query = query.Where("s.reg_status = ?", shelters.RegStatus)
+	}
+	query = created(query, "s.created_at", shelters.Created)
+	if shelters.NewestFirst {
+		query = query.Order("s.created_at DESC")
+	}
+
+	stats := []ShelterPetStats{}
+	err := query.Group("s.shelter_id, i.shelter_name").Order("s.shelter_id").Scan(&stats).Error
+	return stats, err
+}
+
+func (r gormPets) ModerationHistory(shelterID uint) ([]services.PetModerationEntry, error) {
+	return services.PetModerationHistory(r.db, shelterID)
+}
+
+type gormReports struct{ db *gorm.DB }
+
+func (r gormReports) Report(id uint) (models.SubmittedReport, error) {
+	var report models.SubmittedReport
+	err := r.db.Preload("Shelter").Preload("Adopter").Where("id = ?", id).First(&report).Error
+	return report, notFound(err)
+}
+
+func (r gormReports) Reports(filter ReportFilter) ([]models.SubmittedReport, error) {
+	query := r.db.Preload("Shelter").Preload("Adopter")
+	if filter.Status != "" {
+		query = query.Where("submittedreports.status = ?", filter.Status)
+	}
+	query = created(query, "submittedreports.created_at", filter.Created)
+	if filter.ShelterStatus != "" {
+		query = query.Joins("JOIN shelteraccount ON shelteraccount.shelter_id = submittedreports.shelter_id AND shelteraccount.status = ? AND shelteraccount.deleted_at IS NULL", filter.ShelterStatus)
+	}
+	if len(filter.ShelterIDs) > 0 {
+		query = query.Where("submittedreports.shelter_id IN ?", filter.ShelterIDs)
+	}
+	if filter.NewestFirst {
+		query = query.Order("submittedreports.created_at DESC")
+	}
+	var reports []models.SubmittedReport
+	err := query.Find(&reports).Error
+	return reports, err
+}
+
+// ReportedShelters groups the matching reports per shelter in SQL, so only
+// the shelters of the requested page are read
+func (r gormReports) ReportedShelters(filter ReportFilter, page pagination.Page) (pagination.Result[ReportedShelter], error) {
+	reports := r.db.Table("submittedreports r").
+		Select("r.shelter_id, COUNT(*) AS total_reports, MAX(COALESCE(c.severity, ?)) AS max_severity", services.SeverityLow).
+		Joins("JOIN adopterinfo ai ON ai.adopter_id = r.adopter_id AND ai.deleted_at IS NULL").
+		Joins("LEFT JOIN report_categories c ON c.category_id = r.category_id").
+		Group("r.shelter_id")
+	if filter.Status != "" {
+		reports = reports.Where("r.status = ?", filter.Status)
+	}
+	reports = created(reports, "r.created_at", filter.Created)
+	if filter.ShelterStatus != "" {
+		reports = reports.Joins("JOIN shelteraccount sa ON sa.shelter_id = r.shelter_id AND sa.status = ? AND sa.deleted_at IS NULL", filter.ShelterStatus)
+	}
+	if len(filter.ShelterIDs) > 0 {
+		reports = reports.Where("r.shelter_id IN ?", filter.ShelterIDs)
+	}
+
+	openFlags := "FROM shelter_flags f WHERE f.shelter_id = g.shelter_id AND f.action = ? AND f.resolved_at IS NULL"
+	shelters := r.db.Table("(?) g", reports).
+		Select(`g.shelter_id, si.shelter_name, si.shelter_email, g.total_reports, g.max_severity,
+			(SELECT COUNT(*) `+openFlags+`) AS flag_priority,
+			CASE WHEN EXISTS (SELECT 1 `+openFlags+`) THEN 1 ELSE 0 END AS escalated`,
+			services.FlagRaisePriority, services.FlagEscalate).
+		Joins("JOIN shelterinfo si ON si.shelter_id = g.shelter_id AND si.deleted_at IS NULL")
+
+	return ReportedShelterSort.Query(r.db.Table("(?) reported_shelters", shelters), page)
+}
+
+func (r gormReports) Categories() (map[uint]models.ReportCategory, error) {
+	return services.ReportCategories(r.db)
+}
+
+func (r gormReports) CategoryList(includeDisabled bool) ([]models.ReportCategory, error) {
+	query := r.db.Order("severity DESC, name ASC")
+	if !includeDisabled {
+		query = query.Where("enabled = ?", true)
+	}
+	categories := []models.ReportCategory{}
+	err := query.Find(&categories).Error
+	return categories, err
+}
+
+func (r gormReports) Category(id uint) (models.ReportCategory, error) {
+	var category models.ReportCategory
+	err := r.db.Where("category_id = ?", id).First(&category).Error
+	return category, notFound(err)
+}
+
+func (r gormReports) SaveCategory(category *models.ReportCategory) error {
+	return r.db.Save(category).Error
+}
+
+func (r gormReports) Analytics(since time.Time) ([]services.CategoryAnalyticsRow, int64, error) {
+	rows, err := services.ReportCategoryAnalytics(r.db, since)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var uncategorized int64
+	query := r.db.Model(&models.SubmittedReport{}).Where("category_id IS NULL")
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+	err = query.Count(&uncategorized).Error
+	return rows, uncategorized, err
+}
+
+func (r gormReports) Credibility(adopterIDs []uint) (map[uint]services.Credibility, error) {
+	return services.ReporterCredibility(r.db, adopterIDs)
+}
+
+func (r gormReports) Attachments(reportIDs ...uint) (map[uint][]models.ReportAttachment, error) {
+	return services.ReportAttachments(r.db, reportIDs...)
+}
+
+func (r gormReports) Attachment(id uint) (models.ReportAttachment, error) {
+	return services.ReportAttachment(r.db, id)
+}
+
+func (r gormReports) Notices(reportID uint) ([]models.OutcomeNotice, error) {
+	return services.ReportNotices(r.db, reportID)
+}
+
+type gormApplications struct{ db *gorm.DB }
+
+func (r gormApplications) ByShelter(shelterID uint) ([]models.AdoptionSubmission, error) {
+	var submissions []models.AdoptionSubmission
+	err := r.db.
+		Preload("Adopter", func(db *gorm.DB) *gorm.DB {
+			return db.Select("adopter_id", "first_name", "last_name")
+		}).
+		Preload("Pet", func(db *gorm.DB) *gorm.DB {
+			return db.Select("pet_id", "pet_name")
+		}).
+		Where("shelter_id = ?", shelterID).
+		Select("application_id", "adopter_id", "pet_id", "status", "created_at").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r gormApplications) ByAdopter(adopterID uint) ([]models.AdoptionSubmission, error) {
+	var submissions []models.AdoptionSubmission
+	err := r.db.
+		Preload("Shelter", func(db *gorm.DB) *gorm.DB {
+			return db.Select("shelter_id", "shelter_name")
+		}).
+		Preload("Pet", func(db *gorm.DB) *gorm.DB {
+			return db.Select("pet_id", "pet_name")
+		}).
+		Where("adopter_id = ?", adopterID).
+		Select("application_id", "shelter_id", "pet_id", "status", "created_at").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r gormApplications) Frozen(applicationIDs []uint) (map[uint]bool, error) {
+	return services.FrozenApplicationIDs(r.db, applicationIDs)
+}
+
+type gormAdmins struct{ db *gorm.DB }
+
+func (r gormAdmins) ByUsername(username string) (models.AdminAccount, error) {
+	var admin models.AdminAccount
+	err := r.db.Where("username = ?", username).First(&admin).Error
+	return admin, notFound(err)
+}
+
+func (r gormAdmins) Create(admin *models.AdminAccount) error {
+	return r.db.Create(admin).Error
+}
+
+type gormSearch struct{ db *gorm.DB }
+
+func (r gormSearch) Search(query string, types []string, limit int) ([]services.SearchGroup, error) {
+	return services.Search(r.db, query, types, limit)
+}
+
+type gormFlags struct{ db *gorm.DB }
+
+func (r gormFlags) Rules() ([]models.FlagRule, error) {
+	rules := []models.FlagRule{}
+	err := r.db.Order("rule_id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r gormFlags) Rule(id uint) (models.FlagRule, error) {
+	var rule models.FlagRule
+	err := r.db.Where("rule_id = ?", id).First(&rule).Error
+	return rule, notFound(err)
+}
+
+func (r gormFlags) SaveRule(rule *models.FlagRule) error {
+	return r.db.Save(rule).Error
+}
+
+func (r gormFlags) DeleteRule(id uint) error {
+	result := r.db.Where("rule_id = ?", id).Delete(&models.FlagRule{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
+
+func (r gormFlags) Flags(shelterID uint, includeResolved bool) ([]models.ShelterFlag, error) {
+	query := r.db.Preload("Rule").Order("created_at DESC")
+	if !includeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if shelterID != 0 {
+		query = query.Where("shelter_id = ?", shelterID)
+	}
+	flags := []models.ShelterFlag{}
+	err := query.Find(&flags).Error
+	return flags, err
+}
+
+type gormModeration struct{ db *gorm.DB }
+
+func (r gormModeration) Queue(status, source string) ([]models.ModerationItem, error) {
+	query := r.db.Where("status = ?", status).Order("created_at ASC")
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	items := []models.ModerationItem{}
+	err := query.Find(&items).Error
+	return items, err
+}
+
+func (r gormModeration) Terms() ([]models.ScreeningTerm, error) {
+	terms := []models.ScreeningTerm{}
+	err := r.db.Order("category ASC, term_id ASC").Find(&terms).Error
+	return terms, err
+}
+
+func (r gormModeration) Term(id uint) (models.ScreeningTerm, error) {
+	var term models.ScreeningTerm
+	err := r.db.Where("term_id = ?", id).First(&term).Error
+	return term, notFound(err)
+}
+
+func (r gormModeration) SaveTerm(term *models.ScreeningTerm) error {
+	return r.db.Save(term).Error
+}
+
+func (r gormModeration) DeleteTerm(id uint) error {
+	result := r.db.Where("term_id = ?", id).Delete(&models.ScreeningTerm{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
+
+type gormAudit struct{ db *gorm.DB }
+
+func (r gormAudit) Trail(entityType string, entityID uint, limit int) ([]models.AdminAuditLog, error) {
+	return services.AuditTrail(r.db, entityType, entityID, limit)
+}
+
+func (r gormAudit) Deletions(entityType string, openOnly bool) ([]models.AccountDeletion, error) {
+	return services.AccountDeletions(r.db, entityType, openOnly)
+}
+
+type gormNotifications struct{ db *gorm.DB }
+
+func (r gormNotifications) ForRecipient(recipientType string, recipientID uint, unreadOnly bool) ([]models.Notification, error) {
+	return services.RecipientNotifications(r.db, recipientType, recipientID, unreadOnly)
+}
+
+func (r gormNotifications) MarkRead(recipientType string, recipientID, notificationID uint) error {
+	return services.MarkNotificationRead(r.db, recipientType, recipientID, notificationID)
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"pethubadmin/migrations"
+	"pethubadmin/models"
+	"pethubadmin/services"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB returns a private in-memory database with the admin tables
+func openTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(migrations.Models...); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return db
+}
+
+// seedShelters creates n approved shelters, each with a cat, a dog and a
+// rabbit, where only the dog has a vaccine record
+func seedShelters(t testing.TB, db *gorm.DB, n int) {
+	t.Helper()
+	accounts := make([]models.ShelterAccount, n)
+	infos := make([]models.ShelterInfo, n)
+	pets := make([]models.PetInfo, 0, 3*n)
+	media := make([]models.PetMedia, 0, 3*n)
+	for i := range accounts {
+		id := uint(i + 1)
+		accounts[i] = models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", id), Status: services.StatusActive, RegStatus: services.RegStatusApproved, Version: 1}
+		infos[i] = models.ShelterInfo{ShelterID: id, ShelterName: fmt.Sprintf("Shelter %d", id)}
+		for j, petType := range []string{"Cat", "dog", "rabbit"} {
+			petID := uint(3*i + j + 1)
+			pets = append(pets, models.PetInfo{PetID: petID, ShelterID: id, PetType: petType, ListingStatus: services.ListingListed})
+			vaccine := " \t\n"
+			if petType == "dog" {
+				vaccine = "rabies"
+			}
+			media = append(media, models.PetMedia{PetID: petID, PetVaccine: vaccine})
+		}
+	}
+	for _, rows := range []interface{}{&accounts, &infos, &pets, &media} {
+		if err := db.CreateInBatches(rows, 500).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+func TestShelterStats(t *testing.T) {
+	db := openTestDB(t)
+	seedShelters(t, db, 3)
+	db.Model(&models.PetInfo{}).Where("pet_id = ?", 1).Update("hidden_by", services.HoldShelterBlocked)
+	db.Create(&models.ShelterAccount{ShelterID: 4, Username: "empty", Status: services.StatusActive, Version: 1})
+	db.Create(&models.ShelterInfo{ShelterID: 4, ShelterName: "Empty"})
+
+	stats, err := NewGorm(db).Pets.ShelterStats(ShelterFilter{}, PetFilter{ListedOnly: true})
+	if err != nil {
+		t.Fatalf("ShelterStats: %v", err)
+	}
+	want := []ShelterPetStats{
+		{ShelterID: 1, ShelterName: "Shelter 1", Total: 2, Cats: 0, Dogs: 1, Vaccinated: 1},
+		{ShelterID: 2, ShelterName: "Shelter 2", Total: 3, Cats: 1, Dogs: 1, Vaccinated: 1},
+		{ShelterID: 3, ShelterName: "Shelter 3", Total: 3, Cats: 1, Dogs: 1, Vaccinated: 1},
+		{ShelterID: 4, ShelterName: "Empty"},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
+
+func TestUnlistedPetsAreNotCounted(t *testing.T) {
+	db := openTestDB(t)
+	seedShelters(t, db, 2)
+	repos := NewGorm(db)
+	// Shelter 1 loses its dog, the only vaccinated pet; a flagged cat stays
+	if _, err := services.ModeratePet(db, 2, 9, services.ListingUnlisted, "Not a real listing"); err != nil {
+		t.Fatalf("unlist: %v", err)
+	}
+	if _, err := services.ModeratePet(db, 1, 9, services.ListingFlagged, "Checking the photos"); err != nil {
+		t.Fatalf("flag: %v", err)
+	}
+
+	count, err := repos.Pets.Count(PetFilter{ListedOnly: true, ExcludeStatuses: []string{"unavailable"}})
+	if err != nil || count != 5 {
+		t.Fatalf("counted %d pets, err %v; want 5", count, err)
+	}
+
+	stats, err := repos.Pets.ShelterStats(ShelterFilter{}, PetFilter{ListedOnly: true})
+	if err != nil {
+		t.Fatalf("ShelterStats: %v", err)
+	}
+	want := []ShelterPetStats{
+		{ShelterID: 1, ShelterName: "Shelter 1", Total: 2, Cats: 1, Dogs: 0, Vaccinated: 0},
+		{ShelterID: 2, ShelterName: "Shelter 2", Total: 3, Cats: 1, Dogs: 1, Vaccinated: 1},
+	}
+	if len(stats) != len(want) || stats[0] != want[0] || stats[1] != want[1] {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+}
+
+func BenchmarkShelterStats(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		b.Run(fmt.Sprintf("shelters=%d", n), func(b *testing.B) {
+			db := openTestDB(b)
+			seedShelters(b, db, n)
+			pets := NewGorm(db).Pets
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				stats, err := pets.ShelterStats(ShelterFilter{RegStatus: services.RegStatusApproved}, PetFilter{ListedOnly: true})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(stats) != n {
+					b.Fatalf("got %d rows, want %d", len(stats), n)
+				}
+			}
+		})
+	}
+}
+
+func TestHasAccount(t *testing.T) {
+	db := openTestDB(t)
+	for _, row := range []interface{}{
+		&models.AdminAccount{AdminID: 1, Username: "admin"},
+		&models.ShelterAccount{ShelterID: 1, Username: "shelter"},
+		&models.ShelterAccount{ShelterID: 2, Username: "shelter2"},
+		&models.AdopterAccount{AdopterID: 2, Username: "ana"},
+	} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+	repos := NewGorm(db)
+
+	cases := []struct {
+		kind string
+		id   uint
+		want bool
+	}{
+		{services.EntityAdmin, 1, true},
+		{services.EntityShelter, 1, true},
+		{services.EntityAdopter, 1, false},
+		{services.EntityShelter, 2, true},
+		{services.EntityAdopter, 2, true},
+		{services.EntityAdmin, 2, false},
+		{"pet", 1, false},
+	}
+	for _, tc := range cases {
+		got, err := repos.HasAccount(tc.kind, tc.id)
+		if err != nil || got != tc.want {
+			t.Errorf("HasAccount(%s, %d) = %v, %v; want %v", tc.kind, tc.id, got, err, tc.want)
+		}
+	}
+}
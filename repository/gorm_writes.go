@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/services"
+
+	"gorm.io/gorm"
+)
+
+// NewUnitOfWork returns a unit of work over db. The repositories it hands
+// out write through one transaction, which nested services join.
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return gormUnitOfWork{db}
+}
+
+type gormUnitOfWork struct{ db *gorm.DB }
+
+func (u gormUnitOfWork) Do(fn func(tx Repositories) error) error {
+	return services.InTransaction(u.db, func(tx *gorm.DB) error {
+		repos := NewGorm(tx)
+		repos.afterCommit = func(hook func()) { services.AfterCommit(tx, hook) }
+		return fn(repos)
+	})
+}
+
+func (r gormShelters) CheckVersion(id uint, versions []uint) error {
+	return services.CheckShelterVersion(r.db, id, versions)
+}
+
+func (r gormShelters) SetStatus(id, adminID uint, status string, policy services.ShelterBlockPolicy) (services.ShelterModerationResult, error) {
+	return services.SetShelterStatus(r.db, id, adminID, status, policy)
+}
+
+func (r gormShelters) DecideRegistration(id, adminID uint, decision, reasonCode, feedback string, claimTTL time.Duration) (models.ShelterAccount, models.ShelterReviewRound, error) {
+	return services.DecideShelterRegistration(r.db, id, adminID, decision, reasonCode, feedback, claimTTL)
+}
+
+func (r gormShelters) Resubmit(id uint, update services.ShelterInfoSnapshot) (models.ShelterReviewRound, error) {
+	return services.ResubmitShelterRegistration(r.db, id, update)
+}
+
+func (r gormShelters) ClaimReview(id, adminID uint, ttl time.Duration) (models.ShelterReviewClaim, error) {
+	return services.ClaimShelterReview(r.db, id, adminID, ttl)
+}
+
+func (r gormShelters) ReleaseReview(id, adminID uint) error {
+	return services.ReleaseShelterReview(r.db, id, adminID)
+}
+
+func (r gormShelters) Block(id, adminID uint, policy services.ShelterBlockPolicy, reason string) (services.ShelterModerationResult, error) {
+	return services.BlockShelter(r.db, id, adminID, policy, reason)
+}
+
+func (r gormShelters) Reinstate(id, adminID uint) (services.ShelterModerationResult, error) {
+	return services.ReinstateShelter(r.db, id, adminID)
+}
+
+func (r gormShelters) UploadDocument(id uint, docType, fileName, fileData string) (models.ShelterDocument, error) {
+	return services.UploadShelterDocument(r.db, id, docType, fileName, fileData)
+}
+
+func (r gormShelters) ReviewDocument(documentID, adminID uint, status, reason string) (models.ShelterDocument, error) {
+	return services.ReviewShelterDocument(r.db, documentID, adminID, status, reason)
+}
+
+func (r gormShelters) DismissDuplicate(idA, idB, adminID uint) error {
+	return services.DismissDuplicate(r.db, "shelters", idA, idB, adminID)
+}
+
+func (r gormShelters) Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return services.MergeShelters(r.db, survivorID, duplicateID, adminID)
+}
+
+func (r gormShelters) SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error) {
+	return services.SoftDeleteShelter(r.db, id, adminID, reason, settings)
+}
+
+func (r gormShelters) Restore(id, adminID uint) (models.AccountDeletion, error) {
+	return services.RestoreShelter(r.db, id, adminID)
+}
+
+func (r gormShelters) Purge(id, adminID uint) (models.AccountDeletion, error) {
+	return services.PurgeShelter(r.db, id, adminID)
+}
+
+func (r gormAdopters) CheckVersion(id uint, versions []uint) error {
+	return services.CheckAdopterVersion(r.db, id, versions)
+}
+
+func (r gormAdopters) SetStatus(id, adminID uint, status string, options services.AdopterStatusOptions) (models.AdopterAccount, services.CascadeResult, error) {
+	return services.SetAdopterStatus(r.db, id, adminID, status, options)
+}
+
+func (r gormAdopters) DismissDuplicate(idA, idB, adminID uint) error {
+	return services.DismissDuplicate(r.db, "adopters", idA, idB, adminID)
+}
+
+func (r gormAdopters) Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return services.MergeAdopters(r.db, survivorID, duplicateID, adminID)
+}
+
+func (r gormAdopters) SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error) {
+	return services.SoftDeleteAdopter(r.db, id, adminID, reason, settings)
+}
+
+func (r gormAdopters) Restore(id, adminID uint) (models.AccountDeletion, error) {
+	return services.RestoreAdopter(r.db, id, adminID)
+}
+
+func (r gormAdopters) Purge(id, adminID uint) (models.AccountDeletion, error) {
+	return services.PurgeAdopter(r.db, id, adminID)
+}
+
+func (r gormPets) Moderate(id, adminID uint, to, reason string) (services.PetModerationResult, error) {
+	return services.ModeratePet(r.db, id, adminID, to, reason)
+}
+
+func (r gormReports) CheckVersion(id uint, versions []uint) error {
+	return services.CheckReportVersion(r.db, id, versions)
+}
+
+func (r gormReports) Dismiss(id, adminID uint, note string) (models.SubmittedReport, error) {
+	return services.DismissReport(r.db, id, adminID, note)
+}
+
+func (r gormReports) AddAttachment(reportID, adopterID uint, fileName, fileData string) (models.ReportAttachment, error) {
+	return services.AddReportAttachment(r.db, reportID, adopterID, fileName, fileData)
+}
+
+func (r gormReports) BackfillCategories() (int, error) {
+	return services.BackfillReportCategories(r.db)
+}
+
+func (r gormReports) DispatchNotices(channel string, smtp services.SMTPNotifier) (string, int, int, error) {
+	notifier, err := services.NewNotifier(channel, r.db, smtp)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	sent, failed, err := services.DispatchOutcomeNotices(r.db, notifier, 0)
+	return notifier.Channel(), sent, failed, err
+}
+
+func (r gormAdmins) ByID(id uint) (models.AdminAccount, error) {
+	var admin models.AdminAccount
+	err := r.db.Where("admin_id = ?", id).First(&admin).Error
+	return admin, notFound(err)
+}
+
+func (r gormFlags) Evaluate(shelterID uint) ([]models.ShelterFlag, error) {
+	return services.EvaluateShelterFlags(r.db, shelterID)
+}
+
+func (r gormFlags) EvaluateAll() (int, error) {
+	return services.EvaluateAllShelterFlags(r.db)
+}
+
+func (r gormFlags) Resolve(flagID, adminID uint) error {
+	return services.ResolveShelterFlag(r.db, flagID, adminID)
+}
+
+func (r gormModeration) Review(itemID, adminID uint, action, note string) (models.ModerationItem, error) {
+	return services.ReviewModerationItem(r.db, itemID, adminID, action, note)
+}
+
+func (r gormModeration) Scan(source services.ContentSource) (int, error) {
+	return services.ScanContentSource(r.db, source)
+}
@@ -0,0 +1,1366 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/pagination"
+	"pethubadmin/services"
+)
+
+// ErrNotSimulated is returned by the few Memory methods that need the
+// database to answer, such as full text search and flag rule evaluation
+var ErrNotSimulated = errors.New("not simulated by the in-memory repositories")
+
+// Memory keeps every table the admin handlers use in memory, so handlers
+// can be tested with no database. It is both the store behind its
+// Repositories and their UnitOfWork: a unit that fails leaves the tables as
+// they were. Reads made outside a unit see the writes of a running one.
+//
+// Writes follow the services they stand in for, with the same errors,
+// versions and audit entries. Cascades stop at pets and application holds:
+// withdrawing applications, cancelling interviews and queueing outcome
+// notices are left to the GORM repositories.
+type Memory struct {
+	// unit is held for the whole of a unit of work, mu for one method
+	unit sync.Mutex
+	mu   sync.Mutex
+	t    memoryTables
+}
+
+type memoryTables struct {
+	shelters      []models.ShelterAccount
+	shelterInfos  []models.ShelterInfo
+	shelterMedia  []models.ShelterMedia
+	adopters      []models.AdopterAccount
+	adopterInfos  []models.AdopterInfo
+	adopterMedia  []models.AdopterMedia
+	pets          []models.PetInfo
+	petMedia      []models.PetMedia
+	reports       []models.SubmittedReport
+	attachments   []models.ReportAttachment
+	categories    []models.ReportCategory
+	notices       []models.OutcomeNotice
+	noticeReports []models.OutcomeNoticeReport
+	applications  []models.AdoptionSubmission
+	holds         []models.ApplicationHold
+	admins        []models.AdminAccount
+	rules         []models.FlagRule
+	flags         []models.ShelterFlag
+	terms         []models.ScreeningTerm
+	items         []models.ModerationItem
+	audits        []models.AdminAuditLog
+	deletions     []models.AccountDeletion
+	notifications []models.Notification
+	documents     []models.ShelterDocument
+	rounds        []models.ShelterReviewRound
+	claims        []models.ShelterReviewClaim
+	dismissals    []models.DuplicateDismissal
+	merges        []models.AccountMerge
+}
+
+// clone copies every table, so changing a row of the copy leaves the
+// original alone
+func (t memoryTables) clone() memoryTables {
+	return memoryTables{
+		shelters:      cloneRows(t.shelters),
+		shelterInfos:  cloneRows(t.shelterInfos),
+		shelterMedia:  cloneRows(t.shelterMedia),
+		adopters:      cloneRows(t.adopters),
+		adopterInfos:  cloneRows(t.adopterInfos),
+		adopterMedia:  cloneRows(t.adopterMedia),
+		pets:          cloneRows(t.pets),
+		petMedia:      cloneRows(t.petMedia),
+		reports:       cloneRows(t.reports),
+		attachments:   cloneRows(t.attachments),
+		categories:    cloneRows(t.categories),
+		notices:       cloneRows(t.notices),
+		noticeReports: cloneRows(t.noticeReports),
+		applications:  cloneRows(t.applications),
+		holds:         cloneRows(t.holds),
+		admins:        cloneRows(t.admins),
+		rules:         cloneRows(t.rules),
+		flags:         cloneRows(t.flags),
+		terms:         cloneRows(t.terms),
+		items:         cloneRows(t.items),
+		audits:        cloneRows(t.audits),
+		deletions:     cloneRows(t.deletions),
+		notifications: cloneRows(t.notifications),
+		documents:     cloneRows(t.documents),
+		rounds:        cloneRows(t.rounds),
+		claims:        cloneRows(t.claims),
+		dismissals:    cloneRows(t.dismissals),
+		merges:        cloneRows(t.merges),
+	}
+}
+
+func cloneRows[T any](rows []T) []T {
+	return append([]T(nil), rows...)
+}
+
+// NewMemory returns an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Repositories returns repositories that read and write m
+func (m *Memory) Repositories() Repositories {
+	return Repositories{
+		Shelters:      memShelters{m},
+		Adopters:      memAdopters{m},
+		Pets:          memPets{m},
+		Reports:       memReports{m},
+		Applications:  memApplications{m},
+		Admins:        memAdmins{m},
+		Search:        memSearch{},
+		Flags:         memFlags{m},
+		Moderation:    memModeration{m},
+		Audit:         memAudit{m},
+		Notifications: memNotifications{m},
+	}
+}
+
+// Do runs fn as one unit: the tables go back to how they were when fn
+// fails, and the AfterCommit hooks run only when it succeeds. Units run
+// one at a time.
+func (m *Memory) Do(fn func(tx Repositories) error) error {
+	m.unit.Lock()
+	defer m.unit.Unlock()
+
+	m.mu.Lock()
+	before := m.t.clone()
+	m.mu.Unlock()
+
+	var hooks []func()
+	repos := m.Repositories()
+	repos.afterCommit = func(hook func()) { hooks = append(hooks, hook) }
+	if err := fn(repos); err != nil {
+		m.mu.Lock()
+		m.t = before
+		m.mu.Unlock()
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// Add stores rows, given as pointers to models, the way db.Create would:
+// a zero ID is assigned the next free one, a zero CreatedAt is set to now
+// and empty columns with a database default get it. Associations are not
+// stored; add them as rows of their own.
+func (m *Memory) Add(rows ...interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, row := range rows {
+		if err := m.t.insert(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert stores one row for Add and for the repository methods, which
+// already hold mu
+func (t *memoryTables) insert(row interface{}) error {
+	now := time.Now()
+	stamp := func(created *time.Time) {
+		if created.IsZero() {
+			*created = now
+		}
+	}
+	switch r := row.(type) {
+	case *models.ShelterAccount:
+		if r.ShelterID == 0 {
+			r.ShelterID = nextID(t.shelters, func(a models.ShelterAccount) uint { return a.ShelterID })
+		}
+		defaultTo(&r.Status, services.StatusActive)
+		if r.Version == 0 {
+			r.Version = 1
+		}
+		stamp(&r.CreatedAt)
+		account := *r
+		account.ShelterInfo = models.ShelterInfo{}
+		t.shelters = append(t.shelters, account)
+	case *models.ShelterInfo:
+		info := *r
+		info.ShelterMedia = models.ShelterMedia{}
+		t.shelterInfos = append(t.shelterInfos, info)
+	case *models.ShelterMedia:
+		t.shelterMedia = append(t.shelterMedia, *r)
+	case *models.AdopterAccount:
+		if r.AdopterID == 0 {
+			r.AdopterID = nextID(t.adopters, func(a models.AdopterAccount) uint { return a.AdopterID })
+		}
+		defaultTo(&r.Status, services.StatusActive)
+		if r.Version == 0 {
+			r.Version = 1
+		}
+		stamp(&r.CreatedAt)
+		t.adopters = append(t.adopters, *r)
+	case *models.AdopterInfo:
+		info := *r
+		info.AdopterMedia = models.AdopterMedia{}
+		t.adopterInfos = append(t.adopterInfos, info)
+	case *models.AdopterMedia:
+		t.adopterMedia = append(t.adopterMedia, *r)
+	case *models.PetInfo:
+		if r.PetID == 0 {
+			r.PetID = nextID(t.pets, func(p models.PetInfo) uint { return p.PetID })
+		}
+		defaultTo(&r.Status, "available")
+		defaultTo(&r.ListingStatus, services.ListingListed)
+		stamp(&r.CreatedAt)
+		pet := *r
+		pet.PetMedia = models.PetMedia{}
+		t.pets = append(t.pets, pet)
+	case *models.PetMedia:
+		t.petMedia = append(t.petMedia, *r)
+	case *models.SubmittedReport:
+		if r.ID == 0 {
+			r.ID = nextID(t.reports, func(r models.SubmittedReport) uint { return r.ID })
+		}
+		defaultTo(&r.Status, services.ReportStatusPending)
+		if r.Version == 0 {
+			r.Version = 1
+		}
+		stamp(&r.CreatedAt)
+		report := *r
+		report.Shelter, report.Adopter = models.ShelterInfo{}, models.AdopterInfo{}
+		t.reports = append(t.reports, report)
+	case *models.ReportAttachment:
+		if r.AttachmentID == 0 {
+			r.AttachmentID = nextID(t.attachments, func(a models.ReportAttachment) uint { return a.AttachmentID })
+		}
+		stamp(&r.CreatedAt)
+		t.attachments = append(t.attachments, *r)
+	case *models.ReportCategory:
+		if r.CategoryID == 0 {
+			r.CategoryID = nextID(t.categories, func(c models.ReportCategory) uint { return c.CategoryID })
+		}
+		stamp(&r.CreatedAt)
+		t.categories = append(t.categories, *r)
+	case *models.OutcomeNotice:
+		if r.NoticeID == 0 {
+			r.NoticeID = nextID(t.notices, func(n models.OutcomeNotice) uint { return n.NoticeID })
+		}
+		defaultTo(&r.Status, "pending")
+		stamp(&r.CreatedAt)
+		t.notices = append(t.notices, *r)
+	case *models.OutcomeNoticeReport:
+		t.noticeReports = append(t.noticeReports, *r)
+	case *models.AdoptionSubmission:
+		if r.ApplicationID == 0 {
+			r.ApplicationID = nextID(t.applications, func(a models.AdoptionSubmission) uint { return a.ApplicationID })
+		}
+		defaultTo(&r.Status, "pending")
+		stamp(&r.CreatedAt)
+		application := *r
+		application.Shelter, application.Adopter, application.Pet = models.ShelterInfo{}, models.AdopterInfo{}, models.PetInfo{}
+		application.ScheduleInterview = models.ScheduleInterview{}
+		t.applications = append(t.applications, application)
+	case *models.ApplicationHold:
+		stamp(&r.CreatedAt)
+		t.holds = append(t.holds, *r)
+	case *models.AdminAccount:
+		if r.AdminID == 0 {
+			r.AdminID = nextID(t.admins, func(a models.AdminAccount) uint { return a.AdminID })
+		}
+		defaultTo(&r.Role, "admin")
+		t.admins = append(t.admins, *r)
+	case *models.FlagRule:
+		if r.RuleID == 0 {
+			r.RuleID = nextID(t.rules, func(r models.FlagRule) uint { return r.RuleID })
+		}
+		stamp(&r.CreatedAt)
+		t.rules = append(t.rules, *r)
+	case *models.ShelterFlag:
+		if r.FlagID == 0 {
+			r.FlagID = nextID(t.flags, func(f models.ShelterFlag) uint { return f.FlagID })
+		}
+		stamp(&r.CreatedAt)
+		flag := *r
+		flag.Rule = models.FlagRule{}
+		t.flags = append(t.flags, flag)
+	case *models.ScreeningTerm:
+		if r.TermID == 0 {
+			r.TermID = nextID(t.terms, func(s models.ScreeningTerm) uint { return s.TermID })
+		}
+		stamp(&r.CreatedAt)
+		t.terms = append(t.terms, *r)
+	case *models.ModerationItem:
+		if r.ItemID == 0 {
+			r.ItemID = nextID(t.items, func(i models.ModerationItem) uint { return i.ItemID })
+		}
+		defaultTo(&r.Status, services.ModerationOpen)
+		stamp(&r.CreatedAt)
+		t.items = append(t.items, *r)
+	case *models.AdminAuditLog:
+		if r.AuditID == 0 {
+			r.AuditID = nextID(t.audits, func(a models.AdminAuditLog) uint { return a.AuditID })
+		}
+		stamp(&r.CreatedAt)
+		t.audits = append(t.audits, *r)
+	case *models.AccountDeletion:
+		if r.DeletionID == 0 {
+			r.DeletionID = nextID(t.deletions, func(d models.AccountDeletion) uint { return d.DeletionID })
+		}
+		t.deletions = append(t.deletions, *r)
+	case *models.Notification:
+		if r.NotificationID == 0 {
+			r.NotificationID = nextID(t.notifications, func(n models.Notification) uint { return n.NotificationID })
+		}
+		stamp(&r.CreatedAt)
+		t.notifications = append(t.notifications, *r)
+	case *models.ShelterDocument:
+		if r.DocumentID == 0 {
+			r.DocumentID = nextID(t.documents, func(d models.ShelterDocument) uint { return d.DocumentID })
+		}
+		stamp(&r.CreatedAt)
+		t.documents = append(t.documents, *r)
+	case *models.ShelterReviewRound:
+		if r.RoundID == 0 {
+			r.RoundID = nextID(t.rounds, func(r models.ShelterReviewRound) uint { return r.RoundID })
+		}
+		defaultTo(&r.Decision, services.RegStatusPending)
+		t.rounds = append(t.rounds, *r)
+	case *models.ShelterReviewClaim:
+		t.claims = append(t.claims, *r)
+	case *models.DuplicateDismissal:
+		if r.DismissalID == 0 {
+			r.DismissalID = nextID(t.dismissals, func(d models.DuplicateDismissal) uint { return d.DismissalID })
+		}
+		stamp(&r.CreatedAt)
+		t.dismissals = append(t.dismissals, *r)
+	case *models.AccountMerge:
+		if r.MergeID == 0 {
+			r.MergeID = nextID(t.merges, func(a models.AccountMerge) uint { return a.MergeID })
+		}
+		stamp(&r.CreatedAt)
+		t.merges = append(t.merges, *r)
+	default:
+		return fmt.Errorf("memory store has no table for %T", row)
+	}
+	return nil
+}
+
+func defaultTo(value *string, def string) {
+	if *value == "" {
+		*value = def
+	}
+}
+
+// nextID returns one more than the largest ID in rows
+func nextID[T any](rows []T, id func(T) uint) uint {
+	var max uint
+	for _, row := range rows {
+		if id(row) > max {
+			max = id(row)
+		}
+	}
+	return max + 1
+}
+
+// filterRows returns the rows keep accepts, never nil
+func filterRows[T any](rows []T, keep func(T) bool) []T {
+	kept := []T{}
+	for _, row := range rows {
+		if keep(row) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+// findRow returns a pointer to the first row match accepts, or nil
+func findRow[T any](rows []T, match func(T) bool) *T {
+	for i := range rows {
+		if match(rows[i]) {
+			return &rows[i]
+		}
+	}
+	return nil
+}
+
+// newestFirst sorts rows by created, newest first, keeping the insertion
+// order of ties
+func newestFirst[T any](rows []T, created func(T) time.Time) {
+	sort.SliceStable(rows, func(i, j int) bool { return created(rows[i]).After(created(rows[j])) })
+}
+
+// oldestFirst is newestFirst the other way round
+func oldestFirst[T any](rows []T, created func(T) time.Time) {
+	sort.SliceStable(rows, func(i, j int) bool { return created(rows[i]).Before(created(rows[j])) })
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// inRange reports whether created falls in r
+func inRange(created time.Time, r CreatedRange) bool {
+	return (r.From.IsZero() || !created.Before(r.From)) && (r.To.IsZero() || created.Before(r.To))
+}
+
+func (t *memoryTables) shelter(id uint) *models.ShelterAccount {
+	return findRow(t.shelters, func(a models.ShelterAccount) bool { return a.ShelterID == id && !a.DeletedAt.Valid })
+}
+
+func (t *memoryTables) shelterInfo(id uint) *models.ShelterInfo {
+	return findRow(t.shelterInfos, func(i models.ShelterInfo) bool { return i.ShelterID == id && !i.DeletedAt.Valid })
+}
+
+func (t *memoryTables) adopter(id uint) *models.AdopterAccount {
+	return findRow(t.adopters, func(a models.AdopterAccount) bool { return a.AdopterID == id && !a.DeletedAt.Valid })
+}
+
+func (t *memoryTables) adopterInfo(id uint) *models.AdopterInfo {
+	return findRow(t.adopterInfos, func(i models.AdopterInfo) bool { return i.AdopterID == id && !i.DeletedAt.Valid })
+}
+
+func (t *memoryTables) pet(id uint) *models.PetInfo {
+	return findRow(t.pets, func(p models.PetInfo) bool { return p.PetID == id })
+}
+
+func (t *memoryTables) report(id uint) *models.SubmittedReport {
+	return findRow(t.reports, func(r models.SubmittedReport) bool { return r.ID == id })
+}
+
+// withMedia attaches the pet's media, as Preload("PetMedia") does
+func (t *memoryTables) withMedia(pet models.PetInfo) models.PetInfo {
+	if media := findRow(t.petMedia, func(m models.PetMedia) bool { return m.PetID == pet.PetID }); media != nil {
+		pet.PetMedia = *media
+	}
+	return pet
+}
+
+// withParties attaches the report's shelter and reporter profiles
+func (t *memoryTables) withParties(report models.SubmittedReport) models.SubmittedReport {
+	if info := t.shelterInfo(report.ShelterID); info != nil {
+		report.Shelter = *info
+	}
+	if info := t.adopterInfo(report.AdopterID); info != nil {
+		report.Adopter = *info
+	}
+	return report
+}
+
+// flagHoldUntil returns the end of the shelter's running flag hold, or nil
+func (t *memoryTables) flagHoldUntil(shelterID uint, now time.Time) *time.Time {
+	var until *time.Time
+	for _, flag := range t.flags {
+		if flag.ShelterID == shelterID && flag.Action == services.FlagTemporaryHold && flag.ResolvedAt == nil &&
+			flag.HoldUntil != nil && flag.HoldUntil.After(now) && (until == nil || flag.HoldUntil.After(*until)) {
+			until = flag.HoldUntil
+		}
+	}
+	return until
+}
+
+type memShelters struct{ m *Memory }
+
+func (r memShelters) filtered(filter ShelterFilter) []models.ShelterAccount {
+	t := &r.m.t
+	accounts := filterRows(t.shelters, func(a models.ShelterAccount) bool {
+		return !a.DeletedAt.Valid &&
+			(filter.Status == "" || a.Status == filter.Status) &&
+			(filter.RegStatus == "" || a.RegStatus == filter.RegStatus) &&
+			(!filter.HasProfile || t.shelterInfo(a.ShelterID) != nil) &&
+			inRange(a.CreatedAt, filter.Created)
+	})
+	if filter.NewestFirst {
+		newestFirst(accounts, func(a models.ShelterAccount) time.Time { return a.CreatedAt })
+	}
+	return accounts
+}
+
+func (r memShelters) Account(id uint) (models.ShelterAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if account := r.m.t.shelter(id); account != nil {
+		return *account, nil
+	}
+	return models.ShelterAccount{}, ErrNotFound
+}
+
+func (r memShelters) Accounts(filter ShelterFilter) ([]models.ShelterAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.filtered(filter), nil
+}
+
+func (r memShelters) CountAccounts(filter ShelterFilter) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return int64(len(r.filtered(filter))), nil
+}
+
+func (r memShelters) AccountPage(filter ShelterFilter, page pagination.Page) (pagination.Result[models.ShelterAccount], error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return ShelterSort.Slice(r.filtered(filter), page)
+}
+
+func (r memShelters) Infos(ids ...uint) ([]models.ShelterInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return filterRows(r.m.t.shelterInfos, func(i models.ShelterInfo) bool {
+		return !i.DeletedAt.Valid && (len(ids) == 0 || containsID(ids, i.ShelterID))
+	}), nil
+}
+
+func (r memShelters) Info(id uint) (models.ShelterInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	info := r.m.t.shelterInfo(id)
+	if info == nil {
+		return models.ShelterInfo{}, ErrNotFound
+	}
+	withMedia := *info
+	if media := findRow(r.m.t.shelterMedia, func(m models.ShelterMedia) bool { return m.ShelterID == id && !m.DeletedAt.Valid }); media != nil {
+		withMedia.ShelterMedia = *media
+	}
+	return withMedia, nil
+}
+
+func (r memShelters) Media(ids ...uint) (map[uint]models.ShelterMedia, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	byShelter := make(map[uint]models.ShelterMedia)
+	for _, media := range r.m.t.shelterMedia {
+		if !media.DeletedAt.Valid && containsID(ids, media.ShelterID) {
+			byShelter[media.ShelterID] = media
+		}
+	}
+	return byShelter, nil
+}
+
+func (r memShelters) Documents(ids ...uint) ([]models.ShelterDocument, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.documents(ids...), nil
+}
+
+// documents lists the shelters' uploads without their file data, newest first
+func (r memShelters) documents(ids ...uint) []models.ShelterDocument {
+	docs := filterRows(r.m.t.documents, func(d models.ShelterDocument) bool { return containsID(ids, d.ShelterID) })
+	for i := range docs {
+		docs[i].FileData = ""
+	}
+	newestFirst(docs, func(d models.ShelterDocument) time.Time { return d.CreatedAt })
+	return docs
+}
+
+func (r memShelters) Document(id uint) (models.ShelterDocument, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if doc := findRow(r.m.t.documents, func(d models.ShelterDocument) bool { return d.DocumentID == id }); doc != nil {
+		return *doc, nil
+	}
+	return models.ShelterDocument{}, ErrNotFound
+}
+
+func (r memShelters) FlagSummaries(ids []uint) (map[uint]services.ShelterFlagSummary, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	now := time.Now()
+	summaries := make(map[uint]services.ShelterFlagSummary)
+	for _, flag := range r.m.t.flags {
+		if flag.ResolvedAt != nil || !containsID(ids, flag.ShelterID) {
+			continue
+		}
+		summary := summaries[flag.ShelterID]
+		summary.OpenFlags++
+		switch flag.Action {
+		case services.FlagRaisePriority:
+			summary.Priority++
+		case services.FlagEscalate:
+			summary.Escalated = true
+		case services.FlagTemporaryHold:
+			if flag.HoldUntil != nil && flag.HoldUntil.After(now) &&
+				(summary.HoldUntil == nil || flag.HoldUntil.After(*summary.HoldUntil)) {
+				summary.HoldUntil = flag.HoldUntil
+			}
+		}
+		summaries[flag.ShelterID] = summary
+	}
+	return summaries, nil
+}
+
+func (r memShelters) ReviewHistory(id uint) ([]services.ReviewRoundView, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	rounds := filterRows(r.m.t.rounds, func(round models.ShelterReviewRound) bool { return round.ShelterID == id })
+	sort.SliceStable(rounds, func(i, j int) bool { return rounds[i].Round < rounds[j].Round })
+	return services.ReviewRoundViews(rounds), nil
+}
+
+func (r memShelters) ReviewQueue(settings services.ReviewQueueSettings) ([]services.QueueItem, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	pending := filterRows(t.shelters, func(a models.ShelterAccount) bool {
+		return !a.DeletedAt.Valid && a.RegStatus == services.RegStatusPending
+	})
+	ids := make([]uint, len(pending))
+	for i := range pending {
+		ids[i] = pending[i].ShelterID
+		if info := t.shelterInfo(pending[i].ShelterID); info != nil {
+			pending[i].ShelterInfo = *info
+		}
+	}
+	rounds := filterRows(t.rounds, func(round models.ShelterReviewRound) bool {
+		return containsID(ids, round.ShelterID) && round.Decision == services.RegStatusPending
+	})
+	now := time.Now()
+	claims := filterRows(t.claims, func(claim models.ShelterReviewClaim) bool {
+		return containsID(ids, claim.ShelterID) && claim.ExpiresAt.After(now)
+	})
+	return services.BuildReviewQueue(pending, rounds, claims, settings, now), nil
+}
+
+func (r memShelters) ReviewerThroughput(since time.Time) ([]services.ReviewerStats, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	byAdmin := make(map[uint]*services.ReviewerStats)
+	hours := make(map[uint]float64)
+	var stats []services.ReviewerStats
+	var order []uint
+	for _, round := range t.rounds {
+		if round.ReviewedAt == nil || round.ReviewedAt.Before(since) {
+			continue
+		}
+		s, ok := byAdmin[round.ReviewedBy]
+		if !ok {
+			s = &services.ReviewerStats{AdminID: round.ReviewedBy}
+			if admin := findRow(t.admins, func(a models.AdminAccount) bool { return a.AdminID == round.ReviewedBy }); admin != nil {
+				s.Username = admin.Username
+			}
+			byAdmin[round.ReviewedBy] = s
+			order = append(order, round.ReviewedBy)
+		}
+		s.Decisions++
+		switch round.Decision {
+		case services.RegStatusApproved:
+			s.Approved++
+		case services.RegStatusRejected:
+			s.Rejected++
+		}
+		if round.EscalatedAt != nil {
+			s.EscalatedClosed++
+		}
+		hours[round.ReviewedBy] += round.ReviewedAt.Sub(round.SubmittedAt).Hours()
+	}
+	for _, id := range order {
+		s := *byAdmin[id]
+		s.AvgReviewHours = math.Round(hours[id]/float64(s.Decisions)*100) / 100
+		stats = append(stats, s)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Decisions > stats[j].Decisions })
+	return stats, nil
+}
+
+func (r memShelters) Duplicates(minScore float64) ([]services.DuplicateCandidate, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	var profiles []services.DuplicateProfile
+	for _, info := range t.shelterInfos {
+		account := t.shelter(info.ShelterID)
+		if info.DeletedAt.Valid || account == nil {
+			continue
+		}
+		profiles = append(profiles, services.NewDuplicateProfile(info.ShelterID, info.ShelterName,
+			info.ShelterEmail, info.ShelterContact, info.ShelterAddress, account.Status))
+	}
+	return r.m.t.scoreDuplicates("shelters", profiles, minScore), nil
+}
+
+func (t *memoryTables) scoreDuplicates(entityType string, profiles []services.DuplicateProfile, minScore float64) []services.DuplicateCandidate {
+	dismissals := filterRows(t.dismissals, func(d models.DuplicateDismissal) bool { return d.EntityType == entityType })
+	merges := filterRows(t.merges, func(m models.AccountMerge) bool { return m.EntityType == entityType })
+	return services.ScoreDuplicates(profiles, dismissals, merges, minScore)
+}
+
+type memAdopters struct{ m *Memory }
+
+func (r memAdopters) filtered(filter AdopterFilter) []models.AdopterAccount {
+	t := &r.m.t
+	return filterRows(t.adopters, func(a models.AdopterAccount) bool {
+		return !a.DeletedAt.Valid &&
+			(filter.Status == "" || a.Status == filter.Status) &&
+			(!filter.HasProfile || t.adopterInfo(a.AdopterID) != nil) &&
+			inRange(a.CreatedAt, filter.Created)
+	})
+}
+
+func (r memAdopters) Account(id uint) (models.AdopterAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if account := r.m.t.adopter(id); account != nil {
+		return *account, nil
+	}
+	return models.AdopterAccount{}, ErrNotFound
+}
+
+func (r memAdopters) Accounts(filter AdopterFilter) ([]models.AdopterAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.filtered(filter), nil
+}
+
+func (r memAdopters) CountAccounts(filter AdopterFilter) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return int64(len(r.filtered(filter))), nil
+}
+
+func (r memAdopters) AccountPage(filter AdopterFilter, page pagination.Page) (pagination.Result[models.AdopterAccount], error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return AdopterSort.Slice(r.filtered(filter), page)
+}
+
+func (r memAdopters) Infos(ids ...uint) ([]models.AdopterInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return filterRows(r.m.t.adopterInfos, func(i models.AdopterInfo) bool {
+		return !i.DeletedAt.Valid && (len(ids) == 0 || containsID(ids, i.AdopterID))
+	}), nil
+}
+
+func (r memAdopters) Info(id uint) (models.AdopterInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	info := r.m.t.adopterInfo(id)
+	if info == nil {
+		return models.AdopterInfo{}, ErrNotFound
+	}
+	withMedia := *info
+	if media := findRow(r.m.t.adopterMedia, func(m models.AdopterMedia) bool { return m.AdopterID == id && !m.DeletedAt.Valid }); media != nil {
+		withMedia.AdopterMedia = *media
+	}
+	return withMedia, nil
+}
+
+func (r memAdopters) Duplicates(minScore float64) ([]services.DuplicateCandidate, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	var profiles []services.DuplicateProfile
+	for _, info := range t.adopterInfos {
+		account := t.adopter(info.AdopterID)
+		if info.DeletedAt.Valid || account == nil {
+			continue
+		}
+		profiles = append(profiles, services.NewDuplicateProfile(info.AdopterID, info.FirstName+" "+info.LastName,
+			info.Email, info.ContactNumber, info.Address, account.Status))
+	}
+	return t.scoreDuplicates("adopters", profiles, minScore), nil
+}
+
+type memPets struct{ m *Memory }
+
+// matches applies a pet filter the way gormPets.filtered does
+func (filter PetFilter) matches(pet models.PetInfo) bool {
+	return (!filter.ListedOnly || (pet.ListingStatus != services.ListingUnlisted && pet.HiddenBy == "")) &&
+		(filter.ShelterID == 0 || pet.ShelterID == filter.ShelterID) &&
+		(filter.Status == "" || pet.Status == filter.Status) &&
+		!containsString(filter.ExcludeStatuses, pet.Status) &&
+		(filter.PetType == "" || strings.EqualFold(pet.PetType, filter.PetType)) &&
+		inRange(pet.CreatedAt, filter.Created)
+}
+
+func (r memPets) filtered(filter PetFilter) []models.PetInfo {
+	pets := filterRows(r.m.t.pets, filter.matches)
+	for i := range pets {
+		pets[i] = r.m.t.withMedia(pets[i])
+	}
+	return pets
+}
+
+func (r memPets) Pet(id uint) (models.PetInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if pet := r.m.t.pet(id); pet != nil {
+		return r.m.t.withMedia(*pet), nil
+	}
+	return models.PetInfo{}, ErrNotFound
+}
+
+func (r memPets) Pets(filter PetFilter) ([]models.PetInfo, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return r.filtered(filter), nil
+}
+
+func (r memPets) Count(filter PetFilter) (int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return int64(len(filterRows(r.m.t.pets, filter.matches))), nil
+}
+
+func (r memPets) Page(filter PetFilter, page pagination.Page) (pagination.Result[models.PetInfo], error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	return PetSort.Slice(r.filtered(filter), page)
+}
+
+func (r memPets) ShelterStats(shelters ShelterFilter, pets PetFilter) ([]ShelterPetStats, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	accounts := filterRows(t.shelters, func(a models.ShelterAccount) bool {
+		return !a.DeletedAt.Valid && t.shelterInfo(a.ShelterID) != nil &&
+			(shelters.Status == "" || a.Status == shelters.Status) &&
+			(shelters.RegStatus == "" || a.RegStatus == shelters.RegStatus) &&
+			inRange(a.CreatedAt, shelters.Created)
+	})
+	sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].ShelterID < accounts[j].ShelterID })
+	if shelters.NewestFirst {
+		newestFirst(accounts, func(a models.ShelterAccount) time.Time { return a.CreatedAt })
+	}
+
+	stats := make([]ShelterPetStats, 0, len(accounts))
+	for _, account := range accounts {
+		row := ShelterPetStats{ShelterID: account.ShelterID, ShelterName: t.shelterInfo(account.ShelterID).ShelterName}
+		for _, pet := range t.pets {
+			if pet.ShelterID != account.ShelterID || !pets.matches(pet) {
+				continue
+			}
+			row.Total++
+			switch strings.ToLower(pet.PetType) {
+			case "cat":
+				row.Cats++
+			case "dog":
+				row.Dogs++
+			}
+			if findRow(t.petMedia, func(m models.PetMedia) bool {
+				return m.PetID == pet.PetID && strings.TrimLeft(m.PetVaccine, " \t\r\n") != ""
+			}) != nil {
+				row.Vaccinated++
+			}
+		}
+		stats = append(stats, row)
+	}
+	return stats, nil
+}
+
+func (r memPets) ModerationHistory(shelterID uint) ([]services.PetModerationEntry, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	audits := filterRows(t.audits, func(a models.AdminAuditLog) bool {
+		pet := t.pet(a.EntityID)
+		return a.EntityType == services.EntityPet && a.Action == services.ActionPetList && pet != nil && pet.ShelterID == shelterID
+	})
+	newestFirst(audits, func(a models.AdminAuditLog) time.Time { return a.CreatedAt })
+	entries := make([]services.PetModerationEntry, len(audits))
+	for i, a := range audits {
+		entries[i] = services.PetModerationEntry{PetID: a.EntityID, FromStatus: a.FromStatus, ToStatus: a.ToStatus, Reason: a.Details, CreatedAt: a.CreatedAt}
+	}
+	return entries, nil
+}
+
+type memReports struct{ m *Memory }
+
+// matches applies the filter's own columns; ShelterStatus needs the tables
+func (filter ReportFilter) matches(t *memoryTables, report models.SubmittedReport) bool {
+	if filter.ShelterStatus != "" {
+		if account := t.shelter(report.ShelterID); account == nil || account.Status != filter.ShelterStatus {
+			return false
+		}
+	}
+	return (filter.Status == "" || report.Status == filter.Status) &&
+		(len(filter.ShelterIDs) == 0 || containsID(filter.ShelterIDs, report.ShelterID)) &&
+		inRange(report.CreatedAt, filter.Created)
+}
+
+func (r memReports) Report(id uint) (models.SubmittedReport, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if report := r.m.t.report(id); report != nil {
+		return r.m.t.withParties(*report), nil
+	}
+	return models.SubmittedReport{}, ErrNotFound
+}
+
+func (r memReports) Reports(filter ReportFilter) ([]models.SubmittedReport, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	reports := filterRows(t.reports, func(report models.SubmittedReport) bool { return filter.matches(t, report) })
+	for i := range reports {
+		reports[i] = t.withParties(reports[i])
+	}
+	if filter.NewestFirst {
+		newestFirst(reports, func(r models.SubmittedReport) time.Time { return r.CreatedAt })
+	}
+	return reports, nil
+}
+
+func (r memReports) ReportedShelters(filter ReportFilter, page pagination.Page) (pagination.Result[ReportedShelter], error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	byShelter := make(map[uint]*ReportedShelter)
+	var order []uint
+	for _, report := range t.reports {
+		if t.adopterInfo(report.AdopterID) == nil || !filter.matches(t, report) {
+			continue
+		}
+		group, ok := byShelter[report.ShelterID]
+		if !ok {
+			info := t.shelterInfo(report.ShelterID)
+			if info == nil {
+				continue
+			}
+			group = &ReportedShelter{ShelterID: report.ShelterID, ShelterName: info.ShelterName, ShelterEmail: info.ShelterEmail, MaxSeverity: services.SeverityLow}
+			byShelter[report.ShelterID] = group
+			order = append(order, report.ShelterID)
+		}
+		group.TotalReports++
+		if report.CategoryID != nil {
+			category := findRow(t.categories, func(c models.ReportCategory) bool { return c.CategoryID == *report.CategoryID })
+			if category != nil && category.Severity > group.MaxSeverity {
+				group.MaxSeverity = category.Severity
+			}
+		}
+	}
+
+	shelters := make([]ReportedShelter, 0, len(order))
+	for _, id := range order {
+		group := byShelter[id]
+		for _, flag := range t.flags {
+			if flag.ShelterID != id || flag.ResolvedAt != nil {
+				continue
+			}
+			switch flag.Action {
+			case services.FlagRaisePriority:
+				group.FlagPriority++
+			case services.FlagEscalate:
+				group.Escalated = true
+			}
+		}
+		shelters = append(shelters, *group)
+	}
+	return ReportedShelterSort.Slice(shelters, page)
+}
+
+func (r memReports) Categories() (map[uint]models.ReportCategory, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	byID := make(map[uint]models.ReportCategory, len(r.m.t.categories))
+	for _, category := range r.m.t.categories {
+		byID[category.CategoryID] = category
+	}
+	return byID, nil
+}
+
+func (r memReports) CategoryList(includeDisabled bool) ([]models.ReportCategory, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	categories := filterRows(r.m.t.categories, func(c models.ReportCategory) bool { return includeDisabled || c.Enabled })
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Severity != categories[j].Severity {
+			return categories[i].Severity > categories[j].Severity
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+func (r memReports) Category(id uint) (models.ReportCategory, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if category := findRow(r.m.t.categories, func(c models.ReportCategory) bool { return c.CategoryID == id }); category != nil {
+		return *category, nil
+	}
+	return models.ReportCategory{}, ErrNotFound
+}
+
+func (r memReports) SaveCategory(category *models.ReportCategory) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	category.UpdatedAt = time.Now()
+	if stored := findRow(r.m.t.categories, func(c models.ReportCategory) bool { return c.CategoryID == category.CategoryID }); stored != nil && category.CategoryID != 0 {
+		*stored = *category
+		return nil
+	}
+	return r.m.t.insert(category)
+}
+
+func (r memReports) Analytics(since time.Time) ([]services.CategoryAnalyticsRow, int64, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	rows := make([]services.CategoryAnalyticsRow, 0, len(t.categories))
+	for _, category := range t.categories {
+		row := services.CategoryAnalyticsRow{CategoryID: category.CategoryID, Code: category.Code, Name: category.Name, Severity: category.Severity}
+		shelters := make(map[uint]bool)
+		for _, report := range t.reports {
+			if report.CategoryID == nil || *report.CategoryID != category.CategoryID || report.CreatedAt.Before(since) {
+				continue
+			}
+			row.Total++
+			switch report.Status {
+			case services.ReportStatusReported:
+				row.Reported++
+			case services.ReportStatusBlocked:
+				row.Blocked++
+			case services.ReportStatusResolved:
+				row.Resolved++
+			}
+			shelters[report.ShelterID] = true
+		}
+		row.Shelters = int64(len(shelters))
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Severity != rows[j].Severity {
+			return rows[i].Severity > rows[j].Severity
+		}
+		return rows[i].Total > rows[j].Total
+	})
+
+	var uncategorized int64
+	for _, report := range t.reports {
+		if report.CategoryID == nil && !report.CreatedAt.Before(since) {
+			uncategorized++
+		}
+	}
+	return rows, uncategorized, nil
+}
+
+func (r memReports) Credibility(adopterIDs []uint) (map[uint]services.Credibility, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	counts := make(map[uint]*services.Credibility, len(adopterIDs))
+	for _, id := range adopterIDs {
+		counts[id] = &services.Credibility{}
+	}
+	for _, report := range r.m.t.reports {
+		c, ok := counts[report.AdopterID]
+		if !ok {
+			continue
+		}
+		switch {
+		case containsString(services.ActionedReportStatuses, report.Status):
+			c.Actioned++
+		case report.Status == services.ReportStatusDismissed:
+			c.Dismissed++
+		case report.Status == services.ReportStatusReported:
+			c.Pending++
+		}
+	}
+
+	credibility := make(map[uint]services.Credibility, len(counts))
+	for id, c := range counts {
+		c.Score = services.CredibilityScore(c.Actioned, c.Dismissed)
+		credibility[id] = *c
+	}
+	return credibility, nil
+}
+
+func (r memReports) Attachments(reportIDs ...uint) (map[uint][]models.ReportAttachment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	attachments := filterRows(r.m.t.attachments, func(a models.ReportAttachment) bool { return containsID(reportIDs, a.ReportID) })
+	oldestFirst(attachments, func(a models.ReportAttachment) time.Time { return a.CreatedAt })
+	byReport := make(map[uint][]models.ReportAttachment)
+	for _, attachment := range attachments {
+		attachment.FileData = ""
+		byReport[attachment.ReportID] = append(byReport[attachment.ReportID], attachment)
+	}
+	return byReport, nil
+}
+
+func (r memReports) Attachment(id uint) (models.ReportAttachment, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if attachment := findRow(r.m.t.attachments, func(a models.ReportAttachment) bool { return a.AttachmentID == id }); attachment != nil {
+		return *attachment, nil
+	}
+	return models.ReportAttachment{}, services.ErrAttachmentNotFound
+}
+
+func (r memReports) Notices(reportID uint) ([]models.OutcomeNotice, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	notices := filterRows(t.notices, func(n models.OutcomeNotice) bool {
+		return findRow(t.noticeReports, func(link models.OutcomeNoticeReport) bool {
+			return link.NoticeID == n.NoticeID && link.ReportID == reportID
+		}) != nil
+	})
+	oldestFirst(notices, func(n models.OutcomeNotice) time.Time { return n.CreatedAt })
+	return notices, nil
+}
+
+type memApplications struct{ m *Memory }
+
+// ByShelter fills in the same columns as the GORM query selects
+func (r memApplications) ByShelter(shelterID uint) ([]models.AdoptionSubmission, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	submissions := []models.AdoptionSubmission{}
+	for _, a := range t.applications {
+		if a.ShelterID != shelterID {
+			continue
+		}
+		submission := models.AdoptionSubmission{ApplicationID: a.ApplicationID, AdopterID: a.AdopterID, PetID: a.PetID, Status: a.Status, CreatedAt: a.CreatedAt}
+		if info := t.adopterInfo(a.AdopterID); info != nil {
+			submission.Adopter = models.AdopterInfo{AdopterID: info.AdopterID, FirstName: info.FirstName, LastName: info.LastName}
+		}
+		if pet := t.pet(a.PetID); pet != nil {
+			submission.Pet = models.PetInfo{PetID: pet.PetID, PetName: pet.PetName}
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions, nil
+}
+
+func (r memApplications) ByAdopter(adopterID uint) ([]models.AdoptionSubmission, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	submissions := []models.AdoptionSubmission{}
+	for _, a := range t.applications {
+		if a.AdopterID != adopterID {
+			continue
+		}
+		submission := models.AdoptionSubmission{ApplicationID: a.ApplicationID, ShelterID: a.ShelterID, PetID: a.PetID, Status: a.Status, CreatedAt: a.CreatedAt}
+		if info := t.shelterInfo(a.ShelterID); info != nil {
+			submission.Shelter = models.ShelterInfo{ShelterID: info.ShelterID, ShelterName: info.ShelterName}
+		}
+		if pet := t.pet(a.PetID); pet != nil {
+			submission.Pet = models.PetInfo{PetID: pet.PetID, PetName: pet.PetName}
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions, nil
+}
+
+func (r memApplications) Frozen(applicationIDs []uint) (map[uint]bool, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	frozen := make(map[uint]bool)
+	for _, hold := range r.m.t.holds {
+		if containsID(applicationIDs, hold.ApplicationID) {
+			frozen[hold.ApplicationID] = true
+		}
+	}
+	return frozen, nil
+}
+
+type memAdmins struct{ m *Memory }
+
+func (r memAdmins) ByUsername(username string) (models.AdminAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if admin := findRow(r.m.t.admins, func(a models.AdminAccount) bool { return a.Username == username }); admin != nil {
+		return *admin, nil
+	}
+	return models.AdminAccount{}, ErrNotFound
+}
+
+func (r memAdmins) Create(admin *models.AdminAccount) error {
+	return r.m.Add(admin)
+}
+
+type memSearch struct{}
+
+// Search ranks with Postgres full text search, which has no in-memory
+// counterpart
+func (memSearch) Search(string, []string, int) ([]services.SearchGroup, error) {
+	return nil, ErrNotSimulated
+}
+
+type memFlags struct{ m *Memory }
+
+func (r memFlags) Rules() ([]models.FlagRule, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	rules := cloneRows(r.m.t.rules)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].RuleID < rules[j].RuleID })
+	if rules == nil {
+		rules = []models.FlagRule{}
+	}
+	return rules, nil
+}
+
+func (r memFlags) Rule(id uint) (models.FlagRule, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if rule := findRow(r.m.t.rules, func(rule models.FlagRule) bool { return rule.RuleID == id }); rule != nil {
+		return *rule, nil
+	}
+	return models.FlagRule{}, ErrNotFound
+}
+
+func (r memFlags) SaveRule(rule *models.FlagRule) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	rule.UpdatedAt = time.Now()
+	if stored := findRow(r.m.t.rules, func(stored models.FlagRule) bool { return stored.RuleID == rule.RuleID }); stored != nil && rule.RuleID != 0 {
+		*stored = *rule
+		return nil
+	}
+	return r.m.t.insert(rule)
+}
+
+func (r memFlags) DeleteRule(id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := filterRows(r.m.t.rules, func(rule models.FlagRule) bool { return rule.RuleID != id })
+	if len(kept) == len(r.m.t.rules) {
+		return ErrNotFound
+	}
+	r.m.t.rules = kept
+	return nil
+}
+
+func (r memFlags) Flags(shelterID uint, includeResolved bool) ([]models.ShelterFlag, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	t := &r.m.t
+	flags := filterRows(t.flags, func(f models.ShelterFlag) bool {
+		return (includeResolved || f.ResolvedAt == nil) && (shelterID == 0 || f.ShelterID == shelterID)
+	})
+	for i := range flags {
+		if rule := findRow(t.rules, func(rule models.FlagRule) bool { return rule.RuleID == flags[i].RuleID }); rule != nil {
+			flags[i].Rule = *rule
+		}
+	}
+	newestFirst(flags, func(f models.ShelterFlag) time.Time { return f.CreatedAt })
+	return flags, nil
+}
+
+type memModeration struct{ m *Memory }
+
+func (r memModeration) Queue(status, source string) ([]models.ModerationItem, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	items := filterRows(r.m.t.items, func(i models.ModerationItem) bool {
+		return i.Status == status && (source == "" || i.Source == source)
+	})
+	oldestFirst(items, func(i models.ModerationItem) time.Time { return i.CreatedAt })
+	return items, nil
+}
+
+func (r memModeration) Terms() ([]models.ScreeningTerm, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	terms := filterRows(r.m.t.terms, func(models.ScreeningTerm) bool { return true })
+	sort.SliceStable(terms, func(i, j int) bool {
+		if terms[i].Category != terms[j].Category {
+			return terms[i].Category < terms[j].Category
+		}
+		return terms[i].TermID < terms[j].TermID
+	})
+	return terms, nil
+}
+
+func (r memModeration) Term(id uint) (models.ScreeningTerm, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if term := findRow(r.m.t.terms, func(term models.ScreeningTerm) bool { return term.TermID == id }); term != nil {
+		return *term, nil
+	}
+	return models.ScreeningTerm{}, ErrNotFound
+}
+
+func (r memModeration) SaveTerm(term *models.ScreeningTerm) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if stored := findRow(r.m.t.terms, func(stored models.ScreeningTerm) bool { return stored.TermID == term.TermID }); stored != nil && term.TermID != 0 {
+		*stored = *term
+		return nil
+	}
+	return r.m.t.insert(term)
+}
+
+func (r memModeration) DeleteTerm(id uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	kept := filterRows(r.m.t.terms, func(term models.ScreeningTerm) bool { return term.TermID != id })
+	if len(kept) == len(r.m.t.terms) {
+		return ErrNotFound
+	}
+	r.m.t.terms = kept
+	return nil
+}
+
+type memAudit struct{ m *Memory }
+
+func (r memAudit) Trail(entityType string, entityID uint, limit int) ([]models.AdminAuditLog, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	logs := filterRows(r.m.t.audits, func(a models.AdminAuditLog) bool {
+		return (entityType == "" || a.EntityType == entityType) && (entityID == 0 || a.EntityID == entityID)
+	})
+	newestFirst(logs, func(a models.AdminAuditLog) time.Time { return a.CreatedAt })
+	if limit > 0 && len(logs) > limit {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (r memAudit) Deletions(entityType string, openOnly bool) ([]models.AccountDeletion, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	deletions := filterRows(r.m.t.deletions, func(d models.AccountDeletion) bool {
+		return (entityType == "" || d.EntityType == entityType) && (!openOnly || (d.RestoredAt == nil && d.PurgedAt == nil))
+	})
+	newestFirst(deletions, func(d models.AccountDeletion) time.Time { return d.DeletedAt })
+	return deletions, nil
+}
+
+type memNotifications struct{ m *Memory }
+
+func (r memNotifications) ForRecipient(recipientType string, recipientID uint, unreadOnly bool) ([]models.Notification, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	notifications := filterRows(r.m.t.notifications, func(n models.Notification) bool {
+		return n.RecipientType == recipientType && n.RecipientID == recipientID && (!unreadOnly || n.ReadAt == nil)
+	})
+	newestFirst(notifications, func(n models.Notification) time.Time { return n.CreatedAt })
+	return notifications, nil
+}
+
+func (r memNotifications) MarkRead(recipientType string, recipientID, notificationID uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	notification := findRow(r.m.t.notifications, func(n models.Notification) bool {
+		return n.NotificationID == notificationID && n.RecipientType == recipientType && n.RecipientID == recipientID && n.ReadAt == nil
+	})
+	if notification == nil {
+		return services.ErrNotificationNotFound
+	}
+	now := time.Now()
+	notification.ReadAt = &now
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/services"
+
+	"gorm.io/gorm"
+)
+
+func TestMemoryAddAppliesDefaults(t *testing.T) {
+	mem := NewMemory()
+	first := &models.ShelterAccount{Username: "paws"}
+	second := &models.ShelterAccount{Username: "claws"}
+	if err := mem.Add(first, second, &models.ShelterInfo{ShelterID: 2, ShelterName: "Claws"}); err != nil {
+		t.Fatal(err)
+	}
+	if first.ShelterID != 1 || second.ShelterID != 2 {
+		t.Fatalf("assigned ids %d, %d; want 1, 2", first.ShelterID, second.ShelterID)
+	}
+	if first.Version != 1 || first.Status != services.StatusActive || first.CreatedAt.IsZero() {
+		t.Fatalf("stored account = %+v, want version 1, active and a creation time", first)
+	}
+
+	shelters, err := mem.Repositories().Shelters.Accounts(ShelterFilter{HasProfile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shelters) != 1 || shelters[0].ShelterID != 2 {
+		t.Fatalf("shelters with a profile = %+v, want shelter 2", shelters)
+	}
+}
+
+func TestMemoryUnitRollsBack(t *testing.T) {
+	mem := NewMemory()
+	if err := mem.Add(
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", RegStatus: services.RegStatusApproved},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("later step failed")
+	var hooked bool
+	err := mem.Do(func(tx Repositories) error {
+		if _, err := tx.Shelters.Block(1, 7, services.DefaultShelterBlockPolicy, "spam"); err != nil {
+			return err
+		}
+		tx.AfterCommit(func() { hooked = true })
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("unit error = %v, want %v", err, failed)
+	}
+
+	repos := mem.Repositories()
+	shelter, _ := repos.Shelters.Account(1)
+	logs, _ := repos.Audit.Trail("", 0, 0)
+	if shelter.Status != services.StatusActive || shelter.Version != 1 || len(logs) != 0 || hooked {
+		t.Fatalf("after a failed unit: %+v, %d audit rows, hook ran %v", shelter, len(logs), hooked)
+	}
+
+	if err := mem.Do(func(tx Repositories) error {
+		_, err := tx.Shelters.Block(1, 7, services.DefaultShelterBlockPolicy, "spam")
+		tx.AfterCommit(func() { hooked = true })
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if shelter, _ := repos.Shelters.Account(1); shelter.Status != services.StatusInactive || !hooked {
+		t.Fatalf("after a committed unit: %+v, hook ran %v", shelter, hooked)
+	}
+}
+
+func TestMemoryHidesDeletedRows(t *testing.T) {
+	mem := NewMemory()
+	deleted := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	if err := mem.Add(
+		&models.AdopterAccount{AdopterID: 1, Username: "ana"},
+		&models.AdopterAccount{AdopterID: 2, Username: "ben", DeletedAt: deleted},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := mem.Repositories()
+	if _, err := repos.Adopters.Account(2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("deleted adopter lookup: %v, want ErrNotFound", err)
+	}
+	if ok, _ := repos.HasAccount(services.EntityAdopter, 2); ok {
+		t.Fatal("a deleted adopter still has an account")
+	}
+	if ok, _ := repos.HasAccount(services.EntityAdopter, 1); !ok {
+		t.Fatal("a live adopter has no account")
+	}
+}
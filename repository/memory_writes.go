@@ -0,0 +1,1314 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/services"
+
+	"gorm.io/gorm"
+)
+
+// write runs fn on the tables as one step: when fn fails they go back to
+// how they were, as a nested service transaction would roll back
+func (m *Memory) write(fn func(t *memoryTables) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	before := m.t.clone()
+	if err := fn(&m.t); err != nil {
+		m.t = before
+		return err
+	}
+	return nil
+}
+
+// store inserts rows of types insert always knows
+func (t *memoryTables) store(rows ...interface{}) {
+	for _, row := range rows {
+		if err := t.insert(row); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (t *memoryTables) audit(adminID uint, action, entityType string, entityID uint, from, to, details string) {
+	t.store(&models.AdminAuditLog{
+		AdminID:    adminID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		FromStatus: from,
+		ToStatus:   to,
+		Details:    details,
+	})
+}
+
+// checkVersion is services.checkVersion for a row found with or without
+// its soft deleted ones; a missing row passes
+func checkVersion(current *uint, versions []uint) error {
+	if len(versions) == 0 || current == nil {
+		return nil
+	}
+	for _, version := range versions {
+		if version == *current {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: it is now at version %d", services.ErrVersionMismatch, *current)
+}
+
+// transitionShelter moves the status or reg_status of a live shelter
+// account, whichever machine governs
+func (t *memoryTables) transitionShelter(id uint, machine services.StatusMachine, to string) (models.ShelterAccount, error) {
+	account := t.shelter(id)
+	if account == nil {
+		return models.ShelterAccount{}, services.ErrAccountNotFound
+	}
+	from := account.Status
+	if machine.Column == "reg_status" {
+		from = account.RegStatus
+	}
+	updates, err := machine.Plan(from, to)
+	if err != nil {
+		return *account, err
+	}
+	if status, ok := updates["status"].(string); ok {
+		account.Status = status
+	}
+	if regStatus, ok := updates["reg_status"].(string); ok {
+		account.RegStatus = regStatus
+	}
+	account.Version++
+	return *account, nil
+}
+
+// openApplications returns the IDs of the open applications match accepts
+func (t *memoryTables) openApplications(match func(models.AdoptionSubmission) bool) []uint {
+	var ids []uint
+	for _, a := range t.applications {
+		if match(a) && !containsString(services.ClosedApplicationStatuses, a.Status) {
+			ids = append(ids, a.ApplicationID)
+		}
+	}
+	return ids
+}
+
+// holdApplications places a hold from source on each application that has
+// none from it yet and returns how many were placed
+func (t *memoryTables) holdApplications(ids []uint, adminID uint, source, reason string) int64 {
+	var held int64
+	for _, id := range ids {
+		if findRow(t.holds, func(h models.ApplicationHold) bool { return h.ApplicationID == id && h.Source == source }) != nil {
+			continue
+		}
+		t.store(&models.ApplicationHold{ApplicationID: id, Source: source, Reason: reason, HeldBy: adminID})
+		held++
+	}
+	return held
+}
+
+// releaseHolds removes the holds from source on the applications match
+// accepts and returns how many were removed
+func (t *memoryTables) releaseHolds(source string, match func(models.AdoptionSubmission) bool) int64 {
+	kept := t.holds[:0:0]
+	var released int64
+	for _, hold := range t.holds {
+		application := findRow(t.applications, func(a models.AdoptionSubmission) bool { return a.ApplicationID == hold.ApplicationID })
+		if hold.Source == source && application != nil && match(*application) {
+			released++
+			continue
+		}
+		kept = append(kept, hold)
+	}
+	t.holds = kept
+	return released
+}
+
+// hidePets moves the shelter's visible and flag held pets to hiddenBy
+func (t *memoryTables) hidePets(shelterID uint, hiddenBy string) int64 {
+	var hidden int64
+	for i := range t.pets {
+		if t.pets[i].ShelterID == shelterID && (t.pets[i].HiddenBy == "" || t.pets[i].HiddenBy == services.HoldFlagged) {
+			t.pets[i].HiddenBy = hiddenBy
+			hidden++
+		}
+	}
+	return hidden
+}
+
+// movePets moves the shelter's pets hidden by from to to
+func (t *memoryTables) movePets(shelterID uint, from, to string) int64 {
+	var moved int64
+	for i := range t.pets {
+		if t.pets[i].ShelterID == shelterID && t.pets[i].HiddenBy == from {
+			t.pets[i].HiddenBy = to
+			moved++
+		}
+	}
+	return moved
+}
+
+func (r memShelters) CheckVersion(id uint, versions []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var current *uint
+	if account := findRow(r.m.t.shelters, func(a models.ShelterAccount) bool { return a.ShelterID == id }); account != nil {
+		current = &account.Version
+	}
+	return checkVersion(current, versions)
+}
+
+func (r memShelters) SetStatus(id, adminID uint, status string, policy services.ShelterBlockPolicy) (services.ShelterModerationResult, error) {
+	switch services.NormalizeStatus(status) {
+	case services.StatusInactive:
+		return r.Block(id, adminID, policy, "")
+	case services.StatusActive:
+		return r.Reinstate(id, adminID)
+	}
+	return services.ShelterModerationResult{}, fmt.Errorf("%w: %q must be one of %s, %s",
+		services.ErrInvalidStatus, status, services.StatusActive, services.StatusInactive)
+}
+
+func (r memShelters) Block(id, adminID uint, policy services.ShelterBlockPolicy, _ string) (services.ShelterModerationResult, error) {
+	return r.moderate(id, adminID, services.StatusInactive, services.ReportStatusReported, services.ReportStatusBlocked, services.ActionShelterBlock,
+		func(t *memoryTables, unchanged bool) (services.CascadeResult, error) {
+			var result services.CascadeResult
+			if unchanged {
+				return result, nil
+			}
+			if err := policy.Validate(); err != nil {
+				return result, err
+			}
+			if policy.HidePets {
+				result.PetsHidden = t.hidePets(id, services.HoldShelterBlocked)
+			}
+			if policy.Applications == services.ApplicationsFreeze {
+				open := t.openApplications(func(a models.AdoptionSubmission) bool { return a.ShelterID == id })
+				result.ApplicationsFrozen = t.holdApplications(open, adminID, services.HoldShelterBlocked, "Shelter is blocked")
+			}
+			return result, nil
+		})
+}
+
+func (r memShelters) Reinstate(id, adminID uint) (services.ShelterModerationResult, error) {
+	return r.moderate(id, adminID, services.StatusActive, services.ReportStatusBlocked, services.ReportStatusResolved, services.ActionShelterReinstate,
+		func(t *memoryTables, unchanged bool) (services.CascadeResult, error) {
+			var result services.CascadeResult
+			if t.flagHoldUntil(id, time.Now()) != nil {
+				return result, services.ErrShelterOnHold
+			}
+			if unchanged {
+				return result, nil
+			}
+			result.PetsRestored = t.movePets(id, services.HoldShelterBlocked, "")
+			result.ApplicationsThawed = t.releaseHolds(services.HoldShelterBlocked, func(a models.AdoptionSubmission) bool { return a.ShelterID == id })
+			return result, nil
+		})
+}
+
+// moderate is the in-memory services.moderateShelter
+func (r memShelters) moderate(id, adminID uint, to, reportsFrom, reportsTo, action string,
+	cascade func(t *memoryTables, unchanged bool) (services.CascadeResult, error)) (services.ShelterModerationResult, error) {
+	var result services.ShelterModerationResult
+	err := r.m.write(func(t *memoryTables) error {
+		info := t.shelterInfo(id)
+		if info == nil {
+			return services.ErrShelterInfoNotFound
+		}
+		result.Info = *info
+		account := t.shelter(id)
+		if account == nil {
+			return services.ErrAccountNotFound
+		}
+		from := account.Status
+
+		var err error
+		result.Account, err = t.transitionShelter(id, services.ShelterStatusMachine, to)
+		unchanged := errors.Is(err, services.ErrAlreadyInStatus)
+		if err != nil && !unchanged {
+			return err
+		}
+
+		for i := range t.reports {
+			if t.reports[i].ShelterID == id && t.reports[i].Status == reportsFrom {
+				t.reports[i].Status = reportsTo
+				t.reports[i].Version++
+				result.ReportsUpdated++
+			}
+		}
+		if unchanged && result.ReportsUpdated == 0 {
+			return services.ErrAlreadyInStatus
+		}
+
+		if result.Cascade, err = cascade(t, unchanged); err != nil {
+			return err
+		}
+
+		c := result.Cascade
+		t.audit(adminID, action, services.EntityShelter, id, from, to,
+			fmt.Sprintf("%d reports moved from %s to %s; pets hidden %d, restored %d; applications frozen %d, released %d, withdrawn %d; interviews cancelled %d",
+				result.ReportsUpdated, reportsFrom, reportsTo, c.PetsHidden, c.PetsRestored,
+				c.ApplicationsFrozen, c.ApplicationsThawed, c.ApplicationsWithdrawn, c.InterviewsCancelled))
+		return nil
+	})
+	return result, err
+}
+
+func (r memShelters) DecideRegistration(id, adminID uint, decision, reasonCode, feedback string, claimTTL time.Duration) (models.ShelterAccount, models.ShelterReviewRound, error) {
+	var shelter models.ShelterAccount
+	var round models.ShelterReviewRound
+
+	decision = services.NormalizeStatus(decision)
+	if decision == services.RegStatusRejected {
+		if err := services.ValidateRejection(reasonCode, feedback); err != nil {
+			return shelter, round, err
+		}
+	} else {
+		reasonCode = ""
+	}
+
+	err := r.m.write(func(t *memoryTables) error {
+		if _, err := t.claimReview(id, adminID, claimTTL); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if decision == services.RegStatusApproved {
+			if t.flagHoldUntil(id, now) != nil {
+				return services.ErrShelterOnHold
+			}
+			if err := t.ensureDocumentsAccepted(id); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if shelter, err = t.transitionShelter(id, services.ShelterRegStatusMachine, decision); err != nil {
+			return err
+		}
+
+		open, err := t.openRound(id)
+		if err != nil {
+			return err
+		}
+		open.Decision = decision
+		open.ReasonCode = strings.TrimSpace(reasonCode)
+		open.Feedback = strings.TrimSpace(feedback)
+		open.ReviewedBy = adminID
+		open.ReviewedAt = &now
+		round = *open
+
+		action := services.ActionShelterApprove
+		if decision == services.RegStatusRejected {
+			action = services.ActionShelterReject
+		}
+		t.audit(adminID, action, services.EntityShelter, id, services.RegStatusPending, decision,
+			strings.TrimSpace(reasonCode+" "+feedback))
+		if decision == services.RegStatusRejected {
+			t.store(&models.Notification{
+				RecipientType: services.RecipientShelter,
+				RecipientID:   id,
+				Kind:          services.NotifyRegistrationRejected,
+				Title:         "Registration not approved",
+				Message:       services.RejectionMessage(round.ReasonCode, round.Feedback),
+			})
+		}
+
+		t.claims = filterRows(t.claims, func(c models.ShelterReviewClaim) bool { return c.ShelterID != id })
+		return nil
+	})
+	return shelter, round, err
+}
+
+// ensureDocumentsAccepted is services.ensureDocumentsAccepted
+func (t *memoryTables) ensureDocumentsAccepted(id uint) error {
+	docs := filterRows(t.documents, func(d models.ShelterDocument) bool { return d.ShelterID == id })
+	newestFirst(docs, func(d models.ShelterDocument) time.Time { return d.CreatedAt })
+
+	var missing []string
+	for _, item := range services.BuildChecklist(docs) {
+		if item.Status != services.DocStatusAccepted {
+			missing = append(missing, fmt.Sprintf("%s (%s)", item.DocType, item.Status))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", services.ErrRequiredDocsNotAccepted, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// openRound returns the shelter's undecided round, opening one from its
+// profile when there is none
+func (t *memoryTables) openRound(id uint) (*models.ShelterReviewRound, error) {
+	var open *models.ShelterReviewRound
+	for i := range t.rounds {
+		round := &t.rounds[i]
+		if round.ShelterID == id && round.Decision == services.RegStatusPending && (open == nil || round.Round > open.Round) {
+			open = round
+		}
+	}
+	if open != nil {
+		return open, nil
+	}
+
+	var info models.ShelterInfo
+	if live := t.shelterInfo(id); live != nil {
+		info = *live
+	}
+	return t.newRound(id, services.SnapshotFromInfo(info), nil)
+}
+
+func (t *memoryTables) newRound(id uint, snapshot services.ShelterInfoSnapshot, changes []services.FieldChange) (*models.ShelterReviewRound, error) {
+	last := 0
+	for _, round := range t.rounds {
+		if round.ShelterID == id && round.Round > last {
+			last = round.Round
+		}
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	round := models.ShelterReviewRound{
+		ShelterID:   id,
+		Round:       last + 1,
+		Decision:    services.RegStatusPending,
+		Snapshot:    string(snapshotJSON),
+		SubmittedAt: time.Now(),
+	}
+	if changes != nil {
+		changesJSON, err := json.Marshal(changes)
+		if err != nil {
+			return nil, err
+		}
+		round.Changes = string(changesJSON)
+	}
+	t.store(&round)
+	return &t.rounds[len(t.rounds)-1], nil
+}
+
+func (r memShelters) Resubmit(id uint, update services.ShelterInfoSnapshot) (models.ShelterReviewRound, error) {
+	var round models.ShelterReviewRound
+	err := r.m.write(func(t *memoryTables) error {
+		account := t.shelter(id)
+		if account == nil {
+			return services.ErrAccountNotFound
+		}
+		if services.NormalizeStatus(account.RegStatus) != services.RegStatusRejected {
+			return services.ErrNotRejected
+		}
+
+		var current models.ShelterInfo
+		info := t.shelterInfo(id)
+		if info != nil {
+			current = *info
+		}
+		update = services.MergeSnapshot(services.SnapshotFromInfo(current), update)
+		if err := update.Validate(); err != nil {
+			return err
+		}
+		if info != nil {
+			info.ShelterName = update.ShelterName
+			info.ShelterAddress = update.ShelterAddress
+			info.ShelterLandmark = update.ShelterLandmark
+			info.ShelterContact = update.ShelterContact
+			info.ShelterEmail = update.ShelterEmail
+			info.ShelterOwner = update.ShelterOwner
+			info.ShelterDescription = update.ShelterDescription
+			info.ShelterSocial = update.ShelterSocial
+		}
+
+		if _, err := t.transitionShelter(id, services.ShelterRegStatusMachine, services.RegStatusPending); err != nil {
+			return err
+		}
+
+		var lastRejected *models.ShelterReviewRound
+		for i := range t.rounds {
+			candidate := &t.rounds[i]
+			if candidate.ShelterID == id && candidate.Decision == services.RegStatusRejected &&
+				(lastRejected == nil || candidate.Round > lastRejected.Round) {
+				lastRejected = candidate
+			}
+		}
+		var changes []services.FieldChange
+		if lastRejected != nil {
+			var previous services.ShelterInfoSnapshot
+			_ = json.Unmarshal([]byte(lastRejected.Snapshot), &previous)
+			changes = services.DiffSnapshots(previous, update)
+		}
+
+		created, err := t.newRound(id, update, changes)
+		if err != nil {
+			return err
+		}
+		round = *created
+		return nil
+	})
+	return round, err
+}
+
+func (r memShelters) ClaimReview(id, adminID uint, ttl time.Duration) (models.ShelterReviewClaim, error) {
+	var claim models.ShelterReviewClaim
+	err := r.m.write(func(t *memoryTables) error {
+		var err error
+		claim, err = t.claimReview(id, adminID, ttl)
+		return err
+	})
+	return claim, err
+}
+
+// claimReview is services.ClaimShelterReview
+func (t *memoryTables) claimReview(id, adminID uint, ttl time.Duration) (models.ShelterReviewClaim, error) {
+	if adminID == 0 {
+		return models.ShelterReviewClaim{}, services.ErrAdminIDRequired
+	}
+	account := t.shelter(id)
+	if account == nil {
+		return models.ShelterReviewClaim{}, services.ErrAccountNotFound
+	}
+	if services.NormalizeStatus(account.RegStatus) != services.RegStatusPending {
+		return models.ShelterReviewClaim{}, services.ErrNotPending
+	}
+
+	now := time.Now()
+	claim := models.ShelterReviewClaim{ShelterID: id, AdminID: adminID, ClaimedAt: now, ExpiresAt: now.Add(ttl)}
+	held := findRow(t.claims, func(c models.ShelterReviewClaim) bool { return c.ShelterID == id })
+	switch {
+	case held == nil:
+		t.store(&claim)
+	case held.ExpiresAt.Before(now) || held.AdminID == adminID:
+		*held = claim
+	default:
+		return claim, services.ErrClaimedByOther
+	}
+	return claim, nil
+}
+
+func (r memShelters) ReleaseReview(id, adminID uint) error {
+	return r.m.write(func(t *memoryTables) error {
+		kept := filterRows(t.claims, func(c models.ShelterReviewClaim) bool { return c.ShelterID != id || c.AdminID != adminID })
+		if len(kept) == len(t.claims) {
+			return services.ErrClaimNotHeld
+		}
+		t.claims = kept
+		return nil
+	})
+}
+
+func (r memShelters) UploadDocument(id uint, docType, fileName, fileData string) (models.ShelterDocument, error) {
+	var doc models.ShelterDocument
+	docType = strings.ToLower(strings.TrimSpace(docType))
+	if !services.IsDocumentType(docType) {
+		return doc, fmt.Errorf("%w: %q", services.ErrUnknownDocumentType, docType)
+	}
+
+	err := r.m.write(func(t *memoryTables) error {
+		if t.shelter(id) == nil {
+			return services.ErrAccountNotFound
+		}
+		upload, err := services.ValidateUpload(fileData, services.DocumentUploadRules)
+		if err != nil {
+			return err
+		}
+		doc = models.ShelterDocument{
+			ShelterID: id,
+			DocType:   docType,
+			FileName:  fileName,
+			MimeType:  upload.MimeType,
+			SizeBytes: upload.SizeBytes,
+			FileData:  upload.Data,
+			Status:    services.DocStatusPending,
+		}
+		t.store(&doc)
+		return nil
+	})
+	return doc, err
+}
+
+func (r memShelters) ReviewDocument(documentID, adminID uint, status, reason string) (models.ShelterDocument, error) {
+	var doc models.ShelterDocument
+	status = services.NormalizeStatus(status)
+	if status != services.DocStatusAccepted && status != services.DocStatusRejected {
+		return doc, services.ErrInvalidDocumentStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if status == services.DocStatusRejected && reason == "" {
+		return doc, services.ErrDocumentRejectReason
+	}
+	if status == services.DocStatusAccepted {
+		reason = ""
+	}
+
+	err := r.m.write(func(t *memoryTables) error {
+		stored := findRow(t.documents, func(d models.ShelterDocument) bool { return d.DocumentID == documentID })
+		if stored == nil {
+			return services.ErrDocumentNotFound
+		}
+		now := time.Now()
+		stored.Status = status
+		stored.RejectReason = reason
+		stored.ReviewedBy = adminID
+		stored.ReviewedAt = &now
+		doc = *stored
+		doc.FileData = ""
+		return nil
+	})
+	return doc, err
+}
+
+func (r memShelters) DismissDuplicate(idA, idB, adminID uint) error {
+	return r.m.write(func(t *memoryTables) error { return t.dismissDuplicate("shelters", idA, idB, adminID) })
+}
+
+// dismissDuplicate is services.DismissDuplicate
+func (t *memoryTables) dismissDuplicate(entityType string, idA, idB, adminID uint) error {
+	if idA == idB {
+		return services.ErrSameAccount
+	}
+	if idA > idB {
+		idA, idB = idB, idA
+	}
+	if findRow(t.dismissals, func(d models.DuplicateDismissal) bool {
+		return d.EntityType == entityType && d.IDA == idA && d.IDB == idB
+	}) == nil {
+		t.store(&models.DuplicateDismissal{EntityType: entityType, IDA: idA, IDB: idB, DismissedBy: adminID})
+	}
+	return nil
+}
+
+func (r memShelters) Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return r.m.merge("shelters", survivorID, duplicateID, adminID, services.ActionShelterMerge, services.EntityShelter,
+		func(t *memoryTables) (map[string]int64, error) {
+			if t.shelter(survivorID) == nil || t.shelter(duplicateID) == nil {
+				return nil, services.ErrAccountNotFound
+			}
+			if services.NormalizeStatus(t.shelter(duplicateID).Status) == services.StatusActive {
+				if _, err := t.transitionShelter(duplicateID, services.ShelterStatusMachine, services.StatusInactive); err != nil {
+					return nil, err
+				}
+			}
+
+			moved := map[string]int64{"petinfo": 0, "adoption_submissions": 0, "submittedreports": 0, "schedule_interview": 0, "shelterdonations": 0, "shelter_documents": 0}
+			for i := range t.pets {
+				if t.pets[i].ShelterID == duplicateID {
+					t.pets[i].ShelterID = survivorID
+					moved["petinfo"]++
+				}
+			}
+			for i := range t.applications {
+				if t.applications[i].ShelterID == duplicateID {
+					t.applications[i].ShelterID = survivorID
+					moved["adoption_submissions"]++
+				}
+			}
+			for i := range t.reports {
+				if t.reports[i].ShelterID == duplicateID {
+					t.reports[i].ShelterID = survivorID
+					t.reports[i].Version++
+					moved["submittedreports"]++
+				}
+			}
+			for i := range t.documents {
+				if t.documents[i].ShelterID == duplicateID {
+					t.documents[i].ShelterID = survivorID
+					moved["shelter_documents"]++
+				}
+			}
+			return moved, nil
+		})
+}
+
+// merge is services.mergeAccounts; move deactivates the duplicate and
+// moves its rows
+func (m *Memory) merge(entityType string, survivorID, duplicateID, adminID uint, action, auditEntity string,
+	move func(t *memoryTables) (map[string]int64, error)) (models.AccountMerge, error) {
+	merge := models.AccountMerge{
+		EntityType: entityType,
+		SurvivorID: survivorID,
+		MergedID:   duplicateID,
+		MergedBy:   adminID,
+	}
+	if survivorID == duplicateID {
+		return merge, services.ErrSameAccount
+	}
+
+	err := m.write(func(t *memoryTables) error {
+		if findRow(t.merges, func(a models.AccountMerge) bool { return a.EntityType == entityType && a.MergedID == duplicateID }) != nil {
+			return services.ErrAlreadyMerged
+		}
+		moved, err := move(t)
+		if err != nil {
+			return err
+		}
+		movedJSON, err := json.Marshal(moved)
+		if err != nil {
+			return err
+		}
+		merge.MovedRows = string(movedJSON)
+		t.store(&merge)
+		t.audit(adminID, action, auditEntity, duplicateID, "", "", fmt.Sprintf("merged into %d: %s", survivorID, merge.MovedRows))
+		return nil
+	})
+	return merge, err
+}
+
+func (r memShelters) SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error) {
+	return r.m.softDelete(deletableShelter, id, adminID, reason, settings)
+}
+
+func (r memShelters) Restore(id, adminID uint) (models.AccountDeletion, error) {
+	return r.m.restore(deletableShelter, id, adminID)
+}
+
+func (r memShelters) Purge(id, adminID uint) (models.AccountDeletion, error) {
+	return r.m.purge(deletableShelter, id, adminID)
+}
+
+// deletable describes how the memory store soft deletes, restores and
+// purges one kind of account, as services.deletableAccount does
+type deletable struct {
+	entityType string
+	hold       string
+	actions    [3]string // delete, restore, purge
+	// status returns the live account's status, or false when there is none
+	status func(t *memoryTables, id uint) (string, bool)
+	// setDeleted soft deletes or restores the account, info and media and
+	// bumps the account version
+	setDeleted func(t *memoryTables, id uint, deleted bool)
+	// owns reports whether an application belongs to the account
+	owns func(a models.AdoptionSubmission, id uint) bool
+	// purge removes the account's rows and counts them per table
+	purge func(t *memoryTables, id uint) map[string]int64
+}
+
+var deletableShelter = deletable{
+	entityType: services.EntityShelter,
+	hold:       services.HoldShelterDeleted,
+	actions:    [3]string{services.ActionShelterDelete, services.ActionShelterRestore, services.ActionShelterPurge},
+	status: func(t *memoryTables, id uint) (string, bool) {
+		if account := t.shelter(id); account != nil {
+			return account.Status, true
+		}
+		return "", false
+	},
+	setDeleted: func(t *memoryTables, id uint, deleted bool) {
+		at := deletedAt(deleted)
+		for i := range t.shelters {
+			if t.shelters[i].ShelterID == id {
+				t.shelters[i].DeletedAt = at
+				t.shelters[i].Version++
+			}
+		}
+		for i := range t.shelterInfos {
+			if t.shelterInfos[i].ShelterID == id {
+				t.shelterInfos[i].DeletedAt = at
+			}
+		}
+		for i := range t.shelterMedia {
+			if t.shelterMedia[i].ShelterID == id {
+				t.shelterMedia[i].DeletedAt = at
+			}
+		}
+	},
+	owns: func(a models.AdoptionSubmission, id uint) bool { return a.ShelterID == id },
+	purge: func(t *memoryTables, id uint) map[string]int64 {
+		rows := map[string]int64{}
+		applications := idSet(t.applications, func(a models.AdoptionSubmission) (uint, bool) { return a.ApplicationID, a.ShelterID == id })
+		pets := idSet(t.pets, func(p models.PetInfo) (uint, bool) { return p.PetID, p.ShelterID == id })
+		reports := idSet(t.reports, func(r models.SubmittedReport) (uint, bool) { return r.ID, r.ShelterID == id })
+
+		rows["application_holds"] = purgeRows(&t.holds, func(h models.ApplicationHold) bool { return applications[h.ApplicationID] })
+		rows["moderation_queue"] = purgeRows(&t.items, func(i models.ModerationItem) bool {
+			return (i.Source == "adoption_reason" && applications[i.EntityID]) ||
+				(i.Source == "pet_description" && pets[i.EntityID]) ||
+				((i.Source == "report_reason" || i.Source == "report_description") && reports[i.EntityID]) ||
+				(i.Source == "shelter_description" && i.EntityID == id)
+		})
+		rows["adoption_submissions"] = purgeRows(&t.applications, func(a models.AdoptionSubmission) bool { return applications[a.ApplicationID] })
+		rows["petmedia"] = purgeRows(&t.petMedia, func(m models.PetMedia) bool { return pets[m.PetID] })
+		rows["petinfo"] = purgeRows(&t.pets, func(p models.PetInfo) bool { return pets[p.PetID] })
+		rows["outcome_notice_reports"] = purgeRows(&t.noticeReports, func(n models.OutcomeNoticeReport) bool { return reports[n.ReportID] })
+		rows["report_attachments"] = purgeRows(&t.attachments, func(a models.ReportAttachment) bool { return reports[a.ReportID] })
+		rows["submittedreports"] = purgeRows(&t.reports, func(r models.SubmittedReport) bool { return reports[r.ID] })
+		rows["shelter_flags"] = purgeRows(&t.flags, func(f models.ShelterFlag) bool { return f.ShelterID == id })
+		rows["shelter_documents"] = purgeRows(&t.documents, func(d models.ShelterDocument) bool { return d.ShelterID == id })
+		rows["shelter_review_claims"] = purgeRows(&t.claims, func(c models.ShelterReviewClaim) bool { return c.ShelterID == id })
+		rows["shelter_review_rounds"] = purgeRows(&t.rounds, func(r models.ShelterReviewRound) bool { return r.ShelterID == id })
+		rows["notifications"] = purgeRows(&t.notifications, func(n models.Notification) bool {
+			return n.RecipientType == services.RecipientShelter && n.RecipientID == id
+		})
+		rows["outcome_notices"] = purgeRows(&t.notices, func(n models.OutcomeNotice) bool {
+			return n.RecipientType == services.RecipientShelter && n.RecipientID == id
+		})
+		rows["duplicate_dismissals"] = purgeRows(&t.dismissals, func(d models.DuplicateDismissal) bool {
+			return d.EntityType == "shelters" && (d.IDA == id || d.IDB == id)
+		})
+		rows["sheltermedia"] = purgeRows(&t.shelterMedia, func(m models.ShelterMedia) bool { return m.ShelterID == id })
+		rows["shelterinfo"] = purgeRows(&t.shelterInfos, func(i models.ShelterInfo) bool { return i.ShelterID == id })
+		rows["shelteraccount"] = purgeRows(&t.shelters, func(a models.ShelterAccount) bool { return a.ShelterID == id })
+		return rows
+	},
+}
+
+var deletableAdopter = deletable{
+	entityType: services.EntityAdopter,
+	hold:       services.HoldAdopterDeleted,
+	actions:    [3]string{services.ActionAdopterDelete, services.ActionAdopterRestore, services.ActionAdopterPurge},
+	status: func(t *memoryTables, id uint) (string, bool) {
+		if account := t.adopter(id); account != nil {
+			return account.Status, true
+		}
+		return "", false
+	},
+	setDeleted: func(t *memoryTables, id uint, deleted bool) {
+		at := deletedAt(deleted)
+		for i := range t.adopters {
+			if t.adopters[i].AdopterID == id {
+				t.adopters[i].DeletedAt = at
+				t.adopters[i].Version++
+			}
+		}
+		for i := range t.adopterInfos {
+			if t.adopterInfos[i].AdopterID == id {
+				t.adopterInfos[i].DeletedAt = at
+			}
+		}
+		for i := range t.adopterMedia {
+			if t.adopterMedia[i].AdopterID == id {
+				t.adopterMedia[i].DeletedAt = at
+			}
+		}
+	},
+	owns: func(a models.AdoptionSubmission, id uint) bool { return a.AdopterID == id },
+	purge: func(t *memoryTables, id uint) map[string]int64 {
+		rows := map[string]int64{}
+		applications := idSet(t.applications, func(a models.AdoptionSubmission) (uint, bool) { return a.ApplicationID, a.AdopterID == id })
+		reports := idSet(t.reports, func(r models.SubmittedReport) (uint, bool) { return r.ID, r.AdopterID == id })
+
+		rows["application_holds"] = purgeRows(&t.holds, func(h models.ApplicationHold) bool { return applications[h.ApplicationID] })
+		rows["moderation_queue"] = purgeRows(&t.items, func(i models.ModerationItem) bool {
+			return (i.Source == "adoption_reason" && applications[i.EntityID]) ||
+				((i.Source == "report_reason" || i.Source == "report_description") && reports[i.EntityID])
+		})
+		rows["adoption_submissions"] = purgeRows(&t.applications, func(a models.AdoptionSubmission) bool { return applications[a.ApplicationID] })
+		rows["outcome_notice_reports"] = purgeRows(&t.noticeReports, func(n models.OutcomeNoticeReport) bool { return reports[n.ReportID] })
+		rows["report_attachments"] = purgeRows(&t.attachments, func(a models.ReportAttachment) bool { return reports[a.ReportID] })
+		rows["submittedreports"] = purgeRows(&t.reports, func(r models.SubmittedReport) bool { return reports[r.ID] })
+		rows["notifications"] = purgeRows(&t.notifications, func(n models.Notification) bool {
+			return n.RecipientType == services.RecipientAdopter && n.RecipientID == id
+		})
+		rows["outcome_notices"] = purgeRows(&t.notices, func(n models.OutcomeNotice) bool {
+			return n.RecipientType == services.RecipientAdopter && n.RecipientID == id
+		})
+		rows["duplicate_dismissals"] = purgeRows(&t.dismissals, func(d models.DuplicateDismissal) bool {
+			return d.EntityType == "adopters" && (d.IDA == id || d.IDB == id)
+		})
+		rows["adopter_media"] = purgeRows(&t.adopterMedia, func(m models.AdopterMedia) bool { return m.AdopterID == id })
+		rows["adopterinfo"] = purgeRows(&t.adopterInfos, func(i models.AdopterInfo) bool { return i.AdopterID == id })
+		rows["adopteraccount"] = purgeRows(&t.adopters, func(a models.AdopterAccount) bool { return a.AdopterID == id })
+		return rows
+	},
+}
+
+// idSet collects the IDs of the rows pick accepts
+func idSet[T any](rows []T, pick func(T) (uint, bool)) map[uint]bool {
+	ids := make(map[uint]bool)
+	for _, row := range rows {
+		if id, ok := pick(row); ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// purgeRows removes the rows match accepts and returns how many it removed
+func purgeRows[T any](rows *[]T, match func(T) bool) int64 {
+	kept := filterRows(*rows, func(row T) bool { return !match(row) })
+	removed := int64(len(*rows) - len(kept))
+	*rows = kept
+	return removed
+}
+
+// openDeletion returns the account's deletion that is neither restored nor
+// purged, or nil
+func (t *memoryTables) openDeletion(account deletable, id uint) *models.AccountDeletion {
+	var open *models.AccountDeletion
+	for i := range t.deletions {
+		d := &t.deletions[i]
+		if d.EntityType == account.entityType && d.EntityID == id && d.RestoredAt == nil && d.PurgedAt == nil &&
+			(open == nil || d.DeletionID > open.DeletionID) {
+			open = d
+		}
+	}
+	return open
+}
+
+func (m *Memory) softDelete(account deletable, id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := m.write(func(t *memoryTables) error {
+		status, ok := account.status(t, id)
+		if !ok {
+			if t.openDeletion(account, id) != nil {
+				return services.ErrAccountDeleted
+			}
+			return services.ErrAccountNotFound
+		}
+		account.setDeleted(t, id, true)
+
+		var petsHidden int64
+		if account.entityType == services.EntityShelter {
+			petsHidden = t.hidePets(id, account.hold)
+		}
+		open := t.openApplications(func(a models.AdoptionSubmission) bool { return account.owns(a, id) })
+		frozen := t.holdApplications(open, adminID, account.hold, "Account is deleted")
+
+		now := time.Now()
+		deletion = models.AccountDeletion{
+			EntityType:  account.entityType,
+			EntityID:    id,
+			Reason:      reason,
+			PriorStatus: status,
+			DeletedBy:   adminID,
+			DeletedAt:   now,
+			PurgeAfter:  now.Add(settings.RestoreWindow),
+		}
+		t.store(&deletion)
+
+		t.audit(adminID, account.actions[0], account.entityType, id, status, services.StatusDeleted,
+			fmt.Sprintf("restorable until %s; pets hidden %d; applications frozen %d",
+				deletion.PurgeAfter.Format(time.RFC3339), petsHidden, frozen))
+		return nil
+	})
+	return deletion, err
+}
+
+func (m *Memory) restore(account deletable, id, adminID uint) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := m.write(func(t *memoryTables) error {
+		open := t.openDeletion(account, id)
+		if open == nil {
+			return services.ErrAccountNotDeleted
+		}
+		now := time.Now()
+		if now.After(open.PurgeAfter) {
+			return services.ErrRestoreWindowClosed
+		}
+		account.setDeleted(t, id, false)
+
+		var petsRestored int64
+		if account.entityType == services.EntityShelter {
+			// Pets go back under a flag hold that is still running
+			restoreTo := ""
+			if t.flagHoldUntil(id, now) != nil {
+				restoreTo = services.HoldFlagged
+			}
+			petsRestored = t.movePets(id, account.hold, restoreTo)
+		}
+		released := t.releaseHolds(account.hold, func(a models.AdoptionSubmission) bool { return account.owns(a, id) })
+
+		open.RestoredBy = adminID
+		open.RestoredAt = &now
+		deletion = *open
+
+		t.audit(adminID, account.actions[1], account.entityType, id, services.StatusDeleted, deletion.PriorStatus,
+			fmt.Sprintf("pets restored %d; applications released %d", petsRestored, released))
+		return nil
+	})
+	return deletion, err
+}
+
+func (m *Memory) purge(account deletable, id, adminID uint) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := m.write(func(t *memoryTables) error {
+		open := t.openDeletion(account, id)
+		if open == nil {
+			return services.ErrAccountNotDeleted
+		}
+
+		rowsJSON, err := json.Marshal(account.purge(t, id))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		open.PurgedAt = &now
+		open.PurgedRows = string(rowsJSON)
+		deletion = *open
+
+		t.audit(adminID, account.actions[2], account.entityType, id, services.StatusDeleted, "", "purged: "+deletion.PurgedRows)
+		return nil
+	})
+	return deletion, err
+}
+
+func (r memAdopters) CheckVersion(id uint, versions []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var current *uint
+	if account := findRow(r.m.t.adopters, func(a models.AdopterAccount) bool { return a.AdopterID == id }); account != nil {
+		current = &account.Version
+	}
+	return checkVersion(current, versions)
+}
+
+func (r memAdopters) SetStatus(id, adminID uint, status string, options services.AdopterStatusOptions) (models.AdopterAccount, services.CascadeResult, error) {
+	var adopter models.AdopterAccount
+	var cascade services.CascadeResult
+	err := r.m.write(func(t *memoryTables) error {
+		account := t.adopter(id)
+		if account == nil {
+			return services.ErrAccountNotFound
+		}
+		from := account.Status
+		updates, err := services.AdopterStatusMachine.Plan(from, status)
+		if err != nil {
+			return err
+		}
+		account.Status = updates["status"].(string)
+		account.Version++
+		adopter = *account
+
+		switch {
+		case adopter.Status == services.StatusInactive:
+			if err := options.Policy.Validate(); err != nil {
+				return err
+			}
+			if options.Policy.Applications == services.ApplicationsFreeze {
+				open := t.openApplications(func(a models.AdoptionSubmission) bool { return a.AdopterID == id })
+				cascade.ApplicationsFrozen = t.holdApplications(open, adminID, services.HoldAdopterDeactivated, "Adopter account is deactivated")
+			}
+		case adopter.Status == services.StatusActive && options.Restore:
+			cascade.ApplicationsThawed = t.releaseHolds(services.HoldAdopterDeactivated, func(a models.AdoptionSubmission) bool { return a.AdopterID == id })
+		}
+
+		t.audit(adminID, services.ActionAdopterStatus, services.EntityAdopter, id, from, adopter.Status,
+			fmt.Sprintf("applications frozen %d, released %d, withdrawn %d, reopened %d; interviews cancelled %d",
+				cascade.ApplicationsFrozen, cascade.ApplicationsThawed, cascade.ApplicationsWithdrawn,
+				cascade.ApplicationsReopened, cascade.InterviewsCancelled))
+		return nil
+	})
+	return adopter, cascade, err
+}
+
+func (r memAdopters) DismissDuplicate(idA, idB, adminID uint) error {
+	return r.m.write(func(t *memoryTables) error { return t.dismissDuplicate("adopters", idA, idB, adminID) })
+}
+
+func (r memAdopters) Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return r.m.merge("adopters", survivorID, duplicateID, adminID, services.ActionAdopterMerge, services.EntityAdopter,
+		func(t *memoryTables) (map[string]int64, error) {
+			if t.adopter(survivorID) == nil || t.adopter(duplicateID) == nil {
+				return nil, services.ErrAccountNotFound
+			}
+			if duplicate := t.adopter(duplicateID); services.NormalizeStatus(duplicate.Status) == services.StatusActive {
+				duplicate.Status = services.StatusInactive
+				duplicate.Version++
+			}
+
+			moved := map[string]int64{"adoption_submissions": 0, "submittedreports": 0, "schedule_interview": 0, "adopterpets": 0}
+			for i := range t.applications {
+				if t.applications[i].AdopterID == duplicateID {
+					t.applications[i].AdopterID = survivorID
+					moved["adoption_submissions"]++
+				}
+			}
+			for i := range t.reports {
+				if t.reports[i].AdopterID == duplicateID {
+					t.reports[i].AdopterID = survivorID
+					t.reports[i].Version++
+					moved["submittedreports"]++
+				}
+			}
+			return moved, nil
+		})
+}
+
+func (r memAdopters) SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error) {
+	return r.m.softDelete(deletableAdopter, id, adminID, reason, settings)
+}
+
+func (r memAdopters) Restore(id, adminID uint) (models.AccountDeletion, error) {
+	return r.m.restore(deletableAdopter, id, adminID)
+}
+
+func (r memAdopters) Purge(id, adminID uint) (models.AccountDeletion, error) {
+	return r.m.purge(deletableAdopter, id, adminID)
+}
+
+func (r memPets) Moderate(id, adminID uint, to, reason string) (services.PetModerationResult, error) {
+	var result services.PetModerationResult
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return result, services.ErrModerationReasonMissing
+	}
+
+	err := r.m.write(func(t *memoryTables) error {
+		pet := t.pet(id)
+		if pet == nil {
+			return services.ErrPetNotFound
+		}
+		from := pet.ListingStatus
+		if from == "" {
+			from = services.ListingListed
+		}
+		updates, err := services.PetListingMachine.Plan(from, to)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		pet.ListingStatus = updates["listing_status"].(string)
+		pet.ModerationReason = reason
+		pet.ModeratedAt = &now
+		result.Pet = *pet
+
+		switch pet.ListingStatus {
+		case services.ListingUnlisted:
+			open := t.openApplications(func(a models.AdoptionSubmission) bool { return a.PetID == id })
+			result.ApplicationsFrozen = t.holdApplications(open, adminID, services.HoldPetUnlisted, reason)
+		case services.ListingListed:
+			result.ApplicationsThawed = t.releaseHolds(services.HoldPetUnlisted, func(a models.AdoptionSubmission) bool { return a.PetID == id })
+		}
+
+		t.audit(adminID, services.ActionPetList, services.EntityPet, id, from, pet.ListingStatus, reason)
+		return nil
+	})
+	return result, err
+}
+
+func (r memReports) CheckVersion(id uint, versions []uint) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	var current *uint
+	if report := r.m.t.report(id); report != nil {
+		current = &report.Version
+	}
+	return checkVersion(current, versions)
+}
+
+func (r memReports) Dismiss(id, adminID uint, note string) (models.SubmittedReport, error) {
+	var report models.SubmittedReport
+	err := r.m.write(func(t *memoryTables) error {
+		stored := t.report(id)
+		if stored == nil {
+			return services.ErrReportNotFound
+		}
+		if stored.Status != services.ReportStatusReported {
+			return services.ErrReportNotPending
+		}
+		stored.Status = services.ReportStatusDismissed
+		stored.Version++
+		report = *stored
+
+		t.audit(adminID, services.ActionReportDismiss, services.EntityReport, id,
+			services.ReportStatusReported, services.ReportStatusDismissed, strings.TrimSpace(note))
+		return nil
+	})
+	return report, err
+}
+
+func (r memReports) AddAttachment(reportID, adopterID uint, fileName, fileData string) (models.ReportAttachment, error) {
+	var attachment models.ReportAttachment
+	err := r.m.write(func(t *memoryTables) error {
+		report := t.report(reportID)
+		if report == nil {
+			return services.ErrReportNotFound
+		}
+		if report.AdopterID != adopterID {
+			return services.ErrNotReportOwner
+		}
+		if report.Status != services.ReportStatusReported && report.Status != services.ReportStatusPending {
+			return services.ErrReportAlreadyClosed
+		}
+
+		upload, err := services.ValidateUpload(fileData, services.AttachmentUploadRules)
+		if err != nil {
+			return err
+		}
+		attached := filterRows(t.attachments, func(a models.ReportAttachment) bool { return a.ReportID == reportID })
+		if len(attached) >= services.MaxReportAttachments {
+			return services.ErrTooManyAttachments
+		}
+
+		attachment = models.ReportAttachment{
+			ReportID:  reportID,
+			FileName:  strings.TrimSpace(fileName),
+			MimeType:  upload.MimeType,
+			SizeBytes: upload.SizeBytes,
+			FileData:  upload.Data,
+		}
+		t.store(&attachment)
+		return nil
+	})
+	return attachment, err
+}
+
+func (r memReports) BackfillCategories() (int, error) {
+	updated := 0
+	err := r.m.write(func(t *memoryTables) error {
+		for i := range t.reports {
+			if t.reports[i].CategoryID != nil {
+				continue
+			}
+			if categoryID := services.ClassifyReason(t.categories, t.reports[i].Reason); categoryID != nil {
+				id := *categoryID
+				t.reports[i].CategoryID = &id
+				t.reports[i].Version++
+				updated++
+			}
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// DispatchNotices checks the channel as the GORM repositories do and marks
+// the notices due for sending as sent over it, without delivering them
+func (r memReports) DispatchNotices(channel string, smtp services.SMTPNotifier) (string, int, int, error) {
+	notifier, err := services.NewNotifier(channel, nil, smtp)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	sent := 0
+	err = r.m.write(func(t *memoryTables) error {
+		now := time.Now()
+		staleBefore := now.Add(-services.NoticeClaimTimeout)
+		for i := range t.notices {
+			notice := &t.notices[i]
+			due := notice.Status == services.NoticePending ||
+				(notice.Status == services.NoticeFailed && notice.Attempts < services.MaxNoticeAttempts) ||
+				(notice.Status == services.NoticeSending && (notice.ClaimedAt == nil || notice.ClaimedAt.Before(staleBefore)))
+			if !due {
+				continue
+			}
+			notice.Status = services.NoticeSent
+			notice.Channel = notifier.Channel()
+			notice.Attempts++
+			notice.LastError = ""
+			notice.ClaimedAt = &now
+			notice.SentAt = &now
+			sent++
+		}
+		return nil
+	})
+	return notifier.Channel(), sent, 0, err
+}
+
+func (r memAdmins) ByID(id uint) (models.AdminAccount, error) {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	if admin := findRow(r.m.t.admins, func(a models.AdminAccount) bool { return a.AdminID == id }); admin != nil {
+		return *admin, nil
+	}
+	return models.AdminAccount{}, ErrNotFound
+}
+
+// Evaluate needs the rule queries of the flagging service
+func (memFlags) Evaluate(uint) ([]models.ShelterFlag, error) {
+	return nil, ErrNotSimulated
+}
+
+// EvaluateAll needs the rule queries of the flagging service
+func (memFlags) EvaluateAll() (int, error) {
+	return 0, ErrNotSimulated
+}
+
+func (r memFlags) Resolve(flagID, adminID uint) error {
+	return r.m.write(func(t *memoryTables) error {
+		flag := findRow(t.flags, func(f models.ShelterFlag) bool { return f.FlagID == flagID && f.ResolvedAt == nil })
+		if flag == nil {
+			return services.ErrFlagNotFound
+		}
+		now := time.Now()
+		flag.ResolvedAt = &now
+		flag.ResolvedBy = adminID
+		if flag.Action == services.FlagTemporaryHold && t.flagHoldUntil(flag.ShelterID, now) == nil {
+			t.movePets(flag.ShelterID, services.HoldFlagged, "")
+		}
+		return nil
+	})
+}
+
+func (r memModeration) Review(itemID, adminID uint, action, note string) (models.ModerationItem, error) {
+	var item models.ModerationItem
+	var status string
+	switch services.NormalizeStatus(action) {
+	case "approve":
+		status = services.ModerationApproved
+	case "remove":
+		status = services.ModerationRemoved
+	default:
+		return item, services.ErrInvalidModeration
+	}
+
+	err := r.m.write(func(t *memoryTables) error {
+		stored := findRow(t.items, func(i models.ModerationItem) bool { return i.ItemID == itemID && i.Status == services.ModerationOpen })
+		if stored == nil {
+			return services.ErrModerationItemNotFound
+		}
+
+		if status == services.ModerationRemoved {
+			if _, err := services.FindContentSource(stored.Source); err != nil {
+				return err
+			}
+			enabled := filterRows(t.terms, func(term models.ScreeningTerm) bool { return term.Enabled })
+			t.redact(stored.Source, stored.EntityID, services.NewScreener(enabled).Redact)
+		}
+
+		now := time.Now()
+		stored.Status = status
+		stored.ReviewNote = strings.TrimSpace(note)
+		stored.ReviewedBy = adminID
+		stored.ReviewedAt = &now
+		item = *stored
+		return nil
+	})
+	return item, err
+}
+
+// redact rewrites the column a content source names, moving a versioned
+// row on
+func (t *memoryTables) redact(source string, entityID uint, redact func(string) string) {
+	switch source {
+	case "shelter_description":
+		if info := findRow(t.shelterInfos, func(i models.ShelterInfo) bool { return i.ShelterID == entityID }); info != nil {
+			info.ShelterDescription = redact(info.ShelterDescription)
+		}
+	case "pet_description":
+		if pet := t.pet(entityID); pet != nil {
+			pet.PetDescriptions = redact(pet.PetDescriptions)
+		}
+	case "adoption_reason":
+		if a := findRow(t.applications, func(a models.AdoptionSubmission) bool { return a.ApplicationID == entityID }); a != nil {
+			a.ReasonForAdoption = redact(a.ReasonForAdoption)
+		}
+	case "report_reason":
+		if report := t.report(entityID); report != nil {
+			report.Reason = redact(report.Reason)
+			report.Version++
+		}
+	case "report_description":
+		if report := t.report(entityID); report != nil {
+			report.Description = redact(report.Description)
+			report.Version++
+		}
+	}
+}
+
+// Scan needs the screening service's queries over the source tables
+func (memModeration) Scan(services.ContentSource) (int, error) {
+	return 0, ErrNotSimulated
+}
+
+// deletedAt returns the DeletedAt of a row soft deleted now, or of a live one
+func deletedAt(deleted bool) gorm.DeletedAt {
+	if !deleted {
+		return gorm.DeletedAt{}
+	}
+	return gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
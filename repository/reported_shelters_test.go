@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/pagination"
+	"pethubadmin/services"
+
+	"gorm.io/gorm"
+)
+
+// seedReportedShelters fills db with reported shelters of varied report
+// counts, severities and flags, plus reports that must not be grouped
+func seedReportedShelters(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	severe, mild := uint(1), uint(2)
+	categories := []models.ReportCategory{
+		{CategoryID: severe, Code: "animal_abuse", Name: "Animal abuse", Severity: services.SeverityCritical, Enabled: true},
+		{CategoryID: mild, Code: "misinformation", Name: "Misinformation", Severity: services.SeverityMedium, Enabled: true},
+	}
+	now := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	var accounts []models.ShelterAccount
+	var infos []models.ShelterInfo
+	var adopters []models.AdopterInfo
+	var reports []models.SubmittedReport
+	names := []string{"Paws", "bark house", "Zoo", "Ark", "Kitty Co", "Purr"}
+	for i, name := range names {
+		id := uint(i + 1)
+		status := services.StatusActive
+		if id == 6 {
+			status = services.StatusInactive
+		}
+		accounts = append(accounts, models.ShelterAccount{ShelterID: id, Username: fmt.Sprintf("shelter%d", id), Status: status, Version: 1})
+		infos = append(infos, models.ShelterInfo{ShelterID: id, ShelterName: name, ShelterEmail: fmt.Sprintf("s%d@pethub.test", id)})
+		adopters = append(adopters, models.AdopterInfo{AdopterID: id, FirstName: "Ana", Email: fmt.Sprintf("a%d@pethub.test", id)})
+
+		// Shelter i gets i reports; every other report of even shelters is severe
+		for j := 0; j < i+1; j++ {
+			report := models.SubmittedReport{ShelterID: id, AdopterID: id, Reason: "r", Status: services.ReportStatusReported, Version: 1, CreatedAt: now}
+			if id%2 == 0 && j%2 == 0 {
+				report.CategoryID = &severe
+			} else if id == 3 {
+				report.CategoryID = &mild
+			}
+			reports = append(reports, report)
+		}
+	}
+	// Not grouped: resolved, or filed by an adopter without a profile
+	reports = append(reports,
+		models.SubmittedReport{ShelterID: 1, AdopterID: 1, Status: services.ReportStatusResolved, Version: 1, CreatedAt: now},
+		models.SubmittedReport{ShelterID: 1, AdopterID: 99, Status: services.ReportStatusReported, Version: 1, CreatedAt: now},
+	)
+	for i := range reports {
+		reports[i].ID = uint(i + 1)
+	}
+	flags := []models.ShelterFlag{
+		{ShelterID: 1, Action: services.FlagEscalate},
+		{ShelterID: 3, RuleID: 1, Action: services.FlagRaisePriority},
+		{ShelterID: 3, RuleID: 2, Action: services.FlagRaisePriority},
+		{ShelterID: 5, Action: services.FlagRaisePriority, ResolvedAt: &now},
+	}
+
+	for _, rows := range []interface{}{&categories, &accounts, &infos, &adopters, &reports, &flags} {
+		if err := db.Create(rows).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+// allPages walks every page of the grouped list two shelters at a time
+func allPages(t *testing.T, repo ReportRepository, sort []pagination.SortField) []ReportedShelter {
+	t.Helper()
+	filter := ReportFilter{Status: services.ReportStatusReported, ShelterStatus: services.StatusActive}
+	page := pagination.Page{Limit: 2, Sort: sort}
+	var all []ReportedShelter
+	for {
+		result, err := repo.ReportedShelters(filter, page)
+		if err != nil {
+			t.Fatalf("ReportedShelters: %v", err)
+		}
+		if result.Total != 5 {
+			t.Fatalf("total = %d, want the 5 active reported shelters", result.Total)
+		}
+		all = append(all, result.Items...)
+		if result.NextCursor == "" {
+			return all
+		}
+		page.Cursor = result.NextCursor
+	}
+}
+
+func TestReportedShelters(t *testing.T) {
+	db := openTestDB(t)
+	seedReportedShelters(t, db)
+	repo := NewGorm(db).Reports
+
+	first := ReportedShelter{ShelterID: 1, ShelterName: "Paws", ShelterEmail: "s1@pethub.test",
+		TotalReports: 1, MaxSeverity: services.SeverityLow, Escalated: true}
+	third := ReportedShelter{ShelterID: 3, ShelterName: "Zoo", ShelterEmail: "s3@pethub.test",
+		TotalReports: 3, MaxSeverity: services.SeverityMedium, FlagPriority: 2}
+
+	cases := []struct {
+		sort []pagination.SortField
+		want []uint
+	}{
+		// Escalated first, then the most severe, then the most raised
+		{nil, []uint{1, 2, 4, 3, 5}},
+		{[]pagination.SortField{{Field: "total_reports", Desc: true}}, []uint{5, 4, 3, 2, 1}},
+		{[]pagination.SortField{{Field: "shelter_name"}}, []uint{4, 5, 1, 3, 2}},
+	}
+	for _, tc := range cases {
+		got := allPages(t, repo, tc.sort)
+		var ids []uint
+		for _, shelter := range got {
+			ids = append(ids, shelter.ShelterID)
+		}
+		if !reflect.DeepEqual(ids, tc.want) {
+			t.Fatalf("sort %v: shelters %v, want %v", tc.sort, ids, tc.want)
+		}
+		if tc.sort == nil && (got[0] != first || got[3] != third) {
+			t.Fatalf("default order starts %+v, fourth %+v", got[0], got[3])
+		}
+	}
+}
+
+func TestReportsOfShelters(t *testing.T) {
+	db := openTestDB(t)
+	seedReportedShelters(t, db)
+
+	reports, err := NewGorm(db).Reports.Reports(ReportFilter{Status: services.ReportStatusReported, ShelterIDs: []uint{2, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2+4 {
+		t.Fatalf("%d reports, want only those of shelters 2 and 4", len(reports))
+	}
+	for _, report := range reports {
+		if report.ShelterID != 2 && report.ShelterID != 4 {
+			t.Fatalf("report %d of shelter %d", report.ID, report.ShelterID)
+		}
+	}
+}
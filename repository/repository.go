@@ -0,0 +1,398 @@
+// Package repository is the storage layer behind the admin handlers. Each
+// entity has an interface with a GORM implementation, and Memory implements
+// them all without a database for handler tests. Writes that must commit
+// together run inside a UnitOfWork.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"pethubadmin/models"
+	"pethubadmin/pagination"
+	"pethubadmin/services"
+)
+
+// ErrNotFound is returned when a single record lookup matches nothing
+var ErrNotFound = errors.New("record not found")
+
+// CreatedRange bounds created_at; a zero end is open. To is exclusive.
+type CreatedRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// ShelterFilter narrows shelter account queries; empty fields match
+// everything. HasProfile keeps only shelters with a ShelterInfo.
+type ShelterFilter struct {
+	Status      string
+	RegStatus   string
+	Created     CreatedRange
+	HasProfile  bool
+	NewestFirst bool
+}
+
+// AdopterFilter narrows adopter account queries; empty fields match
+// everything. HasProfile keeps only adopters with an AdopterInfo.
+type AdopterFilter struct {
+	Status     string
+	Created    CreatedRange
+	HasProfile bool
+}
+
+// PetFilter narrows pet queries. ListedOnly drops pets hidden by moderation
+// or a cascade; PetType matches regardless of case; zero fields match
+// everything.
+type PetFilter struct {
+	ShelterID       uint
+	Status          string
+	ExcludeStatuses []string
+	PetType         string
+	Created         CreatedRange
+	ListedOnly      bool
+}
+
+// ReportFilter narrows submitted report queries. ShelterStatus keeps only
+// reports against shelters whose account has that status; ShelterIDs keeps
+// only reports against those shelters when set.
+type ReportFilter struct {
+	Status        string
+	ShelterStatus string
+	ShelterIDs    []uint
+	Created       CreatedRange
+	NewestFirst   bool
+}
+
+// ReportedShelter is one shelter of the grouped report list with the
+// aggregates the groups are sorted by. Only reports with a shelter profile
+// and a reporter profile count.
+type ReportedShelter struct {
+	ShelterID    uint
+	ShelterName  string
+	ShelterEmail string
+	TotalReports int
+	// MaxSeverity is the worst category severity, SeverityLow for reports
+	// without a category
+	MaxSeverity int
+	// FlagPriority and Escalated sum up the open flags as
+	// services.ShelterFlagSummaries does
+	FlagPriority int
+	Escalated    bool
+}
+
+// ShelterPetStats is the pet counts of one shelter
+type ShelterPetStats struct {
+	ShelterID   uint
+	ShelterName string
+	Total       int
+	Cats        int
+	Dogs        int
+	Vaccinated  int
+}
+
+// ShelterRepository reads and moderates shelter accounts, profiles and
+// their review data
+type ShelterRepository interface {
+	Account(id uint) (models.ShelterAccount, error)
+	Accounts(filter ShelterFilter) ([]models.ShelterAccount, error)
+	CountAccounts(filter ShelterFilter) (int64, error)
+	// AccountPage returns one page of accounts sorted by ShelterSort fields
+	AccountPage(filter ShelterFilter, page pagination.Page) (pagination.Result[models.ShelterAccount], error)
+	// Infos returns the profiles of the given shelters, or all when no IDs are given
+	Infos(ids ...uint) ([]models.ShelterInfo, error)
+	// Info returns one profile with its media
+	Info(id uint) (models.ShelterInfo, error)
+	Media(ids ...uint) (map[uint]models.ShelterMedia, error)
+	Documents(ids ...uint) ([]models.ShelterDocument, error)
+	// Document returns one document including its file data
+	Document(id uint) (models.ShelterDocument, error)
+	FlagSummaries(ids []uint) (map[uint]services.ShelterFlagSummary, error)
+	// ReviewHistory returns a shelter's review rounds, oldest first
+	ReviewHistory(id uint) ([]services.ReviewRoundView, error)
+	ReviewQueue(settings services.ReviewQueueSettings) ([]services.QueueItem, error)
+	ReviewerThroughput(since time.Time) ([]services.ReviewerStats, error)
+	Duplicates(minScore float64) ([]services.DuplicateCandidate, error)
+
+	// CheckVersion fails with services.ErrVersionMismatch unless the
+	// account is at one of versions; no versions always pass
+	CheckVersion(id uint, versions []uint) error
+	// SetStatus blocks the shelter under policy when status is inactive and
+	// reinstates it when it is active
+	SetStatus(id, adminID uint, status string, policy services.ShelterBlockPolicy) (services.ShelterModerationResult, error)
+	// DecideRegistration approves or rejects a pending registration, taking
+	// the review claim for claimTTL when the admin holds none
+	DecideRegistration(id, adminID uint, decision, reasonCode, feedback string, claimTTL time.Duration) (models.ShelterAccount, models.ShelterReviewRound, error)
+	Resubmit(id uint, update services.ShelterInfoSnapshot) (models.ShelterReviewRound, error)
+	ClaimReview(id, adminID uint, ttl time.Duration) (models.ShelterReviewClaim, error)
+	ReleaseReview(id, adminID uint) error
+	Block(id, adminID uint, policy services.ShelterBlockPolicy, reason string) (services.ShelterModerationResult, error)
+	Reinstate(id, adminID uint) (services.ShelterModerationResult, error)
+	UploadDocument(id uint, docType, fileName, fileData string) (models.ShelterDocument, error)
+	ReviewDocument(documentID, adminID uint, status, reason string) (models.ShelterDocument, error)
+	DismissDuplicate(idA, idB, adminID uint) error
+	Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error)
+	SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error)
+	Restore(id, adminID uint) (models.AccountDeletion, error)
+	Purge(id, adminID uint) (models.AccountDeletion, error)
+}
+
+// AdopterRepository reads and moderates adopter accounts and profiles
+type AdopterRepository interface {
+	Account(id uint) (models.AdopterAccount, error)
+	Accounts(filter AdopterFilter) ([]models.AdopterAccount, error)
+	CountAccounts(filter AdopterFilter) (int64, error)
+	// AccountPage returns one page of accounts sorted by AdopterSort fields
+	AccountPage(filter AdopterFilter, page pagination.Page) (pagination.Result[models.AdopterAccount], error)
+	// Infos returns the profiles of the given adopters, or all when no IDs are given
+	Infos(ids ...uint) ([]models.AdopterInfo, error)
+	// Info returns one profile with its media
+	Info(id uint) (models.AdopterInfo, error)
+	Duplicates(minScore float64) ([]services.DuplicateCandidate, error)
+
+	// CheckVersion is ShelterRepository.CheckVersion for adopter accounts
+	CheckVersion(id uint, versions []uint) error
+	SetStatus(id, adminID uint, status string, options services.AdopterStatusOptions) (models.AdopterAccount, services.CascadeResult, error)
+	DismissDuplicate(idA, idB, adminID uint) error
+	Merge(survivorID, duplicateID, adminID uint) (models.AccountMerge, error)
+	SoftDelete(id, adminID uint, reason string, settings services.AccountDeletionSettings) (models.AccountDeletion, error)
+	Restore(id, adminID uint) (models.AccountDeletion, error)
+	Purge(id, adminID uint) (models.AccountDeletion, error)
+}
+
+// PetRepository reads and moderates pet listings with their media
+type PetRepository interface {
+	// Pet returns one pet with its media
+	Pet(id uint) (models.PetInfo, error)
+	Pets(filter PetFilter) ([]models.PetInfo, error)
+	Count(filter PetFilter) (int64, error)
+	// Page returns one page of pets with their media, sorted by PetSort fields
+	Page(filter PetFilter, page pagination.Page) (pagination.Result[models.PetInfo], error)
+	// ShelterStats counts the pets matching pets for every shelter matching
+	// shelters, including shelters with no pets. Shelters without a profile
+	// are left out.
+	ShelterStats(shelters ShelterFilter, pets PetFilter) ([]ShelterPetStats, error)
+	// ModerationHistory lists the moderation actions on a shelter's pets,
+	// newest first
+	ModerationHistory(shelterID uint) ([]services.PetModerationEntry, error)
+	// Moderate moves a listing to a new status with a required reason
+	Moderate(id, adminID uint, to, reason string) (services.PetModerationResult, error)
+}
+
+// ReportRepository reads submitted reports with the shelter and reporter
+// attached, and the data shown next to them, and closes reports
+type ReportRepository interface {
+	// Report returns one report with its shelter and reporter
+	Report(id uint) (models.SubmittedReport, error)
+	Reports(filter ReportFilter) ([]models.SubmittedReport, error)
+	// ReportedShelters returns one page of the shelters with reports
+	// matching filter, sorted by ReportedShelterSort fields
+	ReportedShelters(filter ReportFilter, page pagination.Page) (pagination.Result[ReportedShelter], error)
+	Categories() (map[uint]models.ReportCategory, error)
+	// CategoryList returns the taxonomy, most severe first
+	CategoryList(includeDisabled bool) ([]models.ReportCategory, error)
+	Category(id uint) (models.ReportCategory, error)
+	// SaveCategory creates the category when it has no ID yet
+	SaveCategory(category *models.ReportCategory) error
+	// Analytics counts reports created since (all time when zero) per
+	// category, and the reports with no category
+	Analytics(since time.Time) ([]services.CategoryAnalyticsRow, int64, error)
+	Credibility(adopterIDs []uint) (map[uint]services.Credibility, error)
+	// Attachments lists attachment metadata, without file data, per report
+	Attachments(reportIDs ...uint) (map[uint][]models.ReportAttachment, error)
+	// Attachment returns one attachment including its file data
+	Attachment(id uint) (models.ReportAttachment, error)
+	// Notices returns the outcome notices sent about a report, oldest first
+	Notices(reportID uint) ([]models.OutcomeNotice, error)
+
+	// CheckVersion is ShelterRepository.CheckVersion for reports
+	CheckVersion(id uint, versions []uint) error
+	Dismiss(id, adminID uint, note string) (models.SubmittedReport, error)
+	// AddAttachment stores an evidence file on an open report filed by adopterID
+	AddAttachment(reportID, adopterID uint, fileName, fileData string) (models.ReportAttachment, error)
+	// BackfillCategories classifies the reports with no category and
+	// returns how many it classified
+	BackfillCategories() (int, error)
+	// DispatchNotices sends pending outcome notices, and retries failed
+	// ones, over the named channel. It returns the channel used and how
+	// many were sent and failed.
+	DispatchNotices(channel string, smtp services.SMTPNotifier) (string, int, int, error)
+}
+
+// ApplicationRepository reads adoption applications with the adopter,
+// shelter and pet names attached
+type ApplicationRepository interface {
+	ByShelter(shelterID uint) ([]models.AdoptionSubmission, error)
+	ByAdopter(adopterID uint) ([]models.AdoptionSubmission, error)
+	// Frozen reports which of the applications are on hold
+	Frozen(applicationIDs []uint) (map[uint]bool, error)
+}
+
+// AdminRepository stores admin accounts
+type AdminRepository interface {
+	ByID(id uint) (models.AdminAccount, error)
+	ByUsername(username string) (models.AdminAccount, error)
+	Create(admin *models.AdminAccount) error
+}
+
+// FlagRepository stores flag rules and raises and resolves the flags they
+// describe
+type FlagRepository interface {
+	Rules() ([]models.FlagRule, error)
+	Rule(id uint) (models.FlagRule, error)
+	// SaveRule creates the rule when it has no ID yet
+	SaveRule(rule *models.FlagRule) error
+	DeleteRule(id uint) error
+	// Flags lists flags newest first, for one shelter when shelterID is set
+	Flags(shelterID uint, includeResolved bool) ([]models.ShelterFlag, error)
+	// Evaluate runs the enabled rules against one shelter and returns the
+	// flags it raised
+	Evaluate(shelterID uint) ([]models.ShelterFlag, error)
+	// EvaluateAll runs the enabled rules against every shelter and returns
+	// how many flags were raised
+	EvaluateAll() (int, error)
+	Resolve(flagID, adminID uint) error
+}
+
+// ModerationRepository reads and reviews the moderation queue and stores the
+// screening terms that fill it
+type ModerationRepository interface {
+	// Queue lists items with the status, oldest first; an empty source
+	// matches every source
+	Queue(status, source string) ([]models.ModerationItem, error)
+	Terms() ([]models.ScreeningTerm, error)
+	Term(id uint) (models.ScreeningTerm, error)
+	// SaveTerm creates the term when it has no ID yet
+	SaveTerm(term *models.ScreeningTerm) error
+	DeleteTerm(id uint) error
+	// Review approves or removes an open item; removing redacts the content
+	Review(itemID, adminID uint, action, note string) (models.ModerationItem, error)
+	// Scan screens every row of a source and returns how many were queued
+	Scan(source services.ContentSource) (int, error)
+}
+
+// AuditRepository reads the audit trail and account deletion records
+type AuditRepository interface {
+	// Trail returns entries newest first; zero arguments match everything
+	Trail(entityType string, entityID uint, limit int) ([]models.AdminAuditLog, error)
+	// Deletions returns deletions newest first; openOnly leaves out restored
+	// and purged accounts
+	Deletions(entityType string, openOnly bool) ([]models.AccountDeletion, error)
+}
+
+// NotificationRepository reads and updates in-app notifications
+type NotificationRepository interface {
+	ForRecipient(recipientType string, recipientID uint, unreadOnly bool) ([]models.Notification, error)
+	// MarkRead fails with services.ErrNotificationNotFound unless the
+	// recipient has that notification unread
+	MarkRead(recipientType string, recipientID, notificationID uint) error
+}
+
+// SearchRepository finds records of several entity types by free text
+type SearchRepository interface {
+	// Search returns up to limit ranked hits per type, grouped by type with
+	// the best group first
+	Search(query string, types []string, limit int) ([]services.SearchGroup, error)
+}
+
+// Repositories bundles the repositories a handler needs
+type Repositories struct {
+	Shelters      ShelterRepository
+	Adopters      AdopterRepository
+	Pets          PetRepository
+	Reports       ReportRepository
+	Applications  ApplicationRepository
+	Admins        AdminRepository
+	Search        SearchRepository
+	Flags         FlagRepository
+	Moderation    ModerationRepository
+	Audit         AuditRepository
+	Notifications NotificationRepository
+
+	// afterCommit is set on the repositories of a unit of work
+	afterCommit func(hook func())
+}
+
+// AfterCommit runs hook once the unit of work the repositories belong to
+// has committed, and drops it if the unit rolls back. Outside a unit it runs
+// immediately. Use it for side effects such as sending messages.
+func (r Repositories) AfterCommit(hook func()) {
+	if r.afterCommit == nil {
+		hook()
+		return
+	}
+	r.afterCommit(hook)
+}
+
+// HasAccount reports whether an account of kind (services.EntityAdmin,
+// EntityShelter or EntityAdopter) has the ID; it lets the JWT middleware
+// tell which account a token without a kind claim is for
+func (r Repositories) HasAccount(kind string, id uint) (bool, error) {
+	var err error
+	switch kind {
+	case services.EntityAdmin:
+		_, err = r.Admins.ByID(id)
+	case services.EntityShelter:
+		_, err = r.Shelters.Account(id)
+	case services.EntityAdopter:
+		_, err = r.Adopters.Account(id)
+	default:
+		return false, nil
+	}
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// UnitOfWork runs an admin action that writes through several repositories
+// as one transaction: every write made through tx commits together or none
+// do. Handlers hold a UnitOfWork so tests can swap in one that injects
+// failures.
+type UnitOfWork interface {
+	Do(fn func(tx Repositories) error) error
+}
+
+// ShelterSort is the sortable fields of shelter account lists
+var ShelterSort = pagination.NewSpec("shelter_id", []pagination.SortField{{Field: "shelter_id"}},
+	pagination.Int("shelter_id", "shelteraccount.shelter_id", func(a models.ShelterAccount) int64 { return int64(a.ShelterID) }),
+	pagination.String("username", "shelteraccount.username", func(a models.ShelterAccount) string { return a.Username }),
+	pagination.String("status", "shelteraccount.status", func(a models.ShelterAccount) string { return a.Status }),
+	pagination.String("reg_status", "shelteraccount.reg_status", func(a models.ShelterAccount) string { return a.RegStatus }),
+	pagination.Time("created_at", "shelteraccount.created_at", func(a models.ShelterAccount) time.Time { return a.CreatedAt }),
+)
+
+// AdopterSort is the sortable fields of adopter account lists
+var AdopterSort = pagination.NewSpec("adopter_id", []pagination.SortField{{Field: "adopter_id"}},
+	pagination.Int("adopter_id", "adopteraccount.adopter_id", func(a models.AdopterAccount) int64 { return int64(a.AdopterID) }),
+	pagination.String("username", "adopteraccount.username", func(a models.AdopterAccount) string { return a.Username }),
+	pagination.String("status", "adopteraccount.status", func(a models.AdopterAccount) string { return a.Status }),
+	pagination.Time("created_at", "adopteraccount.created_at", func(a models.AdopterAccount) time.Time { return a.CreatedAt }),
+)
+
+// PetSort is the sortable fields of pet lists
+var PetSort = pagination.NewSpec("pet_id", []pagination.SortField{{Field: "pet_id"}},
+	pagination.Int("pet_id", "petinfo.pet_id", func(p models.PetInfo) int64 { return int64(p.PetID) }),
+	pagination.String("pet_name", "petinfo.pet_name", func(p models.PetInfo) string { return p.PetName }),
+	pagination.String("pet_type", "petinfo.pet_type", func(p models.PetInfo) string { return p.PetType }),
+	pagination.Int("pet_age", "petinfo.pet_age", func(p models.PetInfo) int64 { return int64(p.PetAge) }),
+	pagination.String("status", "petinfo.status", func(p models.PetInfo) string { return p.Status }),
+	pagination.Time("created_at", "petinfo.created_at", func(p models.PetInfo) time.Time { return p.CreatedAt }),
+)
+
+// ReportedShelterSort is the sortable fields of the grouped report list.
+// The columns are those of the reported_shelters subquery built by the GORM
+// repository.
+var ReportedShelterSort = pagination.NewSpec("shelter_id",
+	[]pagination.SortField{{Field: "escalated", Desc: true}, {Field: "max_severity", Desc: true}, {Field: "flag_priority", Desc: true}},
+	pagination.Int("shelter_id", "reported_shelters.shelter_id", func(r ReportedShelter) int64 { return int64(r.ShelterID) }),
+	pagination.String("shelter_name", "reported_shelters.shelter_name", func(r ReportedShelter) string { return r.ShelterName }),
+	pagination.Int("total_reports", "reported_shelters.total_reports", func(r ReportedShelter) int64 { return int64(r.TotalReports) }),
+	pagination.Int("max_severity", "reported_shelters.max_severity", func(r ReportedShelter) int64 { return int64(r.MaxSeverity) }),
+	pagination.Int("flag_priority", "reported_shelters.flag_priority", func(r ReportedShelter) int64 { return int64(r.FlagPriority) }),
+	pagination.Int("escalated", "reported_shelters.escalated", func(r ReportedShelter) int64 {
+		if r.Escalated {
+			return 1
+		}
+		return 0
+	}),
+)
@@ -1,51 +1,125 @@
 package routes
 
 import (
+	"pethubadmin/config"
 	"pethubadmin/controllers"
 	"pethubadmin/middleware"
+	"pethubadmin/repository"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 )
 
-func AppRoutes(app *fiber.App) {
+func AppRoutes(app *fiber.App, cfg config.Config, db *gorm.DB) {
 
-	pethubRoutes := app.Group("/api", middleware.JWTMiddleware())
+	repos := repository.NewGorm(db)
+	auth := middleware.NewJWTAuth(cfg.JWT, repos)
+	admin := controllers.NewAdminHandler(cfg, repos, repository.NewUnitOfWork(db), auth)
+
+	// ---------------- General Shared Routes ----------------
+	// Main-app shelter and adopter tokens read these too. They are
+	// registered before the /api group so its admin-only middleware, which
+	// runs for every path under /api, never sees them.
+	app.Get("/api/allshelter", auth.RequireToken(), admin.GetShelter)
+	app.Get("/api/users/shelters/:id", auth.RequireToken(), admin.GetAllSheltersByID)
+
+	pethubRoutes := app.Group("/api", auth.JWTMiddleware())
 
 	// ---------------- Admin Routes ----------------
-	app.Post("/admin/register", controllers.RegisterAdmin)
-	app.Post("/admin/login", controllers.LoginAdmin)
-	app.Get("/admin/getallpendingrequest", controllers.GetAllPendingRequests)
-	app.Get("/admin/getalladopters", controllers.GetAllAdopters)
-	app.Get("/admin/getallshelters", controllers.GetAllShelters)
-	app.Post("/admin/updateregstatus", controllers.UpdateRegistrationStatus)
-	app.Post("/admin/updateshelterstatus", controllers.UpdateShelterStatus)
-	app.Post("/admin/updateadopterstatus", controllers.UpdateAdopterStatus)
+	app.Post("/admin/register", admin.RegisterAdmin)
+	app.Post("/admin/login", admin.LoginAdmin)
+	app.Get("/admin/getallpendingrequest", admin.GetAllPendingRequests)
+	app.Get("/admin/getalladopters", admin.GetAllAdopters)
+	app.Get("/admin/getallshelters", admin.GetAllShelters)
+	app.Post("/admin/updateregstatus", auth.JWTMiddleware(), admin.UpdateRegistrationStatus)
+	app.Post("/admin/updateshelterstatus", auth.JWTMiddleware(), admin.UpdateShelterStatus)
+	app.Post("/admin/updateadopterstatus", auth.JWTMiddleware(), admin.UpdateAdopterStatus)
 
 	//try
-	pethubRoutes.Get("/admin/getallshelterstry", controllers.GetAllSheltersAdmintry) // Route to get all shelters by id
-	app.Get("/admin/getalladopterstry", controllers.GetAllAdoptersAdmintry)          // Route to get all adopters by id
-	pethubRoutes.Put("/admin/shelters/:id/approve", controllers.ApproveShelterRegStatus)
-	app.Get("/admin/shelters/count", controllers.CountActiveShelters)
-	app.Get("/admin/adopters/count", controllers.CountAdopters)
-	app.Get("/admin/pets/count", controllers.CountPets)
-	app.Get("/admin/adoptedpets/count", controllers.CountAdoptedPets)
-	app.Get("/admin/pendingshelters/count", controllers.CountPendingShelters)
-	app.Get("/admin/approvedshelters/count", controllers.CountApprovedShelters)
-	pethubRoutes.Get("/admin/blockedadopters", controllers.GetInactiveAdopters)
-	pethubRoutes.Put("/admin/adopters/:id/activate", controllers.ActivateAdopter)
-	app.Get("/admin/allreports", controllers.GetSubmittedReports)
-	app.Get("/admin/shelterpetcounts", controllers.GetShelterPetCounts)
-	app.Get("/admin/vaccinecounts", controllers.GetShelterVaccinationCounts)
-	app.Put("/admin/shelters/:id/status", controllers.UpdateShelterStatusByID)
-	app.Get("/admin/blockedshelters", controllers.GetBlockedShelters)
-	app.Put("/admin/shelters/:id/activate", controllers.UpdateShelterStatusByIDtoactive)
-	app.Get("/applications/adopter/:adopter_id", controllers.GetApplicationsByAdopterID)
-	//app.Get("/admin/adopter/:adopter_id", controllers.GetApplicationsByAdopterID)
-	app.Get("/admin/notifications", controllers.GetAllNotifications)
-	app.Get("/applications/shelter/:shelter_id", controllers.GetApplicationsByShelterID)
-	app.Get("/shelter/:shelter_id/pets", controllers.GetPetsByShelterID)
-
-	// ---------------- General Shared Routes ----------------
-	pethubRoutes.Get("/allshelter", controllers.GetShelter)
-	pethubRoutes.Get("/users/shelters/:id", controllers.GetAllSheltersByID)
+	pethubRoutes.Get("/admin/getallshelterstry", admin.GetAllSheltersAdmintry) // Route to get all shelters by id
+	app.Get("/admin/getalladopterstry", admin.GetAllAdoptersAdmintry)          // Route to get all adopters by id
+	pethubRoutes.Put("/admin/shelters/:id/approve", admin.ApproveShelterRegStatus)
+	app.Get("/admin/shelters/count", admin.CountActiveShelters)
+	app.Get("/admin/adopters/count", admin.CountAdopters)
+	app.Get("/admin/pets/count", admin.CountPets)
+	app.Get("/admin/adoptedpets/count", admin.CountAdoptedPets)
+	app.Get("/admin/pendingshelters/count", admin.CountPendingShelters)
+	app.Get("/admin/approvedshelters/count", admin.CountApprovedShelters)
+	pethubRoutes.Get("/admin/blockedadopters", admin.GetInactiveAdopters)
+	pethubRoutes.Put("/admin/adopters/:id/activate", admin.ActivateAdopter)
+	app.Get("/admin/allreports", admin.GetSubmittedReports)
+	app.Get("/admin/shelterpetcounts", admin.GetShelterPetCounts)
+	app.Get("/admin/vaccinecounts", admin.GetShelterVaccinationCounts)
+	app.Put("/admin/shelters/:id/status", auth.JWTMiddleware(), admin.UpdateShelterStatusByID)
+	app.Get("/admin/blockedshelters", admin.GetBlockedShelters)
+	app.Put("/admin/shelters/:id/activate", auth.JWTMiddleware(), admin.UpdateShelterStatusByIDtoactive)
+	app.Get("/applications/adopter/:adopter_id", admin.GetApplicationsByAdopterID)
+	//app.Get("/admin/adopter/:adopter_id", admin.GetApplicationsByAdopterID)
+	app.Get("/admin/notifications", admin.GetAllNotifications)
+	app.Get("/applications/shelter/:shelter_id", admin.GetApplicationsByShelterID)
+	app.Get("/shelter/:shelter_id/pets", admin.GetPetsByShelterID)
+	pethubRoutes.Get("/admin/rejectionreasons", admin.GetRejectionReasons)
+	pethubRoutes.Get("/admin/shelters/:id/reviews", admin.GetShelterReviewHistory)
+	app.Put("/shelter/:shelter_id/resubmit", auth.RequireShelterOwner("shelter_id"), admin.ResubmitShelterRegistration)
+	pethubRoutes.Get("/admin/shelters/:id/documents", admin.GetShelterDocumentChecklist)
+	pethubRoutes.Get("/admin/documents/:id", admin.GetShelterDocument)
+	pethubRoutes.Put("/admin/documents/:id/review", admin.ReviewShelterDocument)
+	app.Post("/shelter/:shelter_id/documents", auth.RequireShelterOwner("shelter_id"), admin.UploadShelterDocument)
+	app.Get("/shelter/:shelter_id/documents", auth.RequireShelterOwner("shelter_id"), admin.GetShelterDocumentChecklist)
+	app.Get("/shelter/:shelter_id/pets/moderation", auth.RequireShelterOwner("shelter_id"), admin.GetPetModerationHistory)
+	app.Get("/reportcategories", admin.GetReportCategories)
+	app.Post("/reports/:id/attachments", auth.RequireAdopter(), admin.UploadReportAttachment)
+	app.Get("/adopter/:adopter_id/notifications", auth.RequireAdopterOwner("adopter_id"), admin.GetAdopterNotifications)
+	app.Put("/adopter/:adopter_id/notifications/:id/read", auth.RequireAdopterOwner("adopter_id"), admin.MarkAdopterNotificationRead)
+	app.Get("/shelter/:shelter_id/notifications", auth.RequireShelterOwner("shelter_id"), admin.GetShelterNotifications)
+	app.Put("/shelter/:shelter_id/notifications/:id/read", auth.RequireShelterOwner("shelter_id"), admin.MarkShelterNotificationRead)
+	pethubRoutes.Get("/admin/notifications", admin.GetAdminNotifications)
+	pethubRoutes.Put("/admin/notifications/:id/read", admin.MarkAdminNotificationRead)
+	pethubRoutes.Get("/admin/reviewqueue", admin.GetReviewQueue)
+	pethubRoutes.Get("/admin/reviewqueue/throughput", admin.GetReviewerThroughput)
+	pethubRoutes.Post("/admin/reviewqueue/:id/claim", admin.ClaimShelterReview)
+	pethubRoutes.Delete("/admin/reviewqueue/:id/claim", admin.ReleaseShelterReview)
+	pethubRoutes.Post("/admin/bulk/registrations", admin.BulkUpdateRegistrationStatus)
+	pethubRoutes.Post("/admin/bulk/adopters", admin.BulkUpdateAdopterStatus)
+	pethubRoutes.Post("/admin/bulk/shelters", admin.BulkUpdateShelterStatus)
+	pethubRoutes.Get("/admin/auditlogs", admin.GetAuditLogs)
+	pethubRoutes.Get("/admin/flagrules", admin.GetFlagRules)
+	pethubRoutes.Post("/admin/flagrules", middleware.RequireSuperAdmin(repos.Admins), admin.CreateFlagRule)
+	pethubRoutes.Put("/admin/flagrules/:id", middleware.RequireSuperAdmin(repos.Admins), admin.UpdateFlagRule)
+	pethubRoutes.Delete("/admin/flagrules/:id", middleware.RequireSuperAdmin(repos.Admins), admin.DeleteFlagRule)
+	pethubRoutes.Post("/admin/flagrules/evaluate", admin.EvaluateFlagRules)
+	pethubRoutes.Get("/admin/flags", admin.GetShelterFlags)
+	pethubRoutes.Put("/admin/flags/:id/resolve", admin.ResolveShelterFlag)
+	pethubRoutes.Get("/admin/moderation", admin.GetModerationQueue)
+	pethubRoutes.Put("/admin/moderation/:id", admin.ReviewModerationItem)
+	pethubRoutes.Post("/admin/moderation/scan", admin.ScanContent)
+	pethubRoutes.Get("/admin/screeningterms", admin.GetScreeningTerms)
+	pethubRoutes.Post("/admin/screeningterms", middleware.RequireSuperAdmin(repos.Admins), admin.CreateScreeningTerm)
+	pethubRoutes.Put("/admin/screeningterms/:id", middleware.RequireSuperAdmin(repos.Admins), admin.UpdateScreeningTerm)
+	pethubRoutes.Delete("/admin/screeningterms/:id", middleware.RequireSuperAdmin(repos.Admins), admin.DeleteScreeningTerm)
+	pethubRoutes.Put("/admin/pets/:id/unlist", admin.UnlistPet)
+	pethubRoutes.Put("/admin/pets/:id/relist", admin.RelistPet)
+	pethubRoutes.Put("/admin/pets/:id/flag", admin.FlagPet)
+	pethubRoutes.Post("/admin/reportcategories", middleware.RequireSuperAdmin(repos.Admins), admin.CreateReportCategory)
+	pethubRoutes.Put("/admin/reportcategories/:id", middleware.RequireSuperAdmin(repos.Admins), admin.UpdateReportCategory)
+	pethubRoutes.Post("/admin/reportcategories/backfill", admin.BackfillReportCategories)
+	pethubRoutes.Get("/admin/reports/analytics", admin.GetReportAnalytics)
+	pethubRoutes.Get("/admin/reports/:id", admin.GetReportDetail)
+	pethubRoutes.Put("/admin/reports/:id/dismiss", admin.DismissReport)
+	pethubRoutes.Get("/admin/reportattachments/:id", admin.GetReportAttachment)
+	pethubRoutes.Post("/admin/notices/dispatch", admin.DispatchOutcomeNotices)
+	pethubRoutes.Get("/admin/reporters/:id/credibility", admin.GetReporterCredibility)
+	pethubRoutes.Get("/admin/duplicates/:entity", admin.GetDuplicateCandidates)
+	pethubRoutes.Post("/admin/duplicates/:entity/dismiss", admin.DismissDuplicateCandidate)
+	pethubRoutes.Post("/admin/duplicates/:entity/merge", admin.MergeDuplicateAccounts)
+	pethubRoutes.Get("/admin/search", admin.Search)
+	pethubRoutes.Get("/admin/adopters/:adopter_id", admin.GetAdopterInfoById)
+	pethubRoutes.Get("/admin/pets/:id", admin.GetPetByID)
+	pethubRoutes.Get("/admin/deletions", admin.GetAccountDeletions)
+	pethubRoutes.Delete("/admin/shelters/:id", admin.DeleteShelter)
+	pethubRoutes.Post("/admin/shelters/:id/restore", admin.RestoreShelter)
+	pethubRoutes.Delete("/admin/shelters/:id/purge", middleware.RequireSuperAdmin(repos.Admins), admin.PurgeShelter)
+	pethubRoutes.Delete("/admin/adopters/:id", admin.DeleteAdopter)
+	pethubRoutes.Post("/admin/adopters/:id/restore", admin.RestoreAdopter)
+	pethubRoutes.Delete("/admin/adopters/:id/purge", middleware.RequireSuperAdmin(repos.Admins), admin.PurgeAdopter)
 }
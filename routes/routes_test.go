@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pethubadmin/config"
+	"pethubadmin/migrations"
+	"pethubadmin/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testSecret = "test-secret"
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSharedRoutesTakeMainAppTokens(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(migrations.Models...); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.ShelterInfo{ShelterID: 3, ShelterName: "Paws"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	cfg := config.Default()
+	cfg.JWT.SecretKey = testSecret
+	AppRoutes(app, cfg, db)
+
+	cases := []struct {
+		name   string
+		target string
+		token  string
+		passed bool
+	}{
+		{"shelter token on shared list", "/api/allshelter", sign(t, jwt.MapClaims{"id": 3, "kind": "shelter"}), true},
+		{"adopter token on shared list", "/api/allshelter", sign(t, jwt.MapClaims{"id": 5, "kind": "adopter"}), true},
+		{"kindless token on shared list", "/api/allshelter", sign(t, jwt.MapClaims{"id": 5}), true},
+		{"admin token on shared list", "/api/allshelter", sign(t, jwt.MapClaims{"id": 7, "kind": "admin"}), true},
+		{"adopter token on shared shelter", "/api/users/shelters/3", sign(t, jwt.MapClaims{"id": 5, "kind": "adopter"}), true},
+		{"no token on shared list", "/api/allshelter", "", false},
+		{"adopter token on admin route", "/api/admin/flags", sign(t, jwt.MapClaims{"id": 5, "kind": "adopter"}), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			raw, _ := io.ReadAll(resp.Body)
+
+			// The JWT middleware answers a refused token with RetCode 401
+			var refused struct {
+				RetCode string `json:"retCode"`
+			}
+			_ = json.Unmarshal(raw, &refused)
+			passed := resp.StatusCode == fiber.StatusOK && refused.RetCode != "401"
+			if passed != tc.passed {
+				t.Fatalf("status %d, body %s; want passed %v", resp.StatusCode, raw, tc.passed)
+			}
+		})
+	}
+}
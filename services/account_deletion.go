@@ -0,0 +1,413 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Audit actions for account deletion
+const (
+	ActionShelterDelete  = "shelter_delete"
+	ActionShelterRestore = "shelter_restore"
+	ActionShelterPurge   = "shelter_purge"
+	ActionAdopterDelete  = "adopter_delete"
+	ActionAdopterRestore = "adopter_restore"
+	ActionAdopterPurge   = "adopter_purge"
+)
+
+// Cascade sources used while an account is soft deleted, stored in
+// petinfo.hidden_by and application_holds.source
+const (
+	HoldShelterDeleted = "shelter_deleted"
+	HoldAdopterDeleted = "adopter_deleted"
+)
+
+// StatusDeleted is written to the audit log for deletions; it is not an
+// account status
+const StatusDeleted = "deleted"
+
+var (
+	ErrAccountDeleted      = errors.New("account is already deleted")
+	ErrAccountNotDeleted   = errors.New("account is not deleted")
+	ErrRestoreWindowClosed = errors.New("the restore window has closed")
+)
+
+// AccountDeletionSettings controls how long a deleted account can be
+// restored and how often expired deletions are purged
+type AccountDeletionSettings struct {
+	RestoreWindow time.Duration
+	PurgeInterval time.Duration
+}
+
+// DefaultAccountDeletionSettings keeps deleted accounts for 30 days and
+// purges expired ones every hour
+var DefaultAccountDeletionSettings = AccountDeletionSettings{
+	RestoreWindow: 30 * 24 * time.Hour,
+	PurgeInterval: time.Hour,
+}
+
+// PurgeStep removes the rows of one table that belong to a purged account.
+// Where uses @id for the account ID.
+type PurgeStep struct {
+	Table string
+	Where string
+}
+
+// AdopterPurgeCascade is run in order when an adopter is hard deleted:
+// applications with their holds, photos and interviews, adopted-pet records,
+// submitted reports with their attachments, messages, and finally the
+// account itself. Audit entries and merge history are kept.
+var AdopterPurgeCascade = []PurgeStep{
+	{"application_holds", "application_id IN (SELECT application_id FROM adoption_submissions WHERE adopter_id = @id)"},
+	{"application_photos", "image_id IN (SELECT image_id FROM adoption_submissions WHERE adopter_id = @id)"},
+	{"moderation_queue", "source = 'adoption_reason' AND entity_id IN (SELECT application_id FROM adoption_submissions WHERE adopter_id = @id)"},
+	{"schedule_interview", "adopter_id = @id"},
+	{"adoption_submissions", "adopter_id = @id"},
+	{"adopterpets", "adopter_id = @id"},
+	{"outcome_notice_reports", "report_id IN (SELECT id FROM submittedreports WHERE adopter_id = @id)"},
+	{"report_attachments", "report_id IN (SELECT id FROM submittedreports WHERE adopter_id = @id)"},
+	{"moderation_queue", "source IN ('report_reason', 'report_description') AND entity_id IN (SELECT id FROM submittedreports WHERE adopter_id = @id)"},
+	{"submittedreports", "adopter_id = @id"},
+	{"notifications", "recipient_type = 'adopter' AND recipient_id = @id"},
+	{"outcome_notices", "recipient_type = 'adopter' AND recipient_id = @id"},
+	{"duplicate_dismissals", "entity_type = 'adopters' AND (id_a = @id OR id_b = @id)"},
+	{"adopter_media", "adopter_id = @id"},
+	{"adopterinfo", "adopter_id = @id"},
+	{"adopteraccount", "adopter_id = @id"},
+}
+
+// ShelterPurgeCascade is run in order when a shelter is hard deleted:
+// applications with their holds, photos and interviews, adopted-pet records
+// and the pets, reports against the shelter with their attachments and
+// flags, registration records, messages, and finally the account itself.
+var ShelterPurgeCascade = []PurgeStep{
+	{"application_holds", "application_id IN (SELECT application_id FROM adoption_submissions WHERE shelter_id = @id)"},
+	{"application_photos", "image_id IN (SELECT image_id FROM adoption_submissions WHERE shelter_id = @id)"},
+	{"moderation_queue", "source = 'adoption_reason' AND entity_id IN (SELECT application_id FROM adoption_submissions WHERE shelter_id = @id)"},
+	{"schedule_interview", "shelter_id = @id"},
+	{"adoption_submissions", "shelter_id = @id"},
+	{"adopterpets", "pet_id IN (SELECT pet_id FROM petinfo WHERE shelter_id = @id)"},
+	{"moderation_queue", "source = 'pet_description' AND entity_id IN (SELECT pet_id FROM petinfo WHERE shelter_id = @id)"},
+	{"petmedia", "pet_id IN (SELECT pet_id FROM petinfo WHERE shelter_id = @id)"},
+	{"petinfo", "shelter_id = @id"},
+	{"outcome_notice_reports", "report_id IN (SELECT id FROM submittedreports WHERE shelter_id = @id)"},
+	{"report_attachments", "report_id IN (SELECT id FROM submittedreports WHERE shelter_id = @id)"},
+	{"moderation_queue", "source IN ('report_reason', 'report_description') AND entity_id IN (SELECT id FROM submittedreports WHERE shelter_id = @id)"},
+	{"submittedreports", "shelter_id = @id"},
+	{"shelter_flags", "shelter_id = @id"},
+	{"shelterdonations", "shelter_id = @id"},
+	{"shelter_documents", "shelter_id = @id"},
+	{"shelter_review_claims", "shelter_id = @id"},
+	{"shelter_review_rounds", "shelter_id = @id"},
+	{"moderation_queue", "source = 'shelter_description' AND entity_id = @id"},
+	{"notifications", "recipient_type = 'shelter' AND recipient_id = @id"},
+	{"outcome_notices", "recipient_type = 'shelter' AND recipient_id = @id"},
+	{"duplicate_dismissals", "entity_type = 'shelters' AND (id_a = @id OR id_b = @id)"},
+	{"sheltermedia", "shelter_id = @id"},
+	{"shelterinfo", "shelter_id = @id"},
+	{"shelteraccount", "shelter_id = @id"},
+}
+
+// deletableAccount describes how one kind of account is soft deleted,
+// restored and purged
+type deletableAccount struct {
+	entityType string
+	column     string
+	// models are soft deleted together, account first
+	models  []interface{}
+	hold    string
+	cascade []PurgeStep
+	actions [3]string // delete, restore, purge
+}
+
+var (
+	deletableShelter = deletableAccount{
+		entityType: EntityShelter,
+		column:     "shelter_id",
+		models:     []interface{}{&models.ShelterAccount{}, &models.ShelterInfo{}, &models.ShelterMedia{}},
+		hold:       HoldShelterDeleted,
+		cascade:    ShelterPurgeCascade,
+		actions:    [3]string{ActionShelterDelete, ActionShelterRestore, ActionShelterPurge},
+	}
+	deletableAdopter = deletableAccount{
+		entityType: EntityAdopter,
+		column:     "adopter_id",
+		models:     []interface{}{&models.AdopterAccount{}, &models.AdopterInfo{}, &models.AdopterMedia{}},
+		hold:       HoldAdopterDeleted,
+		cascade:    AdopterPurgeCascade,
+		actions:    [3]string{ActionAdopterDelete, ActionAdopterRestore, ActionAdopterPurge},
+	}
+)
+
+// SoftDeleteShelter hides a shelter account with its info and media from
+// every list and count, hides its pets and holds its open applications. It
+// can be restored until the restore window closes.
+func SoftDeleteShelter(db *gorm.DB, shelterID, adminID uint, reason string, settings AccountDeletionSettings) (models.AccountDeletion, error) {
+	return softDelete(db, deletableShelter, shelterID, adminID, reason, settings)
+}
+
+// SoftDeleteAdopter hides an adopter account with its info and media from
+// every list and count and holds its open applications. It can be restored
+// until the restore window closes.
+func SoftDeleteAdopter(db *gorm.DB, adopterID, adminID uint, reason string, settings AccountDeletionSettings) (models.AccountDeletion, error) {
+	return softDelete(db, deletableAdopter, adopterID, adminID, reason, settings)
+}
+
+// RestoreShelter brings back a soft deleted shelter, its pets and its
+// applications
+func RestoreShelter(db *gorm.DB, shelterID, adminID uint) (models.AccountDeletion, error) {
+	return restore(db, deletableShelter, shelterID, adminID)
+}
+
+// RestoreAdopter brings back a soft deleted adopter and its applications
+func RestoreAdopter(db *gorm.DB, adopterID, adminID uint) (models.AccountDeletion, error) {
+	return restore(db, deletableAdopter, adopterID, adminID)
+}
+
+// PurgeShelter hard deletes a soft deleted shelter without waiting for the
+// restore window, running ShelterPurgeCascade
+func PurgeShelter(db *gorm.DB, shelterID, adminID uint) (models.AccountDeletion, error) {
+	return purge(db, deletableShelter, shelterID, adminID)
+}
+
+// PurgeAdopter hard deletes a soft deleted adopter without waiting for the
+// restore window, running AdopterPurgeCascade
+func PurgeAdopter(db *gorm.DB, adopterID, adminID uint) (models.AccountDeletion, error) {
+	return purge(db, deletableAdopter, adopterID, adminID)
+}
+
+// AccountDeletions lists deletions newest first. An empty entityType lists
+// both kinds; openOnly leaves out restored and purged accounts.
+func AccountDeletions(db *gorm.DB, entityType string, openOnly bool) ([]models.AccountDeletion, error) {
+	query := db.Order("deleted_at DESC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if openOnly {
+		query = openDeletions(query)
+	}
+
+	var deletions []models.AccountDeletion
+	err := query.Find(&deletions).Error
+	return deletions, err
+}
+
+// PurgeExpiredDeletions hard deletes every account whose restore window
+// closed before now and returns how many were purged. A failing account is
+// logged and retried on the next run.
+func PurgeExpiredDeletions(db *gorm.DB, now time.Time) (int, error) {
+	var expired []models.AccountDeletion
+	if err := openDeletions(db).Where("purge_after <= ?", now).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, deletion := range expired {
+		account := deletableAdopter
+		if deletion.EntityType == EntityShelter {
+			account = deletableShelter
+		}
+		// Expired purges are not attributed to an admin
+		if _, err := purge(db, account, deletion.EntityID, 0); err != nil {
+			log.Printf("Purge of %s %d failed: %v\n", deletion.EntityType, deletion.EntityID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartPurgeScheduler purges expired deletions on a fixed interval until
+// stop is closed
+func StartPurgeScheduler(db *gorm.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if purged, err := PurgeExpiredDeletions(db, time.Now()); err != nil {
+					log.Printf("Scheduled account purge error: %v\n", err)
+				} else if purged > 0 {
+					log.Printf("Scheduled account purge removed %d accounts\n", purged)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func openDeletions(db *gorm.DB) *gorm.DB {
+	return db.Where("restored_at IS NULL AND purged_at IS NULL")
+}
+
+// openDeletion returns the deletion of an account that is still soft deleted
+func openDeletion(tx *gorm.DB, account deletableAccount, id uint) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := openDeletions(tx).
+		Where("entity_type = ? AND entity_id = ?", account.entityType, id).
+		Order("deletion_id DESC").
+		First(&deletion).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return deletion, ErrAccountNotDeleted
+	}
+	return deletion, err
+}
+
+func softDelete(db *gorm.DB, account deletableAccount, id, adminID uint, reason string, settings AccountDeletionSettings) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var current struct{ Status string }
+		if err := tx.Model(account.models[0]).Select("status").Where(account.column+" = ?", id).Take(&current).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if _, err := openDeletion(tx, account, id); err == nil {
+				return ErrAccountDeleted
+			}
+			return ErrAccountNotFound
+		}
+
+		if err := bumpVersion(tx, account.models[0], account.column, id); err != nil {
+			return err
+		}
+		for _, model := range account.models {
+			if err := tx.Where(account.column+" = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		var petsHidden int64
+		if account.entityType == EntityShelter {
+			update := tx.Model(&models.PetInfo{}).
+				Where("shelter_id = ? AND hidden_by IN ?", id, []string{"", HoldFlagged}).
+				Update("hidden_by", account.hold)
+			if update.Error != nil {
+				return update.Error
+			}
+			petsHidden = update.RowsAffected
+		}
+		frozen, err := freezeOpenApplications(tx, account.column, id, adminID, account.hold, "Account is deleted")
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deletion = models.AccountDeletion{
+			EntityType:  account.entityType,
+			EntityID:    id,
+			Reason:      reason,
+			PriorStatus: current.Status,
+			DeletedBy:   adminID,
+			DeletedAt:   now,
+			PurgeAfter:  now.Add(settings.RestoreWindow),
+		}
+		if err := tx.Create(&deletion).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, adminID, account.actions[0], account.entityType, id, current.Status, StatusDeleted,
+			fmt.Sprintf("restorable until %s; pets hidden %d; applications frozen %d",
+				deletion.PurgeAfter.Format(time.RFC3339), petsHidden, frozen))
+	})
+	return deletion, err
+}
+
+func restore(db *gorm.DB, account deletableAccount, id, adminID uint) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var err error
+		if deletion, err = openDeletion(tx, account, id); err != nil {
+			return err
+		}
+		now := time.Now()
+		if now.After(deletion.PurgeAfter) {
+			return ErrRestoreWindowClosed
+		}
+
+		for _, model := range account.models {
+			if err := tx.Unscoped().Model(model).Where(account.column+" = ?", id).Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := bumpVersion(tx, account.models[0], account.column, id); err != nil {
+			return err
+		}
+
+		var petsRestored int64
+		if account.entityType == EntityShelter {
+			// Pets go back under a flag hold that is still running
+			restoreTo := ""
+			if err := ensureNoFlagHold(tx, id); errors.Is(err, ErrShelterOnHold) {
+				restoreTo = HoldFlagged
+			} else if err != nil {
+				return err
+			}
+			update := tx.Model(&models.PetInfo{}).
+				Where("shelter_id = ? AND hidden_by = ?", id, account.hold).
+				Update("hidden_by", restoreTo)
+			if update.Error != nil {
+				return update.Error
+			}
+			petsRestored = update.RowsAffected
+		}
+		release := tx.Where("source = ? AND application_id IN (?)", account.hold,
+			tx.Model(&models.AdoptionSubmission{}).Select("application_id").Where(account.column+" = ?", id)).
+			Delete(&models.ApplicationHold{})
+		if release.Error != nil {
+			return release.Error
+		}
+
+		deletion.RestoredBy = adminID
+		deletion.RestoredAt = &now
+		if err := tx.Model(&deletion).Updates(map[string]interface{}{"restored_by": adminID, "restored_at": now}).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, adminID, account.actions[1], account.entityType, id, StatusDeleted, deletion.PriorStatus,
+			fmt.Sprintf("pets restored %d; applications released %d", petsRestored, release.RowsAffected))
+	})
+	return deletion, err
+}
+
+func purge(db *gorm.DB, account deletableAccount, id, adminID uint) (models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var err error
+		if deletion, err = openDeletion(tx, account, id); err != nil {
+			return err
+		}
+
+		rows := make(map[string]int64, len(account.cascade))
+		for _, step := range account.cascade {
+			deleted := tx.Exec("DELETE FROM "+step.Table+" WHERE "+step.Where, map[string]interface{}{"id": id})
+			if deleted.Error != nil {
+				return fmt.Errorf("purging %s: %w", step.Table, deleted.Error)
+			}
+			rows[step.Table] += deleted.RowsAffected
+		}
+
+		rowsJSON, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		deletion.PurgedAt = &now
+		deletion.PurgedRows = string(rowsJSON)
+		if err := tx.Model(&deletion).Updates(map[string]interface{}{"purged_at": now, "purged_rows": deletion.PurgedRows}).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, adminID, account.actions[2], account.entityType, id, StatusDeleted, "",
+			"purged: "+deletion.PurgedRows)
+	})
+	return deletion, err
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// seedAccountPair creates shelter n and adopter n with a row in every table
+// a purge cascades to. The adopter has applied for, reported and adopted
+// the shelter's pet, so some rows belong to both accounts.
+func seedAccountPair(t *testing.T, db *gorm.DB, n uint) {
+	t.Helper()
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: n, Username: "shelter" + string(rune('a'+n)), Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+		&models.ShelterInfo{ShelterID: n, ShelterName: "Paws"},
+		&models.ShelterMedia{ShelterID: n},
+		&models.ShelterDonations{DonationID: n, ShelterID: n},
+		&models.ShelterDocument{DocumentID: n, ShelterID: n, DocType: DocOwnerID},
+		&models.ShelterReviewRound{RoundID: n, ShelterID: n, Round: 1},
+		&models.ShelterReviewClaim{ShelterID: n, AdminID: 9},
+		&models.ShelterFlag{FlagID: n, ShelterID: n, Action: FlagRaisePriority},
+		&models.PetInfo{PetID: n, ShelterID: n, ListingStatus: ListingListed},
+		&models.PetMedia{PetID: n},
+
+		&models.AdopterAccount{AdopterID: n, Username: "adopter" + string(rune('a'+n)), Status: StatusActive, Version: 1},
+		&models.AdopterInfo{AdopterID: n, FirstName: "Ana", Email: "adopter" + string(rune('a'+n)) + "@pethub.test"},
+		&models.AdopterMedia{AdopterID: n},
+
+		&models.AdoptionSubmission{ApplicationID: n, ShelterID: n, AdopterID: n, PetID: n, ImageID: n, Status: "pending"},
+		&models.ApplicationPhotos{ImageID: n},
+		&models.ApplicationHold{ApplicationID: n, Source: HoldPetUnlisted},
+		&models.ScheduleInterview{InterviewID: n, ApplicationID: n, ShelterID: n, AdopterID: n, InterviewStatus: InterviewScheduled},
+		&models.AdoptedPet{AdoptedID: n, AdopterID: n, PetID: n},
+		&models.SubmittedReport{ID: n, ShelterID: n, AdopterID: n, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.ReportAttachment{AttachmentID: n, ReportID: n},
+		&models.OutcomeNotice{NoticeID: 2 * n, Template: "t", RecipientType: RecipientShelter, RecipientID: n},
+		&models.OutcomeNotice{NoticeID: 2*n + 1, Template: "t", RecipientType: RecipientAdopter, RecipientID: n},
+		&models.OutcomeNoticeReport{NoticeID: 2 * n, ReportID: n},
+		&models.Notification{RecipientType: RecipientShelter, RecipientID: n},
+		&models.Notification{RecipientType: RecipientAdopter, RecipientID: n},
+		&models.DuplicateDismissal{EntityType: "shelters", IDA: n, IDB: n + 100},
+		&models.DuplicateDismissal{EntityType: "adopters", IDA: n, IDB: n + 100},
+		&models.ModerationItem{Source: "shelter_description", EntityID: n},
+		&models.ModerationItem{Source: "pet_description", EntityID: n},
+		&models.ModerationItem{Source: "adoption_reason", EntityID: n},
+		&models.ModerationItem{Source: "report_reason", EntityID: n},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding %T: %v", row, err)
+		}
+	}
+}
+
+// pairRows is how many rows seedAccountPair writes to each table that
+// belong to the shelter only, to the adopter only, and to both
+var pairRows = map[string][3]int64{
+	"shelteraccount":         {1, 0, 0},
+	"shelterinfo":            {1, 0, 0},
+	"sheltermedia":           {1, 0, 0},
+	"shelterdonations":       {1, 0, 0},
+	"shelter_documents":      {1, 0, 0},
+	"shelter_review_rounds":  {1, 0, 0},
+	"shelter_review_claims":  {1, 0, 0},
+	"shelter_flags":          {1, 0, 0},
+	"petinfo":                {1, 0, 0},
+	"petmedia":               {1, 0, 0},
+	"adopteraccount":         {0, 1, 0},
+	"adopterinfo":            {0, 1, 0},
+	"adopter_media":          {0, 1, 0},
+	"adoption_submissions":   {0, 0, 1},
+	"application_photos":     {0, 0, 1},
+	"application_holds":      {0, 0, 1},
+	"schedule_interview":     {0, 0, 1},
+	"adopterpets":            {0, 0, 1},
+	"submittedreports":       {0, 0, 1},
+	"report_attachments":     {0, 0, 1},
+	"outcome_notice_reports": {0, 0, 1},
+	"outcome_notices":        {1, 1, 0},
+	"notifications":          {1, 1, 0},
+	"duplicate_dismissals":   {1, 1, 0},
+	"moderation_queue":       {2, 0, 2},
+}
+
+func checkTableCounts(t *testing.T, db *gorm.DB, step string, want func(rows [3]int64) int64) {
+	t.Helper()
+	for table, rows := range pairRows {
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != want(rows) {
+			t.Errorf("after %s: %s has %d rows, want %d", step, table, count, want(rows))
+		}
+	}
+}
+
+func TestPurgeRemovesEverythingOfTheAccount(t *testing.T) {
+	db := openTestDB(t)
+	seedAccountPair(t, db, 1)
+	seedAccountPair(t, db, 2)
+
+	if _, err := PurgeShelter(db, 1, 9); !errors.Is(err, ErrAccountNotDeleted) {
+		t.Fatalf("purging a live shelter: err = %v, want ErrAccountNotDeleted", err)
+	}
+
+	if _, err := SoftDeleteShelter(db, 1, 9, "Closed down", DefaultAccountDeletionSettings); err != nil {
+		t.Fatalf("delete shelter: %v", err)
+	}
+	deletion, err := PurgeShelter(db, 1, 9)
+	if err != nil || deletion.PurgedAt == nil {
+		t.Fatalf("purge shelter: %+v, err %v", deletion, err)
+	}
+	// Pair 2 is untouched; adopter 1 keeps only what is its alone
+	checkTableCounts(t, db, "purging shelter 1", func(rows [3]int64) int64 {
+		return rows[0] + rows[1] + rows[2] + rows[1]
+	})
+
+	if _, err := SoftDeleteAdopter(db, 2, 9, "Asked to leave", DefaultAccountDeletionSettings); err != nil {
+		t.Fatalf("delete adopter: %v", err)
+	}
+	if _, err := PurgeAdopter(db, 2, 9); err != nil {
+		t.Fatalf("purge adopter: %v", err)
+	}
+	checkTableCounts(t, db, "purging adopter 2", func(rows [3]int64) int64 {
+		return rows[1] + rows[0]
+	})
+
+	if _, err := RestoreShelter(db, 1, 9); !errors.Is(err, ErrAccountNotDeleted) {
+		t.Fatalf("restoring a purged shelter: err = %v, want ErrAccountNotDeleted", err)
+	}
+	var audits int64
+	db.Model(&models.AdminAuditLog{}).Where("action IN ?", []string{ActionShelterPurge, ActionAdopterPurge}).Count(&audits)
+	if audits != 2 {
+		t.Fatalf("%d purge audit entries, want 2", audits)
+	}
+}
+
+func TestPurgeExpiredDeletionsWaitsForTheWindow(t *testing.T) {
+	db := openTestDB(t)
+	seedAccountPair(t, db, 1)
+	if _, err := SoftDeleteAdopter(db, 1, 9, "", DefaultAccountDeletionSettings); err != nil {
+		t.Fatalf("delete adopter: %v", err)
+	}
+
+	if purged, err := PurgeExpiredDeletions(db, time.Now()); err != nil || purged != 0 {
+		t.Fatalf("purged %d inside the window, err %v; want none", purged, err)
+	}
+	later := time.Now().Add(DefaultAccountDeletionSettings.RestoreWindow + time.Hour)
+	if purged, err := PurgeExpiredDeletions(db, later); err != nil || purged != 1 {
+		t.Fatalf("purged %d after the window, err %v; want 1", purged, err)
+	}
+	var adopters int64
+	db.Table("adopteraccount").Where("adopter_id = ?", 1).Count(&adopters)
+	if adopters != 0 {
+		t.Fatal("expired adopter account was not purged")
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Account status values shared by shelter and adopter accounts
+const (
+	StatusActive   = "active"
+	StatusInactive = "inactive"
+)
+
+// Shelter registration status values
+const (
+	RegStatusPending  = "pending"
+	RegStatusApproved = "approved"
+	RegStatusRejected = "rejected"
+)
+
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrInvalidStatus     = errors.New("invalid status")
+	ErrAlreadyInStatus   = errors.New("account is already in the requested status")
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+)
+
+// Transition describes one allowed move between two status values and
+// the extra column updates that have to be written together with it.
+type Transition struct {
+	From        string
+	To          string
+	SideEffects map[string]interface{}
+}
+
+// StatusMachine holds the allowed transitions for a single status column.
+type StatusMachine struct {
+	Column      string
+	States      []string
+	Transitions []Transition
+}
+
+var (
+	// ShelterStatusMachine governs shelteraccount.status
+	ShelterStatusMachine = StatusMachine{
+		Column: "status",
+		States: []string{StatusActive, StatusInactive},
+		Transitions: []Transition{
+			{From: StatusActive, To: StatusInactive},
+			{From: StatusInactive, To: StatusActive},
+		},
+	}
+
+	// ShelterRegStatusMachine governs shelteraccount.reg_status. Approving a
+	// registration also activates the account, and a rejected shelter goes
+	// back to pending when it resubmits.
+	ShelterRegStatusMachine = StatusMachine{
+		Column: "reg_status",
+		States: []string{RegStatusPending, RegStatusApproved, RegStatusRejected},
+		Transitions: []Transition{
+			{From: RegStatusPending, To: RegStatusApproved, SideEffects: map[string]interface{}{"status": StatusActive}},
+			{From: RegStatusPending, To: RegStatusRejected},
+			{From: RegStatusRejected, To: RegStatusPending},
+		},
+	}
+
+	// AdopterStatusMachine governs adopteraccount.status
+	AdopterStatusMachine = StatusMachine{
+		Column: "status",
+		States: []string{StatusActive, StatusInactive},
+		Transitions: []Transition{
+			{From: StatusActive, To: StatusInactive},
+			{From: StatusInactive, To: StatusActive},
+		},
+	}
+)
+
+// NormalizeStatus lowercases and trims a status value so every handler
+// compares statuses the same way.
+func NormalizeStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+// IsValid reports whether status is one of the machine's states.
+func (m StatusMachine) IsValid(status string) bool {
+	status = NormalizeStatus(status)
+	for _, s := range m.States {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Plan validates a move from one status to another and returns the column
+// updates to apply. It does not touch the database.
+func (m StatusMachine) Plan(from, to string) (map[string]interface{}, error) {
+	from = NormalizeStatus(from)
+	to = NormalizeStatus(to)
+
+	if !m.IsValid(to) {
+		return nil, fmt.Errorf("%w: %q must be one of %s", ErrInvalidStatus, to, strings.Join(m.States, ", "))
+	}
+	if from == to {
+		return nil, ErrAlreadyInStatus
+	}
+
+	for _, t := range m.Transitions {
+		if t.From != from || t.To != to {
+			continue
+		}
+		updates := map[string]interface{}{m.Column: to}
+		for column, value := range t.SideEffects {
+			updates[column] = value
+		}
+		return updates, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s from %q to %q", ErrInvalidTransition, m.Column, from, to)
+}
+
+// TransitionShelterStatus moves a shelter account between active and
+// inactive. Like every shelter transition it locks the account row for
+// the rest of db's transaction.
+func TransitionShelterStatus(db *gorm.DB, shelterID uint, to string) (models.ShelterAccount, error) {
+	return transitionShelter(db, shelterID, ShelterStatusMachine, func(s models.ShelterAccount) string {
+		return s.Status
+	}, to)
+}
+
+// TransitionShelterRegStatus moves a shelter registration between pending,
+// approved and rejected.
+func TransitionShelterRegStatus(db *gorm.DB, shelterID uint, to string) (models.ShelterAccount, error) {
+	return transitionShelter(db, shelterID, ShelterRegStatusMachine, func(s models.ShelterAccount) string {
+		return s.RegStatus
+	}, to)
+}
+
+// TransitionAdopterStatus moves an adopter account between active and
+// inactive. The row stays locked until db's transaction ends, so two
+// concurrent transitions cannot both plan from the same status.
+func TransitionAdopterStatus(db *gorm.DB, adopterID uint, to string) (models.AdopterAccount, error) {
+	var adopter models.AdopterAccount
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("adopter_id = ?", adopterID).First(&adopter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return adopter, ErrAccountNotFound
+		}
+		return adopter, err
+	}
+
+	updates, err := AdopterStatusMachine.Plan(adopter.Status, to)
+	if err != nil {
+		return adopter, err
+	}
+
+	if err := db.Model(&models.AdopterAccount{}).
+		Where("adopter_id = ?", adopterID).
+		Updates(withVersion("adopteraccount", updates)).Error; err != nil {
+		return adopter, err
+	}
+
+	adopter.Status = updates["status"].(string)
+	adopter.Version++
+	return adopter, nil
+}
+
+func transitionShelter(db *gorm.DB, shelterID uint, machine StatusMachine, current func(models.ShelterAccount) string, to string) (models.ShelterAccount, error) {
+	var shelter models.ShelterAccount
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return shelter, ErrAccountNotFound
+		}
+		return shelter, err
+	}
+
+	updates, err := machine.Plan(current(shelter), to)
+	if err != nil {
+		return shelter, err
+	}
+
+	if err := db.Model(&models.ShelterAccount{}).
+		Where("shelter_id = ?", shelterID).
+		Updates(withVersion("shelteraccount", updates)).Error; err != nil {
+		return shelter, err
+	}
+	shelter.Version++
+
+	if status, ok := updates["status"].(string); ok {
+		shelter.Status = status
+	}
+	if regStatus, ok := updates["reg_status"].(string); ok {
+		shelter.RegStatus = regStatus
+	}
+	return shelter, nil
+}
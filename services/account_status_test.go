@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStatusMachinePlan(t *testing.T) {
+	machines := map[string]StatusMachine{
+		"shelter status":     ShelterStatusMachine,
+		"shelter reg_status": ShelterRegStatusMachine,
+		"adopter status":     AdopterStatusMachine,
+	}
+
+	// want holds every allowed move with the updates it writes; every other
+	// pair of states must be refused
+	want := map[string]map[[2]string]map[string]interface{}{
+		"shelter status": {
+			{StatusActive, StatusInactive}: {"status": StatusInactive},
+			{StatusInactive, StatusActive}: {"status": StatusActive},
+		},
+		"shelter reg_status": {
+			{RegStatusPending, RegStatusApproved}: {"reg_status": RegStatusApproved, "status": StatusActive},
+			{RegStatusPending, RegStatusRejected}: {"reg_status": RegStatusRejected},
+			{RegStatusRejected, RegStatusPending}: {"reg_status": RegStatusPending},
+		},
+		"adopter status": {
+			{StatusActive, StatusInactive}: {"status": StatusInactive},
+			{StatusInactive, StatusActive}: {"status": StatusActive},
+		},
+	}
+
+	for name, machine := range machines {
+		for _, from := range machine.States {
+			for _, to := range machine.States {
+				t.Run(name+"/"+from+"->"+to, func(t *testing.T) {
+					updates, err := machine.Plan(from, to)
+
+					expected, allowed := want[name][[2]string{from, to}]
+					switch {
+					case from == to:
+						if !errors.Is(err, ErrAlreadyInStatus) {
+							t.Fatalf("err = %v, want ErrAlreadyInStatus", err)
+						}
+					case allowed:
+						if err != nil {
+							t.Fatalf("err = %v, want the move to be allowed", err)
+						}
+						if !reflect.DeepEqual(updates, expected) {
+							t.Fatalf("updates = %v, want %v", updates, expected)
+						}
+					default:
+						if !errors.Is(err, ErrInvalidTransition) {
+							t.Fatalf("err = %v, want ErrInvalidTransition", err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestStatusMachinePlanNormalizesAndRejectsUnknownStates(t *testing.T) {
+	cases := []struct {
+		from, to string
+		wantErr  error
+	}{
+		{" Active ", "INACTIVE", nil},
+		{"active", "Active", ErrAlreadyInStatus},
+		{"active", "banned", ErrInvalidStatus},
+		{"active", "", ErrInvalidStatus},
+		{"banned", "active", ErrInvalidTransition},
+	}
+
+	for _, tc := range cases {
+		_, err := ShelterStatusMachine.Plan(tc.from, tc.to)
+		if tc.wantErr == nil && err != nil || tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+			t.Errorf("Plan(%q, %q) err = %v, want %v", tc.from, tc.to, err, tc.wantErr)
+		}
+	}
+}
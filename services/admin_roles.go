@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Admin roles. Super admins manage flag rules, screening terms, report
+// categories and purges.
+const (
+	RoleAdmin      = "admin"
+	RoleSuperAdmin = "super_admin"
+)
+
+// EntityAdmin is the audited entity type of admin accounts
+const EntityAdmin = "admin"
+
+var (
+	ErrInvalidRole    = errors.New("role must be 'admin' or 'super_admin'")
+	ErrAdminNotFound  = errors.New("admin account not found")
+	ErrLastSuperAdmin = errors.New("cannot demote the last super admin")
+)
+
+// SetAdminRole gives the admin with username the role and records the
+// change in the audit log. Demoting the last super admin is refused, so
+// someone can always manage the super admin settings.
+func SetAdminRole(db *gorm.DB, username, role string) (models.AdminAccount, error) {
+	var admin models.AdminAccount
+	role = NormalizeStatus(role)
+	if role != RoleAdmin && role != RoleSuperAdmin {
+		return admin, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("username = ?", username).First(&admin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAdminNotFound
+			}
+			return err
+		}
+		from := admin.Role
+		if from == role {
+			return nil
+		}
+		if from == RoleSuperAdmin {
+			// Locking the other super admins keeps two demotions from
+			// each leaving the other as the last one
+			var others []uint
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.AdminAccount{}).
+				Where("role = ? AND admin_id <> ?", RoleSuperAdmin, admin.AdminID).
+				Pluck("admin_id", &others).Error; err != nil {
+				return err
+			}
+			if len(others) == 0 {
+				return ErrLastSuperAdmin
+			}
+		}
+
+		if err := tx.Model(&admin).Update("role", role).Error; err != nil {
+			return err
+		}
+		return RecordAudit(tx, 0, "set_role", EntityAdmin, admin.AdminID, from, role, "")
+	})
+	return admin, err
+}
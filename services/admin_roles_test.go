@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestSetAdminRole(t *testing.T) {
+	db := openTestDB(t)
+	for _, admin := range []models.AdminAccount{{AdminID: 1, Username: "ana", Role: RoleAdmin}, {AdminID: 2, Username: "ben", Role: RoleAdmin}} {
+		if err := db.Create(&admin).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+	role := func(id uint) string {
+		var admin models.AdminAccount
+		db.First(&admin, id)
+		return admin.Role
+	}
+
+	if _, err := SetAdminRole(db, "ana", "owner"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("unknown role: err = %v, want ErrInvalidRole", err)
+	}
+	if _, err := SetAdminRole(db, "cid", RoleSuperAdmin); !errors.Is(err, ErrAdminNotFound) {
+		t.Fatalf("unknown admin: err = %v, want ErrAdminNotFound", err)
+	}
+
+	admin, err := SetAdminRole(db, "ana", RoleSuperAdmin)
+	if err != nil || admin.Role != RoleSuperAdmin || role(1) != RoleSuperAdmin {
+		t.Fatalf("promote: err = %v, role %q", err, role(1))
+	}
+	if _, err := SetAdminRole(db, "ana", RoleAdmin); !errors.Is(err, ErrLastSuperAdmin) || role(1) != RoleSuperAdmin {
+		t.Fatalf("demoting the last super admin: err = %v, role %q", err, role(1))
+	}
+
+	if _, err := SetAdminRole(db, "ben", RoleSuperAdmin); err != nil {
+		t.Fatalf("promote a second: %v", err)
+	}
+	if _, err := SetAdminRole(db, "ana", RoleAdmin); err != nil || role(1) != RoleAdmin {
+		t.Fatalf("demote with another super admin left: err = %v, role %q", err, role(1))
+	}
+
+	var audits []models.AdminAuditLog
+	db.Where("entity_type = ?", EntityAdmin).Order("audit_id").Find(&audits)
+	if len(audits) != 3 || audits[2].EntityID != 1 || audits[2].FromStatus != RoleSuperAdmin || audits[2].ToStatus != RoleAdmin {
+		t.Fatalf("audit entries = %+v, want the three role changes", audits)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Notification kinds sent by the adopter cascade
+const (
+	NotifyAdopterDeactivated = "adopter_deactivated"
+	NotifyAdopterReactivated = "adopter_reactivated"
+)
+
+// AdopterDeactivationPolicy controls what else happens when an adopter is deactivated
+type AdopterDeactivationPolicy struct {
+	Applications     string
+	CancelInterviews bool
+	NotifyShelters   bool
+}
+
+// DefaultAdopterDeactivationPolicy freezes applications, cancels upcoming
+// interviews and tells the shelters
+var DefaultAdopterDeactivationPolicy = AdopterDeactivationPolicy{
+	Applications:     ApplicationsFreeze,
+	CancelInterviews: true,
+	NotifyShelters:   true,
+}
+
+// AdopterStatusOptions carries the cascade settings for SetAdopterStatus.
+// Policy applies when deactivating; Restore applies when activating.
+type AdopterStatusOptions struct {
+	Policy  AdopterDeactivationPolicy
+	Restore bool
+}
+
+// Validate checks the applications policy
+func (p AdopterDeactivationPolicy) Validate() error {
+	switch p.Applications {
+	case ApplicationsKeep, ApplicationsFreeze, ApplicationsWithdraw:
+		return nil
+	}
+	return ErrInvalidCascadePolicy
+}
+
+// cascadeAdopterDeactivation applies the policy to the adopter's applications
+// and interviews inside the status change transaction
+func cascadeAdopterDeactivation(tx *gorm.DB, adopterID, adminID uint, policy AdopterDeactivationPolicy) (CascadeResult, error) {
+	var result CascadeResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+
+	var shelterIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where("adopter_id = ? AND status NOT IN ?", adopterID, ClosedApplicationStatuses).
+		Pluck("shelter_id", &shelterIDs).Error; err != nil {
+		return result, err
+	}
+
+	var err error
+	switch policy.Applications {
+	case ApplicationsFreeze:
+		if result.ApplicationsFrozen, err = freezeOpenApplications(tx, "adopter_id", adopterID, adminID, HoldAdopterDeactivated, "Adopter account is deactivated"); err != nil {
+			return result, err
+		}
+	case ApplicationsWithdraw:
+		if result.ApplicationsWithdrawn, err = withdrawOpenApplications(tx, "adopter_id", adopterID, adminID, HoldAdopterDeactivated, WithdrawnAdopterDeactivated); err != nil {
+			return result, err
+		}
+	}
+
+	if policy.CancelInterviews {
+		cancelled, interviewShelters, err := cancelUpcomingInterviews(tx, "adopter_id", adopterID, "shelter_id")
+		if err != nil {
+			return result, err
+		}
+		result.InterviewsCancelled = cancelled
+		shelterIDs = append(shelterIDs, interviewShelters...)
+	}
+
+	if policy.NotifyShelters {
+		var parts []string
+		switch policy.Applications {
+		case ApplicationsFreeze:
+			parts = append(parts, "their applications are on hold")
+		case ApplicationsWithdraw:
+			parts = append(parts, "their applications have been withdrawn")
+		}
+		if result.InterviewsCancelled > 0 {
+			parts = append(parts, "upcoming interviews with them have been cancelled")
+		}
+		message := fmt.Sprintf("%s's account has been deactivated by PetHub", adopterName(tx, adopterID))
+		if len(parts) > 0 {
+			message += "; " + strings.Join(parts, " and ")
+		}
+
+		if result.Notified, err = NotifyMany(tx, RecipientShelter, shelterIDs, NotifyAdopterDeactivated, "Adopter deactivated", message+"."); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// restoreAdopterApplications releases the holds placed by a deactivation and
+// gives the applications it withdrew back the status and reason they had,
+// unless the pet has since been adopted or the application has moved on.
+// Cancelled interviews cannot be restored; the shelters are asked to reschedule.
+func restoreAdopterApplications(tx *gorm.DB, adopterID uint) (CascadeResult, error) {
+	var result CascadeResult
+
+	applications := tx.Model(&models.AdoptionSubmission{}).Select("application_id").Where("adopter_id = ?", adopterID)
+	var shelterIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where("adopter_id = ?", adopterID).
+		Where("application_id IN (?) OR application_id IN (?)",
+			tx.Model(&models.ApplicationHold{}).Select("application_id").Where("source = ?", HoldAdopterDeactivated),
+			tx.Model(&models.ApplicationWithdrawal{}).Select("application_id").Where("source = ?", HoldAdopterDeactivated)).
+		Pluck("shelter_id", &shelterIDs).Error; err != nil {
+		return result, err
+	}
+
+	release := tx.Where("source = ? AND application_id IN (?)", HoldAdopterDeactivated, applications).
+		Delete(&models.ApplicationHold{})
+	if release.Error != nil {
+		return result, release.Error
+	}
+	result.ApplicationsThawed = release.RowsAffected
+
+	var withdrawals []models.ApplicationWithdrawal
+	if err := tx.Where("source = ? AND application_id IN (?)", HoldAdopterDeactivated, applications).
+		Find(&withdrawals).Error; err != nil {
+		return result, err
+	}
+	adopted := tx.Model(&models.PetInfo{}).Select("pet_id").Where("status = ?", "adopted")
+	for _, withdrawal := range withdrawals {
+		reopen := tx.Model(&models.AdoptionSubmission{}).
+			Where("application_id = ? AND status = ? AND pet_id NOT IN (?)", withdrawal.ApplicationID, ApplicationWithdrawn, adopted).
+			Updates(map[string]interface{}{"status": withdrawal.PriorStatus, "reason_for_rejection": withdrawal.PriorReason})
+		if reopen.Error != nil {
+			return result, reopen.Error
+		}
+		result.ApplicationsReopened += reopen.RowsAffected
+	}
+	// Applications that could not be reopened stay withdrawn for good
+	if len(withdrawals) > 0 {
+		if err := tx.Where("source = ? AND application_id IN (?)", HoldAdopterDeactivated, applications).
+			Delete(&models.ApplicationWithdrawal{}).Error; err != nil {
+			return result, err
+		}
+	}
+
+	var err error
+	result.Notified, err = NotifyMany(tx, RecipientShelter, shelterIDs, NotifyAdopterReactivated, "Adopter reactivated",
+		fmt.Sprintf("%s's account has been reactivated and their applications are active again. Cancelled interviews need to be rescheduled.", adopterName(tx, adopterID)))
+	return result, err
+}
+
+func adopterName(tx *gorm.DB, adopterID uint) string {
+	var info models.AdopterInfo
+	if err := tx.Select("first_name", "last_name").Where("adopter_id = ?", adopterID).First(&info).Error; err != nil {
+		return fmt.Sprintf("Adopter #%d", adopterID)
+	}
+	return strings.TrimSpace(info.FirstName + " " + info.LastName)
+}
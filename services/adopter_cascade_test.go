@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestRestoreGivesWithdrawnApplicationsBackTheirStatus(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		&models.AdopterAccount{AdopterID: 1, Username: "ana", Status: StatusActive, Version: 1},
+		&models.AdopterInfo{AdopterID: 1, FirstName: "Ana", Email: "ana@pethub.test"},
+		&models.PetInfo{PetID: 1, ShelterID: 1, ListingStatus: "listed"},
+		&models.PetInfo{PetID: 2, ShelterID: 1, ListingStatus: "listed"},
+		&models.PetInfo{PetID: 3, ShelterID: 2, ListingStatus: "listed"},
+		&models.AdoptionSubmission{ApplicationID: 1, AdopterID: 1, ShelterID: 1, PetID: 1, Status: "pending"},
+		&models.AdoptionSubmission{ApplicationID: 2, AdopterID: 1, ShelterID: 1, PetID: 2, Status: "interview", ReasonForRejection: "Needs a home visit first"},
+		&models.AdoptionSubmission{ApplicationID: 3, AdopterID: 1, ShelterID: 2, PetID: 3, Status: "approved"},
+		&models.AdoptionSubmission{ApplicationID: 4, AdopterID: 1, ShelterID: 2, PetID: 3, Status: "rejected", ReasonForRejection: "Too far away"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	withdraw := AdopterStatusOptions{Policy: AdopterDeactivationPolicy{Applications: ApplicationsWithdraw}}
+	_, cascade, err := SetAdopterStatus(db, 1, 9, StatusInactive, withdraw)
+	if err != nil || cascade.ApplicationsWithdrawn != 3 {
+		t.Fatalf("withdrew %d, err %v; want the 3 open applications", cascade.ApplicationsWithdrawn, err)
+	}
+
+	// Pet 3 finds another home while the account is inactive
+	db.Model(&models.PetInfo{}).Where("pet_id = ?", 3).Update("status", "adopted")
+
+	_, cascade, err = SetAdopterStatus(db, 1, 9, StatusActive, AdopterStatusOptions{Restore: true})
+	if err != nil || cascade.ApplicationsReopened != 2 {
+		t.Fatalf("reopened %d, err %v; want 2", cascade.ApplicationsReopened, err)
+	}
+
+	want := map[uint][2]string{
+		1: {"pending", ""},
+		2: {"interview", "Needs a home visit first"},
+		3: {ApplicationWithdrawn, WithdrawnAdopterDeactivated},
+		4: {"rejected", "Too far away"},
+	}
+	var applications []models.AdoptionSubmission
+	db.Order("application_id").Find(&applications)
+	for _, application := range applications {
+		got := [2]string{application.Status, application.ReasonForRejection}
+		if got != want[application.ApplicationID] {
+			t.Errorf("application %d = %q, want %q", application.ApplicationID, got, want[application.ApplicationID])
+		}
+	}
+
+	var left int64
+	db.Model(&models.ApplicationWithdrawal{}).Count(&left)
+	if left != 0 {
+		t.Fatalf("%d withdrawal records left after the restore", left)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Audited entity types
+const (
+	EntityShelter = "shelter"
+	EntityAdopter = "adopter"
+	EntityReport  = "report"
+)
+
+// RecordAudit writes an audit entry using the caller's transaction so the
+// entry is only kept when the audited change commits.
+func RecordAudit(tx *gorm.DB, adminID uint, action, entityType string, entityID uint, from, to, details string) error {
+	return tx.Create(&models.AdminAuditLog{
+		AdminID:    adminID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		FromStatus: from,
+		ToStatus:   to,
+		Details:    details,
+	}).Error
+}
+
+// AuditTrail returns the audit entries for one entity, newest first. An
+// empty entityType returns the most recent entries across all entities.
+func AuditTrail(db *gorm.DB, entityType string, entityID uint, limit int) ([]models.AdminAuditLog, error) {
+	query := db.Order("created_at DESC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var logs []models.AdminAuditLog
+	err := query.Find(&logs).Error
+	return logs, err
+}
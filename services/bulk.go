@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bulk execution modes
+const (
+	BulkAtomic     = "atomic"      // all items commit or none do
+	BulkBestEffort = "best_effort" // each item commits on its own
+)
+
+// MaxBulkItems caps how many records a single bulk request may touch
+const MaxBulkItems = 200
+
+var (
+	ErrInvalidBulkMode = errors.New("mode must be 'atomic' or 'best_effort'")
+	ErrBulkEmpty       = errors.New("at least one id is required")
+	ErrBulkTooLarge    = fmt.Errorf("a bulk request may contain at most %d ids", MaxBulkItems)
+	errBulkRolledBack  = errors.New("bulk operation rolled back")
+)
+
+// BulkItemResult is the outcome of one record in a bulk request
+type BulkItemResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Status  string `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkSummary is the outcome of a whole bulk request
+type BulkSummary struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// BulkItemFunc applies an action to one record through the unit of work's
+// tx and returns its new status
+type BulkItemFunc[T any] func(tx T, id uint) (string, error)
+
+// RunBulk applies fn to every id, opening units of work with do. In atomic
+// mode all items share one unit and the first failure rolls everything back;
+// in best-effort mode every item runs in its own unit.
+func RunBulk[T any](do func(fn func(tx T) error) error, ids []uint, mode string, fn BulkItemFunc[T]) (BulkSummary, error) {
+	if mode == "" {
+		mode = BulkBestEffort
+	}
+	summary := BulkSummary{Mode: mode, Results: make([]BulkItemResult, 0, len(ids))}
+
+	switch {
+	case mode != BulkAtomic && mode != BulkBestEffort:
+		return summary, ErrInvalidBulkMode
+	case len(ids) == 0:
+		return summary, ErrBulkEmpty
+	case len(ids) > MaxBulkItems:
+		return summary, ErrBulkTooLarge
+	}
+
+	ids = uniqueIDs(ids)
+
+	if mode == BulkBestEffort {
+		for _, id := range ids {
+			var status string
+			err := do(func(tx T) error {
+				var err error
+				status, err = fn(tx, id)
+				return err
+			})
+			summary.Results = append(summary.Results, itemResult(id, status, err))
+		}
+		summary.Committed = true
+		summary.count()
+		return summary, nil
+	}
+
+	err := do(func(tx T) error {
+		for i, id := range ids {
+			status, err := fn(tx, id)
+			summary.Results = append(summary.Results, itemResult(id, status, err))
+			if err != nil {
+				// Mark the rest as not attempted and roll back
+				for _, rest := range ids[i+1:] {
+					summary.Results = append(summary.Results, BulkItemResult{ID: rest, Error: "not attempted: " + errBulkRolledBack.Error()})
+				}
+				return errBulkRolledBack
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkRolledBack) {
+		for i := range summary.Results {
+			if summary.Results[i].Success {
+				summary.Results[i].Success = false
+				summary.Results[i].Error = errBulkRolledBack.Error()
+			}
+		}
+	} else if err != nil {
+		return summary, err
+	} else {
+		summary.Committed = true
+	}
+
+	summary.count()
+	return summary, nil
+}
+
+func (s *BulkSummary) count() {
+	s.Succeeded, s.Failed = 0, 0
+	for _, r := range s.Results {
+		if r.Success {
+			s.Succeeded++
+		} else {
+			s.Failed++
+		}
+	}
+}
+
+func itemResult(id uint, status string, err error) BulkItemResult {
+	if err != nil {
+		return BulkItemResult{ID: id, Error: err.Error()}
+	}
+	return BulkItemResult{ID: id, Success: true, Status: status}
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+func seedActiveShelters(t *testing.T, db *gorm.DB, ids ...uint) {
+	t.Helper()
+	for _, id := range ids {
+		rows := []interface{}{
+			&models.ShelterAccount{ShelterID: id, Username: "shelter" + string(rune('a'+id)), Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+			&models.ShelterInfo{ShelterID: id, ShelterName: "Paws"},
+			&models.PetInfo{PetID: id, ShelterID: id, PetName: "Rex", ListingStatus: "listed"},
+			&models.SubmittedReport{ID: id, ShelterID: id, AdopterID: 5, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		}
+		for _, row := range rows {
+			if err := db.Create(row).Error; err != nil {
+				t.Fatalf("seeding: %v", err)
+			}
+		}
+	}
+}
+
+// transactionsOn runs each unit of a bulk action with InTransaction on db
+func transactionsOn(db *gorm.DB) func(fn func(tx *gorm.DB) error) error {
+	return func(fn func(tx *gorm.DB) error) error {
+		return InTransaction(db, fn)
+	}
+}
+
+func TestAtomicBulkRollsBackEveryItem(t *testing.T) {
+	db := openTestDB(t)
+	seedActiveShelters(t, db, 1, 2)
+
+	block := func(tx *gorm.DB, id uint) (string, error) {
+		result, err := BlockShelter(tx, id, 9, DefaultShelterBlockPolicy, "")
+		return result.Account.Status, err
+	}
+	// Shelter 3 does not exist, so the item after it is never tried
+	summary, err := RunBulk(transactionsOn(db), []uint{1, 3, 2}, BulkAtomic, block)
+	if err != nil {
+		t.Fatalf("bulk: %v", err)
+	}
+
+	if summary.Committed || summary.Succeeded != 0 || summary.Failed != 3 {
+		t.Fatalf("summary = %+v, want nothing committed and three failures", summary)
+	}
+	wantErrors := map[uint]string{
+		1: errBulkRolledBack.Error(),
+		3: ErrShelterInfoNotFound.Error(),
+		2: "not attempted: " + errBulkRolledBack.Error(),
+	}
+	for _, result := range summary.Results {
+		if result.Success || result.Error != wantErrors[result.ID] {
+			t.Fatalf("result for %d = %+v, want error %q", result.ID, result, wantErrors[result.ID])
+		}
+	}
+
+	var active, reported, audits, notices int64
+	db.Model(&models.ShelterAccount{}).Where("status = ?", StatusActive).Count(&active)
+	db.Model(&models.SubmittedReport{}).Where("status = ?", ReportStatusReported).Count(&reported)
+	db.Model(&models.AdminAuditLog{}).Count(&audits)
+	db.Model(&models.OutcomeNotice{}).Count(&notices)
+	if active != 2 || reported != 2 || audits != 0 || notices != 0 {
+		t.Fatalf("after rollback: %d active shelters, %d open reports, %d audit rows, %d notices; want 2, 2, 0, 0",
+			active, reported, audits, notices)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after rollback, want 2", listed)
+	}
+
+	// Best effort keeps the items that worked
+	summary, err = RunBulk(transactionsOn(db), []uint{1, 3, 2}, BulkBestEffort, block)
+	if err != nil {
+		t.Fatalf("bulk: %v", err)
+	}
+	if !summary.Committed || summary.Succeeded != 2 || summary.Failed != 1 {
+		t.Fatalf("summary = %+v, want two items committed and one failure", summary)
+	}
+	db.Model(&models.ShelterAccount{}).Where("status = ?", StatusActive).Count(&active)
+	if active != 0 {
+		t.Fatalf("%d shelters still active after the best effort block", active)
+	}
+}
+
+func TestBulkRefusesBadRequests(t *testing.T) {
+	db := openTestDB(t)
+	noop := func(tx *gorm.DB, id uint) (string, error) { return "", nil }
+
+	tooMany := make([]uint, MaxBulkItems+1)
+	cases := []struct {
+		name string
+		ids  []uint
+		mode string
+		want error
+	}{
+		{"unknown mode", []uint{1}, "eventually", ErrInvalidBulkMode},
+		{"no ids", nil, BulkAtomic, ErrBulkEmpty},
+		{"too many ids", tooMany, BulkAtomic, ErrBulkTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := RunBulk(transactionsOn(db), tc.ids, tc.mode, noop); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Screening categories
+const (
+	ScreenProfanity = "profanity"
+	ScreenContact   = "contact"
+	ScreenPayment   = "payment"
+)
+
+// Moderation queue item states
+const (
+	ModerationOpen     = "open"
+	ModerationApproved = "approved"
+	ModerationRemoved  = "removed"
+)
+
+const redactedText = "[removed]"
+
+var (
+	ErrModerationItemNotFound = errors.New("moderation item not found")
+	ErrInvalidModeration      = errors.New("action must be 'approve' or 'remove'")
+	ErrInvalidTerm            = errors.New("invalid screening term")
+	ErrUnknownSource          = errors.New("unknown content source")
+)
+
+// ContentSource maps a screened text field to the table and column it lives in
+type ContentSource struct {
+	Name     string
+	Table    string
+	IDColumn string
+	Column   string
+}
+
+// ContentSources lists every user text field that is screened
+var ContentSources = []ContentSource{
+	{Name: "shelter_description", Table: "shelterinfo", IDColumn: "shelter_id", Column: "shelter_description"},
+	{Name: "pet_description", Table: "petinfo", IDColumn: "pet_id", Column: "pet_descriptions"},
+	{Name: "adoption_reason", Table: "adoption_submissions", IDColumn: "application_id", Column: "reason_for_adoption"},
+	{Name: "report_reason", Table: "submittedreports", IDColumn: "id", Column: "reason"},
+	{Name: "report_description", Table: "submittedreports", IDColumn: "id", Column: "description"},
+}
+
+// DefaultScreeningTerms seed the screening_terms table on first start
+var DefaultScreeningTerms = []models.ScreeningTerm{
+	{Category: ScreenContact, Pattern: `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`, IsRegex: true},
+	{Category: ScreenContact, Pattern: `(\+?63|0)\s?9\d{2}[\s\-]?\d{3}[\s\-]?\d{4}`, IsRegex: true},
+	{Category: ScreenContact, Pattern: `(m\.me|messenger\.com|facebook\.com|fb\.com|t\.me|wa\.me)/\S+`, IsRegex: true},
+	{Category: ScreenPayment, Pattern: "gcash"},
+	{Category: ScreenPayment, Pattern: "paymaya"},
+	{Category: ScreenPayment, Pattern: "palawan express"},
+	{Category: ScreenPayment, Pattern: "bank transfer"},
+	{Category: ScreenPayment, Pattern: "send money"},
+	{Category: ScreenPayment, Pattern: "reservation fee"},
+	{Category: ScreenPayment, Pattern: `(account|acct)\s*(no|number|#)\.?\s*:?\s*\d{6,}`, IsRegex: true},
+	{Category: ScreenProfanity, Pattern: "putang ina"},
+	{Category: ScreenProfanity, Pattern: "gago"},
+	{Category: ScreenProfanity, Pattern: "tanga"},
+	{Category: ScreenProfanity, Pattern: "fuck"},
+	{Category: ScreenProfanity, Pattern: "shit"},
+	{Category: ScreenProfanity, Pattern: "bitch"},
+}
+
+var (
+	htmlTagPattern      = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern   = regexp.MustCompile(`\s+`)
+	arrayLiteralPattern = regexp.MustCompile(`^\{(.*)\}$`)
+)
+
+// ScreeningMatch is one term found in a piece of text
+type ScreeningMatch struct {
+	Category string `json:"category"`
+	TermID   uint   `json:"term_id"`
+	Match    string `json:"match"`
+}
+
+// Screener holds compiled screening terms
+type Screener struct {
+	terms []compiledTerm
+}
+
+type compiledTerm struct {
+	term    models.ScreeningTerm
+	pattern *regexp.Regexp
+}
+
+// NormalizeText cleans user text before it is stored or shown: HTML tags and
+// control characters are removed, Postgres array literals such as
+// {"Scam","Neglect"} are unwrapped and whitespace is collapsed.
+func NormalizeText(s string) string {
+	s = strings.TrimSpace(s)
+	if m := arrayLiteralPattern.FindStringSubmatch(s); m != nil {
+		parts := strings.Split(m[1], ",")
+		for i, part := range parts {
+			parts[i] = strings.Trim(strings.TrimSpace(part), `"`)
+		}
+		s = strings.Join(parts, ", ")
+	}
+
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	s = whitespacePattern.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+// CompileTerm builds the regex for a term. Plain words match on word
+// boundaries and ignore case.
+func CompileTerm(term models.ScreeningTerm) (*regexp.Regexp, error) {
+	pattern := term.Pattern
+	if !term.IsRegex {
+		pattern = `\b` + regexp.QuoteMeta(strings.TrimSpace(pattern)) + `\b`
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// ValidateScreeningTerm checks the category and that the pattern compiles
+func ValidateScreeningTerm(term models.ScreeningTerm) error {
+	switch term.Category {
+	case ScreenProfanity, ScreenContact, ScreenPayment:
+	default:
+		return fmt.Errorf("%w: category must be %q, %q or %q", ErrInvalidTerm, ScreenProfanity, ScreenContact, ScreenPayment)
+	}
+	if strings.TrimSpace(term.Pattern) == "" {
+		return fmt.Errorf("%w: pattern is required", ErrInvalidTerm)
+	}
+	if _, err := CompileTerm(term); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTerm, err)
+	}
+	return nil
+}
+
+// SeedScreeningTerms inserts the default terms when the table is empty
+func SeedScreeningTerms(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.ScreeningTerm{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	terms := make([]models.ScreeningTerm, len(DefaultScreeningTerms))
+	for i, term := range DefaultScreeningTerms {
+		term.Enabled = true
+		terms[i] = term
+	}
+	return db.Create(&terms).Error
+}
+
+// LoadScreener compiles every enabled term. Invalid stored patterns are
+// skipped and logged rather than failing the whole screen.
+func LoadScreener(db *gorm.DB) (*Screener, error) {
+	var terms []models.ScreeningTerm
+	if err := db.Where("enabled = ?", true).Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	return NewScreener(terms), nil
+}
+
+// NewScreener compiles the enabled terms, skipping and logging invalid ones
+func NewScreener(terms []models.ScreeningTerm) *Screener {
+	screener := &Screener{}
+	for _, term := range terms {
+		if !term.Enabled {
+			continue
+		}
+		pattern, err := CompileTerm(term)
+		if err != nil {
+			log.Printf("Skipping invalid screening term %d: %v\n", term.TermID, err)
+			continue
+		}
+		screener.terms = append(screener.terms, compiledTerm{term: term, pattern: pattern})
+	}
+	return screener
+}
+
+// Screen returns every term match in text
+func (s *Screener) Screen(text string) []ScreeningMatch {
+	matches := []ScreeningMatch{}
+	for _, t := range s.terms {
+		for _, found := range t.pattern.FindAllString(text, -1) {
+			matches = append(matches, ScreeningMatch{Category: t.term.Category, TermID: t.term.TermID, Match: found})
+		}
+	}
+	return matches
+}
+
+// Redact replaces every term match in text
+func (s *Screener) Redact(text string) string {
+	for _, t := range s.terms {
+		text = t.pattern.ReplaceAllString(text, redactedText)
+	}
+	return text
+}
+
+// ScreenContent normalizes one field, screens it and queues it for
+// moderation when it matches. A field with an identical open item is not
+// queued twice.
+func ScreenContent(db *gorm.DB, screener *Screener, source string, entityID uint, text string) (*models.ModerationItem, error) {
+	content := NormalizeText(text)
+	if content == "" {
+		return nil, nil
+	}
+
+	matches := screener.Screen(content)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	var existing int64
+	if err := db.Model(&models.ModerationItem{}).
+		Where("source = ? AND entity_id = ? AND status = ? AND content = ?", source, entityID, ModerationOpen, content).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, nil
+	}
+
+	matchesJSON, err := json.Marshal(matches)
+	if err != nil {
+		return nil, err
+	}
+	item := models.ModerationItem{
+		Source:   source,
+		EntityID: entityID,
+		Content:  content,
+		Matches:  string(matchesJSON),
+		Status:   ModerationOpen,
+	}
+	if err := db.Create(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// screenBatchSize is how many rows a scan reads at a time
+const screenBatchSize = 500
+
+// ScanContentSource screens every stored row of a source and returns how
+// many new items were queued
+func ScanContentSource(db *gorm.DB, source ContentSource) (int, error) {
+	screener, err := LoadScreener(db)
+	if err != nil {
+		return 0, err
+	}
+	return scanSource(db, screener, source, false)
+}
+
+// ScanChangedContent screens the rows of every source whose text changed
+// since their last scan. The main PetHub app writes these tables and keeps
+// no change time, so a change shows as text that no longer matches the
+// checksum recorded when the row was last screened.
+func ScanChangedContent(db *gorm.DB) (int, error) {
+	screener, err := LoadScreener(db)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, source := range ContentSources {
+		count, err := scanSource(db, screener, source, true)
+		queued += count
+		if err != nil {
+			return queued, err
+		}
+	}
+	return queued, nil
+}
+
+// scanSource screens a source's rows a batch at a time, skipping the rows
+// whose text is unchanged when changedOnly is set, and records what each
+// screened row held
+func scanSource(db *gorm.DB, screener *Screener, source ContentSource, changedOnly bool) (int, error) {
+	queued := 0
+	var lastID uint
+	for {
+		var rows []struct {
+			ID   uint
+			Text string
+		}
+		if err := db.Table(source.Table).
+			Select(fmt.Sprintf("%s AS id, COALESCE(%s, '') AS text", source.IDColumn, source.Column)).
+			Where(source.IDColumn+" > ?", lastID).
+			Order(source.IDColumn).Limit(screenBatchSize).
+			Scan(&rows).Error; err != nil {
+			return queued, err
+		}
+		if len(rows) == 0 {
+			return queued, nil
+		}
+		lastID = rows[len(rows)-1].ID
+
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		var screened []models.ScreenedContent
+		if err := db.Where("source = ? AND entity_id IN ?", source.Name, ids).Find(&screened).Error; err != nil {
+			return queued, err
+		}
+		checksums := make(map[uint]string, len(screened))
+		for _, row := range screened {
+			checksums[row.EntityID] = row.Checksum
+		}
+
+		now := time.Now()
+		changed := []models.ScreenedContent{}
+		for _, row := range rows {
+			checksum := contentChecksum(row.Text)
+			if changedOnly && checksums[row.ID] == checksum {
+				continue
+			}
+			item, err := ScreenContent(db, screener, source.Name, row.ID, row.Text)
+			if err != nil {
+				return queued, err
+			}
+			if item != nil {
+				queued++
+			}
+			changed = append(changed, models.ScreenedContent{Source: source.Name, EntityID: row.ID, Checksum: checksum, ScreenedAt: now})
+		}
+		if len(changed) > 0 {
+			if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&changed).Error; err != nil {
+				return queued, err
+			}
+		}
+	}
+}
+
+// contentChecksum fingerprints text the way it is screened
+func contentChecksum(text string) string {
+	sum := sha256.Sum256([]byte(NormalizeText(text)))
+	return hex.EncodeToString(sum[:])
+}
+
+// StartScreeningScheduler screens changed user text on a fixed interval
+// until stop is closed
+func StartScreeningScheduler(db *gorm.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if queued, err := ScanChangedContent(db); err != nil {
+					log.Printf("Scheduled content screening error: %v\n", err)
+				} else if queued > 0 {
+					log.Printf("Scheduled content screening queued %d items\n", queued)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// FindContentSource looks up a source by name
+func FindContentSource(name string) (ContentSource, error) {
+	for _, source := range ContentSources {
+		if source.Name == name {
+			return source, nil
+		}
+	}
+	return ContentSource{}, fmt.Errorf("%w: %q", ErrUnknownSource, name)
+}
+
+// ReviewModerationItem approves the text as-is or removes the matched parts
+// from the source row
+func ReviewModerationItem(db *gorm.DB, itemID, adminID uint, action, note string) (models.ModerationItem, error) {
+	var item models.ModerationItem
+
+	var status string
+	switch NormalizeStatus(action) {
+	case "approve":
+		status = ModerationApproved
+	case "remove":
+		status = ModerationRemoved
+	default:
+		return item, ErrInvalidModeration
+	}
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Where("item_id = ? AND status = ?", itemID, ModerationOpen).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrModerationItemNotFound
+			}
+			return err
+		}
+
+		if status == ModerationRemoved {
+			source, err := FindContentSource(item.Source)
+			if err != nil {
+				return err
+			}
+			screener, err := LoadScreener(tx)
+			if err != nil {
+				return err
+			}
+			// Redact what the row holds now: the queued content is a
+			// normalized snapshot and the owner may have edited it since
+			var current []string
+			if err := tx.Table(source.Table).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where(source.IDColumn+" = ?", item.EntityID).
+				Pluck(fmt.Sprintf("COALESCE(%s, '')", source.Column), &current).Error; err != nil {
+				return err
+			}
+			if len(current) > 0 {
+				if err := tx.Table(source.Table).
+					Where(source.IDColumn+" = ?", item.EntityID).
+					Updates(withVersion(source.Table, map[string]interface{}{source.Column: screener.Redact(current[0])})).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		now := time.Now()
+		item.Status = status
+		item.ReviewNote = strings.TrimSpace(note)
+		item.ReviewedBy = adminID
+		item.ReviewedAt = &now
+		return tx.Save(&item).Error
+	})
+	return item, err
+}
+
+// DecodeMatches returns the stored matches of a moderation item
+func DecodeMatches(item models.ModerationItem) []ScreeningMatch {
+	matches := []ScreeningMatch{}
+	_ = json.Unmarshal([]byte(item.Matches), &matches)
+	return matches
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestScanChangedContentOnlyScreensChangedRows(t *testing.T) {
+	db := openTestDB(t)
+	if err := SeedScreeningTerms(db); err != nil {
+		t.Fatalf("seeding terms: %v", err)
+	}
+	// Written by the main app, out of reach of any callback here
+	db.Create(&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws", ShelterDescription: "Send a reservation fee by gcash"})
+	db.Create(&models.ShelterInfo{ShelterID: 2, ShelterName: "Tails", ShelterDescription: "Friendly cats"})
+
+	queued, err := ScanChangedContent(db)
+	if err != nil || queued != 1 {
+		t.Fatalf("first pass queued %d, err %v; want shelter 1", queued, err)
+	}
+
+	// An approved item is not queued again while its text stays the same
+	var item models.ModerationItem
+	db.First(&item)
+	if _, err := ReviewModerationItem(db, item.ItemID, 9, "approve", ""); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if queued, err := ScanChangedContent(db); err != nil || queued != 0 {
+		t.Fatalf("unchanged pass queued %d, err %v; want none", queued, err)
+	}
+
+	db.Model(&models.ShelterInfo{}).Where("shelter_id = ?", 2).Update("shelter_description", "Message us at tails@example.com")
+	if queued, err := ScanChangedContent(db); err != nil || queued != 1 {
+		t.Fatalf("pass after an edit queued %d, err %v; want shelter 2", queued, err)
+	}
+}
+
+func TestRemovingRedactsTheCurrentText(t *testing.T) {
+	db := openTestDB(t)
+	if err := SeedScreeningTerms(db); err != nil {
+		t.Fatalf("seeding terms: %v", err)
+	}
+	db.Create(&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws", ShelterDescription: "Pay by gcash"})
+	if _, err := ScanChangedContent(db); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	// The owner edits the text after it was queued
+	edited := "Adoption day on Saturday.\n\nPay by gcash at the door."
+	db.Model(&models.ShelterInfo{}).Where("shelter_id = ?", 1).Update("shelter_description", edited)
+
+	var item models.ModerationItem
+	db.First(&item)
+	if _, err := ReviewModerationItem(db, item.ItemID, 9, "remove", ""); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	var info models.ShelterInfo
+	db.First(&info, 1)
+	if want := "Adoption day on Saturday.\n\nPay by [removed] at the door."; info.ShelterDescription != want {
+		t.Fatalf("description = %q, want %q", info.ShelterDescription, want)
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Audit action for dismissing a report
+const ActionReportDismiss = "report_dismiss"
+
+var (
+	ErrReportNotFound   = errors.New("report not found")
+	ErrReportNotPending = errors.New("only reported reports can be dismissed")
+)
+
+// ActionedReportStatuses are report states that mean an admin acted on it
+var ActionedReportStatuses = []string{ReportStatusBlocked, ReportStatusResolved}
+
+// Credibility summarizes how a reporter's past reports were handled
+type Credibility struct {
+	Actioned  int64   `json:"actioned"`
+	Dismissed int64   `json:"dismissed"`
+	Pending   int64   `json:"pending"`
+	Score     float64 `json:"score"`
+}
+
+// CredibilityScore is the share of a reporter's decided reports that were
+// actioned, smoothed so a reporter with no history starts at 0.5
+func CredibilityScore(actioned, dismissed int64) float64 {
+	return float64(actioned+1) / float64(actioned+dismissed+2)
+}
+
+// ReporterCredibility returns the credibility of each given adopter.
+// Adopters without reports get the neutral score.
+func ReporterCredibility(db *gorm.DB, adopterIDs []uint) (map[uint]Credibility, error) {
+	var rows []struct {
+		AdopterID uint
+		Actioned  int64
+		Dismissed int64
+		Pending   int64
+	}
+	if len(adopterIDs) > 0 {
+		if err := db.Model(&models.SubmittedReport{}).
+			Select(`adopter_id,
+				COUNT(*) FILTER (WHERE status IN ?) AS actioned,
+				COUNT(*) FILTER (WHERE status = ?) AS dismissed,
+				COUNT(*) FILTER (WHERE status = ?) AS pending`,
+				ActionedReportStatuses, ReportStatusDismissed, ReportStatusReported).
+			Where("adopter_id IN ?", adopterIDs).
+			Group("adopter_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	credibility := make(map[uint]Credibility, len(adopterIDs))
+	for _, id := range adopterIDs {
+		credibility[id] = Credibility{Score: CredibilityScore(0, 0)}
+	}
+	for _, row := range rows {
+		credibility[row.AdopterID] = Credibility{
+			Actioned:  row.Actioned,
+			Dismissed: row.Dismissed,
+			Pending:   row.Pending,
+			Score:     CredibilityScore(row.Actioned, row.Dismissed),
+		}
+	}
+	return credibility, nil
+}
+
+// DismissReport closes a report without action, which lowers the
+// reporter's credibility
+func DismissReport(db *gorm.DB, reportID, adminID uint, note string) (models.SubmittedReport, error) {
+	var report models.SubmittedReport
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", reportID).First(&report).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReportNotFound
+			}
+			return err
+		}
+		if report.Status != ReportStatusReported {
+			return ErrReportNotPending
+		}
+
+		if err := tx.Model(&models.SubmittedReport{}).Where("id = ?", reportID).
+			Updates(map[string]interface{}{"status": ReportStatusDismissed, "version": nextVersion}).Error; err != nil {
+			return err
+		}
+		report.Status = ReportStatusDismissed
+		report.Version++
+
+		return RecordAudit(tx, adminID, ActionReportDismiss, EntityReport, reportID,
+			ReportStatusReported, ReportStatusDismissed, strings.TrimSpace(note))
+	})
+	return report, err
+}
+
+// weightedReportCount sums the reporters' credibility over the reports the
+// query matches. With distinct set each reporter counts once.
+func weightedReportCount(db, query *gorm.DB, distinct bool) (float64, error) {
+	var adopterIDs []uint
+	if err := query.Pluck("adopter_id", &adopterIDs).Error; err != nil {
+		return 0, err
+	}
+	if distinct {
+		adopterIDs = uniqueIDs(adopterIDs)
+	}
+
+	credibility, err := ReporterCredibility(db, uniqueIDs(adopterIDs))
+	if err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, id := range adopterIDs {
+		total += credibility[id].Score
+	}
+	return total, nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestCredibilityScore(t *testing.T) {
+	cases := []struct {
+		actioned, dismissed int64
+		want                float64
+	}{
+		{0, 0, 0.5},
+		{1, 1, 0.5},
+		{3, 0, 0.8},
+		{0, 3, 0.2},
+		{8, 0, 0.9},
+	}
+	for _, tc := range cases {
+		if got := CredibilityScore(tc.actioned, tc.dismissed); got != tc.want {
+			t.Errorf("CredibilityScore(%d, %d) = %v, want %v", tc.actioned, tc.dismissed, got, tc.want)
+		}
+	}
+}
+
+func TestDismissalsLowerReporterCredibility(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		// Adopter 5's reports led to action, adopter 6's are about to be dismissed
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 5, Reason: "neglect", Status: ReportStatusBlocked, Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 2, AdopterID: 5, Reason: "neglect", Status: ReportStatusResolved, Version: 1},
+		&models.SubmittedReport{ID: 3, ShelterID: 1, AdopterID: 6, Reason: "rude", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 4, ShelterID: 2, AdopterID: 6, Reason: "rude", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 5, ShelterID: 2, AdopterID: 7, Reason: "scam", Status: ReportStatusReported, Version: 1},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	for _, id := range []uint{3, 4} {
+		report, err := DismissReport(db, id, 9, "Not a policy issue")
+		if err != nil || report.Status != ReportStatusDismissed || report.Version != 2 {
+			t.Fatalf("dismiss %d: %+v, err %v", id, report, err)
+		}
+	}
+	if _, err := DismissReport(db, 3, 9, ""); !errors.Is(err, ErrReportNotPending) {
+		t.Fatalf("dismissing twice: err = %v, want ErrReportNotPending", err)
+	}
+	if _, err := DismissReport(db, 99, 9, ""); !errors.Is(err, ErrReportNotFound) {
+		t.Fatalf("dismissing an unknown report: err = %v, want ErrReportNotFound", err)
+	}
+
+	credibility, err := ReporterCredibility(db, []uint{5, 6, 7, 8})
+	if err != nil {
+		t.Fatalf("credibility: %v", err)
+	}
+	want := map[uint]Credibility{
+		5: {Actioned: 2, Score: 0.75},
+		6: {Dismissed: 2, Score: 0.25},
+		7: {Pending: 1, Score: 0.5},
+		8: {Score: 0.5},
+	}
+	for id, w := range want {
+		if credibility[id] != w {
+			t.Errorf("adopter %d credibility = %+v, want %+v", id, credibility[id], w)
+		}
+	}
+}
+
+func TestWeightedRuleDiscountsDismissedReporters(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"},
+		&models.FlagRule{RuleID: 1, Name: "Weighted pile-up", Kind: RuleReportVolume, MinReports: 2, WindowDays: 7,
+			Action: FlagRaisePriority, WeightByCredibility: true, Enabled: true},
+		// Adopters 5 and 6 have had every earlier report dismissed elsewhere
+		&models.SubmittedReport{ID: 1, ShelterID: 2, AdopterID: 5, Reason: "rude", Status: ReportStatusDismissed, Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 2, AdopterID: 5, Reason: "rude", Status: ReportStatusDismissed, Version: 1},
+		&models.SubmittedReport{ID: 3, ShelterID: 2, AdopterID: 6, Reason: "rude", Status: ReportStatusDismissed, Version: 1},
+		&models.SubmittedReport{ID: 4, ShelterID: 1, AdopterID: 5, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 5, ShelterID: 1, AdopterID: 6, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 6, ShelterID: 1, AdopterID: 7, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	// Three reports weigh 0.25 + 1/3 + 0.5, short of the threshold of 2
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 0 {
+		t.Fatalf("raised %d flags, err %v; want none from low-credibility reporters", len(raised), err)
+	}
+
+	// Two reporters with a record of actioned reports tip it over
+	for adopterID := uint(8); adopterID <= 9; adopterID++ {
+		for i := uint(0); i < 3; i++ {
+			db.Create(&models.SubmittedReport{ID: 10*adopterID + i, ShelterID: 2, AdopterID: adopterID, Reason: "scam", Status: ReportStatusResolved, Version: 1})
+		}
+		db.Create(&models.SubmittedReport{ID: 10*adopterID + 9, ShelterID: 1, AdopterID: adopterID, Reason: "neglect", Status: ReportStatusReported, Version: 1})
+	}
+	raised, err = EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags, err %v; want the weighted rule to fire", len(raised), err)
+	}
+}
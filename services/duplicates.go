@@ -0,0 +1,458 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Weights of each signal in a duplicate score (they add up to 1)
+const (
+	weightEmail   = 0.40
+	weightPhone   = 0.30
+	weightName    = 0.20
+	weightAddress = 0.10
+)
+
+// A name token shared by more than commonTokenShare of the profiles (and by
+// more than commonTokenFloor of them) is too common to block on
+const (
+	commonTokenShare = 0.05
+	commonTokenFloor = 20
+)
+
+// Action used when auditing merges
+const (
+	ActionAdopterMerge = "adopter_merge"
+	ActionShelterMerge = "shelter_merge"
+)
+
+var (
+	ErrSameAccount   = errors.New("survivor and duplicate must be different accounts")
+	ErrUnknownEntity = errors.New("entity type must be 'adopters' or 'shelters'")
+	ErrAlreadyMerged = errors.New("duplicate account has already been merged")
+)
+
+// DuplicateProfile is the normalized identity of one account
+type DuplicateProfile struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+	Status  string `json:"status"`
+
+	email   string
+	phone   string
+	name    []string
+	address []string
+}
+
+// ScoreBreakdown is the similarity of each signal between two profiles (0-1)
+type ScoreBreakdown struct {
+	Email   float64 `json:"email"`
+	Phone   float64 `json:"phone"`
+	Name    float64 `json:"name"`
+	Address float64 `json:"address"`
+}
+
+// DuplicateCandidate is a scored pair of accounts that may be the same person
+type DuplicateCandidate struct {
+	A         DuplicateProfile `json:"a"`
+	B         DuplicateProfile `json:"b"`
+	Score     float64          `json:"score"`
+	Breakdown ScoreBreakdown   `json:"breakdown"`
+}
+
+// NormalizeEmail lowercases an email and removes "+tag" suffixes. For Gmail
+// addresses the dots in the local part are removed as well.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus != -1 {
+		local = local[:plus]
+	}
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// NormalizePhone keeps the last 10 digits so "+63 917 123 4567" and
+// "09171234567" compare equal.
+func NormalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	if len(d) > 10 {
+		d = d[len(d)-10:]
+	}
+	return d
+}
+
+// NormalizeTokens lowercases text and splits it into letter/digit words
+func NormalizeTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// TokenSimilarity is the Jaccard similarity of two token sets
+func TokenSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	setA := make(map[string]bool, len(a))
+	for _, t := range a {
+		setA[t] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, t := range b {
+		setB[t] = true
+	}
+
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	union := len(setA) + len(setB) - shared
+	return float64(shared) / float64(union)
+}
+
+// ScorePair compares two profiles and returns the weighted score
+func ScorePair(a, b DuplicateProfile) (float64, ScoreBreakdown) {
+	var breakdown ScoreBreakdown
+	if a.email != "" && a.email == b.email {
+		breakdown.Email = 1
+	}
+	if len(a.phone) >= 7 && a.phone == b.phone {
+		breakdown.Phone = 1
+	}
+	breakdown.Name = TokenSimilarity(a.name, b.name)
+	breakdown.Address = TokenSimilarity(a.address, b.address)
+
+	score := breakdown.Email*weightEmail + breakdown.Phone*weightPhone +
+		breakdown.Name*weightName + breakdown.Address*weightAddress
+	return float64(int(score*1000)) / 1000, breakdown
+}
+
+// FindAdopterDuplicates scores adopter pairs that share an email, phone or
+// name token and returns those at or above minScore, best first.
+func FindAdopterDuplicates(db *gorm.DB, minScore float64) ([]DuplicateCandidate, error) {
+	var rows []struct {
+		models.AdopterInfo
+		Status string
+	}
+	if err := db.Table("adopterinfo").
+		Select("adopterinfo.*, adopteraccount.status").
+		Joins("JOIN adopteraccount ON adopteraccount.adopter_id = adopterinfo.adopter_id").
+		Where("adopterinfo.deleted_at IS NULL AND adopteraccount.deleted_at IS NULL").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	profiles := make([]DuplicateProfile, 0, len(rows))
+	for _, r := range rows {
+		profiles = append(profiles, NewDuplicateProfile(r.AdopterID, r.FirstName+" "+r.LastName, r.Email, r.ContactNumber, r.Address, r.Status))
+	}
+	return findDuplicates(db, "adopters", profiles, minScore)
+}
+
+// FindShelterDuplicates does the same for shelters using ShelterInfo
+func FindShelterDuplicates(db *gorm.DB, minScore float64) ([]DuplicateCandidate, error) {
+	var rows []struct {
+		models.ShelterInfo
+		Status string
+	}
+	if err := db.Table("shelterinfo").
+		Select("shelterinfo.*, shelteraccount.status").
+		Joins("JOIN shelteraccount ON shelteraccount.shelter_id = shelterinfo.shelter_id").
+		Where("shelterinfo.deleted_at IS NULL AND shelteraccount.deleted_at IS NULL").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	profiles := make([]DuplicateProfile, 0, len(rows))
+	for _, r := range rows {
+		profiles = append(profiles, NewDuplicateProfile(r.ShelterID, r.ShelterName, r.ShelterEmail, r.ShelterContact, r.ShelterAddress, r.Status))
+	}
+	return findDuplicates(db, "shelters", profiles, minScore)
+}
+
+// DismissDuplicate hides a candidate pair from future duplicate listings
+func DismissDuplicate(db *gorm.DB, entityType string, idA, idB, adminID uint) error {
+	if idA == idB {
+		return ErrSameAccount
+	}
+	if idA > idB {
+		idA, idB = idB, idA
+	}
+	return db.Where(models.DuplicateDismissal{EntityType: entityType, IDA: idA, IDB: idB}).
+		Attrs(models.DuplicateDismissal{DismissedBy: adminID}).
+		FirstOrCreate(&models.DuplicateDismissal{}).Error
+}
+
+// MergeAdopters moves applications, reports, interviews and adopted pets from
+// the duplicate adopter to the survivor and deactivates the duplicate.
+func MergeAdopters(db *gorm.DB, survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return mergeAccounts(db, "adopters", survivorID, duplicateID, adminID, "adopter_id", []string{
+		"adoption_submissions", "submittedreports", "schedule_interview", "adopterpets",
+	}, func(tx *gorm.DB) error {
+		if err := accountExists(tx, &models.AdopterAccount{}, "adopter_id", survivorID); err != nil {
+			return err
+		}
+		var duplicate models.AdopterAccount
+		if err := tx.Where("adopter_id = ?", duplicateID).First(&duplicate).Error; err != nil {
+			return notFound(err)
+		}
+		if NormalizeStatus(duplicate.Status) == StatusActive {
+			_, err := TransitionAdopterStatus(tx, duplicateID, StatusInactive)
+			return err
+		}
+		return nil
+	}, ActionAdopterMerge, EntityAdopter)
+}
+
+// MergeShelters moves pets, applications, reports, interviews, donations and
+// documents from the duplicate shelter to the survivor and deactivates it.
+func MergeShelters(db *gorm.DB, survivorID, duplicateID, adminID uint) (models.AccountMerge, error) {
+	return mergeAccounts(db, "shelters", survivorID, duplicateID, adminID, "shelter_id", []string{
+		"petinfo", "adoption_submissions", "submittedreports", "schedule_interview", "shelterdonations", "shelter_documents",
+	}, func(tx *gorm.DB) error {
+		if err := accountExists(tx, &models.ShelterAccount{}, "shelter_id", survivorID); err != nil {
+			return err
+		}
+		var duplicate models.ShelterAccount
+		if err := tx.Where("shelter_id = ?", duplicateID).First(&duplicate).Error; err != nil {
+			return notFound(err)
+		}
+		if NormalizeStatus(duplicate.Status) == StatusActive {
+			_, err := TransitionShelterStatus(tx, duplicateID, StatusInactive)
+			return err
+		}
+		return nil
+	}, ActionShelterMerge, EntityShelter)
+}
+
+func mergeAccounts(db *gorm.DB, entityType string, survivorID, duplicateID, adminID uint, column string, tables []string,
+	deactivate func(tx *gorm.DB) error, action, auditEntity string) (models.AccountMerge, error) {
+	merge := models.AccountMerge{
+		EntityType: entityType,
+		SurvivorID: survivorID,
+		MergedID:   duplicateID,
+		MergedBy:   adminID,
+	}
+	if survivorID == duplicateID {
+		return merge, ErrSameAccount
+	}
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var merged int64
+		if err := tx.Model(&models.AccountMerge{}).
+			Where("entity_type = ? AND merged_id = ?", entityType, duplicateID).
+			Count(&merged).Error; err != nil {
+			return err
+		}
+		if merged > 0 {
+			return ErrAlreadyMerged
+		}
+
+		if err := deactivate(tx); err != nil {
+			return err
+		}
+
+		moved := make(map[string]int64, len(tables))
+		for _, table := range tables {
+			result := tx.Table(table).
+				Where(column+" = ?", duplicateID).
+				Updates(withVersion(table, map[string]interface{}{column: survivorID}))
+			if result.Error != nil {
+				return fmt.Errorf("moving %s: %w", table, result.Error)
+			}
+			moved[table] = result.RowsAffected
+		}
+
+		movedJSON, err := json.Marshal(moved)
+		if err != nil {
+			return err
+		}
+		merge.MovedRows = string(movedJSON)
+		if err := tx.Create(&merge).Error; err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, adminID, action, auditEntity, duplicateID, "", "",
+			fmt.Sprintf("merged into %d: %s", survivorID, merge.MovedRows))
+	})
+	return merge, err
+}
+
+func findDuplicates(db *gorm.DB, entityType string, profiles []DuplicateProfile, minScore float64) ([]DuplicateCandidate, error) {
+	var dismissals []models.DuplicateDismissal
+	if err := db.Where("entity_type = ?", entityType).Find(&dismissals).Error; err != nil {
+		return nil, err
+	}
+
+	var merges []models.AccountMerge
+	if err := db.Where("entity_type = ?", entityType).Find(&merges).Error; err != nil {
+		return nil, err
+	}
+	return ScoreDuplicates(profiles, dismissals, merges, minScore), nil
+}
+
+// ScoreDuplicates returns the pairs of profiles scoring at least minScore,
+// best first, leaving out dismissed pairs and accounts already merged away
+func ScoreDuplicates(profiles []DuplicateProfile, dismissals []models.DuplicateDismissal, merges []models.AccountMerge, minScore float64) []DuplicateCandidate {
+	dismissed := make(map[[2]uint]bool, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[[2]uint{d.IDA, d.IDB}] = true
+	}
+	merged := make(map[uint]bool, len(merges))
+	for _, m := range merges {
+		merged[m.MergedID] = true
+	}
+
+	// Only compare profiles that share a blocking key, instead of every pair
+	common := commonTokens(profiles, merged)
+	blocks := make(map[string][]int)
+	for i, p := range profiles {
+		if merged[p.ID] {
+			continue
+		}
+		if p.email != "" {
+			blocks["e:"+p.email] = append(blocks["e:"+p.email], i)
+		}
+		if len(p.phone) >= 7 {
+			blocks["p:"+p.phone] = append(blocks["p:"+p.phone], i)
+		}
+		for _, token := range uniqueTokens(p.name) {
+			if len(token) > 2 && !common[token] {
+				blocks["n:"+token] = append(blocks["n:"+token], i)
+			}
+		}
+	}
+
+	seen := make(map[[2]uint]bool)
+	candidates := []DuplicateCandidate{}
+	for _, members := range blocks {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				a, b := profiles[members[x]], profiles[members[y]]
+				if a.ID > b.ID {
+					a, b = b, a
+				}
+				key := [2]uint{a.ID, b.ID}
+				if a.ID == b.ID || seen[key] || dismissed[key] {
+					continue
+				}
+				seen[key] = true
+
+				score, breakdown := ScorePair(a, b)
+				if score >= minScore {
+					candidates = append(candidates, DuplicateCandidate{A: a, B: b, Score: score, Breakdown: breakdown})
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].A.ID < candidates[j].A.ID
+	})
+	return candidates
+}
+
+// commonTokens returns the name tokens shared by so many profiles that a
+// block on them would compare nearly every pair ("the", "shelter", ...)
+func commonTokens(profiles []DuplicateProfile, merged map[uint]bool) map[string]bool {
+	counts := make(map[string]int)
+	total := 0
+	for _, p := range profiles {
+		if merged[p.ID] {
+			continue
+		}
+		total++
+		for _, token := range uniqueTokens(p.name) {
+			counts[token]++
+		}
+	}
+
+	limit := int(float64(total) * commonTokenShare)
+	if limit < commonTokenFloor {
+		limit = commonTokenFloor
+	}
+	common := make(map[string]bool)
+	for token, n := range counts {
+		if n > limit {
+			common[token] = true
+		}
+	}
+	return common
+}
+
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	unique := tokens[:0:0]
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			unique = append(unique, token)
+		}
+	}
+	return unique
+}
+
+// NewDuplicateProfile normalizes the identity fields of one account
+func NewDuplicateProfile(id uint, name, email, phone, address, status string) DuplicateProfile {
+	return DuplicateProfile{
+		ID:      id,
+		Name:    strings.TrimSpace(name),
+		Email:   email,
+		Phone:   phone,
+		Address: address,
+		Status:  status,
+		email:   NormalizeEmail(email),
+		phone:   NormalizePhone(phone),
+		name:    NormalizeTokens(name),
+		address: NormalizeTokens(address),
+	}
+}
+
+func accountExists(tx *gorm.DB, model interface{}, column string, id uint) error {
+	var count int64
+	if err := tx.Model(model).Where(column+" = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAccountNotFound
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestScoreDuplicatesSkipsCommonNameTokens(t *testing.T) {
+	// Every shelter shares "rescue"; only the two "Paws" records are alike
+	profiles := []DuplicateProfile{
+		NewDuplicateProfile(1, "Paws Rescue", "paws@example.com", "", "", "active"),
+		NewDuplicateProfile(2, "Paws Rescue", "paws.team@example.com", "", "", "active"),
+	}
+	for id := uint(3); id <= 200; id++ {
+		name := fmt.Sprintf("Shelter%d Rescue", id)
+		profiles = append(profiles, NewDuplicateProfile(id, name, fmt.Sprintf("s%d@example.com", id), "", "", "active"))
+	}
+
+	common := commonTokens(profiles, nil)
+	if !common["rescue"] || common["paws"] {
+		t.Fatalf("common tokens = %v, want only rescue", common)
+	}
+
+	candidates := ScoreDuplicates(profiles, nil, nil, 0)
+	if len(candidates) != 1 || candidates[0].A.ID != 1 || candidates[0].B.ID != 2 {
+		t.Fatalf("got %d candidates, want only the Paws pair: %+v", len(candidates), candidates)
+	}
+}
+
+func TestScoreDuplicatesKeepsTokensInSmallSets(t *testing.T) {
+	// Below the floor a shared token still blocks, however large its share
+	profiles := []DuplicateProfile{
+		NewDuplicateProfile(1, "Happy Tails", "", "", "", "active"),
+		NewDuplicateProfile(2, "Happy Paws", "", "", "", "active"),
+		NewDuplicateProfile(3, "Happy Home", "", "", "", "active"),
+	}
+	if candidates := ScoreDuplicates(profiles, nil, nil, 0); len(candidates) != 3 {
+		t.Fatalf("got %d candidates, want all 3 pairs", len(candidates))
+	}
+}
+
+func TestMergeAdoptersMovesTheirRecords(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		&models.AdopterAccount{AdopterID: 1, Username: "ana", Status: StatusActive},
+		&models.AdopterAccount{AdopterID: 2, Username: "ana2", Status: StatusActive},
+		&models.AdopterAccount{AdopterID: 3, Username: "ben", Status: StatusActive},
+		&models.AdoptionSubmission{ApplicationID: 1, AdopterID: 2, ShelterID: 1, PetID: 1, Status: "pending"},
+		&models.AdoptionSubmission{ApplicationID: 2, AdopterID: 2, ShelterID: 1, PetID: 2, Status: "approved"},
+		&models.AdoptionSubmission{ApplicationID: 3, AdopterID: 3, ShelterID: 1, PetID: 3, Status: "pending"},
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 2, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.ScheduleInterview{InterviewID: 1, ApplicationID: 1, ShelterID: 1, AdopterID: 2},
+		&models.AdoptedPet{AdoptedID: 1, AdopterID: 2, PetID: 2},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	merge, err := MergeAdopters(db, 1, 2, 9)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	count := func(model interface{}, adopterID uint) int64 {
+		t.Helper()
+		var n int64
+		if err := db.Model(model).Where("adopter_id = ?", adopterID).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	moved := []struct {
+		name  string
+		model interface{}
+		want  int64
+	}{
+		{"applications", &models.AdoptionSubmission{}, 2},
+		{"reports", &models.SubmittedReport{}, 1},
+		{"interviews", &models.ScheduleInterview{}, 1},
+		{"adopted pets", &models.AdoptedPet{}, 1},
+	}
+	for _, m := range moved {
+		if got, left := count(m.model, 1), count(m.model, 2); got != m.want || left != 0 {
+			t.Fatalf("%s: survivor has %d and duplicate %d, want %d and 0", m.name, got, left, m.want)
+		}
+	}
+	if other := count(&models.AdoptionSubmission{}, 3); other != 1 {
+		t.Fatalf("another adopter's applications moved: %d left, want 1", other)
+	}
+
+	var report models.SubmittedReport
+	db.First(&report, 1)
+	if report.Version != 2 {
+		t.Fatalf("moved report version = %d, want 2", report.Version)
+	}
+	var duplicate models.AdopterAccount
+	db.Where("adopter_id = ?", 2).First(&duplicate)
+	if duplicate.Status != StatusInactive {
+		t.Fatalf("duplicate status = %q, want inactive", duplicate.Status)
+	}
+	if merge.MovedRows != `{"adopterpets":1,"adoption_submissions":2,"schedule_interview":1,"submittedreports":1}` {
+		t.Fatalf("moved rows = %s", merge.MovedRows)
+	}
+
+	if _, err := MergeAdopters(db, 1, 2, 9); !errors.Is(err, ErrAlreadyMerged) {
+		t.Fatalf("merging again: err = %v, want ErrAlreadyMerged", err)
+	}
+	if _, err := MergeAdopters(db, 1, 1, 9); !errors.Is(err, ErrSameAccount) {
+		t.Fatalf("merging into itself: err = %v, want ErrSameAccount", err)
+	}
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Flag rule kinds
+const (
+	RuleReportVolume = "report_volume" // N or more reports within a window
+	RuleReasonMatch  = "reason_match"  // any report whose reason matches a pattern
+)
+
+// Flag rule actions
+const (
+	FlagRaisePriority = "raise_priority"
+	FlagEscalate      = "escalate"
+	FlagTemporaryHold = "temporary_hold"
+)
+
+// HoldFlagged is stored in petinfo.hidden_by while an open temporary_hold
+// flag keeps a shelter's pets out of the listings
+const HoldFlagged = "flag_hold"
+
+var (
+	ErrRuleNotFound = errors.New("flag rule not found")
+	ErrFlagNotFound = errors.New("flag not found")
+	ErrInvalidRule  = errors.New("invalid flag rule")
+	// ErrShelterOnHold refuses approving or reinstating a shelter while a
+	// temporary_hold flag on it is open and has not expired
+	ErrShelterOnHold = errors.New("shelter is on a temporary hold from an open flag")
+)
+
+// ShelterFlagSummary is the combined effect of a shelter's open flags
+type ShelterFlagSummary struct {
+	Priority  int        `json:"priority"`
+	Escalated bool       `json:"escalated"`
+	HoldUntil *time.Time `json:"hold_until,omitempty"`
+	OpenFlags int        `json:"open_flags"`
+}
+
+// ValidateFlagRule checks that a rule is complete for its kind and action
+func ValidateFlagRule(rule models.FlagRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+
+	switch rule.Kind {
+	case RuleReportVolume:
+		if rule.MinReports < 1 || rule.WindowDays < 1 {
+			return fmt.Errorf("%w: report_volume rules need min_reports and window_days of at least 1", ErrInvalidRule)
+		}
+	case RuleReasonMatch:
+		if strings.TrimSpace(rule.ReasonPattern) == "" {
+			return fmt.Errorf("%w: reason_match rules need a reason_pattern", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be %q or %q", ErrInvalidRule, RuleReportVolume, RuleReasonMatch)
+	}
+
+	switch rule.Action {
+	case FlagRaisePriority, FlagEscalate:
+	case FlagTemporaryHold:
+		if rule.HoldHours < 1 {
+			return fmt.Errorf("%w: temporary_hold rules need hold_hours of at least 1", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: action must be %q, %q or %q", ErrInvalidRule, FlagRaisePriority, FlagEscalate, FlagTemporaryHold)
+	}
+	return nil
+}
+
+// EvaluateShelterFlags runs every enabled rule against one shelter's reports
+// and raises a flag for each rule that matches and has no open flag yet.
+// Once an admin resolves a rule's flag, only reports filed after that count
+// towards the rule again. A temporary_hold flag hides the shelter's pets
+// until it is resolved or expires; an expired hold counts as resolved when
+// it ran out.
+func EvaluateShelterFlags(db *gorm.DB, shelterID uint) ([]models.ShelterFlag, error) {
+	var rules []models.FlagRule
+	if err := db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	raised := []models.ShelterFlag{}
+	for _, rule := range rules {
+		var flag *models.ShelterFlag
+		err := InTransaction(db, func(tx *gorm.DB) (err error) {
+			flag, err = evaluateRule(tx, rule, shelterID, time.Now())
+			return err
+		})
+		if err != nil {
+			return raised, err
+		}
+		if flag != nil {
+			raised = append(raised, *flag)
+		}
+	}
+	return raised, nil
+}
+
+// evaluateRule raises rule's flag on one shelter, or returns nil when the
+// rule has an open flag there or does not match
+func evaluateRule(tx *gorm.DB, rule models.FlagRule, shelterID uint, now time.Time) (*models.ShelterFlag, error) {
+	// A hold that ran out does not keep the rule from firing again, even
+	// before the scheduler has lapsed it
+	if err := lapseFlagHolds(tx.Where("shelter_id = ? AND rule_id = ?", shelterID, rule.RuleID), now); err != nil {
+		return nil, err
+	}
+
+	var open int64
+	if err := tx.Model(&models.ShelterFlag{}).
+		Where("shelter_id = ? AND rule_id = ? AND resolved_at IS NULL", shelterID, rule.RuleID).
+		Count(&open).Error; err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, nil
+	}
+
+	// Reports an admin already dealt with by resolving the flag do not count
+	var lastResolved []time.Time
+	if err := tx.Model(&models.ShelterFlag{}).
+		Where("shelter_id = ? AND rule_id = ? AND resolved_at IS NOT NULL", shelterID, rule.RuleID).
+		Order("resolved_at DESC").Limit(1).
+		Pluck("resolved_at", &lastResolved).Error; err != nil {
+		return nil, err
+	}
+	var since *time.Time
+	if len(lastResolved) > 0 {
+		since = &lastResolved[0]
+	}
+
+	matched, details, err := ruleMatches(tx, rule, shelterID, now, since)
+	if err != nil || !matched {
+		return nil, err
+	}
+
+	flag := models.ShelterFlag{
+		ShelterID: shelterID,
+		RuleID:    rule.RuleID,
+		Action:    rule.Action,
+		Details:   details,
+	}
+	if rule.Action == FlagTemporaryHold {
+		holdUntil := now.Add(time.Duration(rule.HoldHours) * time.Hour)
+		flag.HoldUntil = &holdUntil
+	}
+	// An evaluation racing this one may have raised it since the check above
+	created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&flag)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 0 {
+		return nil, nil
+	}
+	if flag.Action == FlagTemporaryHold {
+		if err := tx.Model(&models.PetInfo{}).
+			Where("shelter_id = ? AND hidden_by = ''", shelterID).
+			Update("hidden_by", HoldFlagged).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &flag, nil
+}
+
+// EvaluateAllShelterFlags evaluates every shelter that received a report
+// within the longest rule window. Used by the scheduler.
+func EvaluateAllShelterFlags(db *gorm.DB) (int, error) {
+	var maxWindow int
+	if err := db.Model(&models.FlagRule{}).
+		Where("enabled = ?", true).
+		Select("COALESCE(MAX(window_days), 0)").
+		Scan(&maxWindow).Error; err != nil {
+		return 0, err
+	}
+
+	query := db.Model(&models.SubmittedReport{}).Distinct("shelter_id")
+	if maxWindow > 0 {
+		query = query.Where("created_at >= ?", time.Now().AddDate(0, 0, -maxWindow))
+	}
+
+	var shelterIDs []uint
+	if err := query.Pluck("shelter_id", &shelterIDs).Error; err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, id := range shelterIDs {
+		raised, err := EvaluateShelterFlags(db, id)
+		if err != nil {
+			return total, err
+		}
+		total += len(raised)
+	}
+	return total, nil
+}
+
+// ResolveShelterFlag closes an open flag. Closing the last hold on a
+// shelter lists its pets again.
+func ResolveShelterFlag(db *gorm.DB, flagID, adminID uint) error {
+	return InTransaction(db, func(tx *gorm.DB) error {
+		var flag models.ShelterFlag
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("flag_id = ? AND resolved_at IS NULL", flagID).First(&flag).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrFlagNotFound
+			}
+			return err
+		}
+
+		if err := tx.Model(&flag).
+			Updates(map[string]interface{}{"resolved_at": time.Now(), "resolved_by": adminID}).Error; err != nil {
+			return err
+		}
+		if flag.Action != FlagTemporaryHold {
+			return nil
+		}
+		_, err := releaseFlagHolds(tx, flag.ShelterID)
+		return err
+	})
+}
+
+// ReleaseExpiredFlagHolds resolves the holds that have run out and lists
+// the pets of every shelter whose holds have all expired. Used by the
+// scheduler.
+func ReleaseExpiredFlagHolds(db *gorm.DB) (int64, error) {
+	var released int64
+	err := InTransaction(db, func(tx *gorm.DB) (err error) {
+		if err = lapseFlagHolds(tx, time.Now()); err != nil {
+			return err
+		}
+		released, err = releaseFlagHolds(tx, 0)
+		return err
+	})
+	return released, err
+}
+
+// lapseFlagHolds resolves the open temporary_hold flags in scope whose hold
+// ended by now, as of when it ended. Reports filed before then no longer
+// count towards the rule, as after an admin resolves a flag.
+func lapseFlagHolds(scope *gorm.DB, now time.Time) error {
+	return scope.Model(&models.ShelterFlag{}).
+		Where("action = ? AND resolved_at IS NULL AND hold_until <= ?", FlagTemporaryHold, now).
+		Update("resolved_at", gorm.Expr("hold_until")).Error
+}
+
+// releaseFlagHolds unhides the pets a hold hid for shelterID, or for every
+// shelter when it is 0, unless their shelter is still on hold
+func releaseFlagHolds(db *gorm.DB, shelterID uint) (int64, error) {
+	query := db.Model(&models.PetInfo{}).
+		Where("hidden_by = ? AND shelter_id NOT IN (?)", HoldFlagged, activeFlagHolds(db).Select("shelter_id"))
+	if shelterID != 0 {
+		query = query.Where("shelter_id = ?", shelterID)
+	}
+	update := query.Update("hidden_by", "")
+	return update.RowsAffected, update.Error
+}
+
+// ensureNoFlagHold fails with ErrShelterOnHold while the shelter is on hold
+func ensureNoFlagHold(tx *gorm.DB, shelterID uint) error {
+	var held int64
+	if err := activeFlagHolds(tx).Where("shelter_id = ?", shelterID).Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		return ErrShelterOnHold
+	}
+	return nil
+}
+
+// activeFlagHolds selects the open temporary_hold flags that have not expired
+func activeFlagHolds(db *gorm.DB) *gorm.DB {
+	return db.Model(&models.ShelterFlag{}).
+		Where("action = ? AND resolved_at IS NULL AND hold_until > ?", FlagTemporaryHold, time.Now())
+}
+
+// ShelterFlagSummaries returns the open-flag summary for each given shelter
+func ShelterFlagSummaries(db *gorm.DB, shelterIDs []uint) (map[uint]ShelterFlagSummary, error) {
+	var flags []models.ShelterFlag
+	if err := db.Where("shelter_id IN ? AND resolved_at IS NULL", shelterIDs).
+		Find(&flags).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	summaries := make(map[uint]ShelterFlagSummary)
+	for _, flag := range flags {
+		summary := summaries[flag.ShelterID]
+		summary.OpenFlags++
+		switch flag.Action {
+		case FlagRaisePriority:
+			summary.Priority++
+		case FlagEscalate:
+			summary.Escalated = true
+		case FlagTemporaryHold:
+			if flag.HoldUntil != nil && flag.HoldUntil.After(now) &&
+				(summary.HoldUntil == nil || flag.HoldUntil.After(*summary.HoldUntil)) {
+				summary.HoldUntil = flag.HoldUntil
+			}
+		}
+		summaries[flag.ShelterID] = summary
+	}
+	return summaries, nil
+}
+
+// StartFlagScheduler re-evaluates all rules and releases expired holds on a
+// fixed interval until stop is closed
+func StartFlagScheduler(db *gorm.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if raised, err := EvaluateAllShelterFlags(db); err != nil {
+					log.Printf("Scheduled flag evaluation error: %v\n", err)
+				} else if raised > 0 {
+					log.Printf("Scheduled flag evaluation raised %d flags\n", raised)
+				}
+				if released, err := ReleaseExpiredFlagHolds(db); err != nil {
+					log.Printf("Releasing expired flag holds failed: %v\n", err)
+				} else if released > 0 {
+					log.Printf("Expired flag holds listed %d pets again\n", released)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// likeEscaper makes a reason pattern match literally under LIKE with the
+// backslash as its escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ruleMatches counts the shelter's reports within the rule's window, and
+// only those filed after since when it is set
+func ruleMatches(db *gorm.DB, rule models.FlagRule, shelterID uint, now time.Time, since *time.Time) (bool, string, error) {
+	query := db.Model(&models.SubmittedReport{}).Where("shelter_id = ?", shelterID)
+	if rule.WindowDays > 0 {
+		query = query.Where("created_at >= ?", now.AddDate(0, 0, -rule.WindowDays))
+	}
+	if since != nil {
+		query = query.Where("created_at > ?", *since)
+	}
+
+	switch rule.Kind {
+	case RuleReportVolume:
+		if rule.WeightByCredibility {
+			weighted, err := weightedReportCount(db, query, rule.DistinctAdopters)
+			if err != nil {
+				return false, "", err
+			}
+			return weighted >= float64(rule.MinReports),
+				fmt.Sprintf("%.1f credibility-weighted reports in the last %d days (threshold %d)", weighted, rule.WindowDays, rule.MinReports), nil
+		}
+
+		var count int64
+		counter := query
+		if rule.DistinctAdopters {
+			counter = counter.Distinct("adopter_id")
+		}
+		if err := counter.Count(&count).Error; err != nil {
+			return false, "", err
+		}
+		return int(count) >= rule.MinReports,
+			fmt.Sprintf("%d reports in the last %d days (threshold %d)", count, rule.WindowDays, rule.MinReports), nil
+
+	case RuleReasonMatch:
+		query = query.Where(`LOWER(reason) LIKE LOWER(?) ESCAPE '\'`, "%"+likeEscaper.Replace(rule.ReasonPattern)+"%")
+		if rule.WeightByCredibility {
+			// A single report from a reporter with no history (score 0.5) is enough
+			weighted, err := weightedReportCount(db, query, false)
+			if err != nil {
+				return false, "", err
+			}
+			return weighted >= CredibilityScore(0, 0),
+				fmt.Sprintf("%.1f credibility-weighted reports with reason matching %q", weighted, rule.ReasonPattern), nil
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return false, "", err
+		}
+		return count > 0, fmt.Sprintf("%d reports with reason matching %q", count, rule.ReasonPattern), nil
+	}
+	return false, "", nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// seedHoldRule creates a shelter with two listed pets, three recent reports
+// and a rule that puts it on hold for reaching two reports
+func seedHoldRule(t *testing.T, db *gorm.DB, status, regStatus string) {
+	t.Helper()
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: status, RegStatus: regStatus, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"},
+		&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", ListingStatus: "listed"},
+		&models.PetInfo{PetID: 2, ShelterID: 1, PetName: "Mia", ListingStatus: "listed"},
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 5, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 1, AdopterID: 6, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+		&models.SubmittedReport{ID: 3, ShelterID: 1, AdopterID: 7, Reason: "scam", Status: ReportStatusReported, Version: 1},
+		&models.FlagRule{RuleID: 1, Name: "Pile-up", Kind: RuleReportVolume, MinReports: 2, WindowDays: 7,
+			Action: FlagTemporaryHold, HoldHours: 24, Enabled: true},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+func listedPets(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var listed int64
+	if err := ListedPets(db.Model(&models.PetInfo{})).Count(&listed).Error; err != nil {
+		t.Fatal(err)
+	}
+	return listed
+}
+
+func TestFlagHoldHidesPetsAndBlocksReinstating(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusActive, RegStatusApproved)
+
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags, err %v; want the hold", len(raised), err)
+	}
+	if listed := listedPets(t, db); listed != 0 {
+		t.Fatalf("%d pets still listed under the hold", listed)
+	}
+
+	// Blocking takes the held pets over; reinstating waits for the hold
+	if _, err := BlockShelter(db, 1, 9, DefaultShelterBlockPolicy, ""); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if _, err := ReinstateShelter(db, 1, 9); !errors.Is(err, ErrShelterOnHold) {
+		t.Fatalf("reinstate err = %v, want ErrShelterOnHold", err)
+	}
+	if _, err := SetShelterStatus(db, 1, 9, StatusActive, DefaultShelterBlockPolicy); !errors.Is(err, ErrShelterOnHold) {
+		t.Fatalf("activate err = %v, want ErrShelterOnHold", err)
+	}
+
+	if err := ResolveShelterFlag(db, raised[0].FlagID, 9); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if listed := listedPets(t, db); listed != 0 {
+		t.Fatalf("%d pets listed while the shelter is still blocked", listed)
+	}
+	if _, err := ReinstateShelter(db, 1, 9); err != nil {
+		t.Fatalf("reinstate after the hold: %v", err)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after reinstating, want 2", listed)
+	}
+}
+
+func TestFlagHoldBlocksRegistrationApproval(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusInactive, RegStatusPending)
+	if _, err := EvaluateShelterFlags(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := DecideShelterRegistration(db, 1, 9, RegStatusApproved, "", "", time.Hour)
+	if !errors.Is(err, ErrShelterOnHold) {
+		t.Fatalf("approve err = %v, want ErrShelterOnHold", err)
+	}
+	// Rejecting is still allowed
+	if _, _, err := DecideShelterRegistration(db, 1, 9, RegStatusRejected, "policy_violation", "Reports under review", time.Hour); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+}
+
+func TestResolvingAFlagReleasesTheHold(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusActive, RegStatusApproved)
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ResolveShelterFlag(db, raised[0].FlagID, 9); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after resolving the hold, want 2", listed)
+	}
+	if err := ResolveShelterFlag(db, raised[0].FlagID, 9); !errors.Is(err, ErrFlagNotFound) {
+		t.Fatalf("resolving twice: err = %v, want ErrFlagNotFound", err)
+	}
+}
+
+func TestResolvedFlagIsNotRaisedAgainForTheSameReports(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusActive, RegStatusApproved)
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags, err %v; want the hold", len(raised), err)
+	}
+	if err := ResolveShelterFlag(db, raised[0].FlagID, 9); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	// The next scheduler pass sees the same reports in the window
+	raised, err = EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 0 {
+		t.Fatalf("re-evaluating raised %d flags, err %v; want none", len(raised), err)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after re-evaluating, want 2", listed)
+	}
+
+	// Reports filed after the resolution count again
+	later := time.Now().Add(time.Minute)
+	for id := uint(4); id <= 5; id++ {
+		db.Create(&models.SubmittedReport{ID: id, ShelterID: 1, AdopterID: id + 4, Reason: "neglect",
+			Status: ReportStatusReported, Version: 1, CreatedAt: later})
+	}
+	raised, err = EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags for new reports, err %v; want the hold again", len(raised), err)
+	}
+	if listed := listedPets(t, db); listed != 0 {
+		t.Fatalf("%d pets still listed under the new hold", listed)
+	}
+}
+
+func TestRacingEvaluationRaisesOneFlag(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusActive, RegStatusApproved)
+
+	// Another evaluation raises the flag between this one's check and insert
+	raced := false
+	db.Callback().Create().Before("gorm:create").Register("test:racing_flag", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*models.ShelterFlag); ok && !raced {
+			raced = true
+			tx.Session(&gorm.Session{NewDB: true}).
+				Exec("INSERT INTO shelter_flags (shelter_id, rule_id, action) VALUES (1, 1, ?)", FlagTemporaryHold)
+		}
+	})
+
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 0 {
+		t.Fatalf("raised %d flags, err %v; want none next to the racing one", len(raised), err)
+	}
+	var open int64
+	db.Model(&models.ShelterFlag{}).Where("resolved_at IS NULL").Count(&open)
+	if !raced || open != 1 {
+		t.Fatalf("%d open flags, want only the racing one", open)
+	}
+}
+
+func TestReleaseExpiredFlagHolds(t *testing.T) {
+	db := openTestDB(t)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	rows := []interface{}{
+		&models.ShelterFlag{ShelterID: 1, Action: FlagTemporaryHold, HoldUntil: &past},
+		&models.ShelterFlag{ShelterID: 2, Action: FlagTemporaryHold, HoldUntil: &future},
+		&models.PetInfo{PetID: 1, ShelterID: 1, ListingStatus: "listed", HiddenBy: HoldFlagged},
+		&models.PetInfo{PetID: 2, ShelterID: 2, ListingStatus: "listed", HiddenBy: HoldFlagged},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	released, err := ReleaseExpiredFlagHolds(db)
+	if err != nil || released != 1 {
+		t.Fatalf("released %d, err %v; want the expired shelter's pet only", released, err)
+	}
+	var pet models.PetInfo
+	db.First(&pet, 2)
+	if pet.HiddenBy != HoldFlagged {
+		t.Fatalf("pet under a running hold has hidden_by %q", pet.HiddenBy)
+	}
+
+	var flags []models.ShelterFlag
+	db.Order("shelter_id").Find(&flags)
+	if flags[0].ResolvedAt == nil || !flags[0].ResolvedAt.Equal(*flags[0].HoldUntil) || flags[1].ResolvedAt != nil {
+		t.Fatalf("flags = %+v, want only the expired hold resolved as of its end", flags)
+	}
+}
+
+func TestExpiredHoldLetsTheRuleFireAgain(t *testing.T) {
+	db := openTestDB(t)
+	seedHoldRule(t, db, StatusActive, RegStatusApproved)
+	db.Model(&models.SubmittedReport{}).Where("1 = 1").Update("created_at", time.Now().Add(-2*time.Hour))
+	raised, err := EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags, err %v; want the hold", len(raised), err)
+	}
+
+	// The hold runs out before the scheduler releases it
+	ended := time.Now().Add(-time.Minute)
+	db.Model(&models.ShelterFlag{}).Where("flag_id = ?", raised[0].FlagID).Update("hold_until", ended)
+
+	// The reports the hold dealt with do not raise it again
+	raised, err = EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 0 {
+		t.Fatalf("re-evaluating raised %d flags, err %v; want none", len(raised), err)
+	}
+	var open int64
+	db.Model(&models.ShelterFlag{}).Where("resolved_at IS NULL").Count(&open)
+	if open != 0 {
+		t.Fatalf("%d flags still open after the hold ran out", open)
+	}
+
+	for id := uint(4); id <= 5; id++ {
+		db.Create(&models.SubmittedReport{ID: id, ShelterID: 1, AdopterID: id + 4, Reason: "neglect", Status: ReportStatusReported, Version: 1})
+	}
+	raised, err = EvaluateShelterFlags(db, 1)
+	if err != nil || len(raised) != 1 {
+		t.Fatalf("raised %d flags for new reports, err %v; want the hold again", len(raised), err)
+	}
+}
+
+func TestReasonPatternIsMatchedLiterally(t *testing.T) {
+	if got, want := likeEscaper.Replace(`100%_sure\`), `100\%\_sure\\`; got != want {
+		t.Fatalf("escaped %q, want %q", got, want)
+	}
+}
+
+func TestReasonMatchRule(t *testing.T) {
+	cases := []struct {
+		pattern string
+		reasons []string
+		raised  bool
+	}{
+		{"scam", []string{"Possible SCAM shelter"}, true},
+		{"Scam", []string{"asked for a scam fee"}, true},
+		{"scam", []string{"neglect", "abuse"}, false},
+		{"100%", []string{"Charged 100% upfront"}, true},
+		{"100%", []string{"Charged 1000 upfront"}, false},
+		{"fee_", []string{"fees charged"}, false},
+		{`C:\dogs`, []string{`Sent files to C:\dogs`}, true},
+		{`C:\dogs`, []string{`Sent files to C:dogs`}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.pattern+" in "+tc.reasons[0], func(t *testing.T) {
+			db := openTestDB(t)
+			rows := []interface{}{
+				&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+				&models.FlagRule{RuleID: 1, Name: "Reason", Kind: RuleReasonMatch, ReasonPattern: tc.pattern, Action: FlagEscalate, Enabled: true},
+			}
+			for i, reason := range tc.reasons {
+				rows = append(rows, &models.SubmittedReport{ID: uint(i + 1), ShelterID: 1, AdopterID: 5, Reason: reason, Status: ReportStatusReported, Version: 1})
+			}
+			for _, row := range rows {
+				if err := db.Create(row).Error; err != nil {
+					t.Fatalf("seeding: %v", err)
+				}
+			}
+
+			raised, err := EvaluateShelterFlags(db, 1)
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+			if (len(raised) == 1) != tc.raised {
+				t.Fatalf("raised %d flags, want raised %v", len(raised), tc.raised)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrEmptyUpload       = errors.New("file data is required")
+	ErrInvalidEncoding   = errors.New("file data must be base64 encoded")
+	ErrUnsupportedType   = errors.New("file type is not allowed")
+	ErrUploadTooLarge    = errors.New("file is too large")
+	ImageMimeTypes       = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	ImageAndPDFMimeTypes = append([]string{"application/pdf"}, ImageMimeTypes...)
+)
+
+// MaxUploadBytes is the largest decoded file any upload accepts
+const MaxUploadBytes = 5 << 20 // 5 MB
+
+// UploadBodyLimit is the request body size the server has to accept for an
+// upload of MaxUploadBytes to arrive whole: base64 grows the file by a
+// third, and the data URI prefix and other JSON fields need some room.
+const UploadBodyLimit = (MaxUploadBytes+2)/3*4 + 64<<10
+
+// UploadRules restricts what a base64 upload may contain
+type UploadRules struct {
+	AllowedTypes []string
+	MaxBytes     int
+}
+
+// Upload is a validated base64 file ready to be stored in a media column
+type Upload struct {
+	Data      string // raw base64 without any data URI prefix
+	MimeType  string
+	SizeBytes int
+}
+
+// ValidateUpload decodes a base64 payload (optionally wrapped in a data URI),
+// sniffs its content type and checks it against the rules. Media is stored
+// as base64 text in the database like the other *_media tables.
+func ValidateUpload(data string, rules UploadRules) (Upload, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return Upload{}, ErrEmptyUpload
+	}
+
+	// Strip "data:<mime>;base64," prefix if present
+	if strings.HasPrefix(data, "data:") {
+		if idx := strings.Index(data, ","); idx != -1 {
+			data = data[idx+1:]
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return Upload{}, ErrInvalidEncoding
+	}
+
+	if rules.MaxBytes > 0 && len(raw) > rules.MaxBytes {
+		return Upload{}, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrUploadTooLarge, len(raw), rules.MaxBytes)
+	}
+
+	mimeType := http.DetectContentType(raw)
+	if idx := strings.Index(mimeType, ";"); idx != -1 {
+		mimeType = mimeType[:idx]
+	}
+
+	allowed := false
+	for _, t := range rules.AllowedTypes {
+		if t == mimeType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return Upload{}, fmt.Errorf("%w: %s", ErrUnsupportedType, mimeType)
+	}
+
+	return Upload{Data: data, MimeType: mimeType, SizeBytes: len(raw)}, nil
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestValidateUploadSizeLimit(t *testing.T) {
+	png := func(size int) string {
+		file := make([]byte, size)
+		copy(file, "\x89PNG\r\n\x1a\n")
+		return "data:image/png;base64," + base64.StdEncoding.EncodeToString(file)
+	}
+
+	upload, err := ValidateUpload(png(MaxUploadBytes), DocumentUploadRules)
+	if err != nil || upload.SizeBytes != MaxUploadBytes || upload.MimeType != "image/png" {
+		t.Fatalf("largest allowed upload: %+v, %v", upload.SizeBytes, err)
+	}
+	if _, err := ValidateUpload(png(MaxUploadBytes+1), DocumentUploadRules); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("one byte over: err = %v, want ErrUploadTooLarge", err)
+	}
+	if encoded := len(png(MaxUploadBytes)); encoded > UploadBodyLimit {
+		t.Fatalf("largest upload encodes to %d bytes, over the %d byte body limit", encoded, UploadBodyLimit)
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Audit actions for account moderation
+const (
+	ActionShelterBlock     = "shelter_block"
+	ActionShelterReinstate = "shelter_reinstate"
+	ActionShelterApprove   = "shelter_approve"
+	ActionShelterReject    = "shelter_reject"
+	ActionAdopterStatus    = "adopter_status"
+)
+
+// Submitted report status values
+const (
+	ReportStatusPending   = "pending" // column default, before the report is routed to admins
+	ReportStatusReported  = "reported"
+	ReportStatusBlocked   = "blocked"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+var ErrShelterInfoNotFound = errors.New("shelter info not found")
+
+// ShelterModerationResult is returned by the block and reinstate operations
+type ShelterModerationResult struct {
+	Account        models.ShelterAccount
+	Info           models.ShelterInfo
+	ReportsUpdated int64
+	Cascade        CascadeResult
+}
+
+// SetShelterStatus moves a shelter to active or inactive the way the
+// moderation routes do: deactivating blocks it under policy and activating
+// reinstates it, so its reports, pets and applications always follow the
+// account.
+func SetShelterStatus(db *gorm.DB, shelterID, adminID uint, to string, policy ShelterBlockPolicy) (ShelterModerationResult, error) {
+	switch NormalizeStatus(to) {
+	case StatusInactive:
+		return BlockShelter(db, shelterID, adminID, policy, "")
+	case StatusActive:
+		return ReinstateShelter(db, shelterID, adminID)
+	}
+	return ShelterModerationResult{}, fmt.Errorf("%w: %q must be one of %s, %s", ErrInvalidStatus, to, StatusActive, StatusInactive)
+}
+
+// SetAdopterStatus changes an adopter account's status and audits it.
+// Deactivating applies options.Policy to the adopter's applications and
+// interviews; activating with options.Restore reverses what it can.
+func SetAdopterStatus(db *gorm.DB, adopterID, adminID uint, to string, options AdopterStatusOptions) (models.AdopterAccount, CascadeResult, error) {
+	var adopter models.AdopterAccount
+	var cascade CascadeResult
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var current models.AdopterAccount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("status").Where("adopter_id = ?", adopterID).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+
+		var err error
+		if adopter, err = TransitionAdopterStatus(tx, adopterID, to); err != nil {
+			return err
+		}
+
+		switch {
+		case adopter.Status == StatusInactive:
+			cascade, err = cascadeAdopterDeactivation(tx, adopterID, adminID, options.Policy)
+		case adopter.Status == StatusActive && options.Restore:
+			cascade, err = restoreAdopterApplications(tx, adopterID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return RecordAudit(tx, adminID, ActionAdopterStatus, EntityAdopter, adopterID, current.Status, adopter.Status,
+			fmt.Sprintf("applications frozen %d, released %d, withdrawn %d, reopened %d; interviews cancelled %d",
+				cascade.ApplicationsFrozen, cascade.ApplicationsThawed, cascade.ApplicationsWithdrawn,
+				cascade.ApplicationsReopened, cascade.InterviewsCancelled))
+	})
+	return adopter, cascade, err
+}
+
+// BlockShelter deactivates a shelter, marks its open reports as blocked,
+// applies the block policy to its pets, applications and interviews and
+// queues outcome notices for the shelter and its reporters. An empty reason
+// is filled in from the report categories. Blocking a shelter that is
+// already inactive only blocks the reports filed since, and fails with
+// ErrAlreadyInStatus when there are none.
+func BlockShelter(db *gorm.DB, shelterID, adminID uint, policy ShelterBlockPolicy, reason string) (ShelterModerationResult, error) {
+	return moderateShelter(db, shelterID, adminID, StatusInactive, ReportStatusReported, ReportStatusBlocked, ActionShelterBlock,
+		func(tx *gorm.DB, info models.ShelterInfo, reports []models.SubmittedReport, unchanged bool) (CascadeResult, error) {
+			if err := queueShelterOutcomeNotices(tx, info, reports, true, reason); err != nil {
+				return CascadeResult{}, err
+			}
+			if unchanged {
+				return CascadeResult{}, nil
+			}
+			return cascadeShelterBlock(tx, shelterID, adminID, info.ShelterName, policy)
+		})
+}
+
+// ReinstateShelter reactivates a blocked shelter, resolves its blocked
+// reports, reverses the pet and application holds of the block and queues
+// outcome notices for the shelter and its reporters. It fails with
+// ErrShelterOnHold while a flag hold on the shelter is running.
+func ReinstateShelter(db *gorm.DB, shelterID, adminID uint) (ShelterModerationResult, error) {
+	return moderateShelter(db, shelterID, adminID, StatusActive, ReportStatusBlocked, ReportStatusResolved, ActionShelterReinstate,
+		func(tx *gorm.DB, info models.ShelterInfo, reports []models.SubmittedReport, unchanged bool) (CascadeResult, error) {
+			if err := ensureNoFlagHold(tx, shelterID); err != nil {
+				return CascadeResult{}, err
+			}
+			if err := queueShelterOutcomeNotices(tx, info, reports, false, ""); err != nil {
+				return CascadeResult{}, err
+			}
+			if unchanged {
+				return CascadeResult{}, nil
+			}
+			return reverseShelterBlock(tx, shelterID, info.ShelterName)
+		})
+}
+
+// shelterCascade runs the follow-up work of a block or reinstatement with
+// the reports that were moved. unchanged is set when the account was
+// already in the target status and only stray reports were moved.
+type shelterCascade func(tx *gorm.DB, info models.ShelterInfo, reports []models.SubmittedReport, unchanged bool) (CascadeResult, error)
+
+func moderateShelter(db *gorm.DB, shelterID, adminID uint, to, reportsFrom, reportsTo, action string, cascade shelterCascade) (ShelterModerationResult, error) {
+	var result ShelterModerationResult
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.First(&result.Info, shelterID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShelterInfoNotFound
+			}
+			return err
+		}
+
+		from, err := currentShelterStatus(tx, shelterID)
+		if err != nil {
+			return err
+		}
+		// An account already in the target status can still have reports
+		// that arrived after it got there; those are moved on their own
+		result.Account, err = TransitionShelterStatus(tx, shelterID, to)
+		unchanged := errors.Is(err, ErrAlreadyInStatus)
+		if err != nil && !unchanged {
+			return err
+		}
+
+		var reports []models.SubmittedReport
+		if err := tx.Select("id", "adopter_id", "category_id").
+			Where("shelter_id = ? AND status = ?", shelterID, reportsFrom).
+			Find(&reports).Error; err != nil {
+			return err
+		}
+		if unchanged && len(reports) == 0 {
+			return ErrAlreadyInStatus
+		}
+		reportIDs := make([]uint, len(reports))
+		for i, report := range reports {
+			reportIDs[i] = report.ID
+		}
+
+		if len(reportIDs) > 0 {
+			reportUpdate := tx.Model(&models.SubmittedReport{}).
+				Where("id IN ?", reportIDs).
+				Updates(map[string]interface{}{"status": reportsTo, "version": nextVersion})
+			if reportUpdate.Error != nil {
+				return reportUpdate.Error
+			}
+			result.ReportsUpdated = reportUpdate.RowsAffected
+		}
+
+		if result.Cascade, err = cascade(tx, result.Info, reports, unchanged); err != nil {
+			return err
+		}
+
+		c := result.Cascade
+		return RecordAudit(tx, adminID, action, EntityShelter, shelterID, from, to,
+			fmt.Sprintf("%d reports moved from %s to %s; pets hidden %d, restored %d; applications frozen %d, released %d, withdrawn %d; interviews cancelled %d",
+				result.ReportsUpdated, reportsFrom, reportsTo, c.PetsHidden, c.PetsRestored,
+				c.ApplicationsFrozen, c.ApplicationsThawed, c.ApplicationsWithdrawn, c.InterviewsCancelled))
+	})
+	return result, err
+}
+
+func currentShelterStatus(tx *gorm.DB, shelterID uint) (string, error) {
+	var shelter models.ShelterAccount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("status").Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrAccountNotFound
+		}
+		return "", err
+	}
+	return shelter.Status, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Notification recipients
+const (
+	RecipientAdopter = "adopter"
+	RecipientShelter = "shelter"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Notify queues an in-app notification using the caller's transaction so
+// it is only delivered when the change it describes commits.
+func Notify(tx *gorm.DB, recipientType string, recipientID uint, kind, title, message string) error {
+	return tx.Create(&models.Notification{
+		RecipientType: recipientType,
+		RecipientID:   recipientID,
+		Kind:          kind,
+		Title:         title,
+		Message:       message,
+	}).Error
+}
+
+// NotifyMany sends the same notification to several recipients of one type
+func NotifyMany(tx *gorm.DB, recipientType string, recipientIDs []uint, kind, title, message string) (int, error) {
+	recipientIDs = uniqueIDs(recipientIDs)
+	if len(recipientIDs) == 0 {
+		return 0, nil
+	}
+
+	notifications := make([]models.Notification, len(recipientIDs))
+	for i, id := range recipientIDs {
+		notifications[i] = models.Notification{
+			RecipientType: recipientType,
+			RecipientID:   id,
+			Kind:          kind,
+			Title:         title,
+			Message:       message,
+		}
+	}
+	if err := tx.Create(&notifications).Error; err != nil {
+		return 0, err
+	}
+	return len(notifications), nil
+}
+
+// RecipientNotifications returns a recipient's notifications, newest first
+func RecipientNotifications(db *gorm.DB, recipientType string, recipientID uint, unreadOnly bool) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+	query := db.Where("recipient_type = ? AND recipient_id = ?", recipientType, recipientID).
+		Order("created_at DESC")
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	err := query.Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkNotificationRead marks one of a recipient's notifications as read
+func MarkNotificationRead(db *gorm.DB, recipientType string, recipientID, notificationID uint) error {
+	result := db.Model(&models.Notification{}).
+		Where("notification_id = ? AND recipient_type = ? AND recipient_id = ? AND read_at IS NULL",
+			notificationID, recipientType, recipientID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
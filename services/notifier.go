@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Delivery channels
+const (
+	ChannelInApp = "inapp"
+	ChannelEmail = "email"
+	ChannelLog   = "log"
+)
+
+var (
+	ErrUnknownChannel = errors.New("notification channel must be 'inapp', 'email' or 'log'")
+	ErrNoAddress      = errors.New("recipient has no email address")
+	ErrBadAddress     = errors.New("recipient email address is malformed")
+)
+
+// OutboundMessage is one rendered message handed to a Notifier
+type OutboundMessage struct {
+	Kind          string
+	RecipientType string
+	RecipientID   uint
+	Address       string
+	Subject       string
+	Body          string
+}
+
+// Notifier delivers outbound messages over one channel
+type Notifier interface {
+	Channel() string
+	Send(msg OutboundMessage) error
+}
+
+// InAppNotifier stores messages in the notifications table
+type InAppNotifier struct {
+	DB *gorm.DB
+}
+
+func (n InAppNotifier) Channel() string { return ChannelInApp }
+
+func (n InAppNotifier) Send(msg OutboundMessage) error {
+	return Notify(n.DB, msg.RecipientType, msg.RecipientID, msg.Kind, msg.Subject, msg.Body)
+}
+
+// LogNotifier only writes messages to the server log. Useful in development.
+type LogNotifier struct{}
+
+func (LogNotifier) Channel() string { return ChannelLog }
+
+func (LogNotifier) Send(msg OutboundMessage) error {
+	log.Printf("Notification to %s %d <%s>: %s\n", msg.RecipientType, msg.RecipientID, msg.Address, msg.Subject)
+	return nil
+}
+
+// SMTPNotifier emails messages through an SMTP server
+type SMTPNotifier struct {
+	Host     string
+	Port     string
+	From     string
+	Password string
+}
+
+func (n SMTPNotifier) Channel() string { return ChannelEmail }
+
+// Send refuses addresses that are not a single plain address and encodes
+// the subject, so neither can add header lines to the message
+func (n SMTPNotifier) Send(msg OutboundMessage) error {
+	if strings.TrimSpace(msg.Address) == "" {
+		return ErrNoAddress
+	}
+	to, err := mail.ParseAddress(msg.Address)
+	if err != nil || to.Name != "" || to.Address != strings.TrimSpace(msg.Address) {
+		return fmt.Errorf("%w: %q", ErrBadAddress, msg.Address)
+	}
+
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s",
+		n.From, to.Address, EncodeSubject(msg.Subject), msg.Body)
+	auth := smtp.PlainAuth("", n.From, n.Password, n.Host)
+	return smtp.SendMail(n.Host+":"+n.Port, auth, n.From, []string{to.Address}, []byte(body))
+}
+
+// EncodeSubject makes text safe for a Subject header: it is put on a
+// single line and anything outside printable ASCII is Q-encoded
+func EncodeSubject(text string) string {
+	return mime.QEncoding.Encode("utf-8", singleLine(text))
+}
+
+// singleLine turns line breaks and other control characters into single
+// spaces
+func singleLine(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r < 0x20 || r == 0x7f
+	}), " ")
+}
+
+// NewNotifier builds the notifier for a channel name
+func NewNotifier(channel string, db *gorm.DB, smtpConfig SMTPNotifier) (Notifier, error) {
+	switch NormalizeStatus(channel) {
+	case "", ChannelInApp:
+		return InAppNotifier{DB: db}, nil
+	case ChannelEmail:
+		return smtpConfig, nil
+	case ChannelLog:
+		return LogNotifier{}, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Outcome notice templates
+const (
+	TemplateReportActioned    = "report_actioned"
+	TemplateReportResolved    = "report_resolved"
+	TemplateAccountSuspended  = "account_suspended"
+	TemplateAccountReinstated = "account_reinstated"
+)
+
+// Outcome notice delivery states
+const (
+	NoticePending = "pending"
+	NoticeSending = "sending" // claimed by a dispatcher
+	NoticeSent    = "sent"
+	NoticeFailed  = "failed"
+)
+
+// MaxNoticeAttempts is how many times a failing notice is retried
+const MaxNoticeAttempts = 5
+
+// NoticeClaimTimeout is how long a notice may stay claimed before it is
+// taken to belong to a dispatcher that died mid-send and is retried
+const NoticeClaimTimeout = 15 * time.Minute
+
+// OutcomeTemplate is the subject and body of one outcome notice
+type OutcomeTemplate struct {
+	Subject string
+	Body    string
+}
+
+// OutcomeData is what the templates can refer to
+type OutcomeData struct {
+	RecipientName string
+	ShelterName   string
+	Reason        string
+	Categories    string
+	ReportCount   int
+}
+
+// OutcomeTemplates holds the text of every outcome notice
+var OutcomeTemplates = map[string]OutcomeTemplate{
+	TemplateReportActioned: {
+		Subject: "Your report about {{.ShelterName}} was actioned",
+		Body: `Hi {{.RecipientName}},
+
+Thank you for your report{{if .Categories}} ({{.Categories}}){{end}} about {{.ShelterName}}. Our team reviewed it and the shelter has been suspended while we look into it further.
+
+- The PetHub team`,
+	},
+	TemplateReportResolved: {
+		Subject: "Update on your report about {{.ShelterName}}",
+		Body: `Hi {{.RecipientName}},
+
+Our review of {{.ShelterName}} is complete and your report has been resolved. The shelter has been reinstated after addressing the issues raised.
+
+- The PetHub team`,
+	},
+	TemplateAccountSuspended: {
+		Subject: "Your PetHub shelter account has been suspended",
+		Body: `Hi {{.ShelterName}},
+
+Your shelter account has been suspended for the following reason: {{.Reason}}.
+{{if .ReportCount}}This decision follows {{.ReportCount}} report(s) from adopters.
+{{end}}
+While suspended your pets are hidden and adoption applications are on hold. Please contact PetHub support to appeal.
+
+- The PetHub team`,
+	},
+	TemplateAccountReinstated: {
+		Subject: "Your PetHub shelter account has been reinstated",
+		Body: `Hi {{.ShelterName}},
+
+Your shelter account has been reinstated. Your pets are visible again and held applications have been released.
+
+- The PetHub team`,
+	},
+}
+
+// RenderOutcome fills in an outcome template
+func RenderOutcome(name string, data OutcomeData) (string, string, error) {
+	tmpl, ok := OutcomeTemplates[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown outcome template %q", name)
+	}
+
+	render := func(text string) (string, error) {
+		t, err := template.New(name).Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		if err := t.Execute(&out, data); err != nil {
+			return "", err
+		}
+		return out.String(), nil
+	}
+
+	subject, err := render(tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := render(tmpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+	return subject, body, nil
+}
+
+// QueueOutcomeNotice renders a template and stores it as a pending notice
+// linked to the reports it is about. It uses the caller's transaction so
+// nothing is sent for a change that rolls back.
+func QueueOutcomeNotice(tx *gorm.DB, name, recipientType string, recipientID uint, address string, data OutcomeData, reportIDs []uint) (models.OutcomeNotice, error) {
+	subject, body, err := RenderOutcome(name, data)
+	if err != nil {
+		return models.OutcomeNotice{}, err
+	}
+
+	notice := models.OutcomeNotice{
+		Template:      name,
+		RecipientType: recipientType,
+		RecipientID:   recipientID,
+		Address:       address,
+		Subject:       subject,
+		Body:          body,
+		Status:        NoticePending,
+	}
+	if err := tx.Create(&notice).Error; err != nil {
+		return notice, err
+	}
+
+	if len(reportIDs) > 0 {
+		links := make([]models.OutcomeNoticeReport, 0, len(reportIDs))
+		for _, id := range uniqueIDs(reportIDs) {
+			links = append(links, models.OutcomeNoticeReport{NoticeID: notice.NoticeID, ReportID: id})
+		}
+		if err := tx.Create(&links).Error; err != nil {
+			return notice, err
+		}
+	}
+	return notice, nil
+}
+
+// DispatchOutcomeNotices sends pending notices, and retries failed ones,
+// through the notifier. It returns how many were sent and failed. Each
+// notice is claimed before it is sent; one a concurrent dispatch claimed or
+// finished since it was read is skipped, so no notice goes out twice. A
+// claim older than NoticeClaimTimeout is retried like a failure.
+func DispatchOutcomeNotices(db *gorm.DB, notifier Notifier, limit int) (int, int, error) {
+	now := time.Now()
+	staleBefore := now.Add(-NoticeClaimTimeout)
+
+	var notices []models.OutcomeNotice
+	query := db.Where("status = ? OR (status = ? AND attempts < ?) OR (status = ? AND (claimed_at IS NULL OR claimed_at < ?))",
+		NoticePending, NoticeFailed, MaxNoticeAttempts, NoticeSending, staleBefore).
+		Order("created_at ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&notices).Error; err != nil {
+		return 0, 0, err
+	}
+
+	sent, failed := 0, 0
+	for _, notice := range notices {
+		claim := db.Model(&models.OutcomeNotice{}).
+			Where("notice_id = ? AND status = ? AND attempts = ?", notice.NoticeID, notice.Status, notice.Attempts).
+			Where("status <> ? OR claimed_at IS NULL OR claimed_at < ?", NoticeSending, staleBefore).
+			Updates(map[string]interface{}{"status": NoticeSending, "claimed_at": now})
+		if claim.Error != nil {
+			return sent, failed, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		err := notifier.Send(OutboundMessage{
+			Kind:          notice.Template,
+			RecipientType: notice.RecipientType,
+			RecipientID:   notice.RecipientID,
+			Address:       notice.Address,
+			Subject:       notice.Subject,
+			Body:          notice.Body,
+		})
+
+		updates := map[string]interface{}{
+			"channel":  notifier.Channel(),
+			"attempts": notice.Attempts + 1,
+		}
+		if err != nil {
+			updates["status"] = NoticeFailed
+			updates["last_error"] = err.Error()
+			failed++
+		} else {
+			updates["status"] = NoticeSent
+			updates["last_error"] = ""
+			updates["sent_at"] = time.Now()
+			sent++
+		}
+		if err := db.Model(&models.OutcomeNotice{}).Where("notice_id = ?", notice.NoticeID).Updates(updates).Error; err != nil {
+			return sent, failed, err
+		}
+	}
+	return sent, failed, nil
+}
+
+// ReportNotices returns every outcome notice sent about a report
+func ReportNotices(db *gorm.DB, reportID uint) ([]models.OutcomeNotice, error) {
+	notices := []models.OutcomeNotice{}
+	err := db.Where("notice_id IN (?)",
+		db.Model(&models.OutcomeNoticeReport{}).Select("notice_id").Where("report_id = ?", reportID)).
+		Order("created_at ASC").
+		Find(&notices).Error
+	return notices, err
+}
+
+// queueShelterOutcomeNotices queues the shelter's notice and one notice per
+// reporter for a block or reinstatement
+func queueShelterOutcomeNotices(tx *gorm.DB, info models.ShelterInfo, reports []models.SubmittedReport, blocked bool, reason string) error {
+	reportIDs := make([]uint, len(reports))
+	byReporter := make(map[uint][]uint)
+	categoryIDs := make(map[uint][]uint)
+	for i, report := range reports {
+		reportIDs[i] = report.ID
+		byReporter[report.AdopterID] = append(byReporter[report.AdopterID], report.ID)
+		if report.CategoryID != nil {
+			categoryIDs[report.AdopterID] = append(categoryIDs[report.AdopterID], *report.CategoryID)
+		}
+	}
+
+	categories, err := ReportCategories(tx)
+	if err != nil {
+		return err
+	}
+	categoryNames := func(ids []uint) string {
+		var names []string
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			if category, ok := categories[id]; ok && !seen[category.Name] {
+				seen[category.Name] = true
+				names = append(names, category.Name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+
+	shelterTemplate, reporterTemplate := TemplateAccountReinstated, TemplateReportResolved
+	if blocked {
+		shelterTemplate, reporterTemplate = TemplateAccountSuspended, TemplateReportActioned
+		if strings.TrimSpace(reason) == "" {
+			var allCategories []uint
+			for _, ids := range categoryIDs {
+				allCategories = append(allCategories, ids...)
+			}
+			if names := categoryNames(allCategories); names != "" {
+				reason = "reports of " + strings.ToLower(names)
+			} else {
+				reason = "reports received from adopters"
+			}
+		}
+	}
+
+	if _, err := QueueOutcomeNotice(tx, shelterTemplate, RecipientShelter, info.ShelterID, info.ShelterEmail, OutcomeData{
+		ShelterName: info.ShelterName,
+		Reason:      strings.TrimSpace(reason),
+		ReportCount: len(reports),
+	}, reportIDs); err != nil {
+		return err
+	}
+
+	if len(byReporter) == 0 {
+		return nil
+	}
+	adopterIDs := make([]uint, 0, len(byReporter))
+	for id := range byReporter {
+		adopterIDs = append(adopterIDs, id)
+	}
+	var adopters []models.AdopterInfo
+	if err := tx.Select("adopter_id", "first_name", "email").Where("adopter_id IN ?", adopterIDs).Find(&adopters).Error; err != nil {
+		return err
+	}
+
+	for _, adopter := range adopters {
+		if _, err := QueueOutcomeNotice(tx, reporterTemplate, RecipientAdopter, adopter.AdopterID, adopter.Email, OutcomeData{
+			RecipientName: adopter.FirstName,
+			ShelterName:   info.ShelterName,
+			Categories:    categoryNames(categoryIDs[adopter.AdopterID]),
+			ReportCount:   len(byReporter[adopter.AdopterID]),
+		}, byReporter[adopter.AdopterID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// racingNotifier finishes another notice, as a concurrent dispatch would,
+// while it sends the first one
+type racingNotifier struct {
+	recordingNotifier
+	db    *gorm.DB
+	taken uint
+}
+
+func (n *racingNotifier) Send(msg OutboundMessage) error {
+	if len(n.sent) == 0 {
+		n.db.Model(&models.OutcomeNotice{}).Where("notice_id = ?", n.taken).Update("status", NoticeSent)
+	}
+	return n.recordingNotifier.Send(msg)
+}
+
+func TestDispatchSkipsNoticesClaimedElsewhere(t *testing.T) {
+	db := openTestDB(t)
+	for _, subject := range []string{"first", "second", "third"} {
+		notice := models.OutcomeNotice{Template: TemplateReportResolved, RecipientType: RecipientAdopter, RecipientID: 1,
+			Address: "ana@example.com", Subject: subject, Status: NoticePending}
+		if err := db.Create(&notice).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	notifier := &racingNotifier{recordingNotifier: recordingNotifier{channel: ChannelLog}, db: db, taken: 2}
+	sent, failed, err := DispatchOutcomeNotices(db, notifier, 0)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if sent != 2 || failed != 0 {
+		t.Fatalf("sent %d, failed %d; want 2 sent", sent, failed)
+	}
+	if len(notifier.sent) != 2 || notifier.sent[0].Subject != "first" || notifier.sent[1].Subject != "third" {
+		t.Fatalf("sent %+v, want the first and third notices only", notifier.sent)
+	}
+
+	var statuses []string
+	db.Model(&models.OutcomeNotice{}).Order("notice_id").Pluck("status", &statuses)
+	for i, status := range statuses {
+		if status != NoticeSent {
+			t.Errorf("notice %d is %s, want sent", i+1, status)
+		}
+	}
+}
+
+func TestDispatchRetriesStaleClaims(t *testing.T) {
+	db := openTestDB(t)
+	stale := time.Now().Add(-NoticeClaimTimeout - time.Minute)
+	fresh := time.Now().Add(-time.Minute)
+	for _, notice := range []models.OutcomeNotice{
+		{Subject: "stale", Status: NoticeSending, ClaimedAt: &stale},
+		{Subject: "fresh", Status: NoticeSending, ClaimedAt: &fresh},
+		{Subject: "unstamped", Status: NoticeSending},
+	} {
+		notice.Template, notice.RecipientType, notice.RecipientID = TemplateReportResolved, RecipientAdopter, 1
+		if err := db.Create(&notice).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	notifier := &recordingNotifier{channel: ChannelLog}
+	sent, failed, err := DispatchOutcomeNotices(db, notifier, 0)
+	if err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if sent != 2 || failed != 0 || len(notifier.sent) != 2 ||
+		notifier.sent[0].Subject != "stale" || notifier.sent[1].Subject != "unstamped" {
+		t.Fatalf("sent %d, failed %d: %+v; want the stale and unstamped claims retried", sent, failed, notifier.sent)
+	}
+
+	var freshNotice, staleNotice models.OutcomeNotice
+	db.Where("subject = ?", "fresh").First(&freshNotice)
+	if freshNotice.Status != NoticeSending {
+		t.Fatalf("fresh claim is %s, want it left to its dispatcher", freshNotice.Status)
+	}
+	db.Where("subject = ?", "stale").First(&staleNotice)
+	if staleNotice.Status != NoticeSent || staleNotice.ClaimedAt == nil || !staleNotice.ClaimedAt.After(stale) {
+		t.Fatalf("stale claim after the retry: %+v", staleNotice)
+	}
+}
+
+func TestEncodeSubject(t *testing.T) {
+	cases := []struct {
+		subject string
+		want    string
+	}{
+		{"Your report was resolved", "Your report was resolved"},
+		{"Paws\r\nBcc: victim@example.com", "Paws Bcc: victim@example.com"},
+		{"Paws\nBcc:\tx", "Paws Bcc: x"},
+		{"Registro de Niño", "=?utf-8?q?Registro_de_Ni=C3=B1o?="},
+	}
+	for _, tc := range cases {
+		if got := EncodeSubject(tc.subject); got != tc.want {
+			t.Errorf("EncodeSubject(%q) = %q, want %q", tc.subject, got, tc.want)
+		}
+	}
+}
+
+func TestSMTPRefusesHeadersInTheAddress(t *testing.T) {
+	notifier := SMTPNotifier{Host: "localhost", Port: "0", From: "admin@pethub.test"}
+	for _, address := range []string{
+		"ana@example.com\r\nBcc: victim@example.com",
+		"Ana <ana@example.com>",
+		"ana@example.com, ben@example.com",
+	} {
+		if err := notifier.Send(OutboundMessage{Address: address, Subject: "x"}); !errors.Is(err, ErrBadAddress) {
+			t.Errorf("address %q: err = %v, want ErrBadAddress", address, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pet listing status values
+const (
+	ListingListed   = "listed"
+	ListingUnlisted = "unlisted"
+	ListingFlagged  = "flagged"
+)
+
+// Audit actions and entity for pet moderation
+const (
+	EntityPet     = "pet"
+	ActionPetList = "pet_listing"
+)
+
+// Application hold sources
+const (
+	HoldPetUnlisted = "pet_unlisted"
+)
+
+// ClosedApplicationStatuses are application states that are never frozen
+var ClosedApplicationStatuses = []string{"rejected", "withdrawn", "cancelled", "completed", "adopted"}
+
+var (
+	ErrPetNotFound             = errors.New("pet not found")
+	ErrModerationReasonMissing = errors.New("a reason is required")
+)
+
+// PetListingMachine governs petinfo.listing_status. A flagged pet stays
+// visible while it is looked into; an unlisted pet is hidden and its open
+// applications are frozen.
+var PetListingMachine = StatusMachine{
+	Column: "listing_status",
+	States: []string{ListingListed, ListingUnlisted, ListingFlagged},
+	Transitions: []Transition{
+		{From: ListingListed, To: ListingUnlisted},
+		{From: ListingListed, To: ListingFlagged},
+		{From: ListingFlagged, To: ListingUnlisted},
+		{From: ListingFlagged, To: ListingListed},
+		{From: ListingUnlisted, To: ListingListed},
+	},
+}
+
+// PetModerationResult is returned by ModeratePet
+type PetModerationResult struct {
+	Pet                models.PetInfo `json:"pet"`
+	ApplicationsFrozen int64          `json:"applications_frozen"`
+	ApplicationsThawed int64          `json:"applications_thawed"`
+}
+
+// PetModerationEntry is one moderation action as shown to the shelter
+type PetModerationEntry struct {
+	PetID      uint      `json:"pet_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ModeratePet moves a pet listing to a new status with a required reason.
+// Unlisting freezes the pet's open applications; relisting releases them.
+func ModeratePet(db *gorm.DB, petID, adminID uint, to, reason string) (PetModerationResult, error) {
+	var result PetModerationResult
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return result, ErrModerationReasonMissing
+	}
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("pet_id = ?", petID).First(&result.Pet).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPetNotFound
+			}
+			return err
+		}
+
+		from := result.Pet.ListingStatus
+		if from == "" {
+			from = ListingListed
+		}
+		updates, err := PetListingMachine.Plan(from, to)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates["moderation_reason"] = reason
+		updates["moderated_at"] = now
+		if err := tx.Model(&models.PetInfo{}).Where("pet_id = ?", petID).Updates(updates).Error; err != nil {
+			return err
+		}
+		result.Pet.ListingStatus = updates["listing_status"].(string)
+		result.Pet.ModerationReason = reason
+		result.Pet.ModeratedAt = &now
+
+		switch result.Pet.ListingStatus {
+		case ListingUnlisted:
+			if result.ApplicationsFrozen, err = holdPetApplications(tx, []uint{petID}, adminID, HoldPetUnlisted, reason); err != nil {
+				return err
+			}
+		case ListingListed:
+			if result.ApplicationsThawed, err = releasePetApplications(tx, []uint{petID}, HoldPetUnlisted); err != nil {
+				return err
+			}
+		}
+
+		return RecordAudit(tx, adminID, ActionPetList, EntityPet, petID, from, result.Pet.ListingStatus, reason)
+	})
+	return result, err
+}
+
+// PetModerationHistory returns the moderation actions taken on a shelter's
+// pets, newest first, without the acting admin
+func PetModerationHistory(db *gorm.DB, shelterID uint) ([]PetModerationEntry, error) {
+	entries := []PetModerationEntry{}
+	err := db.Model(&models.AdminAuditLog{}).
+		Select("entity_id AS pet_id, from_status, to_status, details AS reason, created_at").
+		Where("entity_type = ? AND action = ?", EntityPet, ActionPetList).
+		Where("entity_id IN (?)", db.Model(&models.PetInfo{}).Select("pet_id").Where("shelter_id = ?", shelterID)).
+		Order("created_at DESC").
+		Scan(&entries).Error
+	return entries, err
+}
+
+// FrozenApplicationIDs returns which of the given applications have a hold
+func FrozenApplicationIDs(db *gorm.DB, applicationIDs []uint) (map[uint]bool, error) {
+	var held []uint
+	if err := db.Model(&models.ApplicationHold{}).
+		Distinct("application_id").
+		Where("application_id IN ?", applicationIDs).
+		Pluck("application_id", &held).Error; err != nil {
+		return nil, err
+	}
+
+	frozen := make(map[uint]bool, len(held))
+	for _, id := range held {
+		frozen[id] = true
+	}
+	return frozen, nil
+}
+
+// holdPetApplications places a hold on every open application for the pets
+func holdPetApplications(tx *gorm.DB, petIDs []uint, adminID uint, source, reason string) (int64, error) {
+	var applicationIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where("pet_id IN ? AND status NOT IN ?", petIDs, ClosedApplicationStatuses).
+		Pluck("application_id", &applicationIDs).Error; err != nil {
+		return 0, err
+	}
+	return holdApplications(tx, applicationIDs, adminID, source, reason)
+}
+
+// releasePetApplications removes one hold source from the pets' applications
+func releasePetApplications(tx *gorm.DB, petIDs []uint, source string) (int64, error) {
+	result := tx.Where("source = ? AND application_id IN (?)", source,
+		tx.Model(&models.AdoptionSubmission{}).Select("application_id").Where("pet_id IN ?", petIDs)).
+		Delete(&models.ApplicationHold{})
+	return result.RowsAffected, result.Error
+}
+
+func holdApplications(tx *gorm.DB, applicationIDs []uint, adminID uint, source, reason string) (int64, error) {
+	if len(applicationIDs) == 0 {
+		return 0, nil
+	}
+
+	holds := make([]models.ApplicationHold, len(applicationIDs))
+	for i, id := range applicationIDs {
+		holds[i] = models.ApplicationHold{ApplicationID: id, Source: source, Reason: reason, HeldBy: adminID}
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&holds)
+	if result.Error != nil {
+		return 0, fmt.Errorf("freezing applications: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
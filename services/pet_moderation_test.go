@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+func frozenApplications(t *testing.T, db *gorm.DB) map[uint]bool {
+	t.Helper()
+	frozen, err := FrozenApplicationIDs(db, []uint{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frozen
+}
+
+func TestUnlistingHidesThePetAndFreezesItsApplications(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", ListingStatus: ListingListed},
+		&models.PetInfo{PetID: 2, ShelterID: 1, PetName: "Mia", ListingStatus: ListingListed},
+		&models.AdoptionSubmission{ApplicationID: 1, PetID: 1, AdopterID: 5, ShelterID: 1, Status: "pending"},
+		&models.AdoptionSubmission{ApplicationID: 2, PetID: 1, AdopterID: 6, ShelterID: 1, Status: "interview"},
+		&models.AdoptionSubmission{ApplicationID: 3, PetID: 1, AdopterID: 7, ShelterID: 1, Status: "rejected"},
+		&models.AdoptionSubmission{ApplicationID: 4, PetID: 2, AdopterID: 5, ShelterID: 1, Status: "pending"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	if _, err := ModeratePet(db, 1, 9, ListingUnlisted, " "); !errors.Is(err, ErrModerationReasonMissing) {
+		t.Fatalf("unlisting without a reason: err = %v, want ErrModerationReasonMissing", err)
+	}
+
+	// Flagging keeps the pet visible and its applications open
+	if _, err := ModeratePet(db, 1, 9, ListingFlagged, "Photos look copied"); err != nil {
+		t.Fatalf("flag: %v", err)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed with one flagged, want 2", listed)
+	}
+
+	result, err := ModeratePet(db, 1, 9, ListingUnlisted, "Photos are of another pet")
+	if err != nil {
+		t.Fatalf("unlist: %v", err)
+	}
+	if result.ApplicationsFrozen != 2 {
+		t.Fatalf("froze %d applications, want the 2 open ones", result.ApplicationsFrozen)
+	}
+	if listed := listedPets(t, db); listed != 1 {
+		t.Fatalf("%d pets listed with one unlisted, want 1", listed)
+	}
+	if frozen := frozenApplications(t, db); len(frozen) != 2 || !frozen[1] || !frozen[2] {
+		t.Fatalf("frozen applications = %v, want 1 and 2", frozen)
+	}
+
+	if _, err := ModeratePet(db, 1, 9, ListingFlagged, "Again"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("flagging an unlisted pet: err = %v, want ErrInvalidTransition", err)
+	}
+
+	result, err = ModeratePet(db, 1, 9, ListingListed, "Shelter sent the right photos")
+	if err != nil {
+		t.Fatalf("relist: %v", err)
+	}
+	if result.ApplicationsThawed != 2 || len(frozenApplications(t, db)) != 0 {
+		t.Fatalf("relisting released %d applications, want every hold gone", result.ApplicationsThawed)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after relisting, want 2", listed)
+	}
+
+	history, err := PetModerationHistory(db, 1)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("%d moderation entries, want 3", len(history))
+	}
+}
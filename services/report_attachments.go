@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// MaxReportAttachments caps how many files one report may carry
+const MaxReportAttachments = 5
+
+// AttachmentUploadRules limits report evidence to images and PDFs
+var AttachmentUploadRules = UploadRules{
+	AllowedTypes: ImageAndPDFMimeTypes,
+	MaxBytes:     MaxUploadBytes,
+}
+
+var (
+	ErrAttachmentNotFound  = errors.New("attachment not found")
+	ErrTooManyAttachments  = fmt.Errorf("a report may have at most %d attachments", MaxReportAttachments)
+	ErrReportAlreadyClosed = errors.New("attachments can only be added while the report is open")
+	ErrNotReportOwner      = errors.New("report was filed by another adopter")
+)
+
+// AddReportAttachment validates and stores an evidence file on an open
+// report filed by adopterID
+func AddReportAttachment(db *gorm.DB, reportID, adopterID uint, fileName, fileData string) (models.ReportAttachment, error) {
+	var attachment models.ReportAttachment
+
+	var report models.SubmittedReport
+	if err := db.Select("id", "adopter_id", "status").Where("id = ?", reportID).First(&report).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return attachment, ErrReportNotFound
+		}
+		return attachment, err
+	}
+	if report.AdopterID != adopterID {
+		return attachment, ErrNotReportOwner
+	}
+	if report.Status != ReportStatusReported && report.Status != ReportStatusPending {
+		return attachment, ErrReportAlreadyClosed
+	}
+
+	upload, err := ValidateUpload(fileData, AttachmentUploadRules)
+	if err != nil {
+		return attachment, err
+	}
+
+	var count int64
+	if err := db.Model(&models.ReportAttachment{}).Where("report_id = ?", reportID).Count(&count).Error; err != nil {
+		return attachment, err
+	}
+	if count >= MaxReportAttachments {
+		return attachment, ErrTooManyAttachments
+	}
+
+	attachment = models.ReportAttachment{
+		ReportID:  reportID,
+		FileName:  strings.TrimSpace(fileName),
+		MimeType:  upload.MimeType,
+		SizeBytes: upload.SizeBytes,
+		FileData:  upload.Data,
+	}
+	if err := db.Create(&attachment).Error; err != nil {
+		return attachment, err
+	}
+	return attachment, nil
+}
+
+// ReportAttachments returns attachment metadata (without file data) for the
+// given reports, grouped by report ID
+func ReportAttachments(db *gorm.DB, reportIDs ...uint) (map[uint][]models.ReportAttachment, error) {
+	byReport := make(map[uint][]models.ReportAttachment)
+	if len(reportIDs) == 0 {
+		return byReport, nil
+	}
+
+	var attachments []models.ReportAttachment
+	if err := db.Select("attachment_id", "report_id", "file_name", "mime_type", "size_bytes", "created_at").
+		Where("report_id IN ?", reportIDs).
+		Order("created_at ASC").
+		Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		byReport[attachment.ReportID] = append(byReport[attachment.ReportID], attachment)
+	}
+	return byReport, nil
+}
+
+// ReportAttachment returns one attachment including its file data
+func ReportAttachment(db *gorm.DB, attachmentID uint) (models.ReportAttachment, error) {
+	var attachment models.ReportAttachment
+	if err := db.First(&attachment, attachmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return attachment, ErrAttachmentNotFound
+		}
+		return attachment, err
+	}
+	return attachment, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Report severity levels, lowest to highest
+const (
+	SeverityLow      = 1
+	SeverityMedium   = 2
+	SeverityHigh     = 3
+	SeverityCritical = 4
+)
+
+// CategoryOther is the fallback category for reasons no keyword matches
+const CategoryOther = "other"
+
+var (
+	ErrCategoryNotFound = errors.New("report category not found")
+	ErrInvalidCategory  = errors.New("invalid report category")
+	categoryCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,39}$`)
+)
+
+// DefaultReportCategories seed the report_categories table on first start
+var DefaultReportCategories = []models.ReportCategory{
+	{Code: "animal_neglect", Name: "Animal neglect", Severity: SeverityCritical,
+		Description: "Animals kept without proper food, water, shelter or medical care",
+		Keywords:    "neglect,starving,malnourished,sick,dirty,no food,no water,injured"},
+	{Code: "animal_abuse", Name: "Animal abuse", Severity: SeverityCritical,
+		Description: "Deliberate harm or cruelty to animals",
+		Keywords:    "abuse,cruelty,beating,hurt,torture,kill"},
+	{Code: "scam", Name: "Scam", Severity: SeverityHigh,
+		Description: "Fake listings, adoption fees for pets that do not exist, or identity fraud",
+		Keywords:    "scam,fake,fraud,bogus,reservation fee,deposit"},
+	{Code: "donation_fraud", Name: "Donation fraud", Severity: SeverityHigh,
+		Description: "Donations collected but not used for the animals",
+		Keywords:    "donation,donations,fundraising,misused funds"},
+	{Code: "harassment", Name: "Harassment", Severity: SeverityHigh,
+		Description: "Threats, insults or unwanted contact toward adopters",
+		Keywords:    "harass,harassment,threat,threaten,insult,rude,stalk"},
+	{Code: "misinformation", Name: "Misinformation", Severity: SeverityMedium,
+		Description: "Wrong pet details, health or vaccination claims",
+		Keywords:    "misinformation,misleading,wrong info,false,lied,not vaccinated"},
+	{Code: CategoryOther, Name: "Other", Severity: SeverityLow,
+		Description: "Anything not covered by another category"},
+}
+
+// ReportCategoryView is the category shown next to a report
+type ReportCategoryView struct {
+	CategoryID uint   `json:"category_id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Severity   int    `json:"severity"`
+	Urgency    string `json:"urgency"`
+}
+
+// CategoryAnalyticsRow is one category dimension of the report analytics
+type CategoryAnalyticsRow struct {
+	CategoryID uint   `json:"category_id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Severity   int    `json:"severity"`
+	Total      int64  `json:"total"`
+	Reported   int64  `json:"reported"`
+	Blocked    int64  `json:"blocked"`
+	Resolved   int64  `json:"resolved"`
+	Shelters   int64  `json:"shelters"`
+}
+
+// Urgency names a severity level for notifications
+func Urgency(severity int) string {
+	switch {
+	case severity >= SeverityCritical:
+		return "critical"
+	case severity == SeverityHigh:
+		return "high"
+	case severity == SeverityMedium:
+		return "normal"
+	}
+	return "low"
+}
+
+// ValidateReportCategory checks the code format, name and severity
+func ValidateReportCategory(category models.ReportCategory) error {
+	if !categoryCodePattern.MatchString(category.Code) {
+		return fmt.Errorf("%w: code must be lowercase letters, digits and underscores", ErrInvalidCategory)
+	}
+	if strings.TrimSpace(category.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	}
+	if category.Severity < SeverityLow || category.Severity > SeverityCritical {
+		return fmt.Errorf("%w: severity must be between %d and %d", ErrInvalidCategory, SeverityLow, SeverityCritical)
+	}
+	return nil
+}
+
+// SeedReportCategories inserts the default taxonomy when the table is empty
+func SeedReportCategories(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.ReportCategory{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	categories := make([]models.ReportCategory, len(DefaultReportCategories))
+	for i, category := range DefaultReportCategories {
+		category.Enabled = true
+		categories[i] = category
+	}
+	return db.Create(&categories).Error
+}
+
+// ReportCategories returns every category keyed by ID
+func ReportCategories(db *gorm.DB) (map[uint]models.ReportCategory, error) {
+	var categories []models.ReportCategory
+	if err := db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.ReportCategory, len(categories))
+	for _, category := range categories {
+		byID[category.CategoryID] = category
+	}
+	return byID, nil
+}
+
+// CategoryView returns the category for a report, or the zero view when it
+// has none
+func CategoryView(categories map[uint]models.ReportCategory, categoryID *uint) ReportCategoryView {
+	if categoryID == nil {
+		return ReportCategoryView{Severity: SeverityLow, Urgency: Urgency(SeverityLow)}
+	}
+	category, ok := categories[*categoryID]
+	if !ok {
+		return ReportCategoryView{Severity: SeverityLow, Urgency: Urgency(SeverityLow)}
+	}
+	return ReportCategoryView{
+		CategoryID: category.CategoryID,
+		Code:       category.Code,
+		Name:       category.Name,
+		Severity:   category.Severity,
+		Urgency:    Urgency(category.Severity),
+	}
+}
+
+// ClassifyReason picks the enabled category whose keywords best match a
+// free-text reason, preferring higher severity on ties. Falls back to
+// "other", or nil when that category does not exist.
+func ClassifyReason(categories []models.ReportCategory, reason string) *uint {
+	text := strings.ToLower(NormalizeText(reason))
+
+	var best *models.ReportCategory
+	bestHits := 0
+	var other *uint
+	for i := range categories {
+		category := &categories[i]
+		if !category.Enabled {
+			continue
+		}
+		if category.Code == CategoryOther {
+			other = &category.CategoryID
+		}
+		if strings.EqualFold(strings.TrimSpace(reason), category.Code) || strings.EqualFold(strings.TrimSpace(reason), category.Name) {
+			return &category.CategoryID
+		}
+
+		hits := 0
+		for _, keyword := range strings.Split(category.Keywords, ",") {
+			keyword = strings.ToLower(strings.TrimSpace(keyword))
+			if keyword != "" && strings.Contains(text, keyword) {
+				hits++
+			}
+		}
+		if hits > bestHits || (hits > 0 && hits == bestHits && category.Severity > best.Severity) {
+			best, bestHits = category, hits
+		}
+	}
+
+	if best != nil {
+		return &best.CategoryID
+	}
+	return other
+}
+
+// BackfillReportCategories classifies every report that has no category and
+// returns how many were updated
+func BackfillReportCategories(db *gorm.DB) (int, error) {
+	var categories []models.ReportCategory
+	if err := db.Find(&categories).Error; err != nil {
+		return 0, err
+	}
+
+	var reports []models.SubmittedReport
+	if err := db.Select("id", "reason").Where("category_id IS NULL").Find(&reports).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, report := range reports {
+		categoryID := ClassifyReason(categories, report.Reason)
+		if categoryID == nil {
+			continue
+		}
+		if err := db.Model(&models.SubmittedReport{}).Where("id = ?", report.ID).
+			Updates(map[string]interface{}{"category_id": *categoryID, "version": nextVersion}).Error; err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ReportCategoryAnalytics counts reports per category and status, for
+// reports created on or after since (all time when zero)
+func ReportCategoryAnalytics(db *gorm.DB, since time.Time) ([]CategoryAnalyticsRow, error) {
+	rows := []CategoryAnalyticsRow{}
+
+	join := "LEFT JOIN submittedreports r ON r.category_id = c.category_id"
+	var args []interface{}
+	if !since.IsZero() {
+		join += " AND r.created_at >= ?"
+		args = append(args, since)
+	}
+
+	err := db.Table("report_categories c").
+		Select(`c.category_id, c.code, c.name, c.severity,
+			COUNT(r.id) AS total,
+			COUNT(r.id) FILTER (WHERE r.status = ?) AS reported,
+			COUNT(r.id) FILTER (WHERE r.status = ?) AS blocked,
+			COUNT(r.id) FILTER (WHERE r.status = ?) AS resolved,
+			COUNT(DISTINCT r.shelter_id) AS shelters`,
+			ReportStatusReported, ReportStatusBlocked, ReportStatusResolved).
+		Joins(join, args...).
+		Group("c.category_id, c.code, c.name, c.severity").
+		Order("c.severity DESC, total DESC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// StartClassificationScheduler classifies the reports that came in without a
+// category on a fixed interval until stop is closed. Reports are filed by
+// the main PetHub app, so they are picked up here rather than on insert.
+func StartClassificationScheduler(db *gorm.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if classified, err := BackfillReportCategories(db); err != nil {
+					log.Printf("Scheduled report classification error: %v\n", err)
+				} else if classified > 0 {
+					log.Printf("Scheduled report classification categorized %d reports\n", classified)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"pethubadmin/models"
+)
+
+func TestBackfillClassifiesReportsFiledWithoutACategory(t *testing.T) {
+	db := openTestDB(t)
+	if err := SeedReportCategories(db); err != nil {
+		t.Fatalf("seeding categories: %v", err)
+	}
+	var scam models.ReportCategory
+	db.Where("code = ?", "scam").First(&scam)
+
+	// Filed by the main app, which leaves category_id empty
+	reports := []models.SubmittedReport{
+		{ID: 1, ShelterID: 1, AdopterID: 5, Reason: "They asked for a reservation fee, it was fake", Status: ReportStatusReported, Version: 1},
+		{ID: 2, ShelterID: 1, AdopterID: 6, Reason: "Did not like the staff", Status: ReportStatusReported, Version: 1},
+		{ID: 3, ShelterID: 1, AdopterID: 7, Reason: "scam", CategoryID: &scam.CategoryID, Status: ReportStatusReported, Version: 4},
+	}
+	if err := db.Create(&reports).Error; err != nil {
+		t.Fatalf("seeding reports: %v", err)
+	}
+
+	classified, err := BackfillReportCategories(db)
+	if err != nil || classified != 2 {
+		t.Fatalf("classified %d, err %v; want the two uncategorized reports", classified, err)
+	}
+
+	want := map[uint]string{1: "scam", 2: CategoryOther, 3: "scam"}
+	var got []models.SubmittedReport
+	db.Order("id").Find(&got)
+	for _, report := range got {
+		var category models.ReportCategory
+		db.First(&category, report.CategoryID)
+		if category.Code != want[report.ID] {
+			t.Errorf("report %d classified as %q, want %q", report.ID, category.Code, want[report.ID])
+		}
+	}
+	if got[2].Version != 4 {
+		t.Errorf("already classified report bumped to v%d", got[2].Version)
+	}
+
+	if again, err := BackfillReportCategories(db); err != nil || again != 0 {
+		t.Fatalf("second pass classified %d, err %v; want none", again, err)
+	}
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotPending      = errors.New("shelter registration is not pending")
+	ErrClaimedByOther  = errors.New("shelter registration is claimed by another admin")
+	ErrClaimNotHeld    = errors.New("claim is not held by this admin")
+	ErrAdminIDRequired = errors.New("an authenticated admin is required")
+)
+
+// ReviewQueueSettings controls claim expiry, the review SLA and how often
+// overdue rounds are escalated
+type ReviewQueueSettings struct {
+	ClaimTTL           time.Duration
+	SLA                time.Duration
+	EscalationInterval time.Duration
+}
+
+// DefaultReviewQueueSettings is used when no overrides are configured
+var DefaultReviewQueueSettings = ReviewQueueSettings{
+	ClaimTTL:           30 * time.Minute,
+	SLA:                48 * time.Hour,
+	EscalationInterval: 15 * time.Minute,
+}
+
+// RecipientAdmin addresses escalations to admin accounts
+const RecipientAdmin = "admin"
+
+// KindReviewEscalated is the notification kind of an overdue registration
+const KindReviewEscalated = "review_escalated"
+
+// QueueItem is one pending registration in the review queue
+type QueueItem struct {
+	ShelterID    uint       `json:"shelter_id"`
+	ShelterName  string     `json:"shelter_name"`
+	Username     string     `json:"username"`
+	Round        int        `json:"round"`
+	WaitingSince time.Time  `json:"waiting_since"`
+	WaitingHours float64    `json:"waiting_hours"`
+	DueAt        time.Time  `json:"due_at"`
+	Overdue      bool       `json:"overdue"`
+	EscalatedAt  *time.Time `json:"escalated_at"`
+	ClaimedBy    uint       `json:"claimed_by,omitempty"`
+	ClaimExpires *time.Time `json:"claim_expires_at,omitempty"`
+}
+
+// ReviewerStats summarises the decisions one admin made in a period
+type ReviewerStats struct {
+	AdminID         uint    `json:"admin_id"`
+	Username        string  `json:"username"`
+	Decisions       int     `json:"decisions"`
+	Approved        int     `json:"approved"`
+	Rejected        int     `json:"rejected"`
+	AvgReviewHours  float64 `json:"avg_review_hours"`
+	EscalatedClosed int     `json:"escalated_closed"`
+}
+
+// ClaimShelterReview takes or renews the review lock on a pending shelter.
+// The lock is taken atomically: an existing claim is only replaced when it
+// has expired or already belongs to the same admin.
+func ClaimShelterReview(db *gorm.DB, shelterID, adminID uint, ttl time.Duration) (models.ShelterReviewClaim, error) {
+	claim := models.ShelterReviewClaim{}
+	if adminID == 0 {
+		return claim, ErrAdminIDRequired
+	}
+
+	var shelter models.ShelterAccount
+	if err := db.Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return claim, ErrAccountNotFound
+		}
+		return claim, err
+	}
+	if NormalizeStatus(shelter.RegStatus) != RegStatusPending {
+		return claim, ErrNotPending
+	}
+
+	now := time.Now()
+	claim = models.ShelterReviewClaim{
+		ShelterID: shelterID,
+		AdminID:   adminID,
+		ClaimedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shelter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"admin_id", "claimed_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Or(
+				clause.Expr{SQL: "shelter_review_claims.expires_at < ?", Vars: []interface{}{now}},
+				clause.Expr{SQL: "shelter_review_claims.admin_id = ?", Vars: []interface{}{adminID}},
+			),
+		}},
+	}).Create(&claim)
+	if result.Error != nil {
+		return claim, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return claim, ErrClaimedByOther
+	}
+	return claim, nil
+}
+
+// ReleaseShelterReview drops an admin's claim before it expires
+func ReleaseShelterReview(db *gorm.DB, shelterID, adminID uint) error {
+	result := db.Where("shelter_id = ? AND admin_id = ?", shelterID, adminID).
+		Delete(&models.ShelterReviewClaim{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimNotHeld
+	}
+	return nil
+}
+
+// takeReviewClaim makes sure the deciding admin holds the review claim. A
+// missing or expired claim is taken in the same atomic statement as
+// ClaimShelterReview, so two admins cannot both decide the same round.
+func takeReviewClaim(tx *gorm.DB, shelterID, adminID uint, ttl time.Duration) error {
+	_, err := ClaimShelterReview(tx, shelterID, adminID, ttl)
+	return err
+}
+
+// ReviewQueue lists pending registrations with their waiting time against
+// the SLA. It only reads: rounds are opened and escalated by
+// EscalateOverdueReviews.
+func ReviewQueue(db *gorm.DB, settings ReviewQueueSettings) ([]QueueItem, error) {
+	var pending []models.ShelterAccount
+	if err := db.Where("reg_status = ?", RegStatusPending).
+		Preload("ShelterInfo").
+		Find(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(pending))
+	for _, shelter := range pending {
+		ids = append(ids, shelter.ShelterID)
+	}
+
+	var rounds []models.ShelterReviewRound
+	if err := db.Select("round_id", "shelter_id", "round", "submitted_at", "escalated_at").
+		Where("shelter_id IN ? AND decision = ?", ids, RegStatusPending).
+		Find(&rounds).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var claims []models.ShelterReviewClaim
+	if err := db.Where("shelter_id IN ? AND expires_at > ?", ids, now).
+		Find(&claims).Error; err != nil {
+		return nil, err
+	}
+
+	return BuildReviewQueue(pending, rounds, claims, settings, now), nil
+}
+
+// EscalateOverdueReviews opens a round for pending registrations that
+// predate review rounds, marks every round past the SLA as escalated and,
+// once that has committed, tells each admin through notifier. Email goes
+// to address once per round, since admin accounts have no address of their
+// own. It returns how many rounds were escalated.
+func EscalateOverdueReviews(db *gorm.DB, settings ReviewQueueSettings, notifier Notifier, address string) (int, error) {
+	escalated := 0
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var unopened []models.ShelterAccount
+		if err := tx.Where("reg_status = ?", RegStatusPending).
+			Where("NOT EXISTS (?)", tx.Model(&models.ShelterReviewRound{}).Select("1").
+				Where("shelter_review_rounds.shelter_id = shelteraccount.shelter_id AND decision = ?", RegStatusPending)).
+			Find(&unopened).Error; err != nil {
+			return err
+		}
+		for _, shelter := range unopened {
+			round, err := openRound(tx, shelter.ShelterID)
+			if err != nil {
+				return err
+			}
+			// Registrations that predate review rounds have waited since sign-up
+			if err := tx.Model(&round).Update("submitted_at", shelter.CreatedAt).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		var overdue []models.ShelterReviewRound
+		if err := tx.Select("round_id", "shelter_id", "round", "submitted_at").
+			Where("decision = ? AND escalated_at IS NULL AND submitted_at < ?", RegStatusPending, now.Add(-settings.SLA)).
+			Find(&overdue).Error; err != nil {
+			return err
+		}
+
+		var messages []OutboundMessage
+		if len(overdue) > 0 {
+			var admins []models.AdminAccount
+			if err := tx.Select("admin_id").Find(&admins).Error; err != nil {
+				return err
+			}
+			shelterIDs := make([]uint, len(overdue))
+			for i, round := range overdue {
+				shelterIDs[i] = round.ShelterID
+			}
+			names := make(map[uint]string)
+			var infos []models.ShelterInfo
+			if err := tx.Select("shelter_id", "shelter_name").
+				Where("shelter_id IN ?", uniqueIDs(shelterIDs)).Find(&infos).Error; err != nil {
+				return err
+			}
+			for _, info := range infos {
+				names[info.ShelterID] = info.ShelterName
+			}
+
+			for _, round := range overdue {
+				// Another escalation pass may have got here first
+				update := tx.Model(&models.ShelterReviewRound{}).
+					Where("round_id = ? AND escalated_at IS NULL", round.RoundID).
+					Update("escalated_at", now)
+				if update.Error != nil {
+					return update.Error
+				}
+				if update.RowsAffected == 0 {
+					continue
+				}
+				escalated++
+				messages = append(messages, escalationMessages(round, names[round.ShelterID], admins, notifier.Channel(), address, now)...)
+			}
+		}
+
+		AfterCommit(tx, func() {
+			for _, msg := range messages {
+				if err := notifier.Send(msg); err != nil {
+					log.Printf("Review escalation notice to admin %d failed: %v\n", msg.RecipientID, err)
+				}
+			}
+		})
+		return nil
+	})
+	return escalated, err
+}
+
+func escalationMessages(round models.ShelterReviewRound, shelterName string, admins []models.AdminAccount, channel, address string, now time.Time) []OutboundMessage {
+	// The name is shelter input and ends up in a mail header
+	shelterName = singleLine(shelterName)
+	if shelterName == "" {
+		shelterName = fmt.Sprintf("shelter %d", round.ShelterID)
+	}
+	msg := OutboundMessage{
+		Kind:          KindReviewEscalated,
+		RecipientType: RecipientAdmin,
+		Address:       address,
+		Subject:       fmt.Sprintf("Registration of %s is overdue for review", shelterName),
+		Body: fmt.Sprintf("Round %d of %s's registration has waited %.0f hours, past the review SLA. Please claim it from the review queue.",
+			round.Round, shelterName, now.Sub(round.SubmittedAt).Hours()),
+	}
+	if channel == ChannelEmail {
+		return []OutboundMessage{msg}
+	}
+
+	messages := make([]OutboundMessage, len(admins))
+	for i, admin := range admins {
+		messages[i] = msg
+		messages[i].RecipientID = admin.AdminID
+	}
+	return messages
+}
+
+// StartEscalationScheduler runs EscalateOverdueReviews on the settings'
+// interval until stop is closed
+func StartEscalationScheduler(db *gorm.DB, settings ReviewQueueSettings, notifier Notifier, address string, stop <-chan struct{}) {
+	ticker := time.NewTicker(settings.EscalationInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if escalated, err := EscalateOverdueReviews(db, settings, notifier, address); err != nil {
+					log.Printf("Scheduled review escalation error: %v\n", err)
+				} else if escalated > 0 {
+					log.Printf("Scheduled review escalation flagged %d overdue registrations\n", escalated)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// BuildReviewQueue turns pending shelters, their open rounds and live claims
+// into queue items, escalated first and then oldest first. A shelter with no
+// open round yet is shown as round 1, waiting since sign-up.
+func BuildReviewQueue(pending []models.ShelterAccount, rounds []models.ShelterReviewRound,
+	claims []models.ShelterReviewClaim, settings ReviewQueueSettings, now time.Time) []QueueItem {
+	roundMap := make(map[uint]models.ShelterReviewRound)
+	for _, r := range rounds {
+		roundMap[r.ShelterID] = r
+	}
+	claimMap := make(map[uint]models.ShelterReviewClaim)
+	for _, claim := range claims {
+		if claim.ExpiresAt.After(now) {
+			claimMap[claim.ShelterID] = claim
+		}
+	}
+
+	queue := make([]QueueItem, 0, len(pending))
+	for _, shelter := range pending {
+		round, ok := roundMap[shelter.ShelterID]
+		if !ok {
+			round = models.ShelterReviewRound{ShelterID: shelter.ShelterID, Round: 1, SubmittedAt: shelter.CreatedAt}
+		}
+
+		item := QueueItem{
+			ShelterID:    shelter.ShelterID,
+			ShelterName:  shelter.ShelterInfo.ShelterName,
+			Username:     shelter.Username,
+			Round:        round.Round,
+			WaitingSince: round.SubmittedAt,
+			WaitingHours: roundHours(now.Sub(round.SubmittedAt)),
+			DueAt:        round.SubmittedAt.Add(settings.SLA),
+			EscalatedAt:  round.EscalatedAt,
+		}
+		item.Overdue = now.After(item.DueAt)
+
+		if claim, ok := claimMap[shelter.ShelterID]; ok {
+			expires := claim.ExpiresAt
+			item.ClaimedBy = claim.AdminID
+			item.ClaimExpires = &expires
+		}
+
+		queue = append(queue, item)
+	}
+
+	// Escalated first, then oldest first
+	sort.SliceStable(queue, func(i, j int) bool {
+		if (queue[i].EscalatedAt != nil) != (queue[j].EscalatedAt != nil) {
+			return queue[i].EscalatedAt != nil
+		}
+		return queue[i].WaitingSince.Before(queue[j].WaitingSince)
+	})
+	return queue
+}
+
+// ReviewerThroughput reports per-admin decision counts since the given time
+func ReviewerThroughput(db *gorm.DB, since time.Time) ([]ReviewerStats, error) {
+	var stats []ReviewerStats
+	err := db.Table("shelter_review_rounds AS r").
+		Select(`r.reviewed_by AS admin_id,
+			COALESCE(a.username, '') AS username,
+			COUNT(*) AS decisions,
+			COUNT(*) FILTER (WHERE r.decision = ?) AS approved,
+			COUNT(*) FILTER (WHERE r.decision = ?) AS rejected,
+			COALESCE(ROUND(AVG(EXTRACT(EPOCH FROM (r.reviewed_at - r.submitted_at)) / 3600)::numeric, 2), 0) AS avg_review_hours,
+			COUNT(*) FILTER (WHERE r.escalated_at IS NOT NULL) AS escalated_closed`,
+			RegStatusApproved, RegStatusRejected).
+		Joins("LEFT JOIN adminaccount a ON a.admin_id = r.reviewed_by").
+		Where("r.reviewed_at IS NOT NULL AND r.reviewed_at >= ?", since).
+		Group("r.reviewed_by, a.username").
+		Order("decisions DESC").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func roundHours(d time.Duration) float64 {
+	return float64(int(d.Hours()*100)) / 100
+}
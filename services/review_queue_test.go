@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// recordingNotifier keeps every message it is asked to send
+type recordingNotifier struct {
+	channel string
+	sent    []OutboundMessage
+}
+
+func (n *recordingNotifier) Channel() string { return n.channel }
+
+func (n *recordingNotifier) Send(msg OutboundMessage) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func seedPendingShelter(t *testing.T, db *gorm.DB, id uint, createdAt time.Time) {
+	t.Helper()
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: id, Username: "shelter" + string(rune('a'+id)), Status: StatusInactive, RegStatus: RegStatusPending, Version: 1, CreatedAt: createdAt},
+		&models.ShelterInfo{ShelterID: id, ShelterName: "Paws"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+func TestDecisionTakesTheReviewClaim(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		adminID uint
+		claim   *models.ShelterReviewClaim
+		wantErr error
+	}{
+		{"no admin", 0, nil, ErrAdminIDRequired},
+		{"no claim yet", 7, nil, nil},
+		{"own claim", 7, &models.ShelterReviewClaim{ShelterID: 1, AdminID: 7, ClaimedAt: now, ExpiresAt: now.Add(time.Hour)}, nil},
+		{"expired claim of another admin", 7, &models.ShelterReviewClaim{ShelterID: 1, AdminID: 8, ClaimedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute)}, nil},
+		{"live claim of another admin", 7, &models.ShelterReviewClaim{ShelterID: 1, AdminID: 8, ClaimedAt: now, ExpiresAt: now.Add(time.Hour)}, ErrClaimedByOther},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := openTestDB(t)
+			seedPendingShelter(t, db, 1, now)
+			if tc.claim != nil {
+				db.Create(tc.claim)
+			}
+
+			_, _, err := DecideShelterRegistration(db, 1, tc.adminID, RegStatusRejected, "incomplete_info", "Add your address", time.Hour)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+
+			var shelter models.ShelterAccount
+			db.First(&shelter, 1)
+			var claims int64
+			db.Model(&models.ShelterReviewClaim{}).Count(&claims)
+			if tc.wantErr != nil {
+				if shelter.RegStatus != RegStatusPending {
+					t.Fatalf("refused decision left reg_status %q", shelter.RegStatus)
+				}
+				return
+			}
+			if shelter.RegStatus != RegStatusRejected || claims != 0 {
+				t.Fatalf("reg_status %q with %d claims left, want rejected and none", shelter.RegStatus, claims)
+			}
+		})
+	}
+}
+
+func TestDecisionClaimUsesTheConfiguredTTL(t *testing.T) {
+	db := openTestDB(t)
+	seedPendingShelter(t, db, 1, time.Now())
+
+	if err := takeReviewClaim(db, 1, 7, 10*time.Minute); err != nil {
+		t.Fatalf("taking the claim: %v", err)
+	}
+	var claim models.ShelterReviewClaim
+	db.First(&claim, "shelter_id = ?", 1)
+	if ttl := claim.ExpiresAt.Sub(claim.ClaimedAt); ttl != 10*time.Minute {
+		t.Fatalf("claim held for %v, want the configured 10m", ttl)
+	}
+}
+
+func TestReviewQueueOnlyReads(t *testing.T) {
+	db := openTestDB(t)
+	seedPendingShelter(t, db, 1, time.Now().Add(-100*time.Hour))
+
+	queue, err := ReviewQueue(db, DefaultReviewQueueSettings)
+	if err != nil {
+		t.Fatalf("ReviewQueue: %v", err)
+	}
+	if len(queue) != 1 || queue[0].Round != 1 || !queue[0].Overdue || queue[0].EscalatedAt != nil {
+		t.Fatalf("queue = %+v, want one overdue round 1 that is not escalated yet", queue)
+	}
+
+	var rounds int64
+	db.Model(&models.ShelterReviewRound{}).Count(&rounds)
+	if rounds != 0 {
+		t.Fatalf("reading the queue opened %d rounds", rounds)
+	}
+}
+
+func TestEscalateOverdueReviews(t *testing.T) {
+	signedUp := time.Now().Add(-100 * time.Hour).Truncate(time.Second)
+	db := openTestDB(t)
+	seedPendingShelter(t, db, 1, signedUp)   // predates review rounds, overdue
+	seedPendingShelter(t, db, 2, time.Now()) // within the SLA
+	db.Create(&models.AdminAccount{AdminID: 7, Username: "ana"})
+	db.Create(&models.AdminAccount{AdminID: 8, Username: "ben"})
+
+	notifier := &recordingNotifier{channel: ChannelInApp}
+	escalated, err := EscalateOverdueReviews(db, DefaultReviewQueueSettings, notifier, "team@pethub.test")
+	if err != nil {
+		t.Fatalf("EscalateOverdueReviews: %v", err)
+	}
+	if escalated != 1 || len(notifier.sent) != 2 {
+		t.Fatalf("escalated %d, sent %d messages; want 1 round and a message per admin", escalated, len(notifier.sent))
+	}
+	for i, admin := range []uint{7, 8} {
+		msg := notifier.sent[i]
+		if msg.RecipientType != RecipientAdmin || msg.RecipientID != admin || msg.Kind != KindReviewEscalated {
+			t.Errorf("message %d = %+v", i, msg)
+		}
+	}
+
+	var round models.ShelterReviewRound
+	db.Where("shelter_id = ?", 1).First(&round)
+	if !round.SubmittedAt.Equal(signedUp) || round.EscalatedAt == nil {
+		t.Fatalf("round = submitted %v escalated %v, want submitted at sign-up and escalated", round.SubmittedAt, round.EscalatedAt)
+	}
+	var rounds int64
+	db.Model(&models.ShelterReviewRound{}).Count(&rounds)
+	if rounds != 2 {
+		t.Fatalf("%d rounds open, want one per pending shelter", rounds)
+	}
+
+	// A second pass finds nothing new
+	escalated, err = EscalateOverdueReviews(db, DefaultReviewQueueSettings, notifier, "team@pethub.test")
+	if err != nil || escalated != 0 || len(notifier.sent) != 2 {
+		t.Fatalf("second pass escalated %d (err %v) and sent %d messages in total", escalated, err, len(notifier.sent))
+	}
+}
+
+func TestEscalationEmailsTheTeamOnce(t *testing.T) {
+	db := openTestDB(t)
+	seedPendingShelter(t, db, 1, time.Now().Add(-100*time.Hour))
+	db.Create(&models.AdminAccount{AdminID: 7, Username: "ana"})
+	db.Create(&models.AdminAccount{AdminID: 8, Username: "ben"})
+
+	notifier := &recordingNotifier{channel: ChannelEmail}
+	if _, err := EscalateOverdueReviews(db, DefaultReviewQueueSettings, notifier, "team@pethub.test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Address != "team@pethub.test" {
+		t.Fatalf("sent %+v, want one email to the team address", notifier.sent)
+	}
+}
+
+func TestEscalationSubjectIsOneLine(t *testing.T) {
+	round := models.ShelterReviewRound{ShelterID: 1, Round: 1, SubmittedAt: time.Now().Add(-50 * time.Hour)}
+	messages := escalationMessages(round, "Paws\r\nBcc: victim@example.com", nil, ChannelEmail, "team@pethub.test", time.Now())
+	if want := "Registration of Paws Bcc: victim@example.com is overdue for review"; messages[0].Subject != want {
+		t.Fatalf("subject = %q, want %q", messages[0].Subject, want)
+	}
+}
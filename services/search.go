@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Searchable entity types
+const (
+	SearchAdopters = "adopters"
+	SearchShelters = "shelters"
+	SearchPets     = "pets"
+	SearchReports  = "reports"
+)
+
+// SearchTypes lists every searchable entity type
+var SearchTypes = []string{SearchAdopters, SearchShelters, SearchPets, SearchReports}
+
+// MinSearchLength is the shortest query searched; trigrams need at least
+// two characters to match anything useful
+const MinSearchLength = 2
+
+// ErrSearchQuery is returned for a query that is too short or names an
+// unknown entity type
+var ErrSearchQuery = errors.New("invalid search")
+
+// SearchHit is one matching record
+type SearchHit struct {
+	EntityType string  `json:"entity_type"`
+	ID         uint    `json:"id"`
+	Title      string  `json:"title"`
+	Subtitle   string  `json:"subtitle"`
+	Rank       float64 `json:"rank"`
+	Link       string  `json:"link"`
+}
+
+// SearchGroup is the hits of one entity type, best first
+type SearchGroup struct {
+	EntityType string      `json:"entity_type"`
+	Hits       []SearchHit `json:"hits"`
+}
+
+// searchTarget describes how one entity type is searched. document must
+// match the expression indexed in the admin_search migration exactly, or
+// Postgres will not use the indexes. live, when set, leaves out soft
+// deleted rows and rows whose owning account is soft deleted.
+type searchTarget struct {
+	table    string
+	id       string
+	title    string
+	subtitle string
+	document string
+	live     string
+}
+
+var searchTargets = map[string]searchTarget{
+	SearchAdopters: {
+		table:    "adopterinfo",
+		id:       "adopter_id",
+		title:    "concat_ws(' ', first_name, last_name)",
+		subtitle: "coalesce(email, '')",
+		document: "coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || coalesce(email, '') || ' ' || coalesce(contact_number, '') || ' ' || coalesce(address, '')",
+		live:     "deleted_at IS NULL",
+	},
+	SearchShelters: {
+		table:    "shelterinfo",
+		id:       "shelter_id",
+		title:    "coalesce(shelter_name, '')",
+		subtitle: "coalesce(shelter_email, '')",
+		document: "coalesce(shelter_name, '') || ' ' || coalesce(shelter_owner, '') || ' ' || coalesce(shelter_email, '') || ' ' || coalesce(shelter_contact, '') || ' ' || coalesce(shelter_address, '')",
+		live:     "deleted_at IS NULL",
+	},
+	SearchPets: {
+		table:    "petinfo",
+		id:       "pet_id",
+		title:    "coalesce(pet_name, '')",
+		subtitle: "coalesce(pet_type, '')",
+		document: "coalesce(pet_name, '') || ' ' || coalesce(pet_type, '')",
+		live:     "NOT " + deletedOwner("shelteraccount", "shelter_id", "petinfo"),
+	},
+	SearchReports: {
+		table:    "submittedreports",
+		id:       "id",
+		title:    "coalesce(reason, '')",
+		subtitle: "coalesce(status, '')",
+		document: "coalesce(reason, '') || ' ' || coalesce(description, '')",
+		live: "NOT " + deletedOwner("shelteraccount", "shelter_id", "submittedreports") +
+			" AND NOT " + deletedOwner("adopteraccount", "adopter_id", "submittedreports"),
+	},
+}
+
+// deletedOwner is true when the account in accounts that table.column
+// points at is soft deleted
+func deletedOwner(accounts, column, table string) string {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM %[1]s o WHERE o.%[2]s = %[3]s.%[2]s AND o.deleted_at IS NOT NULL)", accounts, column, table)
+}
+
+// ValidateSearch normalizes a query and the entity types to search, all of
+// them when none are given
+func ValidateSearch(query string, types []string) (string, []string, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if len([]rune(query)) < MinSearchLength {
+		return "", nil, fmt.Errorf("%w: the query needs at least %d characters", ErrSearchQuery, MinSearchLength)
+	}
+	if len(types) == 0 {
+		return query, SearchTypes, nil
+	}
+	var valid []string
+	seen := make(map[string]bool)
+	for _, t := range types {
+		t = NormalizeStatus(t)
+		if _, ok := searchTargets[t]; !ok {
+			return "", nil, fmt.Errorf("%w: unknown type %q, use %s", ErrSearchQuery, t, strings.Join(SearchTypes, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			valid = append(valid, t)
+		}
+	}
+	return query, valid, nil
+}
+
+// Search ranks records of each type against the query using full-text
+// matching on whole words and trigram word similarity for partial words,
+// typos, emails and phone numbers. It returns up to limit hits per type,
+// with the groups ordered by their best hit.
+func Search(db *gorm.DB, query string, types []string, limit int) ([]SearchGroup, error) {
+	query, types, err := ValidateSearch(query, types)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]SearchGroup, 0, len(types))
+	for _, t := range types {
+		target := searchTargets[t]
+		live := "TRUE"
+		if target.live != "" {
+			live = target.live
+		}
+		sql := fmt.Sprintf(`SELECT %[2]s AS id, %[3]s AS title, %[4]s AS subtitle,
+				ts_rank(to_tsvector('simple', %[5]s), plainto_tsquery('simple', ?)) + word_similarity(?, %[5]s) AS rank
+			FROM %[1]s
+			WHERE %[6]s AND (to_tsvector('simple', %[5]s) @@ plainto_tsquery('simple', ?) OR ? <%% (%[5]s))
+			ORDER BY rank DESC, %[2]s
+			LIMIT ?`,
+			target.table, target.id, target.title, target.subtitle, target.document, live)
+
+		var hits []SearchHit
+		if err := db.Raw(sql, query, query, query, query, limit).Scan(&hits).Error; err != nil {
+			return nil, err
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		for i := range hits {
+			hits[i].EntityType = t
+		}
+		groups = append(groups, SearchGroup{EntityType: t, Hits: hits})
+	}
+
+	SortSearchGroups(groups)
+	return groups, nil
+}
+
+// SortSearchGroups orders groups by their best hit
+func SortSearchGroups(groups []SearchGroup) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Hits[0].Rank > groups[j].Hits[0].Rank
+	})
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+func TestSearchLeavesOutRowsOfDeletedOwners(t *testing.T) {
+	db := openTestDB(t)
+	deleted := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "live", Status: StatusActive, Version: 1},
+		&models.ShelterAccount{ShelterID: 2, Username: "gone", Status: StatusActive, Version: 1, DeletedAt: deleted},
+		&models.AdopterAccount{AdopterID: 1, Username: "ana", Status: StatusActive, Version: 1},
+		&models.AdopterAccount{AdopterID: 2, Username: "ben", Status: StatusActive, Version: 1, DeletedAt: deleted},
+		&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex"},
+		&models.PetInfo{PetID: 2, ShelterID: 2, PetName: "Rex"},
+		&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 1, Reason: "scam", Version: 1},
+		&models.SubmittedReport{ID: 2, ShelterID: 2, AdopterID: 1, Reason: "scam", Version: 1},
+		&models.SubmittedReport{ID: 3, ShelterID: 1, AdopterID: 2, Reason: "scam", Version: 1},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	for entity, want := range map[string][]uint{SearchPets: {1}, SearchReports: {1}} {
+		target := searchTargets[entity]
+		var ids []uint
+		if err := db.Table(target.table).Where(target.live).Order(target.id).Pluck(target.id, &ids).Error; err != nil {
+			t.Fatalf("%s: %v", entity, err)
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("%s live rows = %v, want %v", entity, ids, want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// How open applications are handled when their shelter is blocked
+const (
+	ApplicationsKeep     = "keep"
+	ApplicationsFreeze   = "freeze"
+	ApplicationsWithdraw = "withdraw"
+)
+
+// Cascade sources, stored in petinfo.hidden_by and application_holds.source
+const (
+	HoldShelterBlocked     = "shelter_blocked"
+	HoldAdopterDeactivated = "adopter_deactivated"
+)
+
+// Reasons written to reason_for_rejection when a cascade withdraws an
+// application. What they replaced is kept in application_withdrawals.
+const (
+	WithdrawnShelterBlocked     = "Withdrawn automatically because the shelter was blocked"
+	WithdrawnAdopterDeactivated = "Withdrawn automatically because the adopter account was deactivated"
+)
+
+// Application and interview status values written by cascades
+const (
+	ApplicationWithdrawn = "withdrawn"
+	InterviewScheduled   = "scheduled"
+	InterviewCancelled   = "cancelled"
+)
+
+// Notification kinds sent by the shelter cascade
+const (
+	NotifyShelterBlocked    = "shelter_blocked"
+	NotifyShelterReinstated = "shelter_reinstated"
+)
+
+var ErrInvalidCascadePolicy = errors.New("applications policy must be 'keep', 'freeze' or 'withdraw'")
+
+// ShelterBlockPolicy controls what else happens when a shelter is blocked
+type ShelterBlockPolicy struct {
+	HidePets         bool
+	Applications     string
+	CancelInterviews bool
+	NotifyAdopters   bool
+}
+
+// DefaultShelterBlockPolicy hides pets, freezes applications, cancels
+// upcoming interviews and tells the adopters
+var DefaultShelterBlockPolicy = ShelterBlockPolicy{
+	HidePets:         true,
+	Applications:     ApplicationsFreeze,
+	CancelInterviews: true,
+	NotifyAdopters:   true,
+}
+
+// CascadeResult counts the records a cascade touched
+type CascadeResult struct {
+	PetsHidden            int64 `json:"pets_hidden"`
+	PetsRestored          int64 `json:"pets_restored"`
+	ApplicationsFrozen    int64 `json:"applications_frozen"`
+	ApplicationsThawed    int64 `json:"applications_thawed"`
+	ApplicationsWithdrawn int64 `json:"applications_withdrawn"`
+	ApplicationsReopened  int64 `json:"applications_reopened"`
+	InterviewsCancelled   int64 `json:"interviews_cancelled"`
+	Notified              int   `json:"notified"`
+}
+
+// Validate checks the applications policy
+func (p ShelterBlockPolicy) Validate() error {
+	switch p.Applications {
+	case ApplicationsKeep, ApplicationsFreeze, ApplicationsWithdraw:
+		return nil
+	}
+	return ErrInvalidCascadePolicy
+}
+
+// ListedPets limits a petinfo query to pets that are publicly visible
+func ListedPets(db *gorm.DB) *gorm.DB {
+	return db.Where("listing_status <> ? AND hidden_by = ''", ListingUnlisted)
+}
+
+// cascadeShelterBlock applies the block policy to the shelter's pets,
+// applications and interviews inside the blocking transaction
+func cascadeShelterBlock(tx *gorm.DB, shelterID, adminID uint, shelterName string, policy ShelterBlockPolicy) (CascadeResult, error) {
+	var result CascadeResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+
+	// Collect the affected adopters before anything changes
+	var adopterIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where("shelter_id = ? AND status NOT IN ?", shelterID, ClosedApplicationStatuses).
+		Pluck("adopter_id", &adopterIDs).Error; err != nil {
+		return result, err
+	}
+
+	if policy.HidePets {
+		// Pets on a flag hold are taken over: reinstating waits for the hold
+		// to end, so the block is the last thing keeping them hidden
+		update := tx.Model(&models.PetInfo{}).
+			Where("shelter_id = ? AND hidden_by IN ?", shelterID, []string{"", HoldFlagged}).
+			Update("hidden_by", HoldShelterBlocked)
+		if update.Error != nil {
+			return result, update.Error
+		}
+		result.PetsHidden = update.RowsAffected
+	}
+
+	switch policy.Applications {
+	case ApplicationsFreeze:
+		frozen, err := freezeOpenApplications(tx, "shelter_id", shelterID, adminID, HoldShelterBlocked, "Shelter is blocked")
+		if err != nil {
+			return result, err
+		}
+		result.ApplicationsFrozen = frozen
+
+	case ApplicationsWithdraw:
+		withdrawn, err := withdrawOpenApplications(tx, "shelter_id", shelterID, adminID, HoldShelterBlocked, WithdrawnShelterBlocked)
+		if err != nil {
+			return result, err
+		}
+		result.ApplicationsWithdrawn = withdrawn
+	}
+
+	if policy.CancelInterviews {
+		cancelled, interviewAdopters, err := cancelUpcomingInterviews(tx, "shelter_id", shelterID, "adopter_id")
+		if err != nil {
+			return result, err
+		}
+		result.InterviewsCancelled = cancelled
+		adopterIDs = append(adopterIDs, interviewAdopters...)
+	}
+
+	if policy.NotifyAdopters {
+		message := fmt.Sprintf("%s has been suspended by PetHub.", shelterName)
+		switch policy.Applications {
+		case ApplicationsFreeze:
+			message += " Your application is on hold until the shelter is reinstated."
+		case ApplicationsWithdraw:
+			message += " Your application has been withdrawn."
+		}
+		if result.InterviewsCancelled > 0 {
+			message += " Upcoming interviews with this shelter have been cancelled."
+		}
+
+		notified, err := NotifyMany(tx, RecipientAdopter, adopterIDs, NotifyShelterBlocked, "Shelter suspended", message)
+		if err != nil {
+			return result, err
+		}
+		result.Notified = notified
+	}
+	return result, nil
+}
+
+// reverseShelterBlock unhides the shelter's pets and releases the holds the
+// block placed. Withdrawn applications and cancelled interviews stay as they are.
+func reverseShelterBlock(tx *gorm.DB, shelterID uint, shelterName string) (CascadeResult, error) {
+	var result CascadeResult
+
+	var adopterIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where("shelter_id = ? AND application_id IN (?)", shelterID,
+			tx.Model(&models.ApplicationHold{}).Select("application_id").Where("source = ?", HoldShelterBlocked)).
+		Pluck("adopter_id", &adopterIDs).Error; err != nil {
+		return result, err
+	}
+
+	update := tx.Model(&models.PetInfo{}).
+		Where("shelter_id = ? AND hidden_by = ?", shelterID, HoldShelterBlocked).
+		Update("hidden_by", "")
+	if update.Error != nil {
+		return result, update.Error
+	}
+	result.PetsRestored = update.RowsAffected
+
+	release := tx.Where("source = ? AND application_id IN (?)", HoldShelterBlocked,
+		tx.Model(&models.AdoptionSubmission{}).Select("application_id").Where("shelter_id = ?", shelterID)).
+		Delete(&models.ApplicationHold{})
+	if release.Error != nil {
+		return result, release.Error
+	}
+	result.ApplicationsThawed = release.RowsAffected
+
+	notified, err := NotifyMany(tx, RecipientAdopter, adopterIDs, NotifyShelterReinstated, "Shelter reinstated",
+		fmt.Sprintf("%s has been reinstated. Your application is active again.", shelterName))
+	if err != nil {
+		return result, err
+	}
+	result.Notified = notified
+	return result, nil
+}
+
+// freezeOpenApplications holds every open application where column = id
+func freezeOpenApplications(tx *gorm.DB, column string, id, adminID uint, source, reason string) (int64, error) {
+	var applicationIDs []uint
+	if err := tx.Model(&models.AdoptionSubmission{}).
+		Where(column+" = ? AND status NOT IN ?", id, ClosedApplicationStatuses).
+		Pluck("application_id", &applicationIDs).Error; err != nil {
+		return 0, err
+	}
+	return holdApplications(tx, applicationIDs, adminID, source, reason)
+}
+
+// withdrawOpenApplications withdraws every open application where column = id,
+// recording the status and reason each one had under source
+func withdrawOpenApplications(tx *gorm.DB, column string, id, adminID uint, source, reason string) (int64, error) {
+	var open []models.AdoptionSubmission
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("application_id", "status", "reason_for_rejection").
+		Where(column+" = ? AND status NOT IN ?", id, ClosedApplicationStatuses).
+		Find(&open).Error; err != nil {
+		return 0, err
+	}
+	if len(open) == 0 {
+		return 0, nil
+	}
+
+	withdrawals := make([]models.ApplicationWithdrawal, len(open))
+	applicationIDs := make([]uint, len(open))
+	for i, application := range open {
+		withdrawals[i] = models.ApplicationWithdrawal{
+			ApplicationID: application.ApplicationID,
+			Source:        source,
+			PriorStatus:   application.Status,
+			PriorReason:   application.ReasonForRejection,
+			WithdrawnBy:   adminID,
+		}
+		applicationIDs[i] = application.ApplicationID
+	}
+	// A record left from an earlier withdrawal that was never restored is
+	// replaced by this one
+	if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&withdrawals).Error; err != nil {
+		return 0, err
+	}
+
+	update := tx.Model(&models.AdoptionSubmission{}).
+		Where("application_id IN ?", applicationIDs).
+		Updates(map[string]interface{}{
+			"status":               ApplicationWithdrawn,
+			"reason_for_rejection": reason,
+		})
+	return update.RowsAffected, update.Error
+}
+
+// cancelUpcomingInterviews cancels scheduled interviews from today on where
+// column = id and returns the other party's IDs from counterpart
+func cancelUpcomingInterviews(tx *gorm.DB, column string, id uint, counterpart string) (int64, []uint, error) {
+	upcoming := tx.Model(&models.ScheduleInterview{}).
+		Where(column+" = ? AND interview_status = ? AND interview_date >= ?", id, InterviewScheduled, today()).
+		Session(&gorm.Session{})
+
+	var counterparts []uint
+	if err := upcoming.Pluck(counterpart, &counterparts).Error; err != nil {
+		return 0, nil, err
+	}
+	update := upcoming.Update("interview_status", InterviewCancelled)
+	return update.RowsAffected, counterparts, update.Error
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
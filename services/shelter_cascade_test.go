@@ -0,0 +1,189 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// seedShelterWithActivity creates an active shelter with two pets, open and
+// closed applications, and a past and an upcoming interview
+func seedShelterWithActivity(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"},
+		&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", ListingStatus: ListingListed},
+		&models.PetInfo{PetID: 2, ShelterID: 1, PetName: "Mia", ListingStatus: ListingListed},
+		&models.AdoptionSubmission{ApplicationID: 1, ShelterID: 1, PetID: 1, AdopterID: 5, Status: "pending"},
+		&models.AdoptionSubmission{ApplicationID: 2, ShelterID: 1, PetID: 2, AdopterID: 6, Status: "interview"},
+		&models.AdoptionSubmission{ApplicationID: 3, ShelterID: 1, PetID: 2, AdopterID: 7, Status: "rejected", ReasonForRejection: "Too far away"},
+		&models.ScheduleInterview{InterviewID: 1, ApplicationID: 2, ShelterID: 1, AdopterID: 6, InterviewDate: time.Now().AddDate(0, 0, 7), InterviewStatus: InterviewScheduled},
+		&models.ScheduleInterview{InterviewID: 2, ApplicationID: 1, ShelterID: 1, AdopterID: 5, InterviewDate: time.Now().AddDate(0, 0, -7), InterviewStatus: InterviewScheduled},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+}
+
+func interviewStatuses(t *testing.T, db *gorm.DB) map[uint]string {
+	t.Helper()
+	var interviews []models.ScheduleInterview
+	if err := db.Find(&interviews).Error; err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[uint]string{}
+	for _, interview := range interviews {
+		statuses[interview.InterviewID] = interview.InterviewStatus
+	}
+	return statuses
+}
+
+func TestShelterBlockCascadeFreezesAndReinstateReverses(t *testing.T) {
+	db := openTestDB(t)
+	seedShelterWithActivity(t, db)
+
+	blocked, err := BlockShelter(db, 1, 9, DefaultShelterBlockPolicy, "")
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	want := CascadeResult{PetsHidden: 2, ApplicationsFrozen: 2, InterviewsCancelled: 1, Notified: 2}
+	if blocked.Cascade != want {
+		t.Fatalf("block cascade = %+v, want %+v", blocked.Cascade, want)
+	}
+	if listed := listedPets(t, db); listed != 0 {
+		t.Fatalf("%d pets listed while the shelter is blocked", listed)
+	}
+	if frozen := frozenApplications(t, db); len(frozen) != 2 || !frozen[1] || !frozen[2] {
+		t.Fatalf("frozen applications = %v, want the open 1 and 2", frozen)
+	}
+	if statuses := interviewStatuses(t, db); statuses[1] != InterviewCancelled || statuses[2] != InterviewScheduled {
+		t.Fatalf("interviews = %v, want only the upcoming one cancelled", statuses)
+	}
+	var notices int64
+	db.Model(&models.Notification{}).Where("recipient_type = ? AND kind = ?", RecipientAdopter, NotifyShelterBlocked).Count(&notices)
+	if notices != 2 {
+		t.Fatalf("%d adopters told of the block, want 2", notices)
+	}
+
+	reinstated, err := ReinstateShelter(db, 1, 9)
+	if err != nil {
+		t.Fatalf("reinstate: %v", err)
+	}
+	want = CascadeResult{PetsRestored: 2, ApplicationsThawed: 2, Notified: 2}
+	if reinstated.Cascade != want {
+		t.Fatalf("reinstate cascade = %+v, want %+v", reinstated.Cascade, want)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after reinstating, want 2", listed)
+	}
+	if frozen := frozenApplications(t, db); len(frozen) != 0 {
+		t.Fatalf("applications %v still frozen after reinstating", frozen)
+	}
+	// A cancelled interview is not put back on the calendar
+	if statuses := interviewStatuses(t, db); statuses[1] != InterviewCancelled {
+		t.Fatalf("interviews = %v, want the cancelled one left cancelled", statuses)
+	}
+}
+
+func TestShelterBlockCascadeWithdrawsUnderThatPolicy(t *testing.T) {
+	db := openTestDB(t)
+	seedShelterWithActivity(t, db)
+	// A pet already unlisted by an admin must stay unlisted after the block
+	if _, err := ModeratePet(db, 1, 9, ListingUnlisted, "Not a real listing"); err != nil {
+		t.Fatalf("unlist: %v", err)
+	}
+
+	policy := ShelterBlockPolicy{Applications: ApplicationsWithdraw}
+	blocked, err := BlockShelter(db, 1, 9, policy, "")
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if want := (CascadeResult{ApplicationsWithdrawn: 2}); blocked.Cascade != want {
+		t.Fatalf("block cascade = %+v, want %+v", blocked.Cascade, want)
+	}
+	if listed := listedPets(t, db); listed != 1 {
+		t.Fatalf("%d pets listed with HidePets off, want the one still listed", listed)
+	}
+
+	var applications []models.AdoptionSubmission
+	db.Order("application_id").Find(&applications)
+	wantStatus := map[uint][2]string{
+		1: {ApplicationWithdrawn, WithdrawnShelterBlocked},
+		2: {ApplicationWithdrawn, WithdrawnShelterBlocked},
+		3: {"rejected", "Too far away"},
+	}
+	for _, application := range applications {
+		if got := [2]string{application.Status, application.ReasonForRejection}; got != wantStatus[application.ApplicationID] {
+			t.Errorf("application %d = %q, want %q", application.ApplicationID, got, wantStatus[application.ApplicationID])
+		}
+	}
+	var withdrawals int64
+	db.Model(&models.ApplicationWithdrawal{}).Where("source = ?", HoldShelterBlocked).Count(&withdrawals)
+	if withdrawals != 2 {
+		t.Fatalf("%d withdrawals recorded, want 2", withdrawals)
+	}
+	if statuses := interviewStatuses(t, db); statuses[1] != InterviewScheduled {
+		t.Fatalf("interviews = %v, want none cancelled with CancelInterviews off", statuses)
+	}
+
+	// Withdrawn applications stay withdrawn; the pet unlisted before the
+	// block stays unlisted
+	if _, err := ReinstateShelter(db, 1, 9); err != nil {
+		t.Fatalf("reinstate: %v", err)
+	}
+	var withdrawn int64
+	db.Model(&models.AdoptionSubmission{}).Where("status = ?", ApplicationWithdrawn).Count(&withdrawn)
+	if withdrawn != 2 {
+		t.Fatalf("%d applications withdrawn after reinstating, want 2", withdrawn)
+	}
+	if listed := listedPets(t, db); listed != 1 {
+		t.Fatalf("%d pets listed after reinstating, want 1", listed)
+	}
+}
+
+func TestSetShelterStatusBlocksAndReinstates(t *testing.T) {
+	db := openTestDB(t)
+	seedShelterWithActivity(t, db)
+
+	deactivated, err := SetShelterStatus(db, 1, 9, StatusInactive, DefaultShelterBlockPolicy)
+	if err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if deactivated.Account.Status != StatusInactive || deactivated.Cascade.PetsHidden != 2 || deactivated.Cascade.ApplicationsFrozen != 2 {
+		t.Fatalf("deactivate = %+v, want the block cascade", deactivated)
+	}
+	if listed := listedPets(t, db); listed != 0 {
+		t.Fatalf("%d pets listed while the shelter is inactive", listed)
+	}
+
+	activated, err := SetShelterStatus(db, 1, 9, StatusActive, DefaultShelterBlockPolicy)
+	if err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if activated.Account.Status != StatusActive || activated.Cascade.PetsRestored != 2 || activated.Cascade.ApplicationsThawed != 2 {
+		t.Fatalf("activate = %+v, want the block reversed", activated)
+	}
+	if listed := listedPets(t, db); listed != 2 {
+		t.Fatalf("%d pets listed after activating, want 2", listed)
+	}
+	if frozen := frozenApplications(t, db); len(frozen) != 0 {
+		t.Fatalf("applications %v still frozen after activating", frozen)
+	}
+
+	var actions []string
+	db.Model(&models.AdminAuditLog{}).Where("entity_type = ?", EntityShelter).Order("audit_id").Pluck("action", &actions)
+	if len(actions) != 2 || actions[0] != ActionShelterBlock || actions[1] != ActionShelterReinstate {
+		t.Fatalf("audit actions = %v, want block then reinstate", actions)
+	}
+
+	if _, err := SetShelterStatus(db, 1, 9, "suspended", DefaultShelterBlockPolicy); !errors.Is(err, ErrInvalidStatus) {
+		t.Fatalf("unknown status err = %v, want ErrInvalidStatus", err)
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// Verification document types
+const (
+	DocBusinessPermit = "business_permit"
+	DocOwnerID        = "owner_id"
+	DocFacilityPhoto  = "facility_photo"
+)
+
+// Verification document review states
+const (
+	DocStatusPending  = "pending"
+	DocStatusAccepted = "accepted"
+	DocStatusRejected = "rejected"
+)
+
+// RequiredDocumentTypes must all have an accepted document before a shelter
+// registration can be approved.
+var RequiredDocumentTypes = []string{DocBusinessPermit, DocOwnerID, DocFacilityPhoto}
+
+// DocumentUploadRules limits verification uploads to images and PDFs
+var DocumentUploadRules = UploadRules{
+	AllowedTypes: ImageAndPDFMimeTypes,
+	MaxBytes:     MaxUploadBytes,
+}
+
+var (
+	ErrDocumentNotFound        = errors.New("document not found")
+	ErrUnknownDocumentType     = errors.New("unknown document type")
+	ErrDocumentRejectReason    = errors.New("rejecting a document requires a reason")
+	ErrInvalidDocumentStatus   = errors.New("document status must be 'accepted' or 'rejected'")
+	ErrRequiredDocsNotAccepted = errors.New("required verification documents have not been accepted")
+)
+
+// ChecklistItem is the review state of one required document type
+type ChecklistItem struct {
+	DocType      string `json:"doc_type"`
+	Status       string `json:"status"` // missing, pending, accepted or rejected
+	DocumentID   uint   `json:"document_id,omitempty"`
+	RejectReason string `json:"reject_reason,omitempty"`
+}
+
+// IsDocumentType reports whether docType is a known verification document
+func IsDocumentType(docType string) bool {
+	for _, t := range RequiredDocumentTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadShelterDocument validates and stores a verification document. A new
+// upload always starts pending, even if an earlier one of the same type was
+// rejected.
+func UploadShelterDocument(db *gorm.DB, shelterID uint, docType, fileName, fileData string) (models.ShelterDocument, error) {
+	docType = strings.ToLower(strings.TrimSpace(docType))
+	if !IsDocumentType(docType) {
+		return models.ShelterDocument{}, fmt.Errorf("%w: %q", ErrUnknownDocumentType, docType)
+	}
+
+	var shelter models.ShelterAccount
+	if err := db.Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ShelterDocument{}, ErrAccountNotFound
+		}
+		return models.ShelterDocument{}, err
+	}
+
+	upload, err := ValidateUpload(fileData, DocumentUploadRules)
+	if err != nil {
+		return models.ShelterDocument{}, err
+	}
+
+	doc := models.ShelterDocument{
+		ShelterID: shelterID,
+		DocType:   docType,
+		FileName:  fileName,
+		MimeType:  upload.MimeType,
+		SizeBytes: upload.SizeBytes,
+		FileData:  upload.Data,
+		Status:    DocStatusPending,
+	}
+	err = db.Create(&doc).Error
+	return doc, err
+}
+
+// ReviewShelterDocument accepts or rejects one uploaded document
+func ReviewShelterDocument(db *gorm.DB, documentID, adminID uint, status, reason string) (models.ShelterDocument, error) {
+	var doc models.ShelterDocument
+
+	status = NormalizeStatus(status)
+	if status != DocStatusAccepted && status != DocStatusRejected {
+		return doc, ErrInvalidDocumentStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if status == DocStatusRejected && reason == "" {
+		return doc, ErrDocumentRejectReason
+	}
+	if status == DocStatusAccepted {
+		reason = ""
+	}
+
+	if err := db.Omit("FileData").First(&doc, documentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return doc, ErrDocumentNotFound
+		}
+		return doc, err
+	}
+
+	now := time.Now()
+	if err := db.Model(&models.ShelterDocument{}).
+		Where("document_id = ?", documentID).
+		Updates(map[string]interface{}{
+			"status":        status,
+			"reject_reason": reason,
+			"reviewed_by":   adminID,
+			"reviewed_at":   now,
+		}).Error; err != nil {
+		return doc, err
+	}
+
+	doc.Status = status
+	doc.RejectReason = reason
+	doc.ReviewedBy = adminID
+	doc.ReviewedAt = &now
+	return doc, nil
+}
+
+// ShelterDocuments lists a shelter's uploads without their file data
+func ShelterDocuments(db *gorm.DB, shelterIDs ...uint) ([]models.ShelterDocument, error) {
+	var docs []models.ShelterDocument
+	err := db.Omit("FileData").
+		Where("shelter_id IN ?", shelterIDs).
+		Order("created_at DESC").
+		Find(&docs).Error
+	return docs, err
+}
+
+// BuildChecklist reduces a shelter's uploads to one entry per required type,
+// using the most recent upload of each type. docs must be ordered newest first.
+func BuildChecklist(docs []models.ShelterDocument) []ChecklistItem {
+	latest := map[string]models.ShelterDocument{}
+	for _, doc := range docs {
+		if _, seen := latest[doc.DocType]; !seen {
+			latest[doc.DocType] = doc
+		}
+	}
+
+	checklist := make([]ChecklistItem, 0, len(RequiredDocumentTypes))
+	for _, docType := range RequiredDocumentTypes {
+		item := ChecklistItem{DocType: docType, Status: "missing"}
+		if doc, ok := latest[docType]; ok {
+			item.Status = doc.Status
+			item.DocumentID = doc.DocumentID
+			item.RejectReason = doc.RejectReason
+		}
+		checklist = append(checklist, item)
+	}
+	return checklist
+}
+
+// ChecklistComplete reports whether every required document is accepted
+func ChecklistComplete(checklist []ChecklistItem) bool {
+	for _, item := range checklist {
+		if item.Status != DocStatusAccepted {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureDocumentsAccepted blocks approval until the checklist is complete
+func ensureDocumentsAccepted(db *gorm.DB, shelterID uint) error {
+	docs, err := ShelterDocuments(db, shelterID)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, item := range BuildChecklist(docs) {
+		if item.Status != DocStatusAccepted {
+			missing = append(missing, fmt.Sprintf("%s (%s)", item.DocType, item.Status))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrRequiredDocsNotAccepted, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+func regStatus(t *testing.T, db *gorm.DB, shelterID uint) string {
+	t.Helper()
+	var shelter models.ShelterAccount
+	if err := db.Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+		t.Fatal(err)
+	}
+	return shelter.RegStatus
+}
+
+func TestApprovalWaitsForAcceptedDocuments(t *testing.T) {
+	db := openTestDB(t)
+	seedPendingShelter(t, db, 1, time.Now())
+
+	approve := func() error {
+		_, _, err := DecideShelterRegistration(db, 1, 9, RegStatusApproved, "", "", time.Hour)
+		return err
+	}
+	upload := func(docType string) uint {
+		t.Helper()
+		doc := models.ShelterDocument{ShelterID: 1, DocType: docType, FileName: docType + ".pdf", Status: DocStatusPending}
+		if err := db.Create(&doc).Error; err != nil {
+			t.Fatal(err)
+		}
+		return doc.DocumentID
+	}
+	review := func(id uint, status, reason string) {
+		t.Helper()
+		if _, err := ReviewShelterDocument(db, id, 9, status, reason); err != nil {
+			t.Fatalf("review document %d: %v", id, err)
+		}
+	}
+
+	if err := approve(); !errors.Is(err, ErrRequiredDocsNotAccepted) {
+		t.Fatalf("approving with no documents: err = %v, want ErrRequiredDocsNotAccepted", err)
+	}
+
+	permit, ownerID, photo := upload(DocBusinessPermit), upload(DocOwnerID), upload(DocFacilityPhoto)
+	review(permit, DocStatusAccepted, "")
+	review(ownerID, DocStatusAccepted, "")
+	if err := approve(); !errors.Is(err, ErrRequiredDocsNotAccepted) {
+		t.Fatalf("approving with a pending photo: err = %v, want ErrRequiredDocsNotAccepted", err)
+	}
+
+	review(photo, DocStatusRejected, "Blurry")
+	if err := approve(); !errors.Is(err, ErrRequiredDocsNotAccepted) {
+		t.Fatalf("approving with a rejected photo: err = %v, want ErrRequiredDocsNotAccepted", err)
+	}
+
+	// A new upload replaces the rejected one but starts pending again
+	photo = upload(DocFacilityPhoto)
+	if err := approve(); !errors.Is(err, ErrRequiredDocsNotAccepted) {
+		t.Fatalf("approving with the new photo pending: err = %v, want ErrRequiredDocsNotAccepted", err)
+	}
+	if status := regStatus(t, db, 1); status != RegStatusPending {
+		t.Fatalf("reg_status = %q after refused approvals, want pending", status)
+	}
+
+	review(photo, DocStatusAccepted, "")
+	if err := approve(); err != nil {
+		t.Fatalf("approving with every document accepted: %v", err)
+	}
+	if status := regStatus(t, db, 1); status != RegStatusApproved {
+		t.Fatalf("reg_status = %q, want approved", status)
+	}
+}
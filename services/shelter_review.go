@@ -0,0 +1,373 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+)
+
+// RejectionReasons lists the structured reasons an admin can give when
+// rejecting a shelter registration.
+var RejectionReasons = map[string]string{
+	"incomplete_info":        "Registration details are incomplete",
+	"invalid_contact":        "Contact number or email could not be verified",
+	"unverifiable_identity":  "Owner identity could not be verified",
+	"invalid_address":        "Shelter address could not be verified",
+	"duplicate_registration": "Shelter is already registered",
+	"policy_violation":       "Shelter does not meet platform policies",
+	"other":                  "Other",
+}
+
+// NotifyRegistrationRejected is the notification kind that tells a shelter
+// why its registration was rejected
+const NotifyRegistrationRejected = "registration_rejected"
+
+var (
+	ErrRejectionReasonRequired = errors.New("rejection requires a reason_code and feedback")
+	ErrUnknownRejectionReason  = errors.New("unknown rejection reason")
+	ErrNotRejected             = errors.New("only rejected registrations can be resubmitted")
+	ErrIncompleteRegistration  = errors.New("shelter name, email, contact and address are required")
+)
+
+// ShelterInfoSnapshot is the part of ShelterInfo that is reviewed by admins
+// and compared between review rounds.
+type ShelterInfoSnapshot struct {
+	ShelterName        string `json:"shelter_name"`
+	ShelterAddress     string `json:"shelter_address"`
+	ShelterLandmark    string `json:"shelter_landmark"`
+	ShelterContact     string `json:"shelter_contact"`
+	ShelterEmail       string `json:"shelter_email"`
+	ShelterOwner       string `json:"shelter_owner"`
+	ShelterDescription string `json:"shelter_description"`
+	ShelterSocial      string `json:"shelter_social"`
+}
+
+// Validate checks that a snapshot has the fields a registration needs
+func (s ShelterInfoSnapshot) Validate() error {
+	for _, value := range []string{s.ShelterName, s.ShelterEmail, s.ShelterContact, s.ShelterAddress} {
+		if strings.TrimSpace(value) == "" {
+			return ErrIncompleteRegistration
+		}
+	}
+	return nil
+}
+
+// FieldChange is one field that differs between two snapshots
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ReviewRoundView is a review round with its snapshot and diff decoded
+type ReviewRoundView struct {
+	models.ShelterReviewRound
+	ReasonLabel string              `json:"reason_label,omitempty"`
+	Snapshot    ShelterInfoSnapshot `json:"snapshot"`
+	Changes     []FieldChange       `json:"changes"`
+}
+
+// SnapshotFromInfo copies the reviewable fields out of a ShelterInfo row
+func SnapshotFromInfo(info models.ShelterInfo) ShelterInfoSnapshot {
+	return ShelterInfoSnapshot{
+		ShelterName:        info.ShelterName,
+		ShelterAddress:     info.ShelterAddress,
+		ShelterLandmark:    info.ShelterLandmark,
+		ShelterContact:     info.ShelterContact,
+		ShelterEmail:       info.ShelterEmail,
+		ShelterOwner:       info.ShelterOwner,
+		ShelterDescription: info.ShelterDescription,
+		ShelterSocial:      info.ShelterSocial,
+	}
+}
+
+// DiffSnapshots returns the fields that changed from before to after,
+// sorted by field name.
+func DiffSnapshots(before, after ShelterInfoSnapshot) []FieldChange {
+	beforeMap := snapshotFields(before)
+	afterMap := snapshotFields(after)
+
+	changes := []FieldChange{}
+	for field, oldValue := range beforeMap {
+		if newValue := afterMap[field]; newValue != oldValue {
+			changes = append(changes, FieldChange{Field: field, Before: oldValue, After: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// MergeSnapshot returns current with every non-blank field of update
+// written over it
+func MergeSnapshot(current, update ShelterInfoSnapshot) ShelterInfoSnapshot {
+	merged := snapshotFields(current)
+	for field, value := range snapshotFields(update) {
+		if strings.TrimSpace(value) != "" {
+			merged[field] = value
+		}
+	}
+	raw, _ := json.Marshal(merged)
+	var snapshot ShelterInfoSnapshot
+	_ = json.Unmarshal(raw, &snapshot)
+	return snapshot
+}
+
+func snapshotFields(s ShelterInfoSnapshot) map[string]string {
+	raw, _ := json.Marshal(s)
+	fields := map[string]string{}
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
+
+// ValidateRejection checks that a rejection carries a known reason code and
+// non-empty feedback for the shelter.
+func ValidateRejection(reasonCode, feedback string) error {
+	reasonCode = strings.TrimSpace(reasonCode)
+	if reasonCode == "" || strings.TrimSpace(feedback) == "" {
+		return ErrRejectionReasonRequired
+	}
+	if _, ok := RejectionReasons[reasonCode]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownRejectionReason, reasonCode)
+	}
+	return nil
+}
+
+// DecideShelterRegistration approves or rejects a pending registration and
+// closes the current review round in the same transaction. The deciding
+// admin takes the review claim first, for claimTTL when it has none, so
+// the decision is refused while another admin holds it. Approval requires no flag hold on the shelter and
+// every verification document to be accepted. A rejection sends the shelter
+// an in-app notification with the reason and feedback.
+func DecideShelterRegistration(db *gorm.DB, shelterID, adminID uint, decision, reasonCode, feedback string, claimTTL time.Duration) (models.ShelterAccount, models.ShelterReviewRound, error) {
+	var shelter models.ShelterAccount
+	var round models.ShelterReviewRound
+
+	decision = NormalizeStatus(decision)
+	if decision == RegStatusRejected {
+		if err := ValidateRejection(reasonCode, feedback); err != nil {
+			return shelter, round, err
+		}
+	} else {
+		reasonCode = ""
+	}
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if err := takeReviewClaim(tx, shelterID, adminID, claimTTL); err != nil {
+			return err
+		}
+
+		if decision == RegStatusApproved {
+			if err := ensureNoFlagHold(tx, shelterID); err != nil {
+				return err
+			}
+			if err := ensureDocumentsAccepted(tx, shelterID); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		shelter, err = TransitionShelterRegStatus(tx, shelterID, decision)
+		if err != nil {
+			return err
+		}
+
+		round, err = openRound(tx, shelterID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		round.Decision = decision
+		round.ReasonCode = strings.TrimSpace(reasonCode)
+		round.Feedback = strings.TrimSpace(feedback)
+		round.ReviewedBy = adminID
+		round.ReviewedAt = &now
+		if err := tx.Save(&round).Error; err != nil {
+			return err
+		}
+
+		action := ActionShelterApprove
+		if decision == RegStatusRejected {
+			action = ActionShelterReject
+		}
+		if err := RecordAudit(tx, adminID, action, EntityShelter, shelterID, RegStatusPending, decision,
+			strings.TrimSpace(reasonCode+" "+feedback)); err != nil {
+			return err
+		}
+		if decision == RegStatusRejected {
+			if err := Notify(tx, RecipientShelter, shelterID, NotifyRegistrationRejected, "Registration not approved",
+				RejectionMessage(round.ReasonCode, round.Feedback)); err != nil {
+				return err
+			}
+		}
+
+		// The registration is decided, so the review lock is no longer needed
+		return tx.Where("shelter_id = ?", shelterID).Delete(&models.ShelterReviewClaim{}).Error
+	})
+
+	return shelter, round, err
+}
+
+// RejectionMessage tells the shelter the reason and the admin's feedback,
+// and how to try again
+func RejectionMessage(reasonCode, feedback string) string {
+	return fmt.Sprintf("Your registration was not approved. Reason: %s. Feedback: %s\n"+
+		"Correct the details and resubmit your registration for another review.", RejectionReasons[reasonCode], feedback)
+}
+
+// ResubmitShelterRegistration updates the info of a rejected shelter, moves
+// it back to pending and opens a new review round that records what changed
+// since the last rejection. Fields left blank in update keep their current
+// value, so a shelter can resubmit only what it corrected; the merged info
+// must still pass ShelterInfoSnapshot.Validate.
+func ResubmitShelterRegistration(db *gorm.DB, shelterID uint, update ShelterInfoSnapshot) (models.ShelterReviewRound, error) {
+	var round models.ShelterReviewRound
+
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		var shelter models.ShelterAccount
+		if err := tx.Where("shelter_id = ?", shelterID).First(&shelter).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAccountNotFound
+			}
+			return err
+		}
+		if NormalizeStatus(shelter.RegStatus) != RegStatusRejected {
+			return ErrNotRejected
+		}
+
+		var info models.ShelterInfo
+		if err := tx.Where("shelter_id = ?", shelterID).First(&info).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		update = MergeSnapshot(SnapshotFromInfo(info), update)
+		if err := update.Validate(); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.ShelterInfo{}).
+			Where("shelter_id = ?", shelterID).
+			Updates(map[string]interface{}{
+				"shelter_name":        update.ShelterName,
+				"shelter_address":     update.ShelterAddress,
+				"shelter_landmark":    update.ShelterLandmark,
+				"shelter_contact":     update.ShelterContact,
+				"shelter_email":       update.ShelterEmail,
+				"shelter_owner":       update.ShelterOwner,
+				"shelter_description": update.ShelterDescription,
+				"shelter_social":      update.ShelterSocial,
+			}).Error; err != nil {
+			return err
+		}
+
+		if _, err := TransitionShelterRegStatus(tx, shelterID, RegStatusPending); err != nil {
+			return err
+		}
+
+		var lastRejected models.ShelterReviewRound
+		var changes []FieldChange
+		err := tx.Where("shelter_id = ? AND decision = ?", shelterID, RegStatusRejected).
+			Order("round DESC").
+			First(&lastRejected).Error
+		if err == nil {
+			var previous ShelterInfoSnapshot
+			_ = json.Unmarshal([]byte(lastRejected.Snapshot), &previous)
+			changes = DiffSnapshots(previous, update)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		round, err = newRound(tx, shelterID, update, changes)
+		return err
+	})
+
+	return round, err
+}
+
+// ShelterReviewHistory returns every review round for a shelter, oldest first
+func ShelterReviewHistory(db *gorm.DB, shelterID uint) ([]ReviewRoundView, error) {
+	var rounds []models.ShelterReviewRound
+	if err := db.Where("shelter_id = ?", shelterID).
+		Order("round ASC").
+		Find(&rounds).Error; err != nil {
+		return nil, err
+	}
+	return ReviewRoundViews(rounds), nil
+}
+
+// ReviewRoundViews decodes the snapshot and diff of each round
+func ReviewRoundViews(rounds []models.ShelterReviewRound) []ReviewRoundView {
+	history := make([]ReviewRoundView, 0, len(rounds))
+	for _, r := range rounds {
+		view := ReviewRoundView{
+			ShelterReviewRound: r,
+			ReasonLabel:        RejectionReasons[r.ReasonCode],
+			Changes:            []FieldChange{},
+		}
+		_ = json.Unmarshal([]byte(r.Snapshot), &view.Snapshot)
+		if r.Changes != "" {
+			_ = json.Unmarshal([]byte(r.Changes), &view.Changes)
+		}
+		history = append(history, view)
+	}
+	return history
+}
+
+// openRound returns the shelter's undecided round, creating the first one
+// from the current ShelterInfo for registrations that predate review rounds.
+func openRound(tx *gorm.DB, shelterID uint) (models.ShelterReviewRound, error) {
+	var round models.ShelterReviewRound
+	err := tx.Where("shelter_id = ? AND decision = ?", shelterID, RegStatusPending).
+		Order("round DESC").
+		First(&round).Error
+	if err == nil {
+		return round, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return round, err
+	}
+
+	var info models.ShelterInfo
+	if err := tx.Where("shelter_id = ?", shelterID).First(&info).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return round, err
+	}
+	return newRound(tx, shelterID, SnapshotFromInfo(info), nil)
+}
+
+func newRound(tx *gorm.DB, shelterID uint, snapshot ShelterInfoSnapshot, changes []FieldChange) (models.ShelterReviewRound, error) {
+	var last int
+	if err := tx.Model(&models.ShelterReviewRound{}).
+		Where("shelter_id = ?", shelterID).
+		Select("COALESCE(MAX(round), 0)").
+		Scan(&last).Error; err != nil {
+		return models.ShelterReviewRound{}, err
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return models.ShelterReviewRound{}, err
+	}
+	round := models.ShelterReviewRound{
+		ShelterID:   shelterID,
+		Round:       last + 1,
+		Decision:    RegStatusPending,
+		Snapshot:    string(snapshotJSON),
+		SubmittedAt: time.Now(),
+	}
+	if changes != nil {
+		changesJSON, err := json.Marshal(changes)
+		if err != nil {
+			return round, err
+		}
+		round.Changes = string(changesJSON)
+	}
+
+	err = tx.Create(&round).Error
+	return round, err
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pethubadmin/models"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	before := ShelterInfoSnapshot{ShelterName: "Paws", ShelterEmail: "a@paws.ph", ShelterSocial: "fb.com/paws"}
+
+	cases := []struct {
+		name  string
+		after ShelterInfoSnapshot
+		want  []FieldChange
+	}{
+		{"unchanged", before, []FieldChange{}},
+		{"one field", ShelterInfoSnapshot{ShelterName: "Paws", ShelterEmail: "b@paws.ph", ShelterSocial: "fb.com/paws"},
+			[]FieldChange{{Field: "shelter_email", Before: "a@paws.ph", After: "b@paws.ph"}}},
+		{"sorted by field, cleared and filled", ShelterInfoSnapshot{ShelterName: "Paws PH", ShelterEmail: "a@paws.ph", ShelterAddress: "Cebu"},
+			[]FieldChange{
+				{Field: "shelter_address", Before: "", After: "Cebu"},
+				{Field: "shelter_name", Before: "Paws", After: "Paws PH"},
+				{Field: "shelter_social", Before: "fb.com/paws", After: ""},
+			}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DiffSnapshots(before, tc.after); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("DiffSnapshots = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// pawsInfo is a fully filled in registration
+var pawsInfo = ShelterInfoSnapshot{
+	ShelterName:        "Paws",
+	ShelterAddress:     "Cebu City",
+	ShelterLandmark:    "Near the church",
+	ShelterContact:     "0917",
+	ShelterEmail:       "a@paws.ph",
+	ShelterOwner:       "Ana Cruz",
+	ShelterDescription: "Rescues strays",
+	ShelterSocial:      "fb.com/paws",
+}
+
+func TestResubmitMergesOverTheCurrentInfo(t *testing.T) {
+	db := openTestDB(t)
+	original := pawsInfo
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusInactive, RegStatus: RegStatusPending, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: original.ShelterName, ShelterAddress: original.ShelterAddress,
+			ShelterLandmark: original.ShelterLandmark, ShelterContact: original.ShelterContact, ShelterEmail: original.ShelterEmail,
+			ShelterOwner: original.ShelterOwner, ShelterDescription: original.ShelterDescription, ShelterSocial: original.ShelterSocial},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+
+	if _, err := ResubmitShelterRegistration(db, 1, original); !errors.Is(err, ErrNotRejected) {
+		t.Fatalf("resubmitting a pending registration: err = %v, want ErrNotRejected", err)
+	}
+	if _, err := ResubmitShelterRegistration(db, 2, original); !errors.Is(err, ErrAccountNotFound) {
+		t.Fatalf("resubmitting an unknown shelter: err = %v, want ErrAccountNotFound", err)
+	}
+
+	if _, _, err := DecideShelterRegistration(db, 1, 9, RegStatusRejected, "invalid_contact", "Number is unreachable", time.Hour); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	// Only the corrected field is sent; the rest must survive
+	round, err := ResubmitShelterRegistration(db, 1, ShelterInfoSnapshot{ShelterContact: "0918"})
+	if err != nil {
+		t.Fatalf("resubmit: %v", err)
+	}
+	if round.Round != 2 || round.Decision != RegStatusPending {
+		t.Fatalf("round %d decided %q, want an open round 2", round.Round, round.Decision)
+	}
+
+	var info models.ShelterInfo
+	db.Where("shelter_id = ?", 1).First(&info)
+	want := original
+	want.ShelterContact = "0918"
+	if got := SnapshotFromInfo(info); got != want {
+		t.Fatalf("shelter info after resubmit = %+v, want %+v", got, want)
+	}
+
+	var account models.ShelterAccount
+	db.Where("shelter_id = ?", 1).First(&account)
+	if account.RegStatus != RegStatusPending {
+		t.Fatalf("reg_status = %q, want pending", account.RegStatus)
+	}
+
+	history, err := ShelterReviewHistory(db, 1)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("%d rounds in the history, want 2", len(history))
+	}
+	first, second := history[0], history[1]
+	if first.Round != 1 || first.Decision != RegStatusRejected || first.ReasonLabel != RejectionReasons["invalid_contact"] ||
+		first.Feedback != "Number is unreachable" || first.ReviewedBy != 9 || first.Snapshot != original {
+		t.Fatalf("first round = %+v, want the rejection of the original info", first)
+	}
+	wantChanges := []FieldChange{{Field: "shelter_contact", Before: "0917", After: "0918"}}
+	if second.Round != 2 || second.Snapshot != want || !reflect.DeepEqual(second.Changes, wantChanges) {
+		t.Fatalf("second round = %+v, want the resubmitted info changing only the contact", second)
+	}
+}
+
+func TestRejectionTellsTheShelterWhy(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.Create(&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusInactive, RegStatus: RegStatusPending, Version: 1}).Error; err != nil {
+		t.Fatalf("seeding: %v", err)
+	}
+
+	if _, _, err := DecideShelterRegistration(db, 1, 9, RegStatusRejected, "invalid_contact", "Number is unreachable", time.Hour); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	notifications, err := RecipientNotifications(db, RecipientShelter, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || notifications[0].Kind != NotifyRegistrationRejected {
+		t.Fatalf("notifications = %+v, want one rejection", notifications)
+	}
+	message := notifications[0].Message
+	if !strings.Contains(message, RejectionReasons["invalid_contact"]) || !strings.Contains(message, "Number is unreachable") {
+		t.Fatalf("message %q lacks the reason or the feedback", message)
+	}
+}
+
+func TestResubmitNeedsACompleteRegistration(t *testing.T) {
+	db := openTestDB(t)
+	rows := []interface{}{
+		&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusInactive, RegStatus: RegStatusPending, Version: 1},
+		&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws", ShelterEmail: "a@paws.ph", ShelterContact: "0917"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seeding: %v", err)
+		}
+	}
+	if _, _, err := DecideShelterRegistration(db, 1, 9, RegStatusRejected, "invalid_address", "Add your address", time.Hour); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	// The stored info has no address, so a resubmit must add one
+	if _, err := ResubmitShelterRegistration(db, 1, ShelterInfoSnapshot{ShelterContact: "0918"}); !errors.Is(err, ErrIncompleteRegistration) {
+		t.Fatalf("resubmit without an address: err = %v, want ErrIncompleteRegistration", err)
+	}
+	var account models.ShelterAccount
+	db.Where("shelter_id = ?", 1).First(&account)
+	if account.RegStatus != RegStatusRejected {
+		t.Fatalf("reg_status = %q after a refused resubmit, want rejected", account.RegStatus)
+	}
+
+	if _, err := ResubmitShelterRegistration(db, 1, ShelterInfoSnapshot{ShelterAddress: "Cebu City"}); err != nil {
+		t.Fatalf("resubmit with the address: %v", err)
+	}
+}
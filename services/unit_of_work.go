@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnitRolledBack is returned when a unit of work is rolled back because
+// part of it failed, even if the failure was handled further up
+var ErrUnitRolledBack = errors.New("unit of work rolled back")
+
+// unit is the state of the transaction a unit of work runs in, carried in
+// the statement context so nested work can find it
+type unit struct {
+	mu          sync.Mutex
+	failed      error
+	afterCommit []func()
+}
+
+type unitKey struct{}
+
+func currentUnit(db *gorm.DB) *unit {
+	if db == nil || db.Statement == nil || db.Statement.Context == nil {
+		return nil
+	}
+	u, _ := db.Statement.Context.Value(unitKey{}).(*unit)
+	return u
+}
+
+// InTransaction runs fn in a transaction. Work started inside another unit
+// of work joins it instead of opening a savepoint, so the whole action
+// commits or rolls back as one: a nested failure rolls back the outer unit
+// even when the caller handles the error.
+func InTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if u := currentUnit(db); u != nil {
+		err := fn(db)
+		if err != nil {
+			u.mu.Lock()
+			if u.failed == nil {
+				u.failed = err
+			}
+			u.mu.Unlock()
+		}
+		return err
+	}
+
+	u := &unit{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ctx := context.WithValue(tx.Statement.Context, unitKey{}, u)
+		if err := fn(tx.WithContext(ctx)); err != nil {
+			return err
+		}
+		if u.failed != nil {
+			return fmt.Errorf("%w: %v", ErrUnitRolledBack, u.failed)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range u.afterCommit {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs hook once the unit of work tx belongs to has committed,
+// and drops it if the unit rolls back. Outside a unit it runs immediately.
+// Use it for side effects that must not happen for rolled back changes,
+// such as sending messages.
+func AfterCommit(tx *gorm.DB, hook func()) {
+	u := currentUnit(tx)
+	if u == nil {
+		hook()
+		return
+	}
+	u.mu.Lock()
+	u.afterCommit = append(u.afterCommit, hook)
+	u.mu.Unlock()
+}
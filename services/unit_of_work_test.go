@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"pethubadmin/migrations"
+	"pethubadmin/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB returns a private in-memory database with the tables the
+// moderation and review services touch
+func openTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	// One connection, so every session sees the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(migrations.Models...); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return db
+}
+
+// failingWriteUnit runs an action in a transaction and fails it as soon as a
+// write to table has gone through, the way a dropped connection or a
+// constraint violation would
+type failingWriteUnit struct {
+	db    *gorm.DB
+	table string
+}
+
+var errInjected = errors.New("injected failure")
+
+func (u failingWriteUnit) Do(fn func(tx *gorm.DB) error) error {
+	return InTransaction(u.db, func(tx *gorm.DB) error {
+		failAfter := func(db *gorm.DB) {
+			if db.Statement.Table == u.table && db.Error == nil {
+				db.AddError(errInjected)
+			}
+		}
+		callbacks := tx.Callback()
+		if err := callbacks.Update().After("gorm:update").Register("test:fail_after_update", failAfter); err != nil {
+			return err
+		}
+		if err := callbacks.Create().After("gorm:create").Register("test:fail_after_create", failAfter); err != nil {
+			return err
+		}
+		defer callbacks.Update().Remove("test:fail_after_update")
+		defer callbacks.Create().Remove("test:fail_after_create")
+		return fn(tx)
+	})
+}
+
+func TestBlockShelterRollsBackWhenAWriteFails(t *testing.T) {
+	for _, table := range []string{"shelteraccount", "petinfo", "admin_audit_logs"} {
+		t.Run(table, func(t *testing.T) {
+			db := openTestDB(t)
+			seed := []interface{}{
+				&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusActive, RegStatus: RegStatusApproved, Version: 1},
+				&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"},
+				&models.PetInfo{PetID: 1, ShelterID: 1, PetName: "Rex", ListingStatus: "listed"},
+				&models.PetInfo{PetID: 2, ShelterID: 1, PetName: "Mia", ListingStatus: "listed"},
+				&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 5, Reason: "neglect", Status: ReportStatusReported, Version: 1},
+				&models.SubmittedReport{ID: 2, ShelterID: 1, AdopterID: 6, Reason: "scam", Status: ReportStatusReported, Version: 1},
+			}
+			for _, row := range seed {
+				if err := db.Create(row).Error; err != nil {
+					t.Fatalf("seeding: %v", err)
+				}
+			}
+
+			uow := failingWriteUnit{db: db, table: table}
+			err := uow.Do(func(tx *gorm.DB) error {
+				_, err := BlockShelter(tx, 1, 9, DefaultShelterBlockPolicy, "")
+				return err
+			})
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("err = %v, want the injected failure", err)
+			}
+
+			var shelter models.ShelterAccount
+			db.First(&shelter, 1)
+			if shelter.Status != StatusActive || shelter.Version != 1 {
+				t.Errorf("shelter = %s v%d, want active v1", shelter.Status, shelter.Version)
+			}
+
+			var reports []models.SubmittedReport
+			db.Order("id").Find(&reports)
+			for _, report := range reports {
+				if report.Status != ReportStatusReported || report.Version != 1 {
+					t.Errorf("report %d = %s v%d, want reported v1", report.ID, report.Status, report.Version)
+				}
+			}
+
+			var pets []models.PetInfo
+			db.Order("pet_id").Find(&pets)
+			for _, pet := range pets {
+				if pet.HiddenBy != "" || pet.ListingStatus != "listed" {
+					t.Errorf("pet %d hidden_by %q listing %q, want untouched", pet.PetID, pet.HiddenBy, pet.ListingStatus)
+				}
+			}
+
+			var audits, notices int64
+			db.Model(&models.AdminAuditLog{}).Count(&audits)
+			db.Model(&models.OutcomeNotice{}).Count(&notices)
+			if audits != 0 || notices != 0 {
+				t.Errorf("audit entries %d, notices %d, want none", audits, notices)
+			}
+		})
+	}
+}
+
+func TestBlockShelterCommitsInTransaction(t *testing.T) {
+	db := openTestDB(t)
+	db.Create(&models.ShelterAccount{ShelterID: 1, Username: "paws", Status: StatusActive, Version: 1})
+	db.Create(&models.ShelterInfo{ShelterID: 1, ShelterName: "Paws"})
+	db.Create(&models.PetInfo{PetID: 1, ShelterID: 1, ListingStatus: "listed"})
+	db.Create(&models.SubmittedReport{ID: 1, ShelterID: 1, AdopterID: 5, Status: ReportStatusReported, Version: 1})
+
+	var notified bool
+	err := InTransaction(db, func(tx *gorm.DB) error {
+		if _, err := BlockShelter(tx, 1, 9, DefaultShelterBlockPolicy, ""); err != nil {
+			return err
+		}
+		AfterCommit(tx, func() { notified = true })
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	if !notified {
+		t.Error("after-commit hook did not run")
+	}
+
+	var shelter models.ShelterAccount
+	var report models.SubmittedReport
+	var pet models.PetInfo
+	db.First(&shelter, 1)
+	db.First(&report, 1)
+	db.First(&pet, 1)
+	if shelter.Status != StatusInactive || report.Status != ReportStatusBlocked || pet.HiddenBy != HoldShelterBlocked {
+		t.Fatalf("shelter %s, report %s, pet hidden_by %q after block", shelter.Status, report.Status, pet.HiddenBy)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"pethubadmin/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionMismatch is returned when a write names a version of a record
+// (the If-Match header) that is no longer current
+var ErrVersionMismatch = errors.New("record was changed since it was read")
+
+// nextVersion moves a versioned row on. Every write to shelteraccount,
+// adopteraccount or submittedreports includes it so that a client holding
+// an older version can no longer write.
+var nextVersion = gorm.Expr("version + 1")
+
+// versionedTables are the tables with a version column
+var versionedTables = map[string]bool{
+	"shelteraccount":   true,
+	"adopteraccount":   true,
+	"submittedreports": true,
+}
+
+// withVersion adds the version bump to updates when table is versioned
+func withVersion(table string, updates map[string]interface{}) map[string]interface{} {
+	if versionedTables[table] {
+		updates["version"] = nextVersion
+	}
+	return updates
+}
+
+// CheckShelterVersion locks a shelter account and fails with
+// ErrVersionMismatch unless it is at one of versions. No versions means the
+// write has no precondition.
+func CheckShelterVersion(tx *gorm.DB, shelterID uint, versions []uint) error {
+	return checkVersion(tx, &models.ShelterAccount{}, "shelter_id", shelterID, versions)
+}
+
+// CheckAdopterVersion is CheckShelterVersion for adopter accounts
+func CheckAdopterVersion(tx *gorm.DB, adopterID uint, versions []uint) error {
+	return checkVersion(tx, &models.AdopterAccount{}, "adopter_id", adopterID, versions)
+}
+
+// CheckReportVersion is CheckShelterVersion for submitted reports
+func CheckReportVersion(tx *gorm.DB, reportID uint, versions []uint) error {
+	return checkVersion(tx, &models.SubmittedReport{}, "id", reportID, versions)
+}
+
+// checkVersion holds the row lock until the caller's unit of work ends, so
+// a concurrent writer waits and then sees the version this one wrote. A
+// missing row passes; the write that follows reports it as not found.
+func checkVersion(tx *gorm.DB, model interface{}, column string, id uint, versions []uint) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	var current struct{ Version uint }
+	err := tx.Unscoped().Model(model).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("version").Where(column+" = ?", id).
+		Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if version == current.Version {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: it is now at version %d", ErrVersionMismatch, current.Version)
+}
+
+// bumpVersion moves a row on without changing anything else, for writes
+// that only touch columns GORM manages, such as soft deletes
+func bumpVersion(tx *gorm.DB, model interface{}, column string, id uint) error {
+	return tx.Unscoped().Model(model).Where(column+" = ?", id).UpdateColumn("version", nextVersion).Error
+}